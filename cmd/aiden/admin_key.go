@@ -0,0 +1,140 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/congregalis/aiden/internal/auth"
+	"github.com/congregalis/aiden/internal/config"
+	"github.com/congregalis/aiden/internal/db"
+)
+
+const adminKeyUsage = `usage:
+  aiden admin-key create -name NAME [-scopes read,write,export] [-by WHO]
+  aiden admin-key list
+  aiden admin-key revoke KEY_ID
+`
+
+// runAdminKey implements "aiden admin-key". It returns the process exit code.
+func runAdminKey(args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		fmt.Fprint(stderr, adminKeyUsage)
+		return 2
+	}
+
+	var run func(context.Context, *auth.KeyStore) error
+	switch args[0] {
+	case "create":
+		flags := flag.NewFlagSet("admin-key create", flag.ContinueOnError)
+		flags.SetOutput(stderr)
+		flags.Usage = func() { fmt.Fprint(stderr, adminKeyUsage) }
+		name := flags.String("name", "", "who or what the key is for")
+		scopesFlag := flags.String("scopes", "read", "comma-separated scopes: read, write, export")
+		createdBy := flags.String("by", os.Getenv("USER"), "operator creating the key")
+		if err := flags.Parse(args[1:]); err != nil {
+			return 2
+		}
+		if flags.NArg() != 0 || strings.TrimSpace(*name) == "" {
+			flags.Usage()
+			return 2
+		}
+		scopes, err := auth.ParseScopes(*scopesFlag)
+		if err != nil {
+			fmt.Fprintf(stderr, "admin-key create: %v\n", err)
+			return 2
+		}
+		run = func(ctx context.Context, keys *auth.KeyStore) error {
+			key, token, err := keys.Create(ctx, *name, scopes, *createdBy)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(stderr, "created key %s (%s) with scopes %s; the token below is shown only once\n",
+				key.KeyID, key.Name, strings.Join(scopeNames(key.Scopes), ","))
+			fmt.Fprintln(stdout, token)
+			return nil
+		}
+	case "list":
+		if len(args) != 1 {
+			fmt.Fprint(stderr, adminKeyUsage)
+			return 2
+		}
+		run = func(ctx context.Context, keys *auth.KeyStore) error {
+			list, err := keys.List(ctx)
+			if err != nil {
+				return err
+			}
+			return writeAdminKeys(stdout, list)
+		}
+	case "revoke":
+		if len(args) != 2 {
+			fmt.Fprint(stderr, adminKeyUsage)
+			return 2
+		}
+		run = func(ctx context.Context, keys *auth.KeyStore) error {
+			revoked, err := keys.Revoke(ctx, args[1])
+			if err != nil {
+				return err
+			}
+			if !revoked {
+				return fmt.Errorf("no active key with ID %s", args[1])
+			}
+			fmt.Fprintf(stderr, "revoked key %s\n", args[1])
+			return nil
+		}
+	default:
+		fmt.Fprint(stderr, adminKeyUsage)
+		return 2
+	}
+
+	cfg, err := config.Load(".env")
+	if err != nil {
+		fmt.Fprintf(stderr, "admin-key: load config: %v\n", err)
+		return 1
+	}
+	dbConn, err := db.Open(cfg.Database)
+	if err != nil {
+		fmt.Fprintf(stderr, "admin-key: %v\n", err)
+		return 1
+	}
+	defer dbConn.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if err := run(ctx, auth.NewKeyStore(dbConn)); err != nil {
+		fmt.Fprintf(stderr, "admin-key %s: %v\n", args[0], err)
+		return 1
+	}
+	return 0
+}
+
+func writeAdminKeys(out io.Writer, keys []auth.APIKey) error {
+	tw := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "KEY_ID\tNAME\tSCOPES\tCREATED_BY\tCREATED_AT\tLAST_USED_AT\tSTATUS")
+	for _, key := range keys {
+		status := "active"
+		if key.RevokedAt != nil {
+			status = "revoked " + key.RevokedAt.UTC().Format(time.RFC3339)
+		}
+		lastUsed := "-"
+		if key.LastUsedAt != nil {
+			lastUsed = key.LastUsedAt.UTC().Format(time.RFC3339)
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", key.KeyID, key.Name, strings.Join(scopeNames(key.Scopes), ","),
+			key.CreatedBy, key.CreatedAt.UTC().Format(time.RFC3339), lastUsed, status)
+	}
+	return tw.Flush()
+}
+
+func scopeNames(scopes []auth.Scope) []string {
+	names := make([]string, len(scopes))
+	for i, scope := range scopes {
+		names[i] = string(scope)
+	}
+	return names
+}
@@ -0,0 +1,211 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/congregalis/aiden/internal/config"
+	"github.com/congregalis/aiden/internal/db"
+	"github.com/congregalis/aiden/internal/telegram"
+)
+
+const (
+	chatFirstReplyTimeout = 5 * time.Second
+	chatQuietPeriod       = 300 * time.Millisecond
+)
+
+const chatHelp = `Type a message and press Enter to send it to the bot. Lines starting
+with ":" control the simulation:
+  :chat ID        switch to another simulated chat (each chat is its own user)
+  :lang CODE      Telegram client language for new users, e.g. en or zh-CN
+  :edit TEXT      edit the last message sent from this chat
+  :advance DUR    move the clock forward, e.g. :advance 25h
+  :clock          show the simulated time
+  :help           show this help
+  :quit           exit
+`
+
+// fakeClock runs at wall-clock speed plus an offset that :advance moves.
+type fakeClock struct {
+	mu     sync.Mutex
+	offset time.Duration
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return time.Now().Add(c.offset)
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.offset += d
+}
+
+// runChat implements "aiden chat": the real Worker behind a LocalClient, fed
+// from stdin. It returns the process exit code.
+func runChat(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("chat", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	storeKind := flags.String("store", "memory", "memory, or postgres to use DB_DSN from .env")
+	chatID := flags.Int64("chat", 1001, "simulated chat ID to start with")
+	lang := flags.String("lang", "", "Telegram client language code of new users")
+	rulesPath := flags.String("rules", "", "rules file (default: built-in rules)")
+	timeout := flags.Duration("session-timeout", 24*time.Hour, "idle time before a recap")
+	verbose := flags.Bool("v", false, "print worker logs to stderr")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	rules, err := loadEvalRules(*rulesPath)
+	if err != nil {
+		fmt.Fprintf(stderr, "chat: %v\n", err)
+		return 1
+	}
+
+	clock := &fakeClock{}
+	var store telegram.Store
+	switch *storeKind {
+	case "memory":
+		memory := telegram.NewMemoryStore()
+		memory.SetClock(clock.Now)
+		store = memory
+	case "postgres":
+		cfg, err := config.Load(".env")
+		if err != nil {
+			fmt.Fprintf(stderr, "chat: load config: %v\n", err)
+			return 1
+		}
+		dbConn, err := db.Open(cfg.Database)
+		if err != nil {
+			fmt.Fprintf(stderr, "chat: %v\n", err)
+			return 1
+		}
+		defer dbConn.Close()
+		store = telegram.NewSQLStore(dbConn)
+	default:
+		fmt.Fprintf(stderr, "chat: -store must be memory or postgres\n")
+		return 2
+	}
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	if *verbose {
+		logger = slog.New(slog.NewTextHandler(stderr, nil))
+	}
+
+	client := telegram.NewLocalClient()
+	worker := telegram.NewWorker(telegram.WorkerConfig{
+		PollTimeoutSec: 30,
+		AllowedUpdates: []string{"message", "edited_message"},
+		Session:        telegram.SessionConfig{Timeout: *timeout},
+		Rules:          rules,
+		Now:            clock.Now,
+	}, client, store, logger)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	workerDone := make(chan error, 1)
+	go func() {
+		workerDone <- worker.Run(ctx)
+	}()
+	defer func() {
+		cancel()
+		<-workerDone
+	}()
+
+	fmt.Fprintf(stdout, "aiden chat (%s store). Type :help for commands.\n", *storeKind)
+	current := *chatID
+	languageCode := *lang
+	scanner := bufio.NewScanner(stdin)
+	for {
+		fmt.Fprintf(stdout, "[%d] > ", current)
+		if !scanner.Scan() {
+			fmt.Fprintln(stdout)
+			break
+		}
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		if !strings.HasPrefix(line, ":") {
+			client.Send(current, languageCode, line)
+			printReplies(stdout, client)
+			continue
+		}
+
+		name, arg, _ := strings.Cut(strings.TrimPrefix(line, ":"), " ")
+		arg = strings.TrimSpace(arg)
+		switch name {
+		case "quit", "q", "exit":
+			return 0
+		case "help":
+			fmt.Fprint(stdout, chatHelp)
+		case "chat":
+			id, err := strconv.ParseInt(arg, 10, 64)
+			if err != nil || id == 0 {
+				fmt.Fprintln(stdout, "usage: :chat ID")
+				continue
+			}
+			current = id
+		case "lang":
+			languageCode = arg
+		case "edit":
+			if arg == "" || !client.Edit(current, arg) {
+				fmt.Fprintln(stdout, "nothing to edit; usage: :edit TEXT")
+				continue
+			}
+			printReplies(stdout, client)
+		case "advance":
+			d, err := time.ParseDuration(arg)
+			if err != nil || d <= 0 {
+				fmt.Fprintln(stdout, "usage: :advance DURATION, e.g. 25h or 90m")
+				continue
+			}
+			clock.Advance(d)
+			fmt.Fprintf(stdout, "clock: %s\n", clock.Now().Format(time.RFC3339))
+		case "clock":
+			fmt.Fprintf(stdout, "clock: %s\n", clock.Now().Format(time.RFC3339))
+		default:
+			fmt.Fprintf(stdout, "unknown command :%s, type :help\n", name)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		fmt.Fprintf(stderr, "chat: read input: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// printReplies waits for the bot to answer and prints every reply that
+// arrives until it has been quiet for a moment.
+func printReplies(w io.Writer, client *telegram.LocalClient) {
+	wait := chatFirstReplyTimeout
+	for {
+		select {
+		case reply := <-client.Replies():
+			prefix := fmt.Sprintf("bot[%d] < ", reply.ChatID)
+			indent := strings.Repeat(" ", len(prefix))
+			for i, line := range strings.Split(reply.Text, "\n") {
+				if i > 0 {
+					prefix = indent
+				}
+				fmt.Fprintf(w, "%s%s\n", prefix, line)
+			}
+			wait = chatQuietPeriod
+		case <-time.After(wait):
+			if wait == chatFirstReplyTimeout {
+				fmt.Fprintln(w, "(no reply)")
+			}
+			return
+		}
+	}
+}
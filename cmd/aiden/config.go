@@ -0,0 +1,62 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/congregalis/aiden/internal/config"
+)
+
+const configUsage = `usage:
+  aiden config print [-env FILE]
+`
+
+// runConfig implements "aiden config". It returns the process exit code.
+func runConfig(args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 || args[0] != "print" {
+		fmt.Fprint(stderr, configUsage)
+		return 2
+	}
+
+	flags := flag.NewFlagSet("config print", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() { fmt.Fprint(stderr, configUsage) }
+	envFile := flags.String("env", ".env", "dotenv file to read")
+	if err := flags.Parse(args[1:]); err != nil {
+		return 2
+	}
+	if flags.NArg() != 0 {
+		flags.Usage()
+		return 2
+	}
+
+	cfg, settings, err := config.LoadSettings(*envFile)
+	if err != nil {
+		fmt.Fprintf(stderr, "config print: %v\n", err)
+		return 1
+	}
+
+	sort.Slice(settings, func(i, j int) bool { return settings[i].Key < settings[j].Key })
+	tw := tabwriter.NewWriter(stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "KEY\tVALUE\tSOURCE")
+	for _, setting := range settings {
+		value := setting.Display()
+		if value == "" {
+			value = "-"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", setting.Key, value, setting.Source)
+	}
+	if err := tw.Flush(); err != nil {
+		fmt.Fprintf(stderr, "config print: %v\n", err)
+		return 1
+	}
+
+	if err := cfg.Validate(); err != nil {
+		fmt.Fprintf(stderr, "config print: invalid config: %v\n", err)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/congregalis/aiden/internal/config"
+	"github.com/congregalis/aiden/internal/db"
+	"github.com/congregalis/aiden/internal/eval"
+	"github.com/congregalis/aiden/internal/telegram"
+)
+
+const evalUsage = `usage:
+  aiden eval [-rules FILE] [-json] DATASET.jsonl
+  aiden eval export [-rules FILE] [-limit N] [-out FILE]
+`
+
+// runEval implements "aiden eval". It returns the process exit code.
+func runEval(args []string, stdout, stderr io.Writer) int {
+	if len(args) > 0 && args[0] == "export" {
+		return runEvalExport(args[1:], stdout, stderr)
+	}
+
+	flags := flag.NewFlagSet("eval", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() { fmt.Fprint(stderr, evalUsage) }
+	rulesPath := flags.String("rules", "", "rules file to evaluate (default: built-in rules)")
+	asJSON := flags.Bool("json", false, "print the report as JSON")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return 2
+	}
+
+	rules, err := loadEvalRules(*rulesPath)
+	if err != nil {
+		fmt.Fprintf(stderr, "eval: %v\n", err)
+		return 1
+	}
+
+	file, err := os.Open(flags.Arg(0))
+	if err != nil {
+		fmt.Fprintf(stderr, "eval: open dataset: %v\n", err)
+		return 1
+	}
+	defer file.Close()
+
+	examples, err := eval.ReadDataset(file)
+	if err != nil {
+		fmt.Fprintf(stderr, "eval: %s: %v\n", flags.Arg(0), err)
+		return 1
+	}
+
+	report := eval.Evaluate(examples, eval.NewRulesPredictor(rules))
+	if *asJSON {
+		encoder := json.NewEncoder(stdout)
+		encoder.SetIndent("", "  ")
+		encoder.SetEscapeHTML(false)
+		err = encoder.Encode(report)
+	} else {
+		err = report.WriteText(stdout)
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "eval: write report: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+func runEvalExport(args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("eval export", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() { fmt.Fprint(stderr, evalUsage) }
+	rulesPath := flags.String("rules", "", "rules used to pre-fill slots (default: built-in rules)")
+	limit := flags.Int("limit", 1000, "number of latest user turns to read")
+	outPath := flags.String("out", "", "write the seed file here instead of stdout")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() != 0 || *limit <= 0 {
+		flags.Usage()
+		return 2
+	}
+
+	rules, err := loadEvalRules(*rulesPath)
+	if err != nil {
+		fmt.Fprintf(stderr, "eval export: %v\n", err)
+		return 1
+	}
+
+	cfg, err := config.Load(".env")
+	if err != nil {
+		fmt.Fprintf(stderr, "eval export: load config: %v\n", err)
+		return 1
+	}
+	dbConn, err := db.Open(cfg.Database)
+	if err != nil {
+		fmt.Fprintf(stderr, "eval export: %v\n", err)
+		return 1
+	}
+	defer dbConn.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	turns, err := telegram.NewSQLStore(dbConn).ListLatestUserTurns(ctx, *limit)
+	if err != nil {
+		fmt.Fprintf(stderr, "eval export: %v\n", err)
+		return 1
+	}
+
+	out := stdout
+	if *outPath != "" {
+		file, err := os.Create(*outPath)
+		if err != nil {
+			fmt.Fprintf(stderr, "eval export: %v\n", err)
+			return 1
+		}
+		defer file.Close()
+		out = file
+	}
+
+	written, err := eval.WriteSeed(out, turns, eval.NewRulesPredictor(rules))
+	if err != nil {
+		fmt.Fprintf(stderr, "eval export: %v\n", err)
+		return 1
+	}
+	fmt.Fprintf(stderr, "exported %d of %d turns\n", written, len(turns))
+	return 0
+}
+
+func loadEvalRules(path string) (*telegram.RuleSet, error) {
+	if path == "" {
+		return telegram.NewRuleSet(telegram.DefaultRules(), "embedded"), nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read rules: %w", err)
+	}
+	rules, err := telegram.ParseRulesFile(path, data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return telegram.NewRuleSet(rules, path), nil
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"log/slog"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
@@ -11,21 +12,39 @@ import (
 	"syscall"
 	"time"
 
+	"github.com/congregalis/aiden/internal/auth"
 	"github.com/congregalis/aiden/internal/config"
 	"github.com/congregalis/aiden/internal/db"
+	"github.com/congregalis/aiden/internal/flags"
 	httpx "github.com/congregalis/aiden/internal/http"
+	"github.com/congregalis/aiden/internal/http/handlers"
 	"github.com/congregalis/aiden/internal/logger"
+	"github.com/congregalis/aiden/internal/maintenance"
 	"github.com/congregalis/aiden/internal/telegram"
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "eval" {
+		os.Exit(runEval(os.Args[2:], os.Stdout, os.Stderr))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "chat" {
+		os.Exit(runChat(os.Args[2:], os.Stdin, os.Stdout, os.Stderr))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "admin-key" {
+		os.Exit(runAdminKey(os.Args[2:], os.Stdout, os.Stderr))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "config" {
+		os.Exit(runConfig(os.Args[2:], os.Stdout, os.Stderr))
+	}
+
 	cfg, err := config.Load(".env")
 	if err != nil {
 		slog.Error("load config failed", slog.Any("error", err))
 		os.Exit(1)
 	}
 
-	log := logger.New(cfg.Log)
+	logLevel := new(slog.LevelVar)
+	log := logger.New(cfg.Log, logLevel)
 	log.Info("configuration loaded",
 		slog.String("env", cfg.AppEnv),
 		slog.String("http_port", cfg.HTTP.Port),
@@ -47,28 +66,113 @@ func main() {
 
 	log.Info("database connected")
 
-	readinessFn := func(ctx context.Context) error {
-		return dbConn.PingContext(ctx)
-	}
-
-	server := httpx.NewServer(cfg, log, readinessFn)
-
 	rootCtx, cancelRoot := context.WithCancel(context.Background())
 	defer cancelRoot()
 
+	rules := telegram.NewRuleSet(telegram.DefaultRules(), "embedded")
+	if cfg.Rules.File != "" {
+		rulesLoader := telegram.NewRulesLoader(cfg.Rules.File, cfg.Rules.ReloadInterval, rules, log)
+		if err := rulesLoader.Reload(); err != nil {
+			log.Error("load rules failed", slog.Any("error", err))
+			os.Exit(1)
+		}
+
+		hupCh := make(chan os.Signal, 1)
+		signal.Notify(hupCh, syscall.SIGHUP)
+		go rulesLoader.Watch(rootCtx, hupCh)
+	}
+
+	flagStore := flags.NewStore(dbConn)
+	flagEvaluator := flags.NewEvaluator(flagStore, cfg.AppEnv, log)
+	// Every flag stays off until a refresh succeeds, so a failure here is
+	// not fatal; Run keeps retrying.
+	if err := flagEvaluator.Refresh(rootCtx); err != nil {
+		log.Warn("load feature flags failed", slog.Any("error", err))
+	}
+	go flagEvaluator.Run(rootCtx, cfg.Flags.RefreshInterval)
+
 	telegramClient := telegram.NewHTTPClient(cfg.Telegram.BotToken, nil)
 	telegramStore := telegram.NewSQLStore(dbConn)
+	runtimeConfig := workerRuntimeConfig(cfg.Telegram)
 	telegramWorker := telegram.NewWorker(telegram.WorkerConfig{
 		PollTimeoutSec: cfg.Telegram.PollTimeoutSec,
-		PollInterval:   time.Duration(cfg.Telegram.PollIntervalMS) * time.Millisecond,
-		AllowedUpdates: telegram.ParseAllowedUpdates(cfg.Telegram.AllowedUpdates),
+		PollInterval:   runtimeConfig.PollInterval,
+		AllowedUpdates: runtimeConfig.AllowedUpdates,
+		Outbox: telegram.OutboxConfig{
+			PollInterval: time.Duration(cfg.Telegram.OutboxPollIntervalMS) * time.Millisecond,
+			BatchSize:    cfg.Telegram.OutboxBatchSize,
+			MaxAttempts:  cfg.Telegram.OutboxMaxAttempts,
+		},
+		RateLimit:        runtimeConfig.RateLimit,
+		Transcriber:      newTranscriber(cfg.Telegram),
+		MaxDownloadBytes: int64(cfg.Telegram.MaxDownloadBytes),
+		Session:          telegram.SessionConfig{Timeout: cfg.Session.Timeout},
+		Rules:            rules,
+		Flags:            flagEvaluator,
 	}, telegramClient, telegramStore, log)
 
+	configWatcher, err := config.NewWatcher(".env", cfg.Reload.Interval, func(next config.Config) {
+		logLevel.Set(logger.ParseLevel(next.Log.Level))
+		telegramWorker.ApplyRuntimeConfig(workerRuntimeConfig(next.Telegram))
+	}, log)
+	if err != nil {
+		log.Error("start config watcher failed", slog.Any("error", err))
+		os.Exit(1)
+	}
+	configHupCh := make(chan os.Signal, 1)
+	signal.Notify(configHupCh, syscall.SIGHUP)
+	go configWatcher.Watch(rootCtx, configHupCh)
+
+	maintenanceRunner := maintenance.NewRunner(maintenance.Config{
+		Interval:   cfg.Maintenance.Interval,
+		BatchSize:  cfg.Maintenance.BatchSize,
+		TimeBudget: cfg.Maintenance.TimeBudget,
+	}, maintenance.NewPGLocker(dbConn, log), telegram.MaintenanceTasks(telegramStore, telegram.MaintenanceConfig{
+		DedupHorizon:     cfg.Maintenance.DedupHorizon,
+		ActionLogHorizon: cfg.Maintenance.ActionLogHorizon,
+		ConfirmationTTL:  cfg.Maintenance.ConfirmationTTL,
+		SessionTimeout:   cfg.Session.Timeout,
+		ArchiveAfter:     cfg.Session.ArchiveAfter,
+		Retention: telegram.RetentionConfig{
+			TextTTL:  cfg.Retention.TextTTL,
+			HashSalt: cfg.Retention.HashSalt,
+			Rules:    rules,
+		},
+	}), log)
+
+	server := httpx.NewServer(cfg, log, httpx.Dependencies{
+		Ready: []handlers.ReadinessCheck{
+			{Name: "database", Check: dbConn.PingContext},
+			{Name: "schema_version", Check: func(ctx context.Context) error {
+				return db.CheckSchemaVersion(ctx, dbConn)
+			}},
+			{Name: "telegram_polling", Check: func(context.Context) error {
+				return telegramWorker.CheckPolling(cfg.Health.MaxPollFailures)
+			}},
+			{Name: "outbox_backlog", Check: func(ctx context.Context) error {
+				return telegram.CheckOutboxBacklog(ctx, telegramStore, int64(cfg.Health.MaxOutboxBacklog))
+			}},
+		},
+		Live: func() error {
+			return telegramWorker.CheckHeartbeat(cfg.Health.HeartbeatTimeout)
+		},
+		Outbox: telegramStore,
+		Rules:  rules,
+		Admin:  telegramStore,
+
+		Maintenance: maintenanceRunner,
+
+		Flags:         flagStore,
+		FlagEvaluator: flagEvaluator,
+
+		AdminKeys: auth.NewKeyStore(dbConn),
+	})
+
 	serverErrCh := make(chan error, 1)
 	botErrCh := make(chan error, 1)
 
 	var workerWG sync.WaitGroup
-	workerWG.Add(1)
+	workerWG.Add(2)
 	go func() {
 		defer workerWG.Done()
 		if err := telegramWorker.Run(rootCtx); err != nil {
@@ -77,6 +181,10 @@ func main() {
 		}
 		botErrCh <- nil
 	}()
+	go func() {
+		defer workerWG.Done()
+		maintenanceRunner.Run(rootCtx)
+	}()
 
 	go func() {
 		log.Info("http server listening", slog.String("addr", server.Addr))
@@ -88,6 +196,20 @@ func main() {
 		serverErrCh <- nil
 	}()
 
+	var debugServer *http.Server
+	if cfg.Debug.Enabled {
+		debugServer = httpx.NewDebugServer(cfg, telegramWorker)
+		if !isLoopbackAddr(debugServer.Addr) {
+			log.Warn("debug listener is not bound to loopback", slog.String("addr", debugServer.Addr))
+		}
+		go func() {
+			log.Info("debug server listening", slog.String("addr", debugServer.Addr))
+			if err := debugServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				log.Error("debug server failed", slog.Any("error", err))
+			}
+		}()
+	}
+
 	sigCh := make(chan os.Signal, 1)
 	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
 
@@ -117,6 +239,12 @@ func main() {
 		exitCode = 1
 	}
 
+	if debugServer != nil {
+		if err := debugServer.Shutdown(ctx); err != nil {
+			log.Warn("debug server shutdown failed", slog.Any("error", err))
+		}
+	}
+
 	select {
 	case err := <-serverErrCh:
 		if err != nil && !errors.Is(err, http.ErrServerClosed) {
@@ -135,7 +263,7 @@ func main() {
 	select {
 	case <-done:
 	case <-time.After(cfg.HTTP.ShutdownTimeout):
-		log.Warn("telegram worker shutdown timeout reached")
+		log.Warn("background workers shutdown timeout reached")
 		exitCode = 1
 	}
 
@@ -145,3 +273,36 @@ func main() {
 		os.Exit(exitCode)
 	}
 }
+
+// workerRuntimeConfig maps the settings the config watcher may reload.
+func workerRuntimeConfig(cfg config.TelegramConfig) telegram.RuntimeConfig {
+	return telegram.RuntimeConfig{
+		PollInterval:   time.Duration(cfg.PollIntervalMS) * time.Millisecond,
+		AllowedUpdates: telegram.ParseAllowedUpdates(cfg.AllowedUpdates),
+		RateLimit: telegram.RateLimitConfig{
+			GlobalPerSecond:  cfg.RateGlobalPerSec,
+			GlobalBurst:      cfg.RateGlobalBurst,
+			PerChatPerSecond: cfg.RatePerChatPerSec,
+			PerChatBurst:     cfg.RatePerChatBurst,
+		},
+	}
+}
+
+func isLoopbackAddr(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return false
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+func newTranscriber(cfg config.TelegramConfig) telegram.Transcriber {
+	if cfg.Transcriber == "stub" {
+		return telegram.NewStubTranscriber(cfg.TranscriberStubText)
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestKeyFormatRoundTrip(t *testing.T) {
+	keyID, secret, err := generateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	token := formatKey(keyID, secret)
+
+	gotID, gotSecret, ok := splitKey(token)
+	if !ok || gotID != keyID || gotSecret != secret {
+		t.Fatalf("splitKey(%q)=(%q, %q, %v)", token, gotID, gotSecret, ok)
+	}
+	if KeyIDOf(token) != keyID || strings.Contains(KeyIDOf(token), secret) {
+		t.Fatalf("KeyIDOf=%q", KeyIDOf(token))
+	}
+	if hashSecret(secret) == secret || hashSecret(secret) != hashSecret(secret) {
+		t.Fatal("hashSecret must be deterministic and differ from the secret")
+	}
+
+	for _, bad := range []string{"", "secret", "aik_" + keyID, "aik_" + keyID + "_short", "aik_zz" + keyID[2:] + "_" + secret} {
+		if _, _, ok := splitKey(bad); ok || KeyIDOf(bad) != "" {
+			t.Fatalf("splitKey(%q) accepted a malformed key", bad)
+		}
+	}
+}
+
+func TestParseScopes(t *testing.T) {
+	scopes, err := ParseScopes(" read, export,read ")
+	if err != nil || len(scopes) != 2 || scopes[0] != ScopeRead || scopes[1] != ScopeExport {
+		t.Fatalf("ParseScopes=(%v, %v)", scopes, err)
+	}
+	if _, err := ParseScopes("read,admin"); err == nil {
+		t.Fatal("unknown scope accepted")
+	}
+	if _, err := ParseScopes(" , "); err == nil {
+		t.Fatal("empty scope list accepted")
+	}
+}
+
+type failingVerifier struct{ err error }
+
+func (f failingVerifier) Verify(context.Context, string) (Principal, error) {
+	return Principal{}, f.err
+}
+
+func TestChainVerify(t *testing.T) {
+	ctx := context.Background()
+	chain := Chain{NewStaticToken("break-glass"), failingVerifier{err: ErrInvalidKey}}
+
+	principal, err := chain.Verify(ctx, "break-glass")
+	if err != nil || principal.KeyID != StaticTokenID || !principal.Has(ScopeWrite) || !principal.Has(ScopeExport) {
+		t.Fatalf("static token=(%+v, %v)", principal, err)
+	}
+	if _, err := chain.Verify(ctx, "guess"); !errors.Is(err, ErrInvalidKey) {
+		t.Fatalf("err=%v, want ErrInvalidKey", err)
+	}
+
+	down := errors.New("database down")
+	if _, err := (Chain{NewStaticToken(""), failingVerifier{err: down}}).Verify(ctx, ""); !errors.Is(err, down) {
+		t.Fatalf("err=%v, want the store error", err)
+	}
+}
@@ -0,0 +1,194 @@
+package auth
+
+import (
+	"context"
+	"crypto/subtle"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// APIKey is a stored admin API key. The secret itself is never stored.
+type APIKey struct {
+	KeyID      string
+	Name       string
+	Scopes     []Scope
+	CreatedBy  string
+	CreatedAt  time.Time
+	LastUsedAt *time.Time
+	RevokedAt  *time.Time
+}
+
+// lastUsedResolution is how stale last_used_at may get. Verify runs on every
+// admin request, so it only writes once the stored value is older than this.
+const lastUsedResolution = time.Minute
+
+// KeyStore keeps admin API keys in the admin_api_keys table.
+type KeyStore struct {
+	db    *sql.DB
+	types *pgtype.Map
+}
+
+func NewKeyStore(db *sql.DB) *KeyStore {
+	return &KeyStore{db: db, types: pgtype.NewMap()}
+}
+
+// Create stores a new key and returns it with the full token, which is shown
+// once and cannot be recovered later.
+func (s *KeyStore) Create(ctx context.Context, name string, scopes []Scope, createdBy string) (APIKey, string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return APIKey{}, "", fmt.Errorf("API key name is required")
+	}
+	if len(scopes) == 0 {
+		return APIKey{}, "", fmt.Errorf("at least one scope is required")
+	}
+
+	keyID, secret, err := generateKey()
+	if err != nil {
+		return APIKey{}, "", err
+	}
+
+	key := APIKey{KeyID: keyID, Name: name, Scopes: scopes, CreatedBy: createdBy}
+	if err := s.db.QueryRowContext(
+		ctx,
+		`INSERT INTO admin_api_keys(key_id, name, secret_hash, scopes, created_by)
+		 VALUES ($1, $2, $3, $4, $5)
+		 RETURNING created_at`,
+		keyID,
+		name,
+		hashSecret(secret),
+		scopeStrings(scopes),
+		createdBy,
+	).Scan(&key.CreatedAt); err != nil {
+		return APIKey{}, "", fmt.Errorf("insert admin API key: %w", err)
+	}
+	return key, formatKey(keyID, secret), nil
+}
+
+func (s *KeyStore) List(ctx context.Context) ([]APIKey, error) {
+	rows, err := s.db.QueryContext(
+		ctx,
+		`SELECT key_id, name, scopes, created_by, created_at, last_used_at, revoked_at
+		 FROM admin_api_keys
+		 ORDER BY created_at, key_id`,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("list admin API keys: %w", err)
+	}
+	defer rows.Close()
+
+	var keys []APIKey
+	for rows.Next() {
+		var (
+			key        APIKey
+			scopes     []string
+			lastUsedAt sql.NullTime
+			revokedAt  sql.NullTime
+		)
+		if err := rows.Scan(&key.KeyID, &key.Name, s.types.SQLScanner(&scopes), &key.CreatedBy, &key.CreatedAt, &lastUsedAt, &revokedAt); err != nil {
+			return nil, fmt.Errorf("scan admin API key: %w", err)
+		}
+		key.Scopes = toScopes(scopes)
+		if lastUsedAt.Valid {
+			key.LastUsedAt = &lastUsedAt.Time
+		}
+		if revokedAt.Valid {
+			key.RevokedAt = &revokedAt.Time
+		}
+		keys = append(keys, key)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate admin API keys: %w", err)
+	}
+	return keys, nil
+}
+
+// Revoke marks a key as revoked. It reports false when no active key has that ID.
+func (s *KeyStore) Revoke(ctx context.Context, keyID string) (bool, error) {
+	result, err := s.db.ExecContext(
+		ctx,
+		`UPDATE admin_api_keys
+		 SET revoked_at = NOW()
+		 WHERE key_id = $1
+		   AND revoked_at IS NULL`,
+		keyID,
+	)
+	if err != nil {
+		return false, fmt.Errorf("revoke admin API key: %w", err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("read revoke admin API key rows affected: %w", err)
+	}
+	return affected > 0, nil
+}
+
+func (s *KeyStore) Verify(ctx context.Context, token string) (Principal, error) {
+	keyID, secret, ok := splitKey(token)
+	if !ok {
+		return Principal{}, ErrInvalidKey
+	}
+
+	var (
+		name       string
+		secretHash string
+		scopes     []string
+		revokedAt  sql.NullTime
+	)
+	err := s.db.QueryRowContext(
+		ctx,
+		`SELECT name, secret_hash, scopes, revoked_at
+		 FROM admin_api_keys
+		 WHERE key_id = $1`,
+		keyID,
+	).Scan(&name, &secretHash, s.types.SQLScanner(&scopes), &revokedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Principal{}, ErrInvalidKey
+	}
+	if err != nil {
+		return Principal{}, fmt.Errorf("load admin API key: %w", err)
+	}
+
+	if subtle.ConstantTimeCompare([]byte(hashSecret(secret)), []byte(secretHash)) != 1 {
+		return Principal{}, ErrInvalidKey
+	}
+	// Only report revocation to callers who hold the secret, so key IDs
+	// cannot be probed.
+	if revokedAt.Valid {
+		return Principal{}, ErrKeyRevoked
+	}
+
+	if _, err := s.db.ExecContext(
+		ctx,
+		`UPDATE admin_api_keys
+		 SET last_used_at = NOW()
+		 WHERE key_id = $1
+		   AND (last_used_at IS NULL OR last_used_at < NOW() - $2::float8 * INTERVAL '1 second')`,
+		keyID,
+		lastUsedResolution.Seconds(),
+	); err != nil {
+		return Principal{}, fmt.Errorf("touch admin API key: %w", err)
+	}
+	return Principal{KeyID: keyID, Name: name, Scopes: toScopes(scopes)}, nil
+}
+
+func scopeStrings(scopes []Scope) []string {
+	values := make([]string, len(scopes))
+	for i, scope := range scopes {
+		values[i] = string(scope)
+	}
+	return values
+}
+
+func toScopes(values []string) []Scope {
+	scopes := make([]Scope, len(values))
+	for i, value := range values {
+		scopes[i] = Scope(value)
+	}
+	return scopes
+}
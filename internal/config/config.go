@@ -1,10 +1,8 @@
 package config
 
 import (
-	"errors"
 	"fmt"
-	"os"
-	"strconv"
+	"net/url"
 	"strings"
 	"time"
 )
@@ -12,11 +10,20 @@ import (
 const defaultEnvFile = ".env"
 
 type Config struct {
-	AppEnv   string
-	HTTP     HTTPConfig
-	Database DatabaseConfig
-	Telegram TelegramConfig
-	Log      LogConfig
+	AppEnv      string
+	HTTP        HTTPConfig
+	Database    DatabaseConfig
+	Telegram    TelegramConfig
+	Session     SessionConfig
+	Rules       RulesConfig
+	Admin       AdminConfig
+	Retention   RetentionConfig
+	Maintenance MaintenanceConfig
+	Health      HealthConfig
+	Debug       DebugConfig
+	Reload      ReloadConfig
+	Flags       FlagsConfig
+	Log         LogConfig
 }
 
 type HTTPConfig struct {
@@ -34,10 +41,92 @@ type DatabaseConfig struct {
 }
 
 type TelegramConfig struct {
-	BotToken       string
-	PollTimeoutSec int
-	PollIntervalMS int
-	AllowedUpdates string
+	BotToken             string
+	PollTimeoutSec       int
+	PollIntervalMS       int
+	AllowedUpdates       string
+	OutboxPollIntervalMS int
+	OutboxBatchSize      int
+	OutboxMaxAttempts    int
+	RateGlobalPerSec     float64
+	RateGlobalBurst      int
+	RatePerChatPerSec    float64
+	RatePerChatBurst     int
+	MaxDownloadBytes     int
+	Transcriber          string
+	TranscriberStubText  string
+}
+
+type SessionConfig struct {
+	Timeout      time.Duration
+	ArchiveAfter time.Duration
+}
+
+type RulesConfig struct {
+	File           string
+	ReloadInterval time.Duration
+}
+
+type AdminConfig struct {
+	// Token is a break-glass credential with every scope; prefer API keys
+	// created with "aiden admin-key create".
+	Token string
+	// AuthMaxFailures failed attempts from one client within
+	// AuthFailureWindow lock that client out until the window ends.
+	AuthMaxFailures   int
+	AuthFailureWindow time.Duration
+}
+
+// RetentionConfig controls how long conversation turns keep their original
+// text before it is replaced by a salted hash and a redacted summary.
+type RetentionConfig struct {
+	// TextTTL is the age at which turn text is redacted; 0 keeps it forever.
+	TextTTL  time.Duration
+	HashSalt string
+}
+
+// MaintenanceConfig drives the background cleanups. One instance at a time
+// runs them, every Interval, in batches of BatchSize rows and for at most
+// TimeBudget per task.
+type MaintenanceConfig struct {
+	Interval   time.Duration
+	BatchSize  int
+	TimeBudget time.Duration
+	// DedupHorizon must stay past Telegram's 24h redelivery window.
+	DedupHorizon time.Duration
+	// ActionLogHorizon of 0 keeps agent_action_logs forever.
+	ActionLogHorizon time.Duration
+	// ConfirmationTTL disarms /reset confirmations left pending this long.
+	ConfirmationTTL time.Duration
+}
+
+// HealthConfig sets the thresholds behind /healthz and /readyz.
+type HealthConfig struct {
+	// HeartbeatTimeout is how long the polling loop may go without a beat
+	// before /healthz reports it stuck; it must exceed a long poll.
+	HeartbeatTimeout time.Duration
+	// MaxPollFailures consecutive failed getUpdates calls make /readyz fail.
+	MaxPollFailures  int
+	MaxOutboxBacklog int
+}
+
+// DebugConfig enables the second listener serving pprof and runtime state.
+// It has no authentication, so Addr should stay on loopback.
+type DebugConfig struct {
+	Enabled bool
+	Addr    string
+}
+
+// ReloadConfig controls the config watcher. Interval is how often .env and
+// the config file are checked for changes; 0 reloads on SIGHUP only.
+type ReloadConfig struct {
+	Interval time.Duration
+}
+
+// FlagsConfig controls how often each instance reloads feature flags, so a
+// change made through another instance applies here within RefreshInterval.
+type FlagsConfig struct {
+	RefreshInterval time.Duration
 }
 
 type LogConfig struct {
@@ -46,24 +135,39 @@ type LogConfig struct {
 }
 
 func Load(envFilePath string) (Config, error) {
+	cfg, _, err := LoadSettings(envFilePath)
+	if err != nil {
+		return Config{}, err
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return Config{}, err
+	}
+
+	return cfg, nil
+}
+
+// LoadSettings resolves every key from the layers described in loader and
+// reports where each value came from. It does not validate the result.
+func LoadSettings(envFilePath string) (Config, []Setting, error) {
 	if envFilePath == "" {
 		envFilePath = defaultEnvFile
 	}
 
-	if err := loadDotEnv(envFilePath); err != nil && !errors.Is(err, os.ErrNotExist) {
-		return Config{}, fmt.Errorf("load env file: %w", err)
+	l, err := newLoader(envFilePath)
+	if err != nil {
+		return Config{}, nil, err
 	}
 
-	cfg, err := readFromEnv()
+	cfg, err := readConfig(l)
 	if err != nil {
-		return Config{}, err
+		return Config{}, nil, err
 	}
-
-	if err := cfg.Validate(); err != nil {
-		return Config{}, err
+	if err := l.checkUnknownKeys(); err != nil {
+		return Config{}, nil, err
 	}
 
-	return cfg, nil
+	return cfg, l.settings, nil
 }
 
 func (c Config) Validate() error {
@@ -88,6 +192,82 @@ func (c Config) Validate() error {
 	if c.Telegram.PollIntervalMS < 0 {
 		return fmt.Errorf("TELEGRAM_POLL_INTERVAL_MS must be >= 0")
 	}
+	if c.Telegram.OutboxPollIntervalMS <= 0 {
+		return fmt.Errorf("TELEGRAM_OUTBOX_POLL_INTERVAL_MS must be > 0")
+	}
+	if c.Telegram.OutboxBatchSize <= 0 {
+		return fmt.Errorf("TELEGRAM_OUTBOX_BATCH_SIZE must be > 0")
+	}
+	if c.Telegram.OutboxMaxAttempts <= 0 {
+		return fmt.Errorf("TELEGRAM_OUTBOX_MAX_ATTEMPTS must be > 0")
+	}
+	if c.Telegram.RateGlobalPerSec < 0 || c.Telegram.RatePerChatPerSec < 0 {
+		return fmt.Errorf("TELEGRAM_RATE_*_PER_SEC must be >= 0")
+	}
+	if c.Telegram.RateGlobalBurst < 0 || c.Telegram.RatePerChatBurst < 0 {
+		return fmt.Errorf("TELEGRAM_RATE_*_BURST must be >= 0")
+	}
+	if c.Telegram.MaxDownloadBytes <= 0 {
+		return fmt.Errorf("TELEGRAM_MAX_DOWNLOAD_BYTES must be > 0")
+	}
+	switch c.Telegram.Transcriber {
+	case "none", "stub":
+	default:
+		return fmt.Errorf("TRANSCRIBER must be one of none, stub")
+	}
+	if c.Session.Timeout <= 0 {
+		return fmt.Errorf("SESSION_TIMEOUT must be > 0")
+	}
+	if c.Session.ArchiveAfter < 0 {
+		return fmt.Errorf("SESSION_ARCHIVE_AFTER must be >= 0")
+	}
+	if c.Session.ArchiveAfter > 0 && c.Session.ArchiveAfter <= c.Session.Timeout {
+		return fmt.Errorf("SESSION_ARCHIVE_AFTER must be > SESSION_TIMEOUT")
+	}
+	if c.Rules.ReloadInterval < 0 {
+		return fmt.Errorf("RULES_RELOAD_INTERVAL must be >= 0")
+	}
+	if c.Admin.AuthMaxFailures <= 0 || c.Admin.AuthFailureWindow <= 0 {
+		return fmt.Errorf("ADMIN_AUTH_MAX_FAILURES and ADMIN_AUTH_FAILURE_WINDOW must be > 0")
+	}
+	if c.Retention.TextTTL < 0 {
+		return fmt.Errorf("RETENTION_TEXT_TTL must be >= 0")
+	}
+	if c.Retention.TextTTL > 0 {
+		if c.Retention.TextTTL <= c.Session.Timeout {
+			return fmt.Errorf("RETENTION_TEXT_TTL must be > SESSION_TIMEOUT")
+		}
+		if strings.TrimSpace(c.Retention.HashSalt) == "" {
+			return fmt.Errorf("RETENTION_HASH_SALT is required when RETENTION_TEXT_TTL > 0")
+		}
+	}
+	if c.Maintenance.Interval <= 0 || c.Maintenance.BatchSize <= 0 || c.Maintenance.TimeBudget <= 0 {
+		return fmt.Errorf("MAINTENANCE_INTERVAL, MAINTENANCE_BATCH_SIZE and MAINTENANCE_TIME_BUDGET must be > 0")
+	}
+	if c.Maintenance.DedupHorizon < 25*time.Hour {
+		return fmt.Errorf("MAINTENANCE_DEDUP_HORIZON must be >= 25h to stay past Telegram's 24h redelivery window")
+	}
+	if c.Maintenance.ActionLogHorizon < 0 {
+		return fmt.Errorf("MAINTENANCE_ACTION_LOG_HORIZON must be >= 0")
+	}
+	if c.Maintenance.ConfirmationTTL <= 0 {
+		return fmt.Errorf("MAINTENANCE_CONFIRMATION_TTL must be > 0")
+	}
+	if c.Health.HeartbeatTimeout <= time.Duration(c.Telegram.PollTimeoutSec)*time.Second {
+		return fmt.Errorf("HEALTH_HEARTBEAT_TIMEOUT must be > TELEGRAM_POLL_TIMEOUT_SEC")
+	}
+	if c.Health.MaxPollFailures <= 0 || c.Health.MaxOutboxBacklog <= 0 {
+		return fmt.Errorf("HEALTH_MAX_POLL_FAILURES and HEALTH_MAX_OUTBOX_BACKLOG must be > 0")
+	}
+	if c.Debug.Enabled && strings.TrimSpace(c.Debug.Addr) == "" {
+		return fmt.Errorf("DEBUG_ADDR is required when DEBUG_ENABLED is true")
+	}
+	if c.Reload.Interval < 0 {
+		return fmt.Errorf("CONFIG_RELOAD_INTERVAL must be >= 0")
+	}
+	if c.Flags.RefreshInterval <= 0 {
+		return fmt.Errorf("FLAGS_REFRESH_INTERVAL must be > 0")
+	}
 	if c.HTTP.ReadTimeout <= 0 || c.HTTP.WriteTimeout <= 0 || c.HTTP.ShutdownTimeout <= 0 {
 		return fmt.Errorf("HTTP timeouts must be > 0")
 	}
@@ -107,126 +287,293 @@ func (c Config) Validate() error {
 	return nil
 }
 
-func readFromEnv() (Config, error) {
-	readTimeout, err := getEnvDuration("HTTP_READ_TIMEOUT", 10*time.Second)
+const redacted = "[REDACTED]"
+
+// Redacted returns a copy of the config that is safe to show: secrets are
+// masked and the DSN keeps everything but its password.
+func (c Config) Redacted() Config {
+	c.Database.DSN = redactDSN(c.Database.DSN)
+	c.Telegram.BotToken = redactSecret(c.Telegram.BotToken)
+	c.Admin.Token = redactSecret(c.Admin.Token)
+	c.Retention.HashSalt = redactSecret(c.Retention.HashSalt)
+	return c
+}
+
+func redactSecret(value string) string {
+	if value == "" {
+		return ""
+	}
+	return redacted
+}
+
+// redactDSN masks the password of a URL DSN. Key/value DSNs are masked
+// whole, since the password can sit anywhere in them.
+func redactDSN(dsn string) string {
+	if dsn == "" {
+		return ""
+	}
+	parsed, err := url.Parse(dsn)
+	if err != nil || parsed.Scheme == "" || parsed.Host == "" {
+		return redacted
+	}
+	return parsed.Redacted()
+}
+
+func readConfig(l *loader) (Config, error) {
+	readTimeout, err := l.getEnvDuration("HTTP_READ_TIMEOUT", 10*time.Second)
 	if err != nil {
 		return Config{}, err
 	}
 
-	writeTimeout, err := getEnvDuration("HTTP_WRITE_TIMEOUT", 10*time.Second)
+	writeTimeout, err := l.getEnvDuration("HTTP_WRITE_TIMEOUT", 10*time.Second)
 	if err != nil {
 		return Config{}, err
 	}
 
-	shutdownTimeout, err := getEnvDuration("HTTP_SHUTDOWN_TIMEOUT", 10*time.Second)
+	shutdownTimeout, err := l.getEnvDuration("HTTP_SHUTDOWN_TIMEOUT", 10*time.Second)
 	if err != nil {
 		return Config{}, err
 	}
 
-	pollTimeout, err := getEnvInt("TELEGRAM_POLL_TIMEOUT_SEC", 50)
+	pollTimeout, err := l.getEnvInt("TELEGRAM_POLL_TIMEOUT_SEC", 50)
 	if err != nil {
 		return Config{}, err
 	}
 
-	pollInterval, err := getEnvInt("TELEGRAM_POLL_INTERVAL_MS", 200)
+	pollInterval, err := l.getEnvInt("TELEGRAM_POLL_INTERVAL_MS", 200)
 	if err != nil {
 		return Config{}, err
 	}
 
-	maxOpenConns, err := getEnvInt("DB_MAX_OPEN_CONNS", 20)
+	outboxPollInterval, err := l.getEnvInt("TELEGRAM_OUTBOX_POLL_INTERVAL_MS", 1000)
 	if err != nil {
 		return Config{}, err
 	}
 
-	maxIdleConns, err := getEnvInt("DB_MAX_IDLE_CONNS", 10)
+	outboxBatchSize, err := l.getEnvInt("TELEGRAM_OUTBOX_BATCH_SIZE", 50)
 	if err != nil {
 		return Config{}, err
 	}
 
-	connMaxLifetime, err := getEnvDuration("DB_CONN_MAX_LIFETIME", 30*time.Minute)
+	outboxMaxAttempts, err := l.getEnvInt("TELEGRAM_OUTBOX_MAX_ATTEMPTS", 8)
 	if err != nil {
 		return Config{}, err
 	}
 
-	addSource, err := getEnvBool("LOG_ADD_SOURCE", false)
+	rateGlobalPerSec, err := l.getEnvFloat("TELEGRAM_RATE_GLOBAL_PER_SEC", 30)
 	if err != nil {
 		return Config{}, err
 	}
 
-	cfg := Config{
-		AppEnv: getEnv("APP_ENV", "development"),
-		HTTP: HTTPConfig{
-			Port:            getEnv("HTTP_PORT", "8080"),
-			ReadTimeout:     readTimeout,
-			WriteTimeout:    writeTimeout,
-			ShutdownTimeout: shutdownTimeout,
-		},
-		Database: DatabaseConfig{
-			DSN:             getEnv("DB_DSN", ""),
-			MaxOpenConns:    maxOpenConns,
-			MaxIdleConns:    maxIdleConns,
-			ConnMaxLifetime: connMaxLifetime,
-		},
-		Telegram: TelegramConfig{
-			BotToken:       getEnv("TELEGRAM_BOT_TOKEN", ""),
-			PollTimeoutSec: pollTimeout,
-			PollIntervalMS: pollInterval,
-			AllowedUpdates: getEnv("TELEGRAM_ALLOWED_UPDATES", "message"),
-		},
-		Log: LogConfig{
-			Level:     getEnv("LOG_LEVEL", "info"),
-			AddSource: addSource,
-		},
+	rateGlobalBurst, err := l.getEnvInt("TELEGRAM_RATE_GLOBAL_BURST", 30)
+	if err != nil {
+		return Config{}, err
 	}
 
-	return cfg, nil
-}
+	ratePerChatPerSec, err := l.getEnvFloat("TELEGRAM_RATE_PER_CHAT_PER_SEC", 1)
+	if err != nil {
+		return Config{}, err
+	}
 
-func getEnv(key, fallback string) string {
-	if value := strings.TrimSpace(os.Getenv(key)); value != "" {
-		return value
+	ratePerChatBurst, err := l.getEnvInt("TELEGRAM_RATE_PER_CHAT_BURST", 3)
+	if err != nil {
+		return Config{}, err
 	}
-	return fallback
-}
 
-func getEnvInt(key string, fallback int) (int, error) {
-	value := strings.TrimSpace(os.Getenv(key))
-	if value == "" {
-		return fallback, nil
+	maxDownloadBytes, err := l.getEnvInt("TELEGRAM_MAX_DOWNLOAD_BYTES", 20<<20)
+	if err != nil {
+		return Config{}, err
 	}
 
-	parsed, err := strconv.Atoi(value)
+	sessionTimeout, err := l.getEnvDuration("SESSION_TIMEOUT", 24*time.Hour)
 	if err != nil {
-		return 0, fmt.Errorf("parse %s as int: %w", key, err)
+		return Config{}, err
 	}
 
-	return parsed, nil
-}
+	sessionArchiveAfter, err := l.getEnvDuration("SESSION_ARCHIVE_AFTER", 30*24*time.Hour)
+	if err != nil {
+		return Config{}, err
+	}
 
-func getEnvDuration(key string, fallback time.Duration) (time.Duration, error) {
-	value := strings.TrimSpace(os.Getenv(key))
-	if value == "" {
-		return fallback, nil
+	rulesReloadInterval, err := l.getEnvDuration("RULES_RELOAD_INTERVAL", 5*time.Second)
+	if err != nil {
+		return Config{}, err
 	}
 
-	parsed, err := time.ParseDuration(value)
+	adminAuthMaxFailures, err := l.getEnvInt("ADMIN_AUTH_MAX_FAILURES", 10)
 	if err != nil {
-		return 0, fmt.Errorf("parse %s as duration: %w", key, err)
+		return Config{}, err
 	}
 
-	return parsed, nil
-}
+	adminAuthFailureWindow, err := l.getEnvDuration("ADMIN_AUTH_FAILURE_WINDOW", time.Minute)
+	if err != nil {
+		return Config{}, err
+	}
 
-func getEnvBool(key string, fallback bool) (bool, error) {
-	value := strings.TrimSpace(os.Getenv(key))
-	if value == "" {
-		return fallback, nil
+	retentionTextTTL, err := l.getEnvDuration("RETENTION_TEXT_TTL", 0)
+	if err != nil {
+		return Config{}, err
+	}
+
+	maintenanceInterval, err := l.getEnvDuration("MAINTENANCE_INTERVAL", 10*time.Minute)
+	if err != nil {
+		return Config{}, err
+	}
+
+	maintenanceBatchSize, err := l.getEnvInt("MAINTENANCE_BATCH_SIZE", 1000)
+	if err != nil {
+		return Config{}, err
+	}
+
+	maintenanceTimeBudget, err := l.getEnvDuration("MAINTENANCE_TIME_BUDGET", 30*time.Second)
+	if err != nil {
+		return Config{}, err
+	}
+
+	dedupHorizon, err := l.getEnvDuration("MAINTENANCE_DEDUP_HORIZON", 72*time.Hour)
+	if err != nil {
+		return Config{}, err
+	}
+
+	actionLogHorizon, err := l.getEnvDuration("MAINTENANCE_ACTION_LOG_HORIZON", 90*24*time.Hour)
+	if err != nil {
+		return Config{}, err
+	}
+
+	confirmationTTL, err := l.getEnvDuration("MAINTENANCE_CONFIRMATION_TTL", time.Hour)
+	if err != nil {
+		return Config{}, err
+	}
+
+	heartbeatTimeout, err := l.getEnvDuration("HEALTH_HEARTBEAT_TIMEOUT", 3*time.Minute)
+	if err != nil {
+		return Config{}, err
+	}
+
+	maxPollFailures, err := l.getEnvInt("HEALTH_MAX_POLL_FAILURES", 3)
+	if err != nil {
+		return Config{}, err
+	}
+
+	maxOutboxBacklog, err := l.getEnvInt("HEALTH_MAX_OUTBOX_BACKLOG", 1000)
+	if err != nil {
+		return Config{}, err
+	}
+
+	maxOpenConns, err := l.getEnvInt("DB_MAX_OPEN_CONNS", 20)
+	if err != nil {
+		return Config{}, err
 	}
 
-	parsed, err := strconv.ParseBool(value)
+	maxIdleConns, err := l.getEnvInt("DB_MAX_IDLE_CONNS", 10)
 	if err != nil {
-		return false, fmt.Errorf("parse %s as bool: %w", key, err)
+		return Config{}, err
+	}
+
+	connMaxLifetime, err := l.getEnvDuration("DB_CONN_MAX_LIFETIME", 30*time.Minute)
+	if err != nil {
+		return Config{}, err
+	}
+
+	reloadInterval, err := l.getEnvDuration("CONFIG_RELOAD_INTERVAL", 5*time.Second)
+	if err != nil {
+		return Config{}, err
+	}
+
+	flagsRefreshInterval, err := l.getEnvDuration("FLAGS_REFRESH_INTERVAL", 30*time.Second)
+	if err != nil {
+		return Config{}, err
 	}
 
-	return parsed, nil
+	debugEnabled, err := l.getEnvBool("DEBUG_ENABLED", false)
+	if err != nil {
+		return Config{}, err
+	}
+
+	addSource, err := l.getEnvBool("LOG_ADD_SOURCE", false)
+	if err != nil {
+		return Config{}, err
+	}
+
+	cfg := Config{
+		AppEnv: l.getEnv("APP_ENV", "development"),
+		HTTP: HTTPConfig{
+			Port:            l.getEnv("HTTP_PORT", "8080"),
+			ReadTimeout:     readTimeout,
+			WriteTimeout:    writeTimeout,
+			ShutdownTimeout: shutdownTimeout,
+		},
+		Database: DatabaseConfig{
+			DSN:             l.getEnv("DB_DSN", ""),
+			MaxOpenConns:    maxOpenConns,
+			MaxIdleConns:    maxIdleConns,
+			ConnMaxLifetime: connMaxLifetime,
+		},
+		Telegram: TelegramConfig{
+			BotToken:             l.getEnv("TELEGRAM_BOT_TOKEN", ""),
+			PollTimeoutSec:       pollTimeout,
+			PollIntervalMS:       pollInterval,
+			AllowedUpdates:       l.getEnv("TELEGRAM_ALLOWED_UPDATES", "message,edited_message"),
+			OutboxPollIntervalMS: outboxPollInterval,
+			OutboxBatchSize:      outboxBatchSize,
+			OutboxMaxAttempts:    outboxMaxAttempts,
+			RateGlobalPerSec:     rateGlobalPerSec,
+			RateGlobalBurst:      rateGlobalBurst,
+			RatePerChatPerSec:    ratePerChatPerSec,
+			RatePerChatBurst:     ratePerChatBurst,
+			MaxDownloadBytes:     maxDownloadBytes,
+			Transcriber:          strings.ToLower(l.getEnv("TRANSCRIBER", "none")),
+			TranscriberStubText:  l.getEnv("TRANSCRIBER_STUB_TEXT", ""),
+		},
+		Session: SessionConfig{
+			Timeout:      sessionTimeout,
+			ArchiveAfter: sessionArchiveAfter,
+		},
+		Rules: RulesConfig{
+			File:           l.getEnv("RULES_FILE", ""),
+			ReloadInterval: rulesReloadInterval,
+		},
+		Admin: AdminConfig{
+			Token:             l.getEnv("ADMIN_TOKEN", ""),
+			AuthMaxFailures:   adminAuthMaxFailures,
+			AuthFailureWindow: adminAuthFailureWindow,
+		},
+		Retention: RetentionConfig{
+			TextTTL:  retentionTextTTL,
+			HashSalt: l.getEnv("RETENTION_HASH_SALT", ""),
+		},
+		Maintenance: MaintenanceConfig{
+			Interval:         maintenanceInterval,
+			BatchSize:        maintenanceBatchSize,
+			TimeBudget:       maintenanceTimeBudget,
+			DedupHorizon:     dedupHorizon,
+			ActionLogHorizon: actionLogHorizon,
+			ConfirmationTTL:  confirmationTTL,
+		},
+		Health: HealthConfig{
+			HeartbeatTimeout: heartbeatTimeout,
+			MaxPollFailures:  maxPollFailures,
+			MaxOutboxBacklog: maxOutboxBacklog,
+		},
+		Debug: DebugConfig{
+			Enabled: debugEnabled,
+			Addr:    l.getEnv("DEBUG_ADDR", "127.0.0.1:6060"),
+		},
+		Reload: ReloadConfig{
+			Interval: reloadInterval,
+		},
+		Flags: FlagsConfig{
+			RefreshInterval: flagsRefreshInterval,
+		},
+		Log: LogConfig{
+			Level:     l.getEnv("LOG_LEVEL", "info"),
+			AddSource: addSource,
+		},
+	}
+
+	if l.err != nil {
+		return Config{}, l.err
+	}
+
+	return cfg, nil
 }
@@ -0,0 +1,143 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRedactedMasksSecrets(t *testing.T) {
+	cfg := Config{
+		Database:  DatabaseConfig{DSN: "postgres://aiden:s3cret@db:5432/aiden?sslmode=disable"},
+		Telegram:  TelegramConfig{BotToken: "123:abc"},
+		Admin:     AdminConfig{Token: "admin-token"},
+		Retention: RetentionConfig{HashSalt: "salt"},
+	}
+
+	got := cfg.Redacted()
+	if strings.Contains(got.Database.DSN, "s3cret") || !strings.Contains(got.Database.DSN, "aiden:xxxxx@db:5432") {
+		t.Fatalf("dsn=%q", got.Database.DSN)
+	}
+	if got.Telegram.BotToken != redacted || got.Admin.Token != redacted || got.Retention.HashSalt != redacted {
+		t.Fatalf("secrets not masked: %+v", got)
+	}
+	if cfg.Telegram.BotToken != "123:abc" {
+		t.Fatal("Redacted modified the original config")
+	}
+
+	if dsn := (Config{Database: DatabaseConfig{DSN: "host=db password=s3cret"}}).Redacted().Database.DSN; dsn != redacted {
+		t.Fatalf("key/value dsn=%q", dsn)
+	}
+	if token := (Config{}).Redacted().Admin.Token; token != "" {
+		t.Fatalf("empty token became %q", token)
+	}
+}
+
+func writeFile(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("write %s: %v", name, err)
+	}
+	return path
+}
+
+func settingFor(settings []Setting, key string) Setting {
+	for _, setting := range settings {
+		if setting.Key == key {
+			return setting
+		}
+	}
+	return Setting{}
+}
+
+func TestLoadSettingsLayersEnvOverDotEnvOverYAML(t *testing.T) {
+	dir := t.TempDir()
+	tokenFile := writeFile(t, dir, "token", "123:from-file\n")
+	configFile := writeFile(t, dir, "config.yaml", `
+http:
+  port: 7000
+  read_timeout: 3s
+telegram:
+  bot_token_file: `+tokenFile+`
+  allowed_updates: [message, edited_message, callback_query]
+session:
+  timeout: 12h
+`)
+	envFile := writeFile(t, dir, ".env", "HTTP_PORT=8000\nSESSION_TIMEOUT='6h'\n")
+	t.Setenv("CONFIG_FILE", configFile)
+	t.Setenv("HTTP_PORT", "9000")
+
+	cfg, settings, err := LoadSettings(envFile)
+	if err != nil {
+		t.Fatalf("LoadSettings: %v", err)
+	}
+	if cfg.HTTP.Port != "9000" || cfg.Session.Timeout != 6*time.Hour || cfg.HTTP.ReadTimeout != 3*time.Second {
+		t.Fatalf("port=%s timeout=%s read=%s", cfg.HTTP.Port, cfg.Session.Timeout, cfg.HTTP.ReadTimeout)
+	}
+	if cfg.Telegram.BotToken != "123:from-file" || cfg.Telegram.AllowedUpdates != "message,edited_message,callback_query" {
+		t.Fatalf("telegram=%+v", cfg.Telegram)
+	}
+
+	for key, want := range map[string]string{
+		"HTTP_PORT":          "env",
+		"SESSION_TIMEOUT":    envFile,
+		"HTTP_READ_TIMEOUT":  configFile,
+		"TELEGRAM_BOT_TOKEN": configFile + " (TELEGRAM_BOT_TOKEN_FILE)",
+		"LOG_LEVEL":          "default",
+	} {
+		if got := settingFor(settings, key).Source; got != want {
+			t.Fatalf("%s source=%q, want %q", key, got, want)
+		}
+	}
+	if display := settingFor(settings, "TELEGRAM_BOT_TOKEN").Display(); display != redacted {
+		t.Fatalf("token displayed as %q", display)
+	}
+}
+
+func TestLoadSettingsRejectsConflictsAndTypos(t *testing.T) {
+	dir := t.TempDir()
+	envFile := filepath.Join(dir, ".env")
+
+	t.Setenv("ADMIN_TOKEN", "inline")
+	t.Setenv("ADMIN_TOKEN_FILE", writeFile(t, dir, "admin", "from-file"))
+	if _, _, err := LoadSettings(envFile); err == nil || !strings.Contains(err.Error(), "ADMIN_TOKEN and ADMIN_TOKEN_FILE") {
+		t.Fatalf("err=%v, want a conflict", err)
+	}
+	t.Setenv("ADMIN_TOKEN", "")
+	t.Setenv("ADMIN_TOKEN_FILE", "")
+
+	t.Setenv("CONFIG_FILE", writeFile(t, dir, "typo.yaml", "session:\n  timout: 1h\n"))
+	if _, _, err := LoadSettings(envFile); err == nil || !strings.Contains(err.Error(), "session.timout") {
+		t.Fatalf("err=%v, want the unknown key", err)
+	}
+
+	t.Setenv("CONFIG_FILE", filepath.Join(dir, "missing.yaml"))
+	if _, _, err := LoadSettings(envFile); err == nil {
+		t.Fatal("an explicit CONFIG_FILE that does not exist was ignored")
+	}
+}
+
+func TestParseDotEnvValue(t *testing.T) {
+	for raw, want := range map[string]string{
+		``:                    "",
+		`plain`:               "plain",
+		`plain # comment`:     "plain",
+		`a#b`:                 "a#b",
+		`"quoted # not"  # c`: "quoted # not",
+		`"line\nbreak \"q\""`: "line\nbreak \"q\"",
+		`'lit\n "x"'`:         `lit\n "x"`,
+	} {
+		got, err := parseDotEnvValue(raw)
+		if err != nil || got != want {
+			t.Fatalf("parseDotEnvValue(%q)=(%q, %v), want %q", raw, got, err, want)
+		}
+	}
+	for _, raw := range []string{`"open`, `'open`, `"a" b`} {
+		if _, err := parseDotEnvValue(raw); err == nil {
+			t.Fatalf("parseDotEnvValue(%q) accepted bad input", raw)
+		}
+	}
+}
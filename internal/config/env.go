@@ -4,50 +4,106 @@ import (
 	"bufio"
 	"fmt"
 	"os"
+	"regexp"
 	"strings"
 )
 
-func loadDotEnv(path string) error {
+var envKeyPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// parseDotEnv reads KEY=value lines. Values may be double-quoted (with \n,
+// \t, \" and \\ escapes), single-quoted (taken literally) or bare; a bare
+// value ends at " #", which starts a comment.
+func parseDotEnv(path string) (map[string]string, error) {
 	file, err := os.Open(path)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	defer file.Close()
 
+	values := make(map[string]string)
 	scanner := bufio.NewScanner(file)
+	lineNo := 0
 	for scanner.Scan() {
+		lineNo++
 		line := strings.TrimSpace(scanner.Text())
 		if line == "" || strings.HasPrefix(line, "#") {
 			continue
 		}
 
 		line = strings.TrimPrefix(line, "export ")
-		parts := strings.SplitN(line, "=", 2)
-		if len(parts) != 2 {
-			return fmt.Errorf("invalid .env line: %q", line)
+		key, rawValue, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fmt.Errorf("%s:%d: expected KEY=value", path, lineNo)
 		}
 
-		key := strings.TrimSpace(parts[0])
-		if key == "" {
-			return fmt.Errorf("empty .env key in line: %q", line)
+		key = strings.TrimSpace(key)
+		if !envKeyPattern.MatchString(key) {
+			return nil, fmt.Errorf("%s:%d: invalid key %q", path, lineNo, key)
 		}
 
-		value := strings.TrimSpace(parts[1])
-		value = strings.Trim(value, `"`)
-		value = strings.Trim(value, `'`)
-
-		if _, exists := os.LookupEnv(key); exists {
-			continue
+		value, err := parseDotEnvValue(strings.TrimSpace(rawValue))
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %s: %w", path, lineNo, key, err)
 		}
+		values[key] = value
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("scan %s: %w", path, err)
+	}
+
+	return values, nil
+}
+
+func parseDotEnvValue(raw string) (string, error) {
+	if raw == "" {
+		return "", nil
+	}
 
-		if err := os.Setenv(key, value); err != nil {
-			return fmt.Errorf("set env %s: %w", key, err)
+	switch raw[0] {
+	case '\'':
+		end := strings.IndexByte(raw[1:], '\'')
+		if end < 0 {
+			return "", fmt.Errorf("unterminated single quote")
+		}
+		return raw[1 : end+1], checkTrailing(raw[end+2:])
+	case '"':
+		var value strings.Builder
+		for i := 1; i < len(raw); i++ {
+			switch c := raw[i]; {
+			case c == '"':
+				return value.String(), checkTrailing(raw[i+1:])
+			case c == '\\' && i+1 < len(raw):
+				i++
+				switch raw[i] {
+				case 'n':
+					value.WriteByte('\n')
+				case 't':
+					value.WriteByte('\t')
+				default:
+					value.WriteByte(raw[i])
+				}
+			default:
+				value.WriteByte(c)
+			}
 		}
+		return "", fmt.Errorf("unterminated double quote")
 	}
 
-	if err := scanner.Err(); err != nil {
-		return fmt.Errorf("scan .env: %w", err)
+	if i := strings.Index(raw, " #"); i >= 0 {
+		raw = raw[:i]
+	}
+	if i := strings.Index(raw, "\t#"); i >= 0 {
+		raw = raw[:i]
 	}
+	return strings.TrimSpace(raw), nil
+}
 
+// checkTrailing allows only a comment after a closing quote.
+func checkTrailing(rest string) error {
+	rest = strings.TrimSpace(rest)
+	if rest != "" && !strings.HasPrefix(rest, "#") {
+		return fmt.Errorf("unexpected %q after closing quote", rest)
+	}
 	return nil
 }
@@ -0,0 +1,273 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const defaultConfigFile = "config.yaml"
+
+// secretKeys are masked by Setting.Display.
+var secretKeys = map[string]bool{
+	"DB_DSN":              true,
+	"TELEGRAM_BOT_TOKEN":  true,
+	"ADMIN_TOKEN":         true,
+	"RETENTION_HASH_SALT": true,
+}
+
+// Setting is one resolved key and the layer it came from.
+type Setting struct {
+	Key    string
+	Value  string
+	Source string
+}
+
+// Display returns the value with secrets masked.
+func (s Setting) Display() string {
+	if !secretKeys[s.Key] {
+		return s.Value
+	}
+	if s.Key == "DB_DSN" {
+		return redactDSN(s.Value)
+	}
+	return redactSecret(s.Value)
+}
+
+type layer struct {
+	name   string
+	values map[string]string
+	// paths maps a key back to how it was spelled in a YAML file.
+	paths map[string]string
+}
+
+// loader resolves each key from, in order: the process environment, the
+// .env file, config.yaml and finally the built-in default. In every layer
+// KEY_FILE names a file holding the value, for Docker and Kubernetes
+// secrets; setting both KEY and KEY_FILE in one layer is an error. Empty
+// values count as unset.
+type loader struct {
+	layers    []layer
+	requested map[string]bool
+	settings  []Setting
+	err       error
+}
+
+func newLoader(envFilePath string) (*loader, error) {
+	l := &loader{requested: make(map[string]bool)}
+	l.layers = append(l.layers, layer{name: "env", values: environ()})
+
+	dotEnv, err := parseDotEnv(envFilePath)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("load env file: %w", err)
+	}
+	if dotEnv != nil {
+		l.layers = append(l.layers, layer{name: envFilePath, values: dotEnv})
+	}
+
+	configFile := l.getEnv("CONFIG_FILE", defaultConfigFile)
+	if l.err != nil {
+		return nil, l.err
+	}
+	yamlLayer, err := loadYAMLLayer(configFile)
+	if errors.Is(err, os.ErrNotExist) && configFile == defaultConfigFile {
+		return l, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("load config file: %w", err)
+	}
+	l.layers = append(l.layers, yamlLayer)
+	return l, nil
+}
+
+func environ() map[string]string {
+	values := make(map[string]string)
+	for _, entry := range os.Environ() {
+		if key, value, ok := strings.Cut(entry, "="); ok {
+			values[key] = value
+		}
+	}
+	return values
+}
+
+// loadYAMLLayer flattens nested keys into env names: telegram.bot_token
+// becomes TELEGRAM_BOT_TOKEN and lists are joined with commas.
+func loadYAMLLayer(path string) (layer, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return layer{}, err
+	}
+
+	var document map[string]any
+	if err := yaml.Unmarshal(content, &document); err != nil {
+		return layer{}, fmt.Errorf("parse %s: %w", path, err)
+	}
+
+	result := layer{name: path, values: make(map[string]string), paths: make(map[string]string)}
+	if err := flattenYAML(result, "", "", document); err != nil {
+		return layer{}, fmt.Errorf("%s: %w", path, err)
+	}
+	return result, nil
+}
+
+func flattenYAML(into layer, key, path string, node any) error {
+	if nested, ok := node.(map[string]any); ok {
+		for name, child := range nested {
+			childKey, childPath := strings.ToUpper(name), name
+			if key != "" {
+				childKey, childPath = key+"_"+childKey, path+"."+name
+			}
+			if err := flattenYAML(into, childKey, childPath, child); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	var value string
+	switch typed := node.(type) {
+	case nil:
+		return nil
+	case map[any]any:
+		return fmt.Errorf("%s: keys must be strings", path)
+	case []any:
+		items := make([]string, 0, len(typed))
+		for _, item := range typed {
+			items = append(items, fmt.Sprint(item))
+		}
+		value = strings.Join(items, ",")
+	default:
+		value = fmt.Sprint(typed)
+	}
+
+	if previous, exists := into.paths[key]; exists {
+		return fmt.Errorf("%s and %s both set %s", previous, path, key)
+	}
+	into.values[key] = value
+	into.paths[key] = path
+	return nil
+}
+
+func (l *loader) lookup(key string) (value, source string, found bool, err error) {
+	l.requested[key] = true
+	for _, layer := range l.layers {
+		value := strings.TrimSpace(layer.values[key])
+		filePath := strings.TrimSpace(layer.values[key+"_FILE"])
+		switch {
+		case value != "" && filePath != "":
+			return "", "", false, fmt.Errorf("%s and %s_FILE are both set in %s", key, key, layer.name)
+		case value != "":
+			return value, layer.name, true, nil
+		case filePath != "":
+			content, err := os.ReadFile(filePath)
+			if err != nil {
+				return "", "", false, fmt.Errorf("read %s_FILE: %w", key, err)
+			}
+			return strings.TrimSpace(string(content)), fmt.Sprintf("%s (%s_FILE)", layer.name, key), true, nil
+		}
+	}
+	return "", "", false, nil
+}
+
+// resolve records the effective value of key and returns it, or "" when the
+// fallback applies.
+func (l *loader) resolve(key, fallback string) string {
+	value, source, found, err := l.lookup(key)
+	if err != nil {
+		l.err = errors.Join(l.err, err)
+		return ""
+	}
+	if !found {
+		l.settings = append(l.settings, Setting{Key: key, Value: fallback, Source: "default"})
+		return ""
+	}
+	l.settings = append(l.settings, Setting{Key: key, Value: value, Source: source})
+	return value
+}
+
+// checkUnknownKeys rejects YAML keys that no setting reads, so a typo does
+// not silently fall back to the default.
+func (l *loader) checkUnknownKeys() error {
+	var unknown []string
+	for _, layer := range l.layers {
+		for key := range layer.paths {
+			if !l.requested[key] && !l.requested[strings.TrimSuffix(key, "_FILE")] {
+				unknown = append(unknown, layer.paths[key])
+			}
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return fmt.Errorf("unknown config file keys: %s", strings.Join(unknown, ", "))
+	}
+	return nil
+}
+
+func (l *loader) getEnv(key, fallback string) string {
+	if value := l.resolve(key, fallback); value != "" {
+		return value
+	}
+	return fallback
+}
+
+func (l *loader) getEnvInt(key string, fallback int) (int, error) {
+	value := l.resolve(key, strconv.Itoa(fallback))
+	if value == "" {
+		return fallback, nil
+	}
+
+	parsed, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("parse %s as int: %w", key, err)
+	}
+
+	return parsed, nil
+}
+
+func (l *loader) getEnvFloat(key string, fallback float64) (float64, error) {
+	value := l.resolve(key, strconv.FormatFloat(fallback, 'f', -1, 64))
+	if value == "" {
+		return fallback, nil
+	}
+
+	parsed, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, fmt.Errorf("parse %s as float: %w", key, err)
+	}
+
+	return parsed, nil
+}
+
+func (l *loader) getEnvDuration(key string, fallback time.Duration) (time.Duration, error) {
+	value := l.resolve(key, fallback.String())
+	if value == "" {
+		return fallback, nil
+	}
+
+	parsed, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("parse %s as duration: %w", key, err)
+	}
+
+	return parsed, nil
+}
+
+func (l *loader) getEnvBool(key string, fallback bool) (bool, error) {
+	value := l.resolve(key, strconv.FormatBool(fallback))
+	if value == "" {
+		return fallback, nil
+	}
+
+	parsed, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("parse %s as bool: %w", key, err)
+	}
+
+	return parsed, nil
+}
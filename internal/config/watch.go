@@ -0,0 +1,190 @@
+package config
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"sort"
+	"time"
+)
+
+// reloadableKeys take effect without a restart. The apply callback given to
+// NewWatcher must push each of them to where it is used.
+var reloadableKeys = map[string]bool{
+	"LOG_LEVEL":                      true,
+	"TELEGRAM_POLL_INTERVAL_MS":      true,
+	"TELEGRAM_ALLOWED_UPDATES":       true,
+	"TELEGRAM_RATE_GLOBAL_PER_SEC":   true,
+	"TELEGRAM_RATE_GLOBAL_BURST":     true,
+	"TELEGRAM_RATE_PER_CHAT_PER_SEC": true,
+	"TELEGRAM_RATE_PER_CHAT_BURST":   true,
+}
+
+// Change is one key whose value differs between two loads. Old and New are
+// masked like Setting.Display.
+type Change struct {
+	Key        string
+	Old        string
+	New        string
+	Reloadable bool
+}
+
+// Diff lists the keys whose values differ, sorted by key.
+func Diff(old, new []Setting) []Change {
+	previous := make(map[string]Setting, len(old))
+	for _, setting := range old {
+		previous[setting.Key] = setting
+	}
+
+	var changes []Change
+	for _, setting := range new {
+		before, ok := previous[setting.Key]
+		if ok && before.Value == setting.Value {
+			continue
+		}
+		changes = append(changes, Change{
+			Key:        setting.Key,
+			Old:        before.Display(),
+			New:        setting.Display(),
+			Reloadable: reloadableKeys[setting.Key],
+		})
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Key < changes[j].Key })
+	return changes
+}
+
+// Watcher reloads the config on SIGHUP or when .env or the config file
+// changes, hands the reloadable subset to apply and reports everything else
+// as restart required. An invalid config is logged and ignored.
+type Watcher struct {
+	envFilePath string
+	interval    time.Duration
+	apply       func(Config)
+	logger      *slog.Logger
+	// settings are the values in effect: reloadable keys follow the files,
+	// the rest keep their startup values until a restart.
+	settings []Setting
+	modTimes map[string]time.Time
+}
+
+func NewWatcher(envFilePath string, interval time.Duration, apply func(Config), logger *slog.Logger) (*Watcher, error) {
+	if envFilePath == "" {
+		envFilePath = defaultEnvFile
+	}
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	_, settings, err := LoadSettings(envFilePath)
+	if err != nil {
+		return nil, err
+	}
+	w := &Watcher{envFilePath: envFilePath, interval: interval, apply: apply, logger: logger, settings: settings}
+	w.modTimes = w.statFiles()
+	return w, nil
+}
+
+// Reload loads the config once and applies what changed. It returns the
+// changes, including those that need a restart.
+func (w *Watcher) Reload() ([]Change, error) {
+	w.modTimes = w.statFiles()
+
+	cfg, settings, err := LoadSettings(w.envFilePath)
+	if err != nil {
+		return nil, err
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	changes := Diff(w.settings, settings)
+	reloaded := make(map[string]bool)
+	for _, change := range changes {
+		attrs := []any{slog.String("key", change.Key), slog.String("old", change.Old), slog.String("new", change.New)}
+		if change.Reloadable {
+			reloaded[change.Key] = true
+			w.logger.Info("config_reloaded", attrs...)
+			continue
+		}
+		w.logger.Warn("config_restart_required", attrs...)
+	}
+	if len(reloaded) == 0 {
+		return changes, nil
+	}
+
+	w.apply(cfg)
+	for i, setting := range w.settings {
+		if reloaded[setting.Key] {
+			for _, next := range settings {
+				if next.Key == setting.Key {
+					w.settings[i] = next
+				}
+			}
+		}
+	}
+	return changes, nil
+}
+
+// Watch reloads on every value from signals and, when the interval is > 0,
+// whenever .env or the config file changes on disk.
+func (w *Watcher) Watch(ctx context.Context, signals <-chan os.Signal) {
+	var tick <-chan time.Time
+	if w.interval > 0 {
+		ticker := time.NewTicker(w.interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-signals:
+			w.reloadAndLog("signal")
+		case <-tick:
+			if w.filesChanged() {
+				w.reloadAndLog("file_changed")
+			}
+		}
+	}
+}
+
+func (w *Watcher) reloadAndLog(trigger string) {
+	if _, err := w.Reload(); err != nil {
+		w.logger.Error("config_reload_failed",
+			slog.String("trigger", trigger),
+			slog.Any("error", err),
+		)
+	}
+}
+
+// statFiles records the modification time of each watched file; a missing
+// file is recorded as the zero time, so creating it counts as a change.
+func (w *Watcher) statFiles() map[string]time.Time {
+	paths := []string{w.envFilePath}
+	for _, setting := range w.settings {
+		if setting.Key == "CONFIG_FILE" {
+			paths = append(paths, setting.Value)
+		}
+	}
+
+	modTimes := make(map[string]time.Time, len(paths))
+	for _, path := range paths {
+		if info, err := os.Stat(path); err == nil {
+			modTimes[path] = info.ModTime()
+		} else {
+			modTimes[path] = time.Time{}
+		}
+	}
+	return modTimes
+}
+
+func (w *Watcher) filesChanged() bool {
+	current := w.statFiles()
+	for path, modTime := range current {
+		if !modTime.Equal(w.modTimes[path]) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,91 @@
+package config
+
+import (
+	"bytes"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const watchBaseEnv = "DB_DSN=postgres://aiden:pw@db/aiden\nTELEGRAM_BOT_TOKEN=old-token\n"
+
+func TestWatcherAppliesReloadableKeysAndReportsTheRest(t *testing.T) {
+	dir := t.TempDir()
+	envFile := writeFile(t, dir, ".env", watchBaseEnv+"LOG_LEVEL=info\nHTTP_PORT=8080\n")
+	t.Setenv("CONFIG_FILE", writeFile(t, dir, "config.yaml", "telegram:\n  rate_global_per_sec: 30\n"))
+
+	var applied []Config
+	var logs bytes.Buffer
+	watcher, err := NewWatcher(envFile, 0, func(cfg Config) { applied = append(applied, cfg) }, slog.New(slog.NewTextHandler(&logs, nil)))
+	if err != nil {
+		t.Fatalf("NewWatcher: %v", err)
+	}
+
+	writeFile(t, dir, ".env", "DB_DSN=postgres://aiden:pw@db/aiden\nTELEGRAM_BOT_TOKEN=new-token\nLOG_LEVEL=debug\nHTTP_PORT=9090\n")
+	writeFile(t, dir, "config.yaml", "telegram:\n  rate_global_per_sec: 10\n")
+	changes, err := watcher.Reload()
+	if err != nil {
+		t.Fatalf("Reload: %v", err)
+	}
+
+	got := make(map[string]Change)
+	for _, change := range changes {
+		got[change.Key] = change
+	}
+	if len(got) != 4 || !got["LOG_LEVEL"].Reloadable || !got["TELEGRAM_RATE_GLOBAL_PER_SEC"].Reloadable || got["HTTP_PORT"].Reloadable {
+		t.Fatalf("changes=%+v", changes)
+	}
+	if token := got["TELEGRAM_BOT_TOKEN"]; token.Reloadable || token.New != redacted {
+		t.Fatalf("token change=%+v, want restart required and masked", token)
+	}
+	if len(applied) != 1 || applied[0].Log.Level != "debug" || applied[0].Telegram.RateGlobalPerSec != 10 {
+		t.Fatalf("applied=%+v", applied)
+	}
+	if out := logs.String(); !strings.Contains(out, "config_restart_required key=HTTP_PORT") || strings.Contains(out, "new-token") {
+		t.Fatalf("logs=%s", out)
+	}
+
+	// The port still needs a restart; the level is already live.
+	changes, err = watcher.Reload()
+	if err != nil {
+		t.Fatalf("second Reload: %v", err)
+	}
+	if len(changes) != 2 || changes[0].Key != "HTTP_PORT" || len(applied) != 1 {
+		t.Fatalf("second reload changes=%+v applied=%d", changes, len(applied))
+	}
+}
+
+func TestWatcherKeepsConfigWhenReloadIsInvalid(t *testing.T) {
+	dir := t.TempDir()
+	envFile := writeFile(t, dir, ".env", watchBaseEnv+"LOG_LEVEL=info\n")
+	t.Setenv("CONFIG_FILE", filepath.Join(dir, "config.yaml"))
+	writeFile(t, dir, "config.yaml", "")
+
+	applied := 0
+	watcher, err := NewWatcher(envFile, 0, func(Config) { applied++ }, slog.New(slog.DiscardHandler))
+	if err != nil {
+		t.Fatalf("NewWatcher: %v", err)
+	}
+
+	writeFile(t, dir, ".env", watchBaseEnv+"LOG_LEVEL=debug\nTELEGRAM_POLL_TIMEOUT_SEC=0\n")
+	if _, err := watcher.Reload(); err == nil {
+		t.Fatal("invalid config was accepted")
+	}
+	if applied != 0 {
+		t.Fatalf("applied=%d, want 0", applied)
+	}
+	if watcher.filesChanged() {
+		t.Fatal("files reported changed right after a reload")
+	}
+	configFile := writeFile(t, dir, "config.yaml", "log:\n  level: warn\n")
+	later := time.Now().Add(time.Minute)
+	if err := os.Chtimes(configFile, later, later); err != nil {
+		t.Fatalf("chtimes: %v", err)
+	}
+	if !watcher.filesChanged() {
+		t.Fatal("config file change was not noticed")
+	}
+}
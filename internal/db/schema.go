@@ -0,0 +1,29 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// SchemaVersion is the migration this binary expects. Bump it together with
+// the schema_version row whenever a migration is added.
+const SchemaVersion = 29
+
+// CheckSchemaVersion fails unless the database is migrated to exactly
+// SchemaVersion.
+func CheckSchemaVersion(ctx context.Context, db *sql.DB) error {
+	var version int
+	err := db.QueryRowContext(ctx, `SELECT version FROM schema_version`).Scan(&version)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("schema_version is empty, want %d", SchemaVersion)
+	}
+	if err != nil {
+		return fmt.Errorf("query schema version: %w", err)
+	}
+	if version != SchemaVersion {
+		return fmt.Errorf("schema version %d, binary expects %d", version, SchemaVersion)
+	}
+	return nil
+}
@@ -0,0 +1,41 @@
+package db
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestSchemaVersionMatchesNewestMigration(t *testing.T) {
+	entries, err := os.ReadDir(filepath.Join("..", "..", "migrations"))
+	if err != nil {
+		t.Fatalf("read migrations: %v", err)
+	}
+
+	newest, newestFile := 0, ""
+	for _, entry := range entries {
+		if !strings.HasSuffix(entry.Name(), ".up.sql") {
+			continue
+		}
+		number, err := strconv.Atoi(strings.SplitN(entry.Name(), "_", 2)[0])
+		if err != nil {
+			continue
+		}
+		if number > newest {
+			newest, newestFile = number, entry.Name()
+		}
+	}
+	if newest != SchemaVersion {
+		t.Fatalf("SchemaVersion=%d, newest migration is %d", SchemaVersion, newest)
+	}
+
+	content, err := os.ReadFile(filepath.Join("..", "..", "migrations", newestFile))
+	if err != nil {
+		t.Fatalf("read %s: %v", newestFile, err)
+	}
+	if !strings.Contains(string(content), "schema_version") || !strings.Contains(string(content), strconv.Itoa(SchemaVersion)) {
+		t.Fatalf("%s does not set schema_version to %d", newestFile, SchemaVersion)
+	}
+}
@@ -0,0 +1,96 @@
+// Package eval measures intent routing and slot extraction against labeled
+// utterances, and exports real conversation turns as a labeling seed.
+package eval
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/congregalis/aiden/internal/telegram"
+)
+
+// Example is one labeled utterance, stored as a JSONL line:
+//
+//	{"text": "我想每周 5 小时学游泳", "state": "clarifying", "intent": "clarify_goal", "slots": ["main_goal", "time_budget"]}
+//
+// State defaults to clarifying. Slots lists every slot the text alone fills
+// and is only expected to be non-empty for clarify_goal examples, matching
+// the worker, which extracts slots from clarification turns only.
+type Example struct {
+	Text   string   `json:"text"`
+	State  string   `json:"state,omitempty"`
+	Intent string   `json:"intent"`
+	Slots  []string `json:"slots"`
+	// Note is free text for labelers and is ignored by the evaluation.
+	Note string `json:"note,omitempty"`
+}
+
+var knownIntents = []string{
+	telegram.IntentClarifyGoal,
+	telegram.IntentConfirmPlan,
+	telegram.IntentFallbackUnknown,
+	telegram.IntentShowStatus,
+	telegram.IntentResetSession,
+	telegram.IntentUndoTurn,
+	telegram.IntentSkipSlot,
+	telegram.IntentDisambiguate,
+}
+
+// ReadDataset parses a JSONL dataset. Blank lines are skipped; every other
+// line must be a valid example.
+func ReadDataset(r io.Reader) ([]Example, error) {
+	var examples []Example
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64<<10), 1<<20)
+	line := 0
+	for scanner.Scan() {
+		line++
+		raw := strings.TrimSpace(scanner.Text())
+		if raw == "" {
+			continue
+		}
+
+		var example Example
+		decoder := json.NewDecoder(strings.NewReader(raw))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&example); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		if err := example.validate(); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		if example.State == "" {
+			example.State = string(telegram.StateClarifying)
+		}
+		examples = append(examples, example)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read dataset: %w", err)
+	}
+	if len(examples) == 0 {
+		return nil, fmt.Errorf("dataset is empty")
+	}
+	return examples, nil
+}
+
+func (e Example) validate() error {
+	if strings.TrimSpace(e.Text) == "" {
+		return fmt.Errorf("text is required")
+	}
+	if !slices.Contains(knownIntents, e.Intent) {
+		return fmt.Errorf("unknown intent %q", e.Intent)
+	}
+	if e.State != "" && string(telegram.ParsePlanningState(e.State)) != e.State {
+		return fmt.Errorf("unknown state %q", e.State)
+	}
+	for _, slot := range e.Slots {
+		if !slices.Contains(telegram.RequiredSlots(), slot) {
+			return fmt.Errorf("unknown slot %q", slot)
+		}
+	}
+	return nil
+}
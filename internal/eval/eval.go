@@ -0,0 +1,250 @@
+package eval
+
+import (
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/congregalis/aiden/internal/telegram"
+)
+
+// maxListedMistakes caps the mistakes printed by WriteText; the JSON report
+// always carries all of them.
+const maxListedMistakes = 20
+
+type Prediction struct {
+	Intent string
+	Slots  []string
+}
+
+// Predictor is what gets evaluated. RulesPredictor covers the rule-based
+// router; another implementation can wrap a model behind the same interface.
+type Predictor interface {
+	Predict(text string, state telegram.PlanningState) Prediction
+}
+
+type RulesPredictor struct {
+	rules  *telegram.RuleSet
+	router telegram.IntentRouter
+}
+
+func NewRulesPredictor(rules *telegram.RuleSet) RulesPredictor {
+	return RulesPredictor{rules: rules, router: telegram.NewIntentRouter(rules)}
+}
+
+// Predict routes text like the worker does. As in the worker, slots are only
+// extracted from clarify_goal turns.
+func (p RulesPredictor) Predict(text string, state telegram.PlanningState) Prediction {
+	prediction := Prediction{Intent: p.router.Route(text, state).Intent}
+	if prediction.Intent != telegram.IntentClarifyGoal {
+		return prediction
+	}
+	filled := p.rules.Current().UpdateSlotCompletion(nil, text)
+	for _, slot := range telegram.RequiredSlots() {
+		if filled[slot] {
+			prediction.Slots = append(prediction.Slots, slot)
+		}
+	}
+	return prediction
+}
+
+type Metrics struct {
+	Label     string  `json:"label"`
+	Support   int     `json:"support"`
+	Predicted int     `json:"predicted"`
+	Correct   int     `json:"correct"`
+	Precision float64 `json:"precision"`
+	Recall    float64 `json:"recall"`
+	F1        float64 `json:"f1"`
+}
+
+// Confusion counts expected intents (rows) against predicted ones (columns).
+type Confusion struct {
+	Labels []string `json:"labels"`
+	Counts [][]int  `json:"counts"`
+}
+
+type Mistake struct {
+	Index        int      `json:"example"`
+	Text         string   `json:"text"`
+	State        string   `json:"state"`
+	WantIntent   string   `json:"want_intent"`
+	GotIntent    string   `json:"got_intent"`
+	MissingSlots []string `json:"missing_slots,omitempty"`
+	ExtraSlots   []string `json:"extra_slots,omitempty"`
+}
+
+type Report struct {
+	Examples       int       `json:"examples"`
+	IntentAccuracy float64   `json:"intent_accuracy"`
+	Intents        []Metrics `json:"intents"`
+	Slots          []Metrics `json:"slots"`
+	SlotMicroF1    float64   `json:"slot_micro_f1"`
+	Confusion      Confusion `json:"confusion"`
+	Mistakes       []Mistake `json:"mistakes"`
+}
+
+func Evaluate(examples []Example, predictor Predictor) Report {
+	report := Report{Examples: len(examples), Mistakes: []Mistake{}}
+
+	predictions := make([]Prediction, len(examples))
+	labelSet := make(map[string]bool)
+	for i, example := range examples {
+		predictions[i] = predictor.Predict(example.Text, telegram.PlanningState(example.State))
+		labelSet[example.Intent] = true
+		labelSet[predictions[i].Intent] = true
+	}
+	labels := make([]string, 0, len(labelSet))
+	for _, intent := range knownIntents {
+		if labelSet[intent] {
+			labels = append(labels, intent)
+		}
+	}
+	for _, label := range slices.Sorted(maps.Keys(labelSet)) {
+		if !slices.Contains(labels, label) {
+			labels = append(labels, label)
+		}
+	}
+
+	report.Confusion = Confusion{Labels: labels, Counts: make([][]int, len(labels))}
+	for i := range labels {
+		report.Confusion.Counts[i] = make([]int, len(labels))
+	}
+	intentMetrics := make(map[string]*Metrics, len(labels))
+	for _, label := range labels {
+		intentMetrics[label] = &Metrics{Label: label}
+	}
+	slotMetrics := make(map[string]*Metrics)
+	for _, slot := range telegram.RequiredSlots() {
+		slotMetrics[slot] = &Metrics{Label: slot}
+	}
+
+	correct := 0
+	var slotTotal Metrics
+	for i, example := range examples {
+		got := predictions[i]
+		want := intentMetrics[example.Intent]
+		want.Support++
+		intentMetrics[got.Intent].Predicted++
+		if got.Intent == example.Intent {
+			want.Correct++
+			correct++
+		}
+		report.Confusion.Counts[slices.Index(labels, example.Intent)][slices.Index(labels, got.Intent)]++
+
+		var missing, extra []string
+		for _, slot := range telegram.RequiredSlots() {
+			wanted := slices.Contains(example.Slots, slot)
+			predicted := slices.Contains(got.Slots, slot)
+			metrics := slotMetrics[slot]
+			if wanted {
+				metrics.Support++
+				slotTotal.Support++
+			}
+			if predicted {
+				metrics.Predicted++
+				slotTotal.Predicted++
+			}
+			switch {
+			case wanted && predicted:
+				metrics.Correct++
+				slotTotal.Correct++
+			case wanted:
+				missing = append(missing, slot)
+			case predicted:
+				extra = append(extra, slot)
+			}
+		}
+
+		if got.Intent != example.Intent || len(missing) > 0 || len(extra) > 0 {
+			report.Mistakes = append(report.Mistakes, Mistake{
+				Index:        i + 1,
+				Text:         example.Text,
+				State:        example.State,
+				WantIntent:   example.Intent,
+				GotIntent:    got.Intent,
+				MissingSlots: missing,
+				ExtraSlots:   extra,
+			})
+		}
+	}
+
+	if len(examples) > 0 {
+		report.IntentAccuracy = ratio(correct, len(examples))
+	}
+	for _, label := range labels {
+		report.Intents = append(report.Intents, intentMetrics[label].finish())
+	}
+	for _, slot := range telegram.RequiredSlots() {
+		report.Slots = append(report.Slots, slotMetrics[slot].finish())
+	}
+	report.SlotMicroF1 = slotTotal.finish().F1
+	return report
+}
+
+func (m *Metrics) finish() Metrics {
+	m.Precision = ratio(m.Correct, m.Predicted)
+	m.Recall = ratio(m.Correct, m.Support)
+	if m.Precision+m.Recall > 0 {
+		m.F1 = 2 * m.Precision * m.Recall / (m.Precision + m.Recall)
+	}
+	return *m
+}
+
+func ratio(numerator, denominator int) float64 {
+	if denominator == 0 {
+		return 0
+	}
+	return float64(numerator) / float64(denominator)
+}
+
+// WriteText prints the report as aligned tables for a terminal.
+func (r Report) WriteText(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(tw, "examples: %d\nintent accuracy: %.3f\nslot micro F1: %.3f\n\n", r.Examples, r.IntentAccuracy, r.SlotMicroF1)
+
+	writeMetrics := func(title string, rows []Metrics) {
+		fmt.Fprintf(tw, "%s\tsupport\tpredicted\tprecision\trecall\tf1\n", title)
+		for _, m := range rows {
+			fmt.Fprintf(tw, "%s\t%d\t%d\t%.3f\t%.3f\t%.3f\n", m.Label, m.Support, m.Predicted, m.Precision, m.Recall, m.F1)
+		}
+		fmt.Fprintln(tw)
+	}
+	writeMetrics("intent", r.Intents)
+	writeMetrics("slot", r.Slots)
+
+	fmt.Fprintf(tw, "expected \\ predicted\t%s\n", strings.Join(r.Confusion.Labels, "\t"))
+	for i, label := range r.Confusion.Labels {
+		cells := make([]string, len(r.Confusion.Counts[i]))
+		for j, count := range r.Confusion.Counts[i] {
+			cells[j] = fmt.Sprint(count)
+		}
+		fmt.Fprintf(tw, "%s\t%s\n", label, strings.Join(cells, "\t"))
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	if len(r.Mistakes) == 0 {
+		return nil
+	}
+	fmt.Fprintf(w, "\nmistakes (%d):\n", len(r.Mistakes))
+	for i, m := range r.Mistakes {
+		if i == maxListedMistakes {
+			fmt.Fprintf(w, "  ... %d more, use -json to see all\n", len(r.Mistakes)-i)
+			break
+		}
+		fmt.Fprintf(w, "  #%d %q [%s] intent %s -> %s", m.Index, m.Text, m.State, m.WantIntent, m.GotIntent)
+		if len(m.MissingSlots) > 0 {
+			fmt.Fprintf(w, " missing=%s", strings.Join(m.MissingSlots, ","))
+		}
+		if len(m.ExtraSlots) > 0 {
+			fmt.Fprintf(w, " extra=%s", strings.Join(m.ExtraSlots, ","))
+		}
+		fmt.Fprintln(w)
+	}
+	return nil
+}
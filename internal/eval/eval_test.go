@@ -0,0 +1,139 @@
+package eval
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/congregalis/aiden/internal/telegram"
+)
+
+type stubPredictor map[string]Prediction
+
+func (s stubPredictor) Predict(text string, _ telegram.PlanningState) Prediction {
+	return s[text]
+}
+
+func TestEvaluateComputesMetricsAndConfusion(t *testing.T) {
+	examples := []Example{
+		{Text: "a", Intent: telegram.IntentClarifyGoal, Slots: []string{telegram.SlotMainGoal, telegram.SlotTimeBudget}},
+		{Text: "b", Intent: telegram.IntentClarifyGoal, Slots: []string{telegram.SlotMainGoal}},
+		{Text: "c", Intent: telegram.IntentConfirmPlan},
+		{Text: "d", Intent: telegram.IntentConfirmPlan},
+	}
+	predictor := stubPredictor{
+		"a": {Intent: telegram.IntentClarifyGoal, Slots: []string{telegram.SlotMainGoal}},
+		"b": {Intent: telegram.IntentClarifyGoal, Slots: []string{telegram.SlotMainGoal, telegram.SlotRiskFlags}},
+		"c": {Intent: telegram.IntentConfirmPlan},
+		"d": {Intent: telegram.IntentClarifyGoal},
+	}
+
+	report := Evaluate(examples, predictor)
+
+	if report.IntentAccuracy != 0.75 {
+		t.Fatalf("accuracy=%v, want 0.75", report.IntentAccuracy)
+	}
+	clarify, confirm := report.Intents[0], report.Intents[1]
+	if clarify.Label != telegram.IntentClarifyGoal || clarify.Precision != 2.0/3 || clarify.Recall != 1 {
+		t.Fatalf("clarify metrics=%+v", clarify)
+	}
+	if confirm.Precision != 1 || confirm.Recall != 0.5 {
+		t.Fatalf("confirm metrics=%+v", confirm)
+	}
+	if got := report.Confusion.Counts[1]; got[0] != 1 || got[1] != 1 {
+		t.Fatalf("confirm row=%v, want one confirm and one clarify", got)
+	}
+
+	// 2 correct slots out of 3 predicted and 3 expected.
+	if report.SlotMicroF1 < 0.666 || report.SlotMicroF1 > 0.667 {
+		t.Fatalf("slot micro F1=%v", report.SlotMicroF1)
+	}
+	if len(report.Mistakes) != 3 || report.Mistakes[0].MissingSlots[0] != telegram.SlotTimeBudget || report.Mistakes[1].ExtraSlots[0] != telegram.SlotRiskFlags {
+		t.Fatalf("mistakes=%+v", report.Mistakes)
+	}
+
+	var out bytes.Buffer
+	if err := report.WriteText(&out); err != nil {
+		t.Fatalf("write text: %v", err)
+	}
+	if !strings.Contains(out.String(), "intent accuracy: 0.750") || !strings.Contains(out.String(), "expected \\ predicted") {
+		t.Fatalf("text report=%s", out.String())
+	}
+}
+
+func TestReadDatasetReportsLine(t *testing.T) {
+	input := `{"text": "我想学游泳", "intent": "clarify_goal", "slots": ["main_goal"]}
+
+{"text": "确认", "intent": "confirm", "slots": []}`
+	_, err := ReadDataset(strings.NewReader(input))
+	if err == nil || !strings.Contains(err.Error(), `line 3: unknown intent "confirm"`) {
+		t.Fatalf("error=%v", err)
+	}
+
+	_, err = ReadDataset(strings.NewReader(`{"text": "x", "intent": "clarify_goal", "slots": ["budget"]}`))
+	if err == nil || !strings.Contains(err.Error(), `unknown slot "budget"`) {
+		t.Fatalf("error=%v", err)
+	}
+}
+
+// TestSampleDatasetWithDefaultRules keeps the bundled dataset and the
+// built-in rules in agreement; update both when rules change on purpose.
+func TestSampleDatasetWithDefaultRules(t *testing.T) {
+	file, err := os.Open("testdata/sample.jsonl")
+	if err != nil {
+		t.Fatalf("open sample: %v", err)
+	}
+	defer file.Close()
+
+	examples, err := ReadDataset(file)
+	if err != nil {
+		t.Fatalf("read sample: %v", err)
+	}
+	report := Evaluate(examples, NewRulesPredictor(nil))
+	if len(report.Mistakes) > 0 {
+		t.Fatalf("sample dataset mistakes: %+v", report.Mistakes)
+	}
+}
+
+func TestRedact(t *testing.T) {
+	tests := map[string]string{
+		"邮箱 ada@example.com 手机 138 0013 8000":     "邮箱 <email> 手机 <number>",
+		"see https://example.com/x?y=1 or @ada_l": "see <url> or <handle>",
+		"每周 5 小时，3 个月":                            "每周 5 小时，3 个月",
+	}
+	for input, want := range tests {
+		if got := Redact(input); got != want {
+			t.Fatalf("Redact(%q)=%q, want %q", input, got, want)
+		}
+	}
+}
+
+func TestWriteSeedRedactsAndDeduplicates(t *testing.T) {
+	turns := []telegram.ConversationTurn{
+		{Content: "我想学游泳，联系我 13800138000", Intent: telegram.IntentClarifyGoal},
+		{Content: "我想学游泳，联系我 13900139000", Intent: telegram.IntentClarifyGoal},
+		{Content: "/status", Intent: telegram.IntentShowStatus},
+		{Content: "确认", Intent: ""},
+	}
+
+	var out bytes.Buffer
+	written, err := WriteSeed(&out, turns, NewRulesPredictor(nil))
+	if err != nil {
+		t.Fatalf("write seed: %v", err)
+	}
+	if written != 2 {
+		t.Fatalf("written=%d, want 2", written)
+	}
+
+	examples, err := ReadDataset(&out)
+	if err != nil {
+		t.Fatalf("seed is not a valid dataset: %v", err)
+	}
+	if examples[0].Text != "我想学游泳，联系我 <number>" || examples[0].Slots[0] != telegram.SlotMainGoal {
+		t.Fatalf("first example=%+v", examples[0])
+	}
+	if examples[1].Intent != telegram.IntentConfirmPlan {
+		t.Fatalf("second example=%+v, want the predicted intent", examples[1])
+	}
+}
@@ -0,0 +1,65 @@
+package eval
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/congregalis/aiden/internal/telegram"
+)
+
+var redactions = []struct {
+	pattern     *regexp.Regexp
+	replacement string
+}{
+	{regexp.MustCompile(`(?i)\b(https?://|www\.)\S+`), "<url>"},
+	{regexp.MustCompile(`[\w.+-]+@[\w-]+(\.[\w-]+)+`), "<email>"},
+	{regexp.MustCompile(`@\w{3,}`), "<handle>"},
+	{regexp.MustCompile(`\+?\d[\d -]{5,}\d`), "<number>"},
+}
+
+// Redact replaces URLs, e-mail addresses, Telegram handles and digit runs of
+// seven or more (phone, ID and card numbers) with placeholders. Short numbers
+// such as "5 小时" are kept because slot extraction depends on them.
+func Redact(text string) string {
+	for _, r := range redactions {
+		text = r.pattern.ReplaceAllString(text, r.replacement)
+	}
+	return text
+}
+
+// WriteSeed writes user turns as dataset lines for labelers. Text is
+// redacted, commands and duplicates are dropped, and the labels are
+// pre-filled: the intent logged at the time when it is a known one,
+// otherwise the current prediction, and the slots the current rules extract.
+// Every line still needs a human review before it is used as ground truth.
+func WriteSeed(w io.Writer, turns []telegram.ConversationTurn, predictor Predictor) (int, error) {
+	encoder := json.NewEncoder(w)
+	encoder.SetEscapeHTML(false)
+	seen := make(map[string]bool, len(turns))
+	written := 0
+	for _, turn := range turns {
+		text := Redact(strings.TrimSpace(turn.Content))
+		if text == "" || telegram.ParseCommand(text).IsCommand || seen[text] {
+			continue
+		}
+		seen[text] = true
+
+		prediction := predictor.Predict(text, telegram.StateClarifying)
+		example := Example{Text: text, Intent: turn.Intent, Slots: prediction.Slots}
+		if !slices.Contains(knownIntents, example.Intent) {
+			example.Intent = prediction.Intent
+		}
+		if example.Slots == nil {
+			example.Slots = []string{}
+		}
+		if err := encoder.Encode(example); err != nil {
+			return written, fmt.Errorf("write seed line: %w", err)
+		}
+		written++
+	}
+	return written, nil
+}
@@ -0,0 +1,127 @@
+package flags
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Source lists every flag; Store implements it.
+type Source interface {
+	ListFlags(ctx context.Context) ([]Flag, error)
+}
+
+// Evaluator answers flag checks from an in-memory copy of the flags,
+// refreshed by Run. A nil *Evaluator has every flag off and records nothing.
+type Evaluator struct {
+	source Source
+	env    string
+	logger *slog.Logger
+	flags  atomic.Pointer[map[Key]Flag]
+}
+
+func NewEvaluator(source Source, env string, logger *slog.Logger) *Evaluator {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	e := &Evaluator{source: source, env: env, logger: logger}
+	e.flags.Store(&map[Key]Flag{})
+	return e
+}
+
+// Refresh reloads every flag from the source.
+func (e *Evaluator) Refresh(ctx context.Context) error {
+	list, err := e.source.ListFlags(ctx)
+	if err != nil {
+		return fmt.Errorf("refresh feature flags: %w", err)
+	}
+	byKey := make(map[Key]Flag, len(list))
+	for _, flag := range list {
+		byKey[flag.Key] = flag
+	}
+	e.flags.Store(&byKey)
+	return nil
+}
+
+// Run refreshes every interval until ctx is done. A failed refresh keeps
+// the previous flags.
+func (e *Evaluator) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := e.Refresh(ctx); err != nil && ctx.Err() == nil {
+				e.logger.Warn("feature_flags_refresh_failed", slog.Any("error", err))
+			}
+		}
+	}
+}
+
+// Enabled reports whether key is on for userID.
+func (e *Evaluator) Enabled(ctx context.Context, userID string, key Key) bool {
+	return e.Evaluate(ctx, userID, key).Enabled
+}
+
+// Evaluate is Enabled with the reason. The result is added to the Recorder
+// in ctx, if there is one.
+func (e *Evaluator) Evaluate(ctx context.Context, userID string, key Key) Evaluation {
+	if e == nil {
+		return Evaluation{Key: key, Reason: ReasonUnknown}
+	}
+
+	result := Evaluation{Key: key, Reason: ReasonUnknown}
+	if flag, ok := (*e.flags.Load())[key]; ok {
+		result = flag.Evaluate(e.env, userID)
+	}
+	if recorder, ok := ctx.Value(recorderKey{}).(*Recorder); ok {
+		recorder.add(result)
+	}
+	return result
+}
+
+type recorderKey struct{}
+
+// Recorder collects the evaluations made with a context, so they can be
+// stored next to the action they influenced.
+type Recorder struct {
+	mu          sync.Mutex
+	evaluations []Evaluation
+}
+
+// WithRecorder returns a context whose evaluations are collected by the
+// returned Recorder.
+func WithRecorder(ctx context.Context) (context.Context, *Recorder) {
+	recorder := &Recorder{}
+	return context.WithValue(ctx, recorderKey{}, recorder), recorder
+}
+
+func (r *Recorder) add(evaluation Evaluation) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.evaluations = append(r.evaluations, evaluation)
+}
+
+// Payload returns the evaluations keyed by flag, ready for a JSON payload.
+func (r *Recorder) Payload() map[string]any {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if len(r.evaluations) == 0 {
+		return nil
+	}
+	payload := make(map[string]any, len(r.evaluations))
+	for _, evaluation := range r.evaluations {
+		payload[string(evaluation.Key)] = map[string]any{
+			"enabled": evaluation.Enabled,
+			"reason":  evaluation.Reason,
+		}
+	}
+	return payload
+}
@@ -0,0 +1,93 @@
+// Package flags turns conversational features on for a subset of users.
+package flags
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"slices"
+	"time"
+)
+
+// Key names a feature flag.
+type Key string
+
+const (
+	// LLMExtraction extracts goal slots with an LLM instead of the keyword
+	// rules.
+	LLMExtraction Key = "llm_extraction"
+	// PlanGeneration generates the first plan once the goal brief is ready
+	// for review.
+	PlanGeneration Key = "plan_generation"
+)
+
+// Reasons an evaluation came out the way it did.
+const (
+	ReasonUnknown    = "unknown"
+	ReasonDenyList   = "deny_list"
+	ReasonAllowList  = "allow_list"
+	ReasonEnvDefault = "env_default"
+	ReasonRollout    = "rollout"
+	ReasonOff        = "off"
+)
+
+type Flag struct {
+	Key            Key
+	Description    string
+	RolloutPercent int
+	AllowUserIDs   []string
+	DenyUserIDs    []string
+	// EnvDefaults turns the flag on for everyone not denied in the listed
+	// APP_ENV values.
+	EnvDefaults map[string]bool
+	UpdatedBy   string
+	UpdatedAt   time.Time
+}
+
+func (f Flag) Validate() error {
+	if f.Key == "" {
+		return fmt.Errorf("flag key is required")
+	}
+	if f.RolloutPercent < 0 || f.RolloutPercent > 100 {
+		return fmt.Errorf("rollout_percent must be between 0 and 100")
+	}
+	for _, userID := range f.AllowUserIDs {
+		if slices.Contains(f.DenyUserIDs, userID) {
+			return fmt.Errorf("user %s is on both the allow and the deny list", userID)
+		}
+	}
+	return nil
+}
+
+// Evaluation is the outcome of one flag check for one user.
+type Evaluation struct {
+	Key     Key    `json:"key"`
+	Enabled bool   `json:"enabled"`
+	Reason  string `json:"reason"`
+}
+
+// Evaluate decides flag for userID in env: the allow list wins, then the
+// deny list, then the environment default, then the rollout bucket.
+func (f Flag) Evaluate(env, userID string) Evaluation {
+	result := Evaluation{Key: f.Key}
+	switch {
+	case slices.Contains(f.AllowUserIDs, userID):
+		result.Enabled, result.Reason = true, ReasonAllowList
+	case slices.Contains(f.DenyUserIDs, userID):
+		result.Reason = ReasonDenyList
+	case f.EnvDefaults[env]:
+		result.Enabled, result.Reason = true, ReasonEnvDefault
+	case f.RolloutPercent > 0:
+		result.Enabled, result.Reason = Bucket(f.Key, userID) < f.RolloutPercent, ReasonRollout
+	default:
+		result.Reason = ReasonOff
+	}
+	return result
+}
+
+// Bucket places userID in [0, 100) for key. It is stable, so raising
+// rollout_percent only adds users, and independent across flags.
+func Bucket(key Key, userID string) int {
+	sum := sha256.Sum256([]byte(string(key) + ":" + userID))
+	return int(binary.BigEndian.Uint64(sum[:8]) % 100)
+}
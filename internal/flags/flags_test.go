@@ -0,0 +1,130 @@
+package flags
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestFlagEvaluateOrder(t *testing.T) {
+	flag := Flag{
+		Key:          LLMExtraction,
+		AllowUserIDs: []string{"allowed"},
+		DenyUserIDs:  []string{"denied"},
+		EnvDefaults:  map[string]bool{"staging": true},
+	}
+
+	tests := []struct {
+		name    string
+		env     string
+		userID  string
+		enabled bool
+		reason  string
+	}{
+		{name: "allow list", env: "production", userID: "allowed", enabled: true, reason: ReasonAllowList},
+		{name: "deny list beats env default", env: "staging", userID: "denied", enabled: false, reason: ReasonDenyList},
+		{name: "env default", env: "staging", userID: "someone", enabled: true, reason: ReasonEnvDefault},
+		{name: "off", env: "production", userID: "someone", enabled: false, reason: ReasonOff},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := flag.Evaluate(tt.env, tt.userID)
+			if got.Enabled != tt.enabled || got.Reason != tt.reason {
+				t.Fatalf("Evaluate=%+v, want enabled=%v reason=%s", got, tt.enabled, tt.reason)
+			}
+		})
+	}
+}
+
+func TestRolloutOnlyAddsUsersAsPercentGrows(t *testing.T) {
+	var enabledAt10, enabledAt50 int
+	for i := range 1000 {
+		userID := fmt.Sprintf("user-%d", i)
+		at10 := Flag{Key: PlanGeneration, RolloutPercent: 10}.Evaluate("production", userID).Enabled
+		at50 := Flag{Key: PlanGeneration, RolloutPercent: 50}.Evaluate("production", userID).Enabled
+		if at10 && !at50 {
+			t.Fatalf("%s enabled at 10%% but not at 50%%", userID)
+		}
+		if at10 {
+			enabledAt10++
+		}
+		if at50 {
+			enabledAt50++
+		}
+	}
+	if enabledAt10 < 50 || enabledAt10 > 150 || enabledAt50 < 400 || enabledAt50 > 600 {
+		t.Fatalf("enabled at 10%%=%d, at 50%%=%d out of 1000", enabledAt10, enabledAt50)
+	}
+	if Bucket(PlanGeneration, "user-1") != Bucket(PlanGeneration, "user-1") {
+		t.Fatalf("bucket is not stable")
+	}
+}
+
+func TestValidateRejectsBadFlags(t *testing.T) {
+	for _, flag := range []Flag{
+		{},
+		{Key: LLMExtraction, RolloutPercent: 101},
+		{Key: LLMExtraction, AllowUserIDs: []string{"u1"}, DenyUserIDs: []string{"u1"}},
+	} {
+		if err := flag.Validate(); err == nil {
+			t.Fatalf("Validate(%+v) succeeded, want error", flag)
+		}
+	}
+}
+
+type fakeSource struct {
+	flags []Flag
+	err   error
+}
+
+func (s *fakeSource) ListFlags(context.Context) ([]Flag, error) {
+	return s.flags, s.err
+}
+
+func TestEvaluatorRecordsEvaluations(t *testing.T) {
+	source := &fakeSource{flags: []Flag{{Key: LLMExtraction, AllowUserIDs: []string{"u1"}}}}
+	evaluator := NewEvaluator(source, "production", nil)
+	ctx, recorder := WithRecorder(context.Background())
+
+	if evaluator.Enabled(ctx, "u1", LLMExtraction) {
+		t.Fatalf("flag enabled before the first refresh")
+	}
+	if err := evaluator.Refresh(ctx); err != nil {
+		t.Fatalf("refresh: %v", err)
+	}
+	if !evaluator.Enabled(ctx, "u1", LLMExtraction) {
+		t.Fatalf("allowed user does not get the flag")
+	}
+	if got := evaluator.Evaluate(ctx, "u1", "missing"); got.Enabled || got.Reason != ReasonUnknown {
+		t.Fatalf("unknown flag evaluation=%+v", got)
+	}
+
+	source.err = errors.New("db down")
+	if err := evaluator.Refresh(ctx); err == nil {
+		t.Fatalf("refresh succeeded, want error")
+	}
+	if !evaluator.Enabled(ctx, "u1", LLMExtraction) {
+		t.Fatalf("failed refresh dropped the cached flags")
+	}
+
+	payload := recorder.Payload()
+	llm, _ := payload[string(LLMExtraction)].(map[string]any)
+	if llm["enabled"] != true || llm["reason"] != ReasonAllowList {
+		t.Fatalf("recorded llm_extraction=%v", llm)
+	}
+	if _, ok := payload["missing"]; !ok {
+		t.Fatalf("recorded payload=%v, want the unknown flag too", payload)
+	}
+}
+
+func TestNilEvaluatorIsOff(t *testing.T) {
+	var evaluator *Evaluator
+	ctx, recorder := WithRecorder(context.Background())
+	if evaluator.Enabled(ctx, "u1", LLMExtraction) {
+		t.Fatalf("nil evaluator enabled a flag")
+	}
+	if payload := recorder.Payload(); payload != nil {
+		t.Fatalf("nil evaluator recorded %v", payload)
+	}
+}
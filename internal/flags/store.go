@@ -0,0 +1,127 @@
+package flags
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// Store keeps flags in the feature_flags table.
+type Store struct {
+	db    *sql.DB
+	types *pgtype.Map
+}
+
+func NewStore(db *sql.DB) *Store {
+	return &Store{db: db, types: pgtype.NewMap()}
+}
+
+const flagColumns = `key, description, rollout_percent, allow_user_ids, deny_user_ids, env_defaults, updated_by, updated_at`
+
+func (s *Store) ListFlags(ctx context.Context) ([]Flag, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT `+flagColumns+` FROM feature_flags ORDER BY key`)
+	if err != nil {
+		return nil, fmt.Errorf("list feature flags: %w", err)
+	}
+	defer rows.Close()
+
+	var list []Flag
+	for rows.Next() {
+		flag, err := s.scanFlag(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, flag)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate feature flags: %w", err)
+	}
+	return list, nil
+}
+
+func (s *Store) GetFlag(ctx context.Context, key Key) (Flag, bool, error) {
+	flag, err := s.scanFlag(s.db.QueryRowContext(ctx, `SELECT `+flagColumns+` FROM feature_flags WHERE key = $1`, string(key)))
+	if errors.Is(err, sql.ErrNoRows) {
+		return Flag{}, false, nil
+	}
+	if err != nil {
+		return Flag{}, false, err
+	}
+	return flag, true, nil
+}
+
+// SaveFlag creates or replaces a flag and returns it as stored.
+func (s *Store) SaveFlag(ctx context.Context, flag Flag) (Flag, error) {
+	if err := flag.Validate(); err != nil {
+		return Flag{}, err
+	}
+	envDefaults, err := json.Marshal(nonNilDefaults(flag.EnvDefaults))
+	if err != nil {
+		return Flag{}, fmt.Errorf("marshal env defaults: %w", err)
+	}
+
+	saved, err := s.scanFlag(s.db.QueryRowContext(ctx, `
+INSERT INTO feature_flags (key, description, rollout_percent, allow_user_ids, deny_user_ids, env_defaults, updated_by, updated_at)
+VALUES ($1, $2, $3, $4, $5, $6::jsonb, $7, NOW())
+ON CONFLICT (key) DO UPDATE SET
+    description = EXCLUDED.description,
+    rollout_percent = EXCLUDED.rollout_percent,
+    allow_user_ids = EXCLUDED.allow_user_ids,
+    deny_user_ids = EXCLUDED.deny_user_ids,
+    env_defaults = EXCLUDED.env_defaults,
+    updated_by = EXCLUDED.updated_by,
+    updated_at = NOW()
+RETURNING `+flagColumns,
+		string(flag.Key), flag.Description, flag.RolloutPercent,
+		nonNilIDs(flag.AllowUserIDs), nonNilIDs(flag.DenyUserIDs), envDefaults, flag.UpdatedBy,
+	))
+	if err != nil {
+		return Flag{}, fmt.Errorf("save feature flag: %w", err)
+	}
+	return saved, nil
+}
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func (s *Store) scanFlag(row rowScanner) (Flag, error) {
+	var (
+		flag        Flag
+		key         string
+		envDefaults []byte
+	)
+	if err := row.Scan(
+		&key, &flag.Description, &flag.RolloutPercent,
+		s.types.SQLScanner(&flag.AllowUserIDs), s.types.SQLScanner(&flag.DenyUserIDs),
+		&envDefaults, &flag.UpdatedBy, &flag.UpdatedAt,
+	); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return Flag{}, err
+		}
+		return Flag{}, fmt.Errorf("scan feature flag: %w", err)
+	}
+	flag.Key = Key(key)
+	if err := json.Unmarshal(envDefaults, &flag.EnvDefaults); err != nil {
+		return Flag{}, fmt.Errorf("decode env defaults of %s: %w", key, err)
+	}
+	return flag, nil
+}
+
+func nonNilIDs(ids []string) []string {
+	if ids == nil {
+		return []string{}
+	}
+	return ids
+}
+
+func nonNilDefaults(defaults map[string]bool) map[string]bool {
+	if defaults == nil {
+		return map[string]bool{}
+	}
+	return defaults
+}
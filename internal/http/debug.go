@@ -0,0 +1,36 @@
+package httpx
+
+import (
+	"net/http"
+	"net/http/pprof"
+	"time"
+
+	"github.com/congregalis/aiden/internal/config"
+	"github.com/congregalis/aiden/internal/http/handlers"
+)
+
+// NewDebugServer builds the optional debug listener. It has no
+// authentication and no write timeout, because CPU profiles and traces
+// stream for as long as the caller asks; keep it bound to loopback.
+func NewDebugServer(cfg config.Config, worker handlers.WorkerStatsReporter) *http.Server {
+	debugHandler := handlers.NewDebugHandler(cfg.Redacted(), worker)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /debug/pprof/", pprof.Index)
+	mux.HandleFunc("GET /debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("GET /debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("GET /debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("POST /debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("GET /debug/pprof/trace", pprof.Trace)
+	mux.HandleFunc("GET /debug/goroutines", debugHandler.Goroutines)
+	mux.HandleFunc("GET /debug/metrics", debugHandler.Metrics)
+	mux.HandleFunc("GET /debug/telegram", debugHandler.Telegram)
+	mux.HandleFunc("GET /debug/config", debugHandler.Config)
+	mux.HandleFunc("GET /debug/buildinfo", debugHandler.BuildInfo)
+
+	return &http.Server{
+		Addr:              cfg.Debug.Addr,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+}
@@ -0,0 +1,418 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"mime"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/congregalis/aiden/internal/auth"
+	"github.com/congregalis/aiden/internal/http/middleware"
+	"github.com/congregalis/aiden/internal/telegram"
+	"github.com/congregalis/aiden/pkg/traceid"
+)
+
+const (
+	defaultAdminListLimit = 50
+	maxAdminListLimit     = 500
+	maxAdminBodyBytes     = 16 << 10
+)
+
+var uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+type AdminStore interface {
+	SearchUsers(ctx context.Context, chatQuery string, limit int) ([]telegram.AdminUser, error)
+	GetAdminUser(ctx context.Context, userID string) (telegram.AdminUser, []telegram.Goal, bool, error)
+	GetAdminGoal(ctx context.Context, goalID string) (telegram.AdminGoal, bool, error)
+	ListSessionTurns(ctx context.Context, sessionID, cursor string, limit int) (telegram.TurnPage, error)
+	ForceSessionState(ctx context.Context, sessionID string, transition telegram.SessionTransition, audit telegram.AuditEntry) (telegram.PlanningSession, error)
+	ListAuditEntries(ctx context.Context, targetType, targetID string, limit int) ([]telegram.AuditEntry, error)
+	ExportUserData(ctx context.Context, userID string) (telegram.UserExport, bool, error)
+	RecordAudit(ctx context.Context, entry telegram.AuditEntry) error
+}
+
+type AdminHandler struct {
+	store AdminStore
+}
+
+func NewAdminHandler(store AdminStore) AdminHandler {
+	return AdminHandler{store: store}
+}
+
+// Register mounts the /admin/v1 routes on mux. Reading conversation turns
+// needs the export scope because it returns what users wrote.
+func (h AdminHandler) Register(mux *http.ServeMux) {
+	mux.Handle("GET /admin/v1/users", middleware.RequireScope(auth.ScopeRead, http.HandlerFunc(h.ListUsers)))
+	mux.Handle("GET /admin/v1/users/{id}", middleware.RequireScope(auth.ScopeRead, http.HandlerFunc(h.GetUser)))
+	mux.Handle("GET /admin/v1/users/{id}/export", middleware.RequireScope(auth.ScopeExport, http.HandlerFunc(h.ExportUser)))
+	mux.Handle("GET /admin/v1/goals/{id}", middleware.RequireScope(auth.ScopeRead, http.HandlerFunc(h.GetGoal)))
+	mux.Handle("GET /admin/v1/sessions/{id}/turns", middleware.RequireScope(auth.ScopeExport, http.HandlerFunc(h.ListTurns)))
+	mux.Handle("POST /admin/v1/sessions/{id}/transition", middleware.RequireScope(auth.ScopeWrite, http.HandlerFunc(h.TransitionSession)))
+	mux.Handle("GET /admin/v1/audit-log", middleware.RequireScope(auth.ScopeRead, http.HandlerFunc(h.ListAudit)))
+}
+
+// ListUsers searches users by chat ID, either in full or masked as it
+// appears in logs (?chat=12***89).
+func (h AdminHandler) ListUsers(w http.ResponseWriter, r *http.Request) {
+	limit, err := parseLimit(r.URL.Query().Get("limit"), defaultAdminListLimit, maxAdminListLimit)
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	users, err := h.store.SearchUsers(r.Context(), r.URL.Query().Get("chat"), limit)
+	if errors.Is(err, telegram.ErrInvalidChatQuery) {
+		writeError(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err != nil {
+		writeError(w, r, http.StatusInternalServerError, "search users failed")
+		return
+	}
+
+	items := make([]map[string]any, 0, len(users))
+	for _, user := range users {
+		items = append(items, adminUserJSON(user))
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"items":    items,
+		"count":    len(items),
+		"trace_id": traceid.FromContext(r.Context()),
+	})
+}
+
+func (h AdminHandler) GetUser(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathUUID(w, r)
+	if !ok {
+		return
+	}
+
+	user, goals, found, err := h.store.GetAdminUser(r.Context(), id)
+	if err != nil {
+		writeError(w, r, http.StatusInternalServerError, "load user failed")
+		return
+	}
+	if !found {
+		writeError(w, r, http.StatusNotFound, "user not found")
+		return
+	}
+
+	goalItems := make([]map[string]any, 0, len(goals))
+	for _, goal := range goals {
+		goalItems = append(goalItems, map[string]any{
+			"id":         goal.ID,
+			"title":      goal.Title,
+			"status":     goal.Status,
+			"current":    goal.ID == user.CurrentGoalID,
+			"created_at": formatTime(goal.CreatedAt),
+		})
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"user":     adminUserJSON(user),
+		"goals":    goalItems,
+		"trace_id": traceid.FromContext(r.Context()),
+	})
+}
+
+// ExportUser downloads everything stored about a user, the same file /mydata
+// sends them, as ?format=json (default) or ?format=zip. Every export is
+// audited before any data leaves.
+func (h AdminHandler) ExportUser(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathUUID(w, r)
+	if !ok {
+		return
+	}
+	format, ok := telegram.ParseExportFormat(r.URL.Query().Get("format"))
+	if !ok {
+		writeError(w, r, http.StatusBadRequest, "format must be json or zip")
+		return
+	}
+
+	export, found, err := h.store.ExportUserData(r.Context(), id)
+	if err != nil {
+		writeError(w, r, http.StatusInternalServerError, "export user failed")
+		return
+	}
+	if !found {
+		writeError(w, r, http.StatusNotFound, "user not found")
+		return
+	}
+	fileName, content, err := telegram.EncodeUserExport(export, format)
+	if err != nil {
+		writeError(w, r, http.StatusInternalServerError, "export user failed")
+		return
+	}
+
+	principal, _ := auth.PrincipalFromContext(r.Context())
+	if err := h.store.RecordAudit(r.Context(), telegram.AuditEntry{
+		Actor:      principal.Actor(),
+		Action:     "user.export",
+		TargetType: "user",
+		TargetID:   id,
+		Payload:    map[string]any{"format": format, "bytes": len(content)},
+		TraceID:    traceid.FromContext(r.Context()),
+	}); err != nil {
+		writeError(w, r, http.StatusInternalServerError, "record audit failed")
+		return
+	}
+
+	contentType := "application/json"
+	if format == telegram.ExportFormatZIP {
+		contentType = "application/zip"
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": fileName}))
+	w.Header().Set("Content-Length", strconv.Itoa(len(content)))
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(content)
+}
+
+// GetGoal shows a goal with its session state, slot completion and every
+// profile (plan) version.
+func (h AdminHandler) GetGoal(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathUUID(w, r)
+	if !ok {
+		return
+	}
+
+	goal, found, err := h.store.GetAdminGoal(r.Context(), id)
+	if err != nil {
+		writeError(w, r, http.StatusInternalServerError, "load goal failed")
+		return
+	}
+	if !found {
+		writeError(w, r, http.StatusNotFound, "goal not found")
+		return
+	}
+
+	profiles := make([]map[string]any, 0, len(goal.Profiles))
+	for _, profile := range goal.Profiles {
+		profiles = append(profiles, map[string]any{
+			"id":                 profile.ID,
+			"version_no":         profile.VersionNo,
+			"template_version":   profile.TemplateVersion,
+			"completeness_score": profile.CompletenessScore,
+			"confirmation_state": profile.ConfirmationState,
+			"active":             profile.ID == goal.ActiveProfileID,
+			"open_questions":     profile.OpenQuestions,
+			"profile":            profile.Profile,
+			"markdown":           profile.Markdown,
+			"created_at":         formatTime(profile.CreatedAt),
+		})
+	}
+
+	var session map[string]any
+	if goal.Session != nil {
+		session = sessionJSON(*goal.Session)
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"goal": map[string]any{
+			"id":                  goal.ID,
+			"user_id":             goal.UserID,
+			"user_chat_id_masked": goal.UserChatIDMasked,
+			"title":               goal.Title,
+			"status":              goal.Status,
+			"created_at":          formatTime(goal.CreatedAt),
+			"updated_at":          formatTime(goal.UpdatedAt),
+		},
+		"session":  session,
+		"profiles": profiles,
+		"trace_id": traceid.FromContext(r.Context()),
+	})
+}
+
+// ListTurns pages through a session's conversation, oldest first. Pass the
+// returned next_cursor as ?cursor= to get the following page.
+func (h AdminHandler) ListTurns(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathUUID(w, r)
+	if !ok {
+		return
+	}
+	limit, err := parseLimit(r.URL.Query().Get("limit"), defaultAdminListLimit, maxAdminListLimit)
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	page, err := h.store.ListSessionTurns(r.Context(), id, r.URL.Query().Get("cursor"), limit)
+	if errors.Is(err, telegram.ErrInvalidTurnsCursor) {
+		writeError(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err != nil {
+		writeError(w, r, http.StatusInternalServerError, "list turns failed")
+		return
+	}
+
+	items := make([]map[string]any, 0, len(page.Turns))
+	for _, turn := range page.Turns {
+		item := map[string]any{
+			"id":                  turn.ID,
+			"role":                turn.Role,
+			"content":             turn.Content,
+			"intent":              turn.Intent,
+			"intent_confidence":   turn.IntentConfidence,
+			"intent_ranking":      turn.IntentRanking,
+			"telegram_message_id": turn.TelegramMessageID,
+			"revision":            turn.Revision,
+			"created_at":          formatTime(turn.CreatedAt),
+			"undone_at":           nil,
+		}
+		if turn.UndoneAt != nil {
+			item["undone_at"] = formatTime(*turn.UndoneAt)
+		}
+		items = append(items, item)
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"items":       items,
+		"count":       len(items),
+		"next_cursor": page.NextCursor,
+		"trace_id":    traceid.FromContext(r.Context()),
+	})
+}
+
+type transitionRequest struct {
+	State  string `json:"state"`
+	Reset  bool   `json:"reset"`
+	Reason string `json:"reason"`
+}
+
+// TransitionSession forces a session into another state, for example to
+// unstick it, and records the change in the audit log.
+func (h AdminHandler) TransitionSession(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathUUID(w, r)
+	if !ok {
+		return
+	}
+
+	var req transitionRequest
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxAdminBodyBytes))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&req); err != nil {
+		writeError(w, r, http.StatusBadRequest, `body must be JSON like {"state": "idle", "reset": true, "reason": "..."}`)
+		return
+	}
+	state := telegram.ParsePlanningState(req.State)
+	if string(state) != req.State {
+		writeError(w, r, http.StatusBadRequest, "state must be one of idle, clarifying, review, confirmed")
+		return
+	}
+	reason := strings.TrimSpace(req.Reason)
+	if reason == "" {
+		writeError(w, r, http.StatusBadRequest, "reason is required")
+		return
+	}
+	principal, _ := auth.PrincipalFromContext(r.Context())
+
+	session, err := h.store.ForceSessionState(r.Context(), id,
+		telegram.SessionTransition{State: state, Reset: req.Reset, Reason: reason},
+		telegram.AuditEntry{
+			Actor:      principal.Actor(),
+			Action:     "session.transition",
+			TargetType: "planning_session",
+			TargetID:   id,
+			TraceID:    traceid.FromContext(r.Context()),
+		},
+	)
+	if errors.Is(err, telegram.ErrSessionNotFound) {
+		writeError(w, r, http.StatusNotFound, "session not found")
+		return
+	}
+	if err != nil {
+		writeError(w, r, http.StatusInternalServerError, "transition session failed")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"session":  sessionJSON(session),
+		"trace_id": traceid.FromContext(r.Context()),
+	})
+}
+
+// ListAudit returns the newest audit entries, optionally filtered with
+// ?target_type= and ?target_id=.
+func (h AdminHandler) ListAudit(w http.ResponseWriter, r *http.Request) {
+	limit, err := parseLimit(r.URL.Query().Get("limit"), defaultAdminListLimit, maxAdminListLimit)
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	query := r.URL.Query()
+	entries, err := h.store.ListAuditEntries(r.Context(), query.Get("target_type"), query.Get("target_id"), limit)
+	if err != nil {
+		writeError(w, r, http.StatusInternalServerError, "list audit log failed")
+		return
+	}
+
+	items := make([]map[string]any, 0, len(entries))
+	for _, entry := range entries {
+		items = append(items, map[string]any{
+			"id":          entry.ID,
+			"actor":       entry.Actor,
+			"action":      entry.Action,
+			"target_type": entry.TargetType,
+			"target_id":   entry.TargetID,
+			"payload":     entry.Payload,
+			"trace_id":    entry.TraceID,
+			"created_at":  formatTime(entry.CreatedAt),
+		})
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"items":    items,
+		"count":    len(items),
+		"trace_id": traceid.FromContext(r.Context()),
+	})
+}
+
+func adminUserJSON(user telegram.AdminUser) map[string]any {
+	return map[string]any{
+		"id":              user.ID,
+		"chat_id_masked":  user.ChatIDMasked,
+		"language":        user.Language,
+		"timezone":        user.Timezone,
+		"is_reachable":    user.IsReachable,
+		"current_goal_id": user.CurrentGoalID,
+		"goal_count":      user.GoalCount,
+		"created_at":      formatTime(user.CreatedAt),
+	}
+}
+
+func sessionJSON(session telegram.PlanningSession) map[string]any {
+	return map[string]any{
+		"id":              session.ID,
+		"goal_id":         session.GoalID,
+		"state":           session.State,
+		"slot_completion": session.SlotCompletion,
+		"skipped_slots":   session.SkippedSlots,
+		"turn_count":      session.TurnCount,
+		"last_intent":     session.LastIntent,
+		"pending_action":  session.PendingAction,
+		"updated_at":      formatTime(session.UpdatedAt),
+	}
+}
+
+func pathUUID(w http.ResponseWriter, r *http.Request) (string, bool) {
+	id := r.PathValue("id")
+	if !uuidPattern.MatchString(id) {
+		writeError(w, r, http.StatusNotFound, "not found")
+		return "", false
+	}
+	return strings.ToLower(id), true
+}
+
+func formatTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339)
+}
+
+func writeError(w http.ResponseWriter, r *http.Request, status int, message string) {
+	writeJSON(w, status, map[string]any{
+		"error":    message,
+		"trace_id": traceid.FromContext(r.Context()),
+	})
+}
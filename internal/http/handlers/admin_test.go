@@ -0,0 +1,265 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/congregalis/aiden/internal/auth"
+	"github.com/congregalis/aiden/internal/http/middleware"
+	"github.com/congregalis/aiden/internal/telegram"
+)
+
+const (
+	testSessionID = "0b7d6a52-3f7e-4c55-9a8e-1f2d3c4b5a69"
+	testUserID    = "5c1e9b0a-7d2f-4e8a-b3c6-0f1a2b3c4d5e"
+)
+
+type fakeAdminStore struct {
+	transitions []telegram.SessionTransition
+	audits      []telegram.AuditEntry
+	chatQuery   string
+	cursor      string
+}
+
+func (f *fakeAdminStore) SearchUsers(_ context.Context, chatQuery string, _ int) ([]telegram.AdminUser, error) {
+	f.chatQuery = chatQuery
+	if chatQuery == "abc" {
+		return nil, telegram.ErrInvalidChatQuery
+	}
+	return []telegram.AdminUser{{ID: "u1", ChatIDMasked: "12***89"}}, nil
+}
+
+func (f *fakeAdminStore) GetAdminUser(context.Context, string) (telegram.AdminUser, []telegram.Goal, bool, error) {
+	return telegram.AdminUser{}, nil, false, nil
+}
+
+func (f *fakeAdminStore) GetAdminGoal(context.Context, string) (telegram.AdminGoal, bool, error) {
+	return telegram.AdminGoal{}, false, nil
+}
+
+func (f *fakeAdminStore) ListSessionTurns(_ context.Context, _ string, cursor string, _ int) (telegram.TurnPage, error) {
+	f.cursor = cursor
+	if cursor == "bad" {
+		return telegram.TurnPage{}, telegram.ErrInvalidTurnsCursor
+	}
+	return telegram.TurnPage{
+		Turns:      []telegram.AdminTurn{{ConversationTurn: telegram.ConversationTurn{ID: "t1", Role: "user", Content: "hi"}}},
+		NextCursor: "next",
+	}, nil
+}
+
+func (f *fakeAdminStore) ForceSessionState(_ context.Context, sessionID string, transition telegram.SessionTransition, audit telegram.AuditEntry) (telegram.PlanningSession, error) {
+	if sessionID != testSessionID {
+		return telegram.PlanningSession{}, telegram.ErrSessionNotFound
+	}
+	f.transitions = append(f.transitions, transition)
+	f.audits = append(f.audits, audit)
+	return telegram.PlanningSession{ID: sessionID, State: transition.State}, nil
+}
+
+func (f *fakeAdminStore) ListAuditEntries(context.Context, string, string, int) ([]telegram.AuditEntry, error) {
+	return f.audits, nil
+}
+
+func (f *fakeAdminStore) ExportUserData(_ context.Context, userID string) (telegram.UserExport, bool, error) {
+	if userID != testUserID {
+		return telegram.UserExport{}, false, nil
+	}
+	return telegram.UserExport{
+		ExportedAt: time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC),
+		User:       telegram.ExportedUser{ID: testUserID, TelegramChatID: 1234567, Language: "en"},
+		Goals:      []telegram.ExportedGoal{},
+	}, true, nil
+}
+
+func (f *fakeAdminStore) RecordAudit(_ context.Context, entry telegram.AuditEntry) error {
+	f.audits = append(f.audits, entry)
+	return nil
+}
+
+type fakeVerifier map[string]auth.Principal
+
+func (f fakeVerifier) Verify(_ context.Context, token string) (auth.Principal, error) {
+	principal, ok := f[token]
+	if !ok {
+		return auth.Principal{}, auth.ErrInvalidKey
+	}
+	return principal, nil
+}
+
+func serveAdmin(store AdminStore, method, target, body string, header map[string]string) *httptest.ResponseRecorder {
+	return serveAdminAs("secret", store, method, target, body, header)
+}
+
+func serveAdminAs(token string, store AdminStore, method, target, body string, header map[string]string) *httptest.ResponseRecorder {
+	mux := http.NewServeMux()
+	NewAdminHandler(store).Register(mux)
+	verifier := auth.Chain{
+		auth.NewStaticToken("secret"),
+		fakeVerifier{
+			"reader":   {KeyID: "k1", Name: "dashboard", Scopes: []auth.Scope{auth.ScopeRead}},
+			"operator": {KeyID: "k2", Name: "oncall", Scopes: []auth.Scope{auth.ScopeRead, auth.ScopeWrite}},
+		},
+	}
+	handler := middleware.AdminAuth(verifier, middleware.NewFailureLimiter(100, time.Minute), mux)
+
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	req.Header.Set("Authorization", "Bearer "+token)
+	for key, value := range header {
+		req.Header.Set(key, value)
+	}
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestAdminTransitionWritesAuditWithIdentity(t *testing.T) {
+	store := &fakeAdminStore{}
+	rec := serveAdmin(store, http.MethodPost, "/admin/v1/sessions/"+testSessionID+"/transition",
+		`{"state": "idle", "reset": true, "reason": "stuck in review"}`,
+		map[string]string{middleware.AdminActorHeader: "alice"})
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status=%d body=%s", rec.Code, rec.Body)
+	}
+	if len(store.transitions) != 1 || store.transitions[0] != (telegram.SessionTransition{State: telegram.StateIdle, Reset: true, Reason: "stuck in review"}) {
+		t.Fatalf("transitions=%+v", store.transitions)
+	}
+	audit := store.audits[0]
+	if audit.Actor != "admin_token:alice" || audit.Action != "session.transition" || audit.TargetID != testSessionID {
+		t.Fatalf("audit=%+v", audit)
+	}
+
+	var response map[string]map[string]any
+	_ = json.Unmarshal(rec.Body.Bytes(), &response)
+	if response["session"]["state"] != "idle" {
+		t.Fatalf("response=%s", rec.Body)
+	}
+}
+
+func TestAdminRoutesRequireScopes(t *testing.T) {
+	transition := `{"state": "idle", "reason": "stuck"}`
+	tests := []struct {
+		name   string
+		token  string
+		method string
+		target string
+		status int
+	}{
+		{name: "read lists users", token: "reader", method: http.MethodGet, target: "/admin/v1/users", status: http.StatusOK},
+		{name: "read cannot transition", token: "reader", method: http.MethodPost, target: "/admin/v1/sessions/" + testSessionID + "/transition", status: http.StatusForbidden},
+		{name: "read cannot export turns", token: "reader", method: http.MethodGet, target: "/admin/v1/sessions/" + testSessionID + "/turns", status: http.StatusForbidden},
+		{name: "write transitions", token: "operator", method: http.MethodPost, target: "/admin/v1/sessions/" + testSessionID + "/transition", status: http.StatusOK},
+		{name: "write cannot export turns", token: "operator", method: http.MethodGet, target: "/admin/v1/sessions/" + testSessionID + "/turns", status: http.StatusForbidden},
+		{name: "write cannot export users", token: "operator", method: http.MethodGet, target: "/admin/v1/users/" + testUserID + "/export", status: http.StatusForbidden},
+		{name: "unknown key", token: "nope", method: http.MethodGet, target: "/admin/v1/users", status: http.StatusUnauthorized},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			store := &fakeAdminStore{}
+			rec := serveAdminAs(tc.token, store, tc.method, tc.target, transition, nil)
+			if rec.Code != tc.status {
+				t.Fatalf("status=%d, want %d: %s", rec.Code, tc.status, rec.Body)
+			}
+		})
+	}
+
+	store := &fakeAdminStore{}
+	serveAdminAs("operator", store, http.MethodPost, "/admin/v1/sessions/"+testSessionID+"/transition", transition,
+		map[string]string{middleware.AdminActorHeader: "mallory"})
+	if len(store.audits) != 1 || store.audits[0].Actor != "k2:oncall" {
+		t.Fatalf("API key audits=%+v, want actor from the key and not the header", store.audits)
+	}
+}
+
+func TestAdminTransitionValidation(t *testing.T) {
+	tests := []struct {
+		name   string
+		target string
+		body   string
+		status int
+	}{
+		{name: "unknown state", target: testSessionID, body: `{"state": "done", "reason": "x"}`, status: http.StatusBadRequest},
+		{name: "missing reason", target: testSessionID, body: `{"state": "idle"}`, status: http.StatusBadRequest},
+		{name: "unknown field", target: testSessionID, body: `{"state": "idle", "reason": "x", "force": true}`, status: http.StatusBadRequest},
+		{name: "not a uuid", target: "123", body: `{"state": "idle", "reason": "x"}`, status: http.StatusNotFound},
+		{name: "missing session", target: "11111111-2222-3333-4444-555555555555", body: `{"state": "idle", "reason": "x"}`, status: http.StatusNotFound},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			store := &fakeAdminStore{}
+			rec := serveAdmin(store, http.MethodPost, "/admin/v1/sessions/"+tc.target+"/transition", tc.body, nil)
+			if rec.Code != tc.status {
+				t.Fatalf("status=%d, want %d: %s", rec.Code, tc.status, rec.Body)
+			}
+			if len(store.audits) != 0 {
+				t.Fatalf("rejected request wrote audit entries: %+v", store.audits)
+			}
+		})
+	}
+}
+
+func TestAdminListEndpoints(t *testing.T) {
+	store := &fakeAdminStore{}
+
+	rec := serveAdmin(store, http.MethodGet, "/admin/v1/users?chat=12***89", "", nil)
+	if rec.Code != http.StatusOK || store.chatQuery != "12***89" || !strings.Contains(rec.Body.String(), `"chat_id_masked":"12***89"`) {
+		t.Fatalf("users: status=%d query=%q body=%s", rec.Code, store.chatQuery, rec.Body)
+	}
+	if rec := serveAdmin(store, http.MethodGet, "/admin/v1/users?chat=abc", "", nil); rec.Code != http.StatusBadRequest {
+		t.Fatalf("invalid chat query status=%d", rec.Code)
+	}
+
+	rec = serveAdmin(store, http.MethodGet, "/admin/v1/sessions/"+testSessionID+"/turns?cursor=abc&limit=10", "", nil)
+	if rec.Code != http.StatusOK || store.cursor != "abc" || !strings.Contains(rec.Body.String(), `"next_cursor":"next"`) {
+		t.Fatalf("turns: status=%d body=%s", rec.Code, rec.Body)
+	}
+	if rec := serveAdmin(store, http.MethodGet, "/admin/v1/sessions/"+testSessionID+"/turns?cursor=bad", "", nil); rec.Code != http.StatusBadRequest {
+		t.Fatalf("bad cursor status=%d", rec.Code)
+	}
+
+	if rec := serveAdmin(store, http.MethodGet, "/admin/v1/goals/"+testSessionID, "", nil); rec.Code != http.StatusNotFound {
+		t.Fatalf("missing goal status=%d", rec.Code)
+	}
+}
+
+func TestAdminExportUser(t *testing.T) {
+	store := &fakeAdminStore{}
+	rec := serveAdmin(store, http.MethodGet, "/admin/v1/users/"+testUserID+"/export", "", nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status=%d body=%s", rec.Code, rec.Body)
+	}
+	if got := rec.Header().Get("Content-Disposition"); got != "attachment; filename=aiden-export-20260301.json" {
+		t.Fatalf("Content-Disposition=%q", got)
+	}
+	var export telegram.UserExport
+	if err := json.Unmarshal(rec.Body.Bytes(), &export); err != nil || export.User.ID != testUserID {
+		t.Fatalf("body=%s (%v)", rec.Body, err)
+	}
+	if len(store.audits) != 1 || store.audits[0].Action != "user.export" || store.audits[0].TargetID != testUserID || store.audits[0].Actor != "admin_token" {
+		t.Fatalf("audits=%+v", store.audits)
+	}
+
+	rec = serveAdmin(store, http.MethodGet, "/admin/v1/users/"+testUserID+"/export?format=zip", "", nil)
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != "application/zip" || !strings.HasPrefix(rec.Body.String(), "PK") {
+		t.Fatalf("zip: status=%d type=%q", rec.Code, rec.Header().Get("Content-Type"))
+	}
+
+	store = &fakeAdminStore{}
+	if rec := serveAdmin(store, http.MethodGet, "/admin/v1/users/"+testUserID+"/export?format=pdf", "", nil); rec.Code != http.StatusBadRequest {
+		t.Fatalf("bad format status=%d", rec.Code)
+	}
+	if rec := serveAdmin(store, http.MethodGet, "/admin/v1/users/"+testSessionID+"/export", "", nil); rec.Code != http.StatusNotFound {
+		t.Fatalf("missing user status=%d", rec.Code)
+	}
+	if len(store.audits) != 0 {
+		t.Fatalf("failed exports wrote audit entries: %+v", store.audits)
+	}
+}
@@ -0,0 +1,157 @@
+package handlers
+
+import (
+	"math"
+	"net/http"
+	"reflect"
+	"runtime/debug"
+	"runtime/metrics"
+	"runtime/pprof"
+	"time"
+
+	"github.com/congregalis/aiden/internal/telegram"
+)
+
+// DebugHandler serves runtime state on the debug listener. It never sits on
+// the public port.
+type DebugHandler struct {
+	config any
+	worker WorkerStatsReporter
+}
+
+type WorkerStatsReporter interface {
+	Stats() telegram.WorkerStats
+}
+
+// NewDebugHandler takes the effective config, already redacted. worker may
+// be nil.
+func NewDebugHandler(config any, worker WorkerStatsReporter) DebugHandler {
+	return DebugHandler{config: config, worker: worker}
+}
+
+// Goroutines dumps every goroutine's stack as plain text.
+func (h DebugHandler) Goroutines(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	_ = pprof.Lookup("goroutine").WriteTo(w, 2)
+}
+
+// Metrics reads every runtime/metrics sample. Histograms are summarised as a
+// count and a few quantiles.
+func (h DebugHandler) Metrics(w http.ResponseWriter, _ *http.Request) {
+	descriptions := metrics.All()
+	samples := make([]metrics.Sample, len(descriptions))
+	for i, description := range descriptions {
+		samples[i].Name = description.Name
+	}
+	metrics.Read(samples)
+
+	values := make(map[string]any, len(samples))
+	for _, sample := range samples {
+		switch sample.Value.Kind() {
+		case metrics.KindUint64:
+			values[sample.Name] = sample.Value.Uint64()
+		case metrics.KindFloat64:
+			values[sample.Name] = finite(sample.Value.Float64())
+		case metrics.KindFloat64Histogram:
+			values[sample.Name] = summarizeHistogram(sample.Value.Float64Histogram())
+		}
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"metrics": values})
+}
+
+// Telegram shows the worker's polling and send rate limiting counters.
+func (h DebugHandler) Telegram(w http.ResponseWriter, _ *http.Request) {
+	if h.worker == nil {
+		writeJSON(w, http.StatusNotFound, map[string]any{"error": "telegram worker not running"})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"telegram": h.worker.Stats()})
+}
+
+// Config shows the effective config with durations spelled out.
+func (h DebugHandler) Config(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]any{"config": configView(reflect.ValueOf(h.config))})
+}
+
+func (h DebugHandler) BuildInfo(w http.ResponseWriter, _ *http.Request) {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		writeJSON(w, http.StatusNotFound, map[string]any{"error": "build info unavailable"})
+		return
+	}
+
+	deps := make([]map[string]any, 0, len(info.Deps))
+	for _, dep := range info.Deps {
+		deps = append(deps, map[string]any{"path": dep.Path, "version": dep.Version})
+	}
+	settings := make(map[string]string, len(info.Settings))
+	for _, setting := range info.Settings {
+		settings[setting.Key] = setting.Value
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"go_version": info.GoVersion,
+		"path":       info.Path,
+		"main":       map[string]any{"path": info.Main.Path, "version": info.Main.Version},
+		"deps":       deps,
+		"settings":   settings,
+	})
+}
+
+func summarizeHistogram(histogram *metrics.Float64Histogram) map[string]any {
+	var total uint64
+	for _, count := range histogram.Counts {
+		total += count
+	}
+	summary := map[string]any{"count": total}
+	for _, q := range []struct {
+		name     string
+		quantile float64
+	}{{"p50", 0.5}, {"p90", 0.9}, {"p99", 0.99}} {
+		summary[q.name] = histogramQuantile(histogram, total, q.quantile)
+	}
+	return summary
+}
+
+// histogramQuantile returns the upper bound of the bucket holding quantile,
+// or its lower bound for the open-ended last bucket.
+func histogramQuantile(histogram *metrics.Float64Histogram, total uint64, quantile float64) float64 {
+	if total == 0 {
+		return 0
+	}
+	target := uint64(math.Ceil(quantile * float64(total)))
+	var seen uint64
+	for i, count := range histogram.Counts {
+		seen += count
+		if seen >= target {
+			if upper := histogram.Buckets[i+1]; !math.IsInf(upper, 1) {
+				return upper
+			}
+			return finite(histogram.Buckets[i])
+		}
+	}
+	return 0
+}
+
+// finite keeps NaN and infinities out of the JSON encoder, which rejects them.
+func finite(value float64) float64 {
+	if math.IsNaN(value) || math.IsInf(value, 0) {
+		return 0
+	}
+	return value
+}
+
+func configView(value reflect.Value) any {
+	if value.Type() == reflect.TypeFor[time.Duration]() {
+		return time.Duration(value.Int()).String()
+	}
+	if value.Kind() != reflect.Struct {
+		return value.Interface()
+	}
+	fields := make(map[string]any, value.NumField())
+	for i := range value.NumField() {
+		if field := value.Type().Field(i); field.IsExported() {
+			fields[field.Name] = configView(value.Field(i))
+		}
+	}
+	return fields
+}
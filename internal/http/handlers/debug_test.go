@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/congregalis/aiden/internal/telegram"
+)
+
+func TestDebugMetricsEncodeEverySample(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	NewDebugHandler(nil, nil).Metrics(recorder, httptest.NewRequest(http.MethodGet, "/debug/metrics", nil))
+
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("status=%d body=%s", recorder.Code, recorder.Body)
+	}
+	var body struct {
+		Metrics map[string]any `json:"metrics"`
+	}
+	if err := json.Unmarshal(recorder.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decode body: %v", err)
+	}
+	if goroutines, ok := body.Metrics["/sched/goroutines:goroutines"].(float64); !ok || goroutines < 1 {
+		t.Fatalf("goroutines=%v", body.Metrics["/sched/goroutines:goroutines"])
+	}
+	if latencies, ok := body.Metrics["/sched/latencies:seconds"].(map[string]any); !ok || latencies["count"] == nil {
+		t.Fatalf("histogram summary=%v", body.Metrics["/sched/latencies:seconds"])
+	}
+}
+
+func TestDebugConfigSpellsOutDurations(t *testing.T) {
+	type session struct{ Timeout time.Duration }
+	config := struct {
+		AppEnv  string
+		Session session
+	}{AppEnv: "production", Session: session{Timeout: 24 * time.Hour}}
+
+	recorder := httptest.NewRecorder()
+	NewDebugHandler(config, nil).Config(recorder, httptest.NewRequest(http.MethodGet, "/debug/config", nil))
+
+	if body := recorder.Body.String(); !strings.Contains(body, `"Timeout":"24h0m0s"`) || !strings.Contains(body, `"AppEnv":"production"`) {
+		t.Fatalf("body=%s", body)
+	}
+}
+
+func TestDebugGoroutinesDumpsStacks(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	NewDebugHandler(nil, nil).Goroutines(recorder, httptest.NewRequest(http.MethodGet, "/debug/goroutines", nil))
+
+	if !strings.Contains(recorder.Body.String(), "goroutine ") {
+		t.Fatalf("body=%s", recorder.Body)
+	}
+}
+
+type fakeWorkerStats telegram.WorkerStats
+
+func (f fakeWorkerStats) Stats() telegram.WorkerStats {
+	return telegram.WorkerStats(f)
+}
+
+func TestDebugTelegramShowsWorkerStats(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	NewDebugHandler(nil, fakeWorkerStats{PollSuccesses: 4, SendQueueDepth: 2, SendPauses: 1}).
+		Telegram(recorder, httptest.NewRequest(http.MethodGet, "/debug/telegram", nil))
+
+	var body struct {
+		Telegram map[string]float64 `json:"telegram"`
+	}
+	if err := json.Unmarshal(recorder.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decode body: %v", err)
+	}
+	if body.Telegram["poll_successes"] != 4 || body.Telegram["send_queue_depth"] != 2 || body.Telegram["send_pauses"] != 1 {
+		t.Fatalf("telegram=%v", body.Telegram)
+	}
+}
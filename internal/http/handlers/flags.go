@@ -0,0 +1,210 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/congregalis/aiden/internal/auth"
+	"github.com/congregalis/aiden/internal/flags"
+	"github.com/congregalis/aiden/internal/http/middleware"
+	"github.com/congregalis/aiden/internal/telegram"
+	"github.com/congregalis/aiden/pkg/traceid"
+)
+
+var flagKeyPattern = regexp.MustCompile(`^[a-z][a-z0-9_]{0,63}$`)
+
+type FlagStore interface {
+	ListFlags(ctx context.Context) ([]flags.Flag, error)
+	GetFlag(ctx context.Context, key flags.Key) (flags.Flag, bool, error)
+	SaveFlag(ctx context.Context, flag flags.Flag) (flags.Flag, error)
+}
+
+type AuditRecorder interface {
+	RecordAudit(ctx context.Context, entry telegram.AuditEntry) error
+}
+
+type FlagsHandler struct {
+	store     FlagStore
+	audit     AuditRecorder
+	evaluator *flags.Evaluator
+}
+
+// NewFlagsHandler serves the flag admin routes. evaluator is refreshed after
+// every change so this instance applies it at once; other instances pick it
+// up on their next refresh.
+func NewFlagsHandler(store FlagStore, audit AuditRecorder, evaluator *flags.Evaluator) FlagsHandler {
+	return FlagsHandler{store: store, audit: audit, evaluator: evaluator}
+}
+
+func (h FlagsHandler) Register(mux *http.ServeMux) {
+	mux.Handle("GET /admin/v1/flags", middleware.RequireScope(auth.ScopeRead, http.HandlerFunc(h.List)))
+	mux.Handle("PATCH /admin/v1/flags/{key}", middleware.RequireScope(auth.ScopeWrite, http.HandlerFunc(h.Update)))
+	mux.Handle("GET /admin/v1/flags/{key}/evaluate", middleware.RequireScope(auth.ScopeRead, http.HandlerFunc(h.Evaluate)))
+}
+
+func (h FlagsHandler) List(w http.ResponseWriter, r *http.Request) {
+	list, err := h.store.ListFlags(r.Context())
+	if err != nil {
+		writeError(w, r, http.StatusInternalServerError, "list flags failed")
+		return
+	}
+
+	items := make([]map[string]any, 0, len(list))
+	for _, flag := range list {
+		items = append(items, flagJSON(flag))
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"items":    items,
+		"count":    len(items),
+		"trace_id": traceid.FromContext(r.Context()),
+	})
+}
+
+type flagUpdateRequest struct {
+	Description    *string          `json:"description"`
+	RolloutPercent *int             `json:"rollout_percent"`
+	AllowUserIDs   *[]string        `json:"allow_user_ids"`
+	DenyUserIDs    *[]string        `json:"deny_user_ids"`
+	EnvDefaults    *map[string]bool `json:"env_defaults"`
+}
+
+// Update changes the fields present in the body and keeps the others. A key
+// that does not exist yet is created, off for everyone unless the body says
+// otherwise.
+func (h FlagsHandler) Update(w http.ResponseWriter, r *http.Request) {
+	key, ok := pathFlagKey(w, r)
+	if !ok {
+		return
+	}
+
+	var req flagUpdateRequest
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxAdminBodyBytes))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&req); err != nil {
+		writeError(w, r, http.StatusBadRequest, `body must be JSON like {"rollout_percent": 10, "allow_user_ids": ["..."]}`)
+		return
+	}
+
+	before, found, err := h.store.GetFlag(r.Context(), key)
+	if err != nil {
+		writeError(w, r, http.StatusInternalServerError, "load flag failed")
+		return
+	}
+	if !found {
+		before = flags.Flag{Key: key}
+	}
+	after := before
+	if req.Description != nil {
+		after.Description = strings.TrimSpace(*req.Description)
+	}
+	if req.RolloutPercent != nil {
+		after.RolloutPercent = *req.RolloutPercent
+	}
+	if req.AllowUserIDs != nil {
+		after.AllowUserIDs = *req.AllowUserIDs
+	}
+	if req.DenyUserIDs != nil {
+		after.DenyUserIDs = *req.DenyUserIDs
+	}
+	if req.EnvDefaults != nil {
+		after.EnvDefaults = *req.EnvDefaults
+	}
+	if err := after.Validate(); err != nil {
+		writeError(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	principal, _ := auth.PrincipalFromContext(r.Context())
+	after.UpdatedBy = principal.Actor()
+	saved, err := h.store.SaveFlag(r.Context(), after)
+	if err != nil {
+		writeError(w, r, http.StatusInternalServerError, "save flag failed")
+		return
+	}
+
+	payload := map[string]any{"after": flagJSON(saved)}
+	if found {
+		payload["before"] = flagJSON(before)
+	}
+	if err := h.audit.RecordAudit(r.Context(), telegram.AuditEntry{
+		Actor:      principal.Actor(),
+		Action:     "flag.update",
+		TargetType: "feature_flag",
+		TargetID:   string(key),
+		Payload:    payload,
+		TraceID:    traceid.FromContext(r.Context()),
+	}); err != nil {
+		writeError(w, r, http.StatusInternalServerError, "flag saved but audit log failed")
+		return
+	}
+	// A failed refresh is retried by the periodic one; the change is saved.
+	if h.evaluator != nil {
+		_ = h.evaluator.Refresh(r.Context())
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"flag":     flagJSON(saved),
+		"trace_id": traceid.FromContext(r.Context()),
+	})
+}
+
+// Evaluate shows what ?user= gets from this instance's cached flags and why.
+func (h FlagsHandler) Evaluate(w http.ResponseWriter, r *http.Request) {
+	key, ok := pathFlagKey(w, r)
+	if !ok {
+		return
+	}
+	userID := strings.TrimSpace(r.URL.Query().Get("user"))
+	if userID == "" {
+		writeError(w, r, http.StatusBadRequest, "user is required")
+		return
+	}
+
+	evaluation := h.evaluator.Evaluate(r.Context(), userID, key)
+	writeJSON(w, http.StatusOK, map[string]any{
+		"user":       userID,
+		"evaluation": evaluation,
+		"bucket":     flags.Bucket(key, userID),
+		"trace_id":   traceid.FromContext(r.Context()),
+	})
+}
+
+func flagJSON(flag flags.Flag) map[string]any {
+	envDefaults := flag.EnvDefaults
+	if envDefaults == nil {
+		envDefaults = map[string]bool{}
+	}
+	item := map[string]any{
+		"key":             flag.Key,
+		"description":     flag.Description,
+		"rollout_percent": flag.RolloutPercent,
+		"allow_user_ids":  nonNilStrings(flag.AllowUserIDs),
+		"deny_user_ids":   nonNilStrings(flag.DenyUserIDs),
+		"env_defaults":    envDefaults,
+		"updated_by":      flag.UpdatedBy,
+		"updated_at":      nil,
+	}
+	if !flag.UpdatedAt.IsZero() {
+		item["updated_at"] = formatTime(flag.UpdatedAt)
+	}
+	return item
+}
+
+func nonNilStrings(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+	return values
+}
+
+func pathFlagKey(w http.ResponseWriter, r *http.Request) (flags.Key, bool) {
+	key := r.PathValue("key")
+	if !flagKeyPattern.MatchString(key) {
+		writeError(w, r, http.StatusNotFound, "not found")
+		return "", false
+	}
+	return flags.Key(key), true
+}
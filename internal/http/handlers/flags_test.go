@@ -0,0 +1,120 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/congregalis/aiden/internal/auth"
+	"github.com/congregalis/aiden/internal/flags"
+	"github.com/congregalis/aiden/internal/http/middleware"
+)
+
+type fakeFlagStore struct {
+	flags map[flags.Key]flags.Flag
+}
+
+func (f *fakeFlagStore) ListFlags(context.Context) ([]flags.Flag, error) {
+	list := make([]flags.Flag, 0, len(f.flags))
+	for _, flag := range f.flags {
+		list = append(list, flag)
+	}
+	return list, nil
+}
+
+func (f *fakeFlagStore) GetFlag(_ context.Context, key flags.Key) (flags.Flag, bool, error) {
+	flag, ok := f.flags[key]
+	return flag, ok, nil
+}
+
+func (f *fakeFlagStore) SaveFlag(_ context.Context, flag flags.Flag) (flags.Flag, error) {
+	flag.UpdatedAt = time.Date(2026, 10, 1, 9, 0, 0, 0, time.UTC)
+	f.flags[flag.Key] = flag
+	return flag, nil
+}
+
+func serveFlags(token string, store *fakeFlagStore, audit *fakeAdminStore, evaluator *flags.Evaluator, method, target, body string) *httptest.ResponseRecorder {
+	mux := http.NewServeMux()
+	NewFlagsHandler(store, audit, evaluator).Register(mux)
+	verifier := auth.Chain{
+		auth.NewStaticToken("secret"),
+		fakeVerifier{"reader": {KeyID: "k1", Name: "dashboard", Scopes: []auth.Scope{auth.ScopeRead}}},
+	}
+	handler := middleware.AdminAuth(verifier, middleware.NewFailureLimiter(100, time.Minute), mux)
+
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set(middleware.AdminActorHeader, "alice")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestFlagsUpdateKeepsOmittedFieldsAuditsAndRefreshes(t *testing.T) {
+	store := &fakeFlagStore{flags: map[flags.Key]flags.Flag{
+		flags.LLMExtraction: {Key: flags.LLMExtraction, Description: "LLM slots"},
+	}}
+	audit := &fakeAdminStore{}
+	evaluator := flags.NewEvaluator(store, "production", nil)
+
+	rec := serveFlags("secret", store, audit, evaluator, http.MethodPatch, "/admin/v1/flags/llm_extraction",
+		`{"allow_user_ids": ["u1"]}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status=%d body=%s", rec.Code, rec.Body)
+	}
+	saved := store.flags[flags.LLMExtraction]
+	if saved.Description != "LLM slots" || len(saved.AllowUserIDs) != 1 || saved.UpdatedBy != "admin_token:alice" {
+		t.Fatalf("saved flag=%+v", saved)
+	}
+	if len(audit.audits) != 1 || audit.audits[0].Action != "flag.update" || audit.audits[0].TargetID != "llm_extraction" {
+		t.Fatalf("audits=%+v", audit.audits)
+	}
+	if _, ok := audit.audits[0].Payload["before"]; !ok {
+		t.Fatalf("audit payload=%v, want before and after", audit.audits[0].Payload)
+	}
+
+	rec = serveFlags("reader", store, audit, evaluator, http.MethodGet, "/admin/v1/flags/llm_extraction/evaluate?user=u1", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("evaluate status=%d body=%s", rec.Code, rec.Body)
+	}
+	var response struct {
+		Evaluation flags.Evaluation `json:"evaluation"`
+	}
+	_ = json.Unmarshal(rec.Body.Bytes(), &response)
+	if !response.Evaluation.Enabled || response.Evaluation.Reason != flags.ReasonAllowList {
+		t.Fatalf("evaluation=%+v, want the update applied without waiting for a refresh", response.Evaluation)
+	}
+}
+
+func TestFlagsRoutesValidateAndRequireScopes(t *testing.T) {
+	tests := []struct {
+		name   string
+		token  string
+		method string
+		target string
+		body   string
+		status int
+	}{
+		{name: "read lists flags", token: "reader", method: http.MethodGet, target: "/admin/v1/flags", status: http.StatusOK},
+		{name: "read cannot update", token: "reader", method: http.MethodPatch, target: "/admin/v1/flags/llm_extraction", body: `{"rollout_percent": 5}`, status: http.StatusForbidden},
+		{name: "percent out of range", token: "secret", method: http.MethodPatch, target: "/admin/v1/flags/llm_extraction", body: `{"rollout_percent": 120}`, status: http.StatusBadRequest},
+		{name: "allowed and denied", token: "secret", method: http.MethodPatch, target: "/admin/v1/flags/llm_extraction", body: `{"allow_user_ids": ["u1"], "deny_user_ids": ["u1"]}`, status: http.StatusBadRequest},
+		{name: "unknown field", token: "secret", method: http.MethodPatch, target: "/admin/v1/flags/llm_extraction", body: `{"enabled": true}`, status: http.StatusBadRequest},
+		{name: "bad key", token: "secret", method: http.MethodPatch, target: "/admin/v1/flags/Bad-Key", body: `{}`, status: http.StatusNotFound},
+		{name: "evaluate needs user", token: "reader", method: http.MethodGet, target: "/admin/v1/flags/llm_extraction/evaluate", status: http.StatusBadRequest},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			store := &fakeFlagStore{flags: map[flags.Key]flags.Flag{}}
+			rec := serveFlags(tc.token, store, &fakeAdminStore{}, nil, tc.method, tc.target, tc.body)
+			if rec.Code != tc.status {
+				t.Fatalf("status=%d, want %d: %s", rec.Code, tc.status, rec.Body)
+			}
+		})
+	}
+}
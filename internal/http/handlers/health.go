@@ -4,22 +4,35 @@ import (
 	"context"
 	"encoding/json"
 	"net/http"
+	"sync"
 	"time"
 
 	"github.com/congregalis/aiden/pkg/traceid"
 )
 
-type ReadinessFunc func(context.Context) error
+const readinessTimeout = 2 * time.Second
+
+// ReadinessCheck is one dependency /readyz reports on. The service is ready
+// only when every check passes.
+type ReadinessCheck struct {
+	Name  string
+	Check func(context.Context) error
+}
+
+// LivenessFunc fails when the process is stuck and should be restarted.
+type LivenessFunc func() error
 
 type HealthHandler struct {
 	startedAt time.Time
-	readyFn   ReadinessFunc
+	checks    []ReadinessCheck
+	liveFn    LivenessFunc
 }
 
-func NewHealthHandler(readyFn ReadinessFunc) HealthHandler {
+func NewHealthHandler(checks []ReadinessCheck, liveFn LivenessFunc) HealthHandler {
 	return HealthHandler{
 		startedAt: time.Now().UTC(),
-		readyFn:   readyFn,
+		checks:    checks,
+		liveFn:    liveFn,
 	}
 }
 
@@ -30,29 +43,53 @@ func (h HealthHandler) Healthz(w http.ResponseWriter, r *http.Request) {
 		"trace_id": traceid.FromContext(r.Context()),
 		"uptime":   time.Since(h.startedAt).String(),
 	}
+	if h.liveFn != nil {
+		if err := h.liveFn(); err != nil {
+			response["status"] = "unhealthy"
+			response["error"] = err.Error()
+			writeJSON(w, http.StatusServiceUnavailable, response)
+			return
+		}
+	}
 	writeJSON(w, http.StatusOK, response)
 }
 
+// Readyz runs every check concurrently and reports each one, so a failing
+// probe says which dependency is behind it.
 func (h HealthHandler) Readyz(w http.ResponseWriter, r *http.Request) {
-	if h.readyFn != nil {
-		ctx, cancel := context.WithTimeout(r.Context(), 2*time.Second)
-		defer cancel()
-
-		if err := h.readyFn(ctx); err != nil {
-			response := map[string]any{
-				"status":   "not_ready",
-				"trace_id": traceid.FromContext(r.Context()),
+	ctx, cancel := context.WithTimeout(r.Context(), readinessTimeout)
+	defer cancel()
+
+	results := make([]map[string]any, len(h.checks))
+	var wg sync.WaitGroup
+	for i, check := range h.checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			result := map[string]any{"status": "ok"}
+			if err := check.Check(ctx); err != nil {
+				result["status"] = "failed"
+				result["error"] = err.Error()
 			}
-			writeJSON(w, http.StatusServiceUnavailable, response)
-			return
+			results[i] = result
+		}()
+	}
+	wg.Wait()
+
+	status, code := "ready", http.StatusOK
+	checks := make(map[string]any, len(h.checks))
+	for i, check := range h.checks {
+		checks[check.Name] = results[i]
+		if results[i]["status"] != "ok" {
+			status, code = "not_ready", http.StatusServiceUnavailable
 		}
 	}
 
-	response := map[string]any{
-		"status":   "ready",
+	writeJSON(w, code, map[string]any{
+		"status":   status,
+		"checks":   checks,
 		"trace_id": traceid.FromContext(r.Context()),
-	}
-	writeJSON(w, http.StatusOK, response)
+	})
 }
 
 func writeJSON(w http.ResponseWriter, status int, payload map[string]any) {
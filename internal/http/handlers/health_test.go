@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestReadyzReportsEveryCheck(t *testing.T) {
+	handler := NewHealthHandler([]ReadinessCheck{
+		{Name: "database", Check: func(context.Context) error { return nil }},
+		{Name: "schema_version", Check: func(context.Context) error { return errors.New("schema version 25, binary expects 26") }},
+	}, nil)
+
+	recorder := httptest.NewRecorder()
+	handler.Readyz(recorder, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+	if recorder.Code != http.StatusServiceUnavailable {
+		t.Fatalf("status=%d, want 503", recorder.Code)
+	}
+	var body struct {
+		Status string                       `json:"status"`
+		Checks map[string]map[string]string `json:"checks"`
+	}
+	if err := json.Unmarshal(recorder.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decode body: %v", err)
+	}
+	if body.Status != "not_ready" || body.Checks["database"]["status"] != "ok" {
+		t.Fatalf("unexpected body: %+v", body)
+	}
+	if schema := body.Checks["schema_version"]; schema["status"] != "failed" || schema["error"] != "schema version 25, binary expects 26" {
+		t.Fatalf("schema check=%v", schema)
+	}
+}
+
+func TestHealthzFailsWhenStuck(t *testing.T) {
+	stuck := errors.New("worker heartbeat is 5m0s old")
+	handler := NewHealthHandler(nil, func() error { return stuck })
+
+	recorder := httptest.NewRecorder()
+	handler.Healthz(recorder, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	if recorder.Code != http.StatusServiceUnavailable {
+		t.Fatalf("status=%d, want 503", recorder.Code)
+	}
+
+	stuck = nil
+	recorder = httptest.NewRecorder()
+	handler.Healthz(recorder, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("status=%d after recovery, want 200", recorder.Code)
+	}
+}
@@ -0,0 +1,52 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/congregalis/aiden/internal/maintenance"
+	"github.com/congregalis/aiden/pkg/traceid"
+)
+
+type MaintenanceReporter interface {
+	Report() maintenance.Report
+}
+
+type MaintenanceHandler struct {
+	reporter MaintenanceReporter
+}
+
+func NewMaintenanceHandler(reporter MaintenanceReporter) MaintenanceHandler {
+	return MaintenanceHandler{reporter: reporter}
+}
+
+// Status shows what each cleanup task did since this instance started. Only
+// the instance holding the advisory lock runs tasks; the others count
+// lock_skips.
+func (h MaintenanceHandler) Status(w http.ResponseWriter, r *http.Request) {
+	report := h.reporter.Report()
+
+	tasks := make([]map[string]any, 0, len(report.Tasks))
+	for _, task := range report.Tasks {
+		item := map[string]any{
+			"name":             task.Name,
+			"runs":             task.Runs,
+			"errors":           task.Errors,
+			"budget_exceeded":  task.BudgetExceeded,
+			"rows":             task.Rows,
+			"last_run_at":      nil,
+			"last_duration_ms": task.LastDuration.Milliseconds(),
+			"last_rows":        task.LastRows,
+			"last_error":       task.LastError,
+		}
+		if !task.LastRunAt.IsZero() {
+			item["last_run_at"] = task.LastRunAt.UTC().Format(time.RFC3339)
+		}
+		tasks = append(tasks, item)
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"lock_skips": report.LockSkips,
+		"tasks":      tasks,
+		"trace_id":   traceid.FromContext(r.Context()),
+	})
+}
@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/congregalis/aiden/internal/telegram"
+	"github.com/congregalis/aiden/pkg/traceid"
+)
+
+const (
+	defaultDeadLetterLimit = 50
+	maxDeadLetterLimit     = 500
+)
+
+type DeadLetterLister interface {
+	ListDeadOutgoingMessages(context.Context, int) ([]telegram.OutboxMessage, error)
+}
+
+type OutboxHandler struct {
+	store DeadLetterLister
+}
+
+func NewOutboxHandler(store DeadLetterLister) OutboxHandler {
+	return OutboxHandler{store: store}
+}
+
+func (h OutboxHandler) DeadLetters(w http.ResponseWriter, r *http.Request) {
+	limit, err := parseLimit(r.URL.Query().Get("limit"), defaultDeadLetterLimit, maxDeadLetterLimit)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]any{
+			"error":    err.Error(),
+			"trace_id": traceid.FromContext(r.Context()),
+		})
+		return
+	}
+
+	messages, err := h.store.ListDeadOutgoingMessages(r.Context(), limit)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]any{
+			"error":    "list dead letters failed",
+			"trace_id": traceid.FromContext(r.Context()),
+		})
+		return
+	}
+
+	items := make([]map[string]any, 0, len(messages))
+	for _, message := range messages {
+		items = append(items, map[string]any{
+			"id":                  message.ID,
+			"chat_id_masked":      telegram.MaskChatID(message.ChatID),
+			"text":                message.Text,
+			"reply_to_message_id": message.ReplyToMessageID,
+			"attempts":            message.Attempts,
+			"last_error":          message.LastError,
+			"created_at":          message.CreatedAt.UTC().Format(time.RFC3339),
+			"updated_at":          message.UpdatedAt.UTC().Format(time.RFC3339),
+		})
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"items":    items,
+		"count":    len(items),
+		"trace_id": traceid.FromContext(r.Context()),
+	})
+}
+
+func parseLimit(raw string, fallback, max int) (int, error) {
+	if raw == "" {
+		return fallback, nil
+	}
+
+	limit, err := strconv.Atoi(raw)
+	if err != nil || limit <= 0 {
+		return 0, errors.New("limit must be a positive integer")
+	}
+	if limit > max {
+		limit = max
+	}
+	return limit, nil
+}
@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/congregalis/aiden/internal/telegram"
+	"github.com/congregalis/aiden/pkg/traceid"
+)
+
+const maxDryRunBodyBytes = 16 << 10
+
+type RulesDryRunner interface {
+	DryRun(text string, state telegram.PlanningState) telegram.RuleEvaluation
+}
+
+type RulesHandler struct {
+	rules RulesDryRunner
+}
+
+func NewRulesHandler(rules RulesDryRunner) RulesHandler {
+	return RulesHandler{rules: rules}
+}
+
+type dryRunRequest struct {
+	Text  string `json:"text"`
+	State string `json:"state"`
+}
+
+// DryRun classifies a message against the live rules without touching any
+// session and reports which rules fired.
+func (h RulesHandler) DryRun(w http.ResponseWriter, r *http.Request) {
+	var req dryRunRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxDryRunBodyBytes)).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]any{
+			"error":    "body must be JSON like {\"text\": \"...\", \"state\": \"clarifying\"}",
+			"trace_id": traceid.FromContext(r.Context()),
+		})
+		return
+	}
+	if strings.TrimSpace(req.Text) == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]any{
+			"error":    "text is required",
+			"trace_id": traceid.FromContext(r.Context()),
+		})
+		return
+	}
+
+	state := telegram.ParsePlanningState(req.State)
+	if req.State != "" && string(state) != req.State {
+		writeJSON(w, http.StatusBadRequest, map[string]any{
+			"error":    "state must be one of idle, clarifying, review, confirmed",
+			"trace_id": traceid.FromContext(r.Context()),
+		})
+		return
+	}
+	evaluation := h.rules.DryRun(req.Text, state)
+	writeJSON(w, http.StatusOK, map[string]any{
+		"state":      state,
+		"evaluation": evaluation,
+		"trace_id":   traceid.FromContext(r.Context()),
+	})
+}
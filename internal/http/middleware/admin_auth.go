@@ -0,0 +1,190 @@
+package middleware
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/congregalis/aiden/internal/auth"
+)
+
+// AdminActorHeader lets operators who share ADMIN_TOKEN say who they are, so
+// the audit log can tell them apart. API keys carry their own name instead.
+const AdminActorHeader = "X-Admin-Actor"
+
+const maxAdminActorLength = 64
+
+// limiterPruneSize is how many tracked clients make the limiter drop expired
+// entries.
+const limiterPruneSize = 1024
+
+// FailureLimiter locks a client out after too many failed logins within a
+// window.
+type FailureLimiter struct {
+	maxFailures int
+	window      time.Duration
+	now         func() time.Time
+
+	mu       sync.Mutex
+	failures map[string]*failureWindow
+}
+
+type failureWindow struct {
+	count   int
+	resetAt time.Time
+}
+
+func NewFailureLimiter(maxFailures int, window time.Duration) *FailureLimiter {
+	return &FailureLimiter{
+		maxFailures: maxFailures,
+		window:      window,
+		now:         time.Now,
+		failures:    make(map[string]*failureWindow),
+	}
+}
+
+// retryAfter returns how long client stays locked out, or 0.
+func (l *FailureLimiter) retryAfter(client string) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	entry, ok := l.failures[client]
+	if !ok || entry.count < l.maxFailures {
+		return 0
+	}
+	wait := entry.resetAt.Sub(l.now())
+	if wait <= 0 {
+		delete(l.failures, client)
+		return 0
+	}
+	return wait
+}
+
+func (l *FailureLimiter) fail(client string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	if len(l.failures) >= limiterPruneSize {
+		for key, entry := range l.failures {
+			if !now.Before(entry.resetAt) {
+				delete(l.failures, key)
+			}
+		}
+	}
+
+	entry, ok := l.failures[client]
+	if !ok || !now.Before(entry.resetAt) {
+		entry = &failureWindow{resetAt: now.Add(l.window)}
+		l.failures[client] = entry
+	}
+	entry.count++
+}
+
+// AdminAuth accepts requests whose bearer token the verifier resolves to a
+// principal and stores that principal in the request context.
+func AdminAuth(verifier auth.Verifier, limiter *FailureLimiter, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		client := clientAddr(r)
+		if wait := limiter.retryAfter(client); wait > 0 {
+			recordAuth(r.Context(), "", "rate_limited")
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+			writeAuthError(w, http.StatusTooManyRequests, "too many failed attempts")
+			return
+		}
+
+		token, ok := bearerToken(r)
+		if !ok {
+			limiter.fail(client)
+			recordAuth(r.Context(), "", "missing")
+			unauthorized(w)
+			return
+		}
+
+		principal, err := verifier.Verify(r.Context(), token)
+		switch {
+		case errors.Is(err, auth.ErrInvalidKey):
+			limiter.fail(client)
+			recordAuth(r.Context(), auth.KeyIDOf(token), "invalid")
+			unauthorized(w)
+			return
+		case errors.Is(err, auth.ErrKeyRevoked):
+			limiter.fail(client)
+			recordAuth(r.Context(), auth.KeyIDOf(token), "revoked")
+			unauthorized(w)
+			return
+		case err != nil:
+			recordAuth(r.Context(), auth.KeyIDOf(token), "error")
+			writeAuthError(w, http.StatusServiceUnavailable, "authentication unavailable")
+			return
+		}
+
+		if principal.KeyID == auth.StaticTokenID {
+			if actor := strings.TrimSpace(r.Header.Get(AdminActorHeader)); actor != "" {
+				if len(actor) > maxAdminActorLength {
+					actor = strings.ToValidUTF8(actor[:maxAdminActorLength], "")
+				}
+				principal.Name = actor
+			}
+		}
+
+		recordAuth(r.Context(), principal.KeyID, "ok")
+		next.ServeHTTP(w, r.WithContext(auth.WithPrincipal(r.Context(), principal)))
+	})
+}
+
+// RequireScope rejects requests whose principal lacks scope. It must run
+// inside AdminAuth.
+func RequireScope(scope auth.Scope, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		principal, ok := auth.PrincipalFromContext(r.Context())
+		if !ok {
+			unauthorized(w)
+			return
+		}
+		if !principal.Has(scope) {
+			recordAuth(r.Context(), principal.KeyID, "forbidden")
+			writeAuthError(w, http.StatusForbidden, "missing scope "+string(scope))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func unauthorized(w http.ResponseWriter) {
+	w.Header().Set("WWW-Authenticate", `Bearer realm="admin"`)
+	writeAuthError(w, http.StatusUnauthorized, "unauthorized")
+}
+
+func writeAuthError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]any{"error": message})
+}
+
+// clientAddr is the peer address. X-Forwarded-For is ignored on purpose: a
+// client could otherwise pick a fresh value for every guess.
+func clientAddr(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+func bearerToken(r *http.Request) (string, bool) {
+	header := strings.TrimSpace(r.Header.Get("Authorization"))
+	scheme, token, found := strings.Cut(header, " ")
+	if !found || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+
+	token = strings.TrimSpace(token)
+	return token, token != ""
+}
@@ -0,0 +1,107 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/congregalis/aiden/internal/auth"
+)
+
+const testAPIKey = "aik_0123456789abcdef_" + "00112233445566778899aabbccddeeff00112233445566778899aabbccddeeff"
+
+type stubVerifier struct{ err error }
+
+func (s stubVerifier) Verify(_ context.Context, token string) (auth.Principal, error) {
+	if s.err != nil {
+		return auth.Principal{}, s.err
+	}
+	if token != testAPIKey {
+		return auth.Principal{}, auth.ErrInvalidKey
+	}
+	return auth.Principal{KeyID: "0123456789abcdef", Name: "ci", Scopes: []auth.Scope{auth.ScopeRead}}, nil
+}
+
+func TestAdminAuthLocksOutRepeatedFailures(t *testing.T) {
+	now := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	limiter := NewFailureLimiter(3, time.Minute)
+	limiter.now = func() time.Time { return now }
+	handler := AdminAuth(stubVerifier{}, limiter, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	call := func(token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/admin/v1/users", nil)
+		req.RemoteAddr = "203.0.113.7:51234"
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	for i := 0; i < 3; i++ {
+		if rec := call("wrong"); rec.Code != http.StatusUnauthorized {
+			t.Fatalf("attempt %d status=%d", i, rec.Code)
+		}
+	}
+	rec := call(testAPIKey)
+	if rec.Code != http.StatusTooManyRequests || rec.Header().Get("Retry-After") != "60" {
+		t.Fatalf("locked out status=%d retry-after=%q", rec.Code, rec.Header().Get("Retry-After"))
+	}
+
+	now = now.Add(time.Minute)
+	if rec := call(testAPIKey); rec.Code != http.StatusNoContent {
+		t.Fatalf("after window status=%d", rec.Code)
+	}
+}
+
+func TestAdminAuthStoreErrorIsNotAFailedLogin(t *testing.T) {
+	limiter := NewFailureLimiter(1, time.Minute)
+	handler := AdminAuth(stubVerifier{err: errors.New("database down")}, limiter, http.NotFoundHandler())
+
+	for i := 0; i < 2; i++ {
+		req := httptest.NewRequest(http.MethodGet, "/admin/v1/users", nil)
+		req.Header.Set("Authorization", "Bearer "+testAPIKey)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != http.StatusServiceUnavailable {
+			t.Fatalf("attempt %d status=%d", i, rec.Code)
+		}
+	}
+}
+
+func TestRequestLoggerRecordsKeyIDNotSecret(t *testing.T) {
+	var logs bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&logs, nil))
+	protected := RequireScope(auth.ScopeWrite, http.NotFoundHandler())
+	handler := RequestLogger(logger, AdminAuth(stubVerifier{}, NewFailureLimiter(10, time.Minute), protected))
+
+	wrongSecret := testAPIKey[:len(testAPIKey)-1] + "0"
+	for _, token := range []string{testAPIKey, wrongSecret} {
+		req := httptest.NewRequest(http.MethodPost, "/admin/v1/sessions/x/transition", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	lines := strings.Split(strings.TrimSpace(logs.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("logs=%s", logs.String())
+	}
+	if !strings.Contains(lines[0], "status=403") || !strings.Contains(lines[0], "auth_key_id=0123456789abcdef auth_result=forbidden") {
+		t.Fatalf("forbidden log=%s", lines[0])
+	}
+	if !strings.Contains(lines[1], "status=401") || !strings.Contains(lines[1], "auth_key_id=0123456789abcdef auth_result=invalid") {
+		t.Fatalf("invalid log=%s", lines[1])
+	}
+	if strings.Contains(logs.String(), "00112233445566778899") {
+		t.Fatalf("secret leaked into logs: %s", logs.String())
+	}
+}
@@ -1,6 +1,7 @@
 package middleware
 
 import (
+	"context"
 	"log/slog"
 	"net/http"
 	"time"
@@ -29,24 +30,48 @@ func (w *statusWriter) Write(data []byte) (int, error) {
 	return n, err
 }
 
+// authOutcome is filled in by AdminAuth so RequestLogger can log who tried
+// to authenticate. It only ever holds the key ID, never the secret.
+type authOutcome struct {
+	keyID  string
+	result string
+}
+
+type authOutcomeKey struct{}
+
+func recordAuth(ctx context.Context, keyID, result string) {
+	if outcome, ok := ctx.Value(authOutcomeKey{}).(*authOutcome); ok {
+		outcome.keyID = keyID
+		outcome.result = result
+	}
+}
+
 func RequestLogger(logger *slog.Logger, next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		start := time.Now()
 		sw := &statusWriter{ResponseWriter: w}
+		outcome := &authOutcome{}
 
-		next.ServeHTTP(sw, r)
+		next.ServeHTTP(sw, r.WithContext(context.WithValue(r.Context(), authOutcomeKey{}, outcome)))
 
 		if sw.status == 0 {
 			sw.status = http.StatusOK
 		}
 
-		logger.Info("http_request",
+		attrs := []any{
 			slog.String("method", r.Method),
 			slog.String("path", r.URL.Path),
 			slog.Int("status", sw.status),
 			slog.Int("response_bytes", sw.size),
 			slog.Int64("duration_ms", time.Since(start).Milliseconds()),
 			slog.String("trace_id", traceid.FromContext(r.Context())),
-		)
+		}
+		if outcome.result != "" {
+			attrs = append(attrs,
+				slog.String("auth_key_id", outcome.keyID),
+				slog.String("auth_result", outcome.result),
+			)
+		}
+		logger.Info("http_request", attrs...)
 	})
 }
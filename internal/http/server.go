@@ -5,18 +5,62 @@ import (
 	"log/slog"
 	"net/http"
 
+	"github.com/congregalis/aiden/internal/auth"
 	"github.com/congregalis/aiden/internal/config"
+	"github.com/congregalis/aiden/internal/flags"
 	"github.com/congregalis/aiden/internal/http/handlers"
 	"github.com/congregalis/aiden/internal/http/middleware"
 )
 
-func NewServer(cfg config.Config, logger *slog.Logger, readyFn handlers.ReadinessFunc) *http.Server {
-	healthHandler := handlers.NewHealthHandler(readyFn)
+type Dependencies struct {
+	Ready  []handlers.ReadinessCheck
+	Live   handlers.LivenessFunc
+	Outbox handlers.DeadLetterLister
+	Rules  handlers.RulesDryRunner
+	Admin  handlers.AdminStore
+	// AdminKeys verifies admin API keys. ADMIN_TOKEN is accepted as well.
+	AdminKeys   auth.Verifier
+	Maintenance handlers.MaintenanceReporter
+	// Flags serves the flag admin routes; changes are audited through Admin.
+	Flags         handlers.FlagStore
+	FlagEvaluator *flags.Evaluator
+}
+
+func NewServer(cfg config.Config, logger *slog.Logger, deps Dependencies) *http.Server {
+	healthHandler := handlers.NewHealthHandler(deps.Ready, deps.Live)
 
 	mux := http.NewServeMux()
 	mux.HandleFunc("GET /healthz", healthHandler.Healthz)
 	mux.HandleFunc("GET /readyz", healthHandler.Readyz)
 
+	if cfg.Admin.Token != "" || deps.AdminKeys != nil {
+		admin := http.NewServeMux()
+		if deps.Outbox != nil {
+			outboxHandler := handlers.NewOutboxHandler(deps.Outbox)
+			admin.Handle("GET /admin/outbox/dead-letters", middleware.RequireScope(auth.ScopeRead, http.HandlerFunc(outboxHandler.DeadLetters)))
+		}
+		if deps.Rules != nil {
+			rulesHandler := handlers.NewRulesHandler(deps.Rules)
+			admin.Handle("POST /admin/rules/dry-run", middleware.RequireScope(auth.ScopeRead, http.HandlerFunc(rulesHandler.DryRun)))
+		}
+		if deps.Admin != nil {
+			handlers.NewAdminHandler(deps.Admin).Register(admin)
+		}
+		if deps.Flags != nil && deps.Admin != nil {
+			handlers.NewFlagsHandler(deps.Flags, deps.Admin, deps.FlagEvaluator).Register(admin)
+		}
+		if deps.Maintenance != nil {
+			maintenanceHandler := handlers.NewMaintenanceHandler(deps.Maintenance)
+			admin.Handle("GET /admin/maintenance", middleware.RequireScope(auth.ScopeRead, http.HandlerFunc(maintenanceHandler.Status)))
+		}
+		verifier := auth.Chain{auth.NewStaticToken(cfg.Admin.Token)}
+		if deps.AdminKeys != nil {
+			verifier = append(verifier, deps.AdminKeys)
+		}
+		limiter := middleware.NewFailureLimiter(cfg.Admin.AuthMaxFailures, cfg.Admin.AuthFailureWindow)
+		mux.Handle("/admin/", middleware.AdminAuth(verifier, limiter, admin))
+	}
+
 	handler := middleware.TraceID(mux)
 	handler = middleware.RequestLogger(logger, handler)
 
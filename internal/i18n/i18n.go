@@ -0,0 +1,208 @@
+package i18n
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	ZhCN          = "zh-CN"
+	En            = "en"
+	DefaultLocale = ZhCN
+)
+
+type localeInfo struct {
+	name           string
+	listSeparator  string
+	groupSeparator string
+	// singular reports whether the locale has a distinct form for a count of one.
+	singular bool
+}
+
+var supportedLocales = map[string]localeInfo{
+	ZhCN: {name: "简体中文", listSeparator: "、", groupSeparator: ",", singular: false},
+	En:   {name: "English", listSeparator: ", ", groupSeparator: ",", singular: true},
+}
+
+// Message is a catalog entry. One is used when the count is exactly one in a
+// locale that distinguishes singular; Other covers every other case and is
+// the only form needed for plain messages.
+type Message struct {
+	One   string
+	Other string
+}
+
+// Catalog resolves message IDs per locale, falling back from a regional
+// locale to its base language and finally to the fallback locale.
+type Catalog struct {
+	fallback string
+	messages map[string]map[string]Message
+}
+
+func NewCatalog(fallback string, messages map[string]map[string]Message) (*Catalog, error) {
+	base, ok := messages[fallback]
+	if !ok {
+		return nil, fmt.Errorf("fallback locale %s has no messages", fallback)
+	}
+	for locale, entries := range messages {
+		if _, ok := supportedLocales[locale]; !ok {
+			return nil, fmt.Errorf("unsupported locale %s", locale)
+		}
+		for id, message := range entries {
+			if message.Other == "" {
+				return nil, fmt.Errorf("message %s in %s has no text", id, locale)
+			}
+			if _, ok := base[id]; !ok {
+				return nil, fmt.Errorf("message %s in %s is missing from fallback locale %s", id, locale, fallback)
+			}
+		}
+	}
+	return &Catalog{fallback: fallback, messages: messages}, nil
+}
+
+func MustNewCatalog(fallback string, messages map[string]map[string]Message) *Catalog {
+	catalog, err := NewCatalog(fallback, messages)
+	if err != nil {
+		panic(err)
+	}
+	return catalog
+}
+
+// T renders a message with fmt-style args. Unknown IDs render as the ID so a
+// missing entry is visible instead of producing an empty reply.
+func (c *Catalog) T(locale, id string, args ...any) string {
+	message, _, ok := c.lookup(locale, id)
+	if !ok {
+		return id
+	}
+	return render(message.Other, args)
+}
+
+// N renders the plural form of a message that matches count.
+func (c *Catalog) N(locale, id string, count int, args ...any) string {
+	message, resolved, ok := c.lookup(locale, id)
+	if !ok {
+		return id
+	}
+	text := message.Other
+	if count == 1 && message.One != "" && supportedLocales[resolved].singular {
+		text = message.One
+	}
+	return render(text, args)
+}
+
+// Missing lists the IDs present in the fallback locale but not in locale.
+func (c *Catalog) Missing(locale string) []string {
+	entries := c.messages[locale]
+	missing := make([]string, 0)
+	for id := range c.messages[c.fallback] {
+		if _, ok := entries[id]; !ok {
+			missing = append(missing, id)
+		}
+	}
+	sort.Strings(missing)
+	return missing
+}
+
+func (c *Catalog) lookup(locale, id string) (Message, string, bool) {
+	for _, candidate := range []string{locale, baseLanguage(locale), c.fallback} {
+		if message, ok := c.messages[candidate][id]; ok {
+			return message, candidate, true
+		}
+	}
+	return Message{}, "", false
+}
+
+func render(text string, args []any) string {
+	if len(args) == 0 {
+		return text
+	}
+	return fmt.Sprintf(text, args...)
+}
+
+// Normalize maps a user or Telegram supplied language tag such as "en-US",
+// "zh_hans" or "中文" to a supported locale.
+func Normalize(raw string) (string, bool) {
+	value := strings.ToLower(strings.TrimSpace(strings.ReplaceAll(raw, "_", "-")))
+	switch value {
+	case "中文", "简体中文", "chinese", "cn":
+		return ZhCN, true
+	case "english", "英文", "英语":
+		return En, true
+	}
+
+	switch baseLanguage(value) {
+	case "zh":
+		return ZhCN, true
+	case "en":
+		return En, true
+	default:
+		return "", false
+	}
+}
+
+// Locales returns the supported locale tags in sorted order.
+func Locales() []string {
+	out := make([]string, 0, len(supportedLocales))
+	for locale := range supportedLocales {
+		out = append(out, locale)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// DisplayName returns the locale's name in its own language.
+func DisplayName(locale string) string {
+	return localeFor(locale).name
+}
+
+// FormatNumber groups digits by thousands using the locale's separator.
+func FormatNumber(locale string, n int64) string {
+	digits := strconv.FormatInt(n, 10)
+	sign := ""
+	if strings.HasPrefix(digits, "-") {
+		sign, digits = "-", digits[1:]
+	}
+	if len(digits) <= 3 {
+		return sign + digits
+	}
+
+	separator := localeFor(locale).groupSeparator
+	var builder strings.Builder
+	builder.WriteString(sign)
+	head := len(digits) % 3
+	if head > 0 {
+		builder.WriteString(digits[:head])
+	}
+	for i := head; i < len(digits); i += 3 {
+		if builder.Len() > len(sign) {
+			builder.WriteString(separator)
+		}
+		builder.WriteString(digits[i : i+3])
+	}
+	return builder.String()
+}
+
+// JoinList joins items with the locale's list separator.
+func JoinList(locale string, items []string) string {
+	return strings.Join(items, localeFor(locale).listSeparator)
+}
+
+func localeFor(locale string) localeInfo {
+	if info, ok := supportedLocales[locale]; ok {
+		return info
+	}
+	if normalized, ok := Normalize(locale); ok {
+		return supportedLocales[normalized]
+	}
+	return supportedLocales[DefaultLocale]
+}
+
+func baseLanguage(locale string) string {
+	if idx := strings.IndexAny(locale, "-_"); idx >= 0 {
+		return strings.ToLower(locale[:idx])
+	}
+	return strings.ToLower(locale)
+}
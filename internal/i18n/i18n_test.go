@@ -0,0 +1,113 @@
+package i18n
+
+import "testing"
+
+func testCatalog(t *testing.T) *Catalog {
+	t.Helper()
+
+	catalog, err := NewCatalog(ZhCN, map[string]map[string]Message{
+		ZhCN: {
+			"greeting": {Other: "你好，%s"},
+			"turns":    {Other: "%s 轮"},
+			"only_zh":  {Other: "仅中文"},
+		},
+		En: {
+			"greeting": {Other: "Hello, %s"},
+			"turns":    {One: "%s turn", Other: "%s turns"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("new catalog: %v", err)
+	}
+	return catalog
+}
+
+func TestCatalogFallsBackToBaseLanguageThenFallbackLocale(t *testing.T) {
+	catalog := testCatalog(t)
+
+	tests := []struct {
+		locale string
+		id     string
+		want   string
+	}{
+		{locale: En, id: "greeting", want: "Hello, Ada"},
+		{locale: "en-GB", id: "greeting", want: "Hello, Ada"},
+		{locale: "fr", id: "greeting", want: "你好，Ada"},
+		{locale: En, id: "only_zh", want: "仅中文"},
+		{locale: En, id: "unknown", want: "unknown"},
+	}
+	for _, tt := range tests {
+		var got string
+		if tt.id == "greeting" {
+			got = catalog.T(tt.locale, tt.id, "Ada")
+		} else {
+			got = catalog.T(tt.locale, tt.id)
+		}
+		if got != tt.want {
+			t.Fatalf("T(%q, %q)=%q, want %q", tt.locale, tt.id, got, tt.want)
+		}
+	}
+}
+
+func TestCatalogPluralForms(t *testing.T) {
+	catalog := testCatalog(t)
+
+	if got := catalog.N(En, "turns", 1, FormatNumber(En, 1)); got != "1 turn" {
+		t.Fatalf("N(en, 1)=%q", got)
+	}
+	if got := catalog.N(En, "turns", 1200, FormatNumber(En, 1200)); got != "1,200 turns" {
+		t.Fatalf("N(en, 1200)=%q", got)
+	}
+	if got := catalog.N(ZhCN, "turns", 1, FormatNumber(ZhCN, 1)); got != "1 轮" {
+		t.Fatalf("N(zh-CN, 1)=%q", got)
+	}
+	if missing := catalog.Missing(En); len(missing) != 1 || missing[0] != "only_zh" {
+		t.Fatalf("Missing(en)=%v, want [only_zh]", missing)
+	}
+}
+
+func TestNewCatalogRejectsUnknownIDs(t *testing.T) {
+	_, err := NewCatalog(ZhCN, map[string]map[string]Message{
+		ZhCN: {"a": {Other: "甲"}},
+		En:   {"b": {Other: "b"}},
+	})
+	if err == nil {
+		t.Fatalf("expected an error for an id missing from the fallback locale")
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	tests := map[string]string{
+		"en":      En,
+		"EN-us":   En,
+		"english": En,
+		"zh":      ZhCN,
+		"zh_Hans": ZhCN,
+		"zh-TW":   ZhCN,
+		"中文":      ZhCN,
+	}
+	for raw, want := range tests {
+		got, ok := Normalize(raw)
+		if !ok || got != want {
+			t.Fatalf("Normalize(%q)=(%q, %v), want %q", raw, got, ok, want)
+		}
+	}
+	if _, ok := Normalize("fr"); ok {
+		t.Fatalf("expected fr to be unsupported")
+	}
+}
+
+func TestFormatNumberAndJoinList(t *testing.T) {
+	numbers := map[int64]string{0: "0", 999: "999", 1000: "1,000", 1234567: "1,234,567", -45000: "-45,000"}
+	for n, want := range numbers {
+		if got := FormatNumber(En, n); got != want {
+			t.Fatalf("FormatNumber(%d)=%q, want %q", n, got, want)
+		}
+	}
+	if got := JoinList(ZhCN, []string{"甲", "乙"}); got != "甲、乙" {
+		t.Fatalf("JoinList(zh-CN)=%q", got)
+	}
+	if got := JoinList(En, []string{"a", "b"}); got != "a, b" {
+		t.Fatalf("JoinList(en)=%q", got)
+	}
+}
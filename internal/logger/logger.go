@@ -1,24 +1,46 @@
 package logger
 
 import (
+	"io"
 	"log/slog"
 	"os"
 	"strings"
 
 	"github.com/congregalis/aiden/internal/config"
+	"github.com/congregalis/aiden/internal/pii"
 )
 
-func New(cfg config.LogConfig) *slog.Logger {
-	options := &slog.HandlerOptions{
-		Level:     parseLevel(cfg.Level),
-		AddSource: cfg.AddSource,
-	}
+// New returns a JSON logger whose level follows level, so a config reload
+// can change it; level is initialised from cfg.Level.
+func New(cfg config.LogConfig, level *slog.LevelVar) *slog.Logger {
+	return slog.New(newHandler(os.Stdout, cfg, level))
+}
 
-	handler := slog.NewJSONHandler(os.Stdout, options)
-	return slog.New(handler)
+func newHandler(w io.Writer, cfg config.LogConfig, level *slog.LevelVar) slog.Handler {
+	level.Set(ParseLevel(cfg.Level))
+	return slog.NewJSONHandler(w, &slog.HandlerOptions{
+		Level:       level,
+		AddSource:   cfg.AddSource,
+		ReplaceAttr: redactAttr,
+	})
+}
+
+// redactAttr masks phone numbers, e-mail addresses and ID numbers in string
+// and error values, so user text quoted in a message or an error cannot leak
+// into the logs.
+func redactAttr(_ []string, attr slog.Attr) slog.Attr {
+	switch attr.Value.Kind() {
+	case slog.KindString:
+		attr.Value = slog.StringValue(pii.Redact(attr.Value.String()))
+	case slog.KindAny:
+		if err, ok := attr.Value.Any().(error); ok {
+			attr.Value = slog.StringValue(pii.Redact(err.Error()))
+		}
+	}
+	return attr
 }
 
-func parseLevel(level string) slog.Level {
+func ParseLevel(level string) slog.Level {
 	switch strings.ToLower(strings.TrimSpace(level)) {
 	case "debug":
 		return slog.LevelDebug
@@ -0,0 +1,34 @@
+package logger
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/congregalis/aiden/internal/config"
+)
+
+func TestHandlerRedactsPII(t *testing.T) {
+	var buf bytes.Buffer
+	log := slog.New(newHandler(&buf, config.LogConfig{Level: "info"}, new(slog.LevelVar)))
+
+	log.Info("reply failed for ada@example.com",
+		slog.String("text", "call me at 13800138000"),
+		slog.Any("error", errors.New("bad input 11010519491231002X")),
+		slog.Int64("update_id", 13800138000),
+	)
+
+	out := buf.String()
+	for _, leaked := range []string{"ada@example.com", `"call me at 13800138000"`, "11010519491231002X"} {
+		if strings.Contains(out, leaked) {
+			t.Fatalf("log line leaks %q: %s", leaked, out)
+		}
+	}
+	for _, kept := range []string{"<email>", "call me at <phone>", "bad input <id>", `"update_id":13800138000`} {
+		if !strings.Contains(out, kept) {
+			t.Fatalf("log line is missing %q: %s", kept, out)
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package maintenance
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// LockKey is the Postgres advisory lock key for maintenance: "aiden" in
+// ASCII.
+const LockKey int64 = 0x616964656e
+
+const unlockTimeout = 5 * time.Second
+
+// PGLocker takes a session-level Postgres advisory lock. The lock lives on a
+// dedicated connection, which is held until unlock so the lock cannot leak
+// to another session through the pool.
+type PGLocker struct {
+	db     *sql.DB
+	key    int64
+	logger *slog.Logger
+}
+
+func NewPGLocker(db *sql.DB, logger *slog.Logger) *PGLocker {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &PGLocker{db: db, key: LockKey, logger: logger}
+}
+
+func (l *PGLocker) TryLock(ctx context.Context) (func(), bool, error) {
+	conn, err := l.db.Conn(ctx)
+	if err != nil {
+		return nil, false, fmt.Errorf("get connection: %w", err)
+	}
+
+	var locked bool
+	if err := conn.QueryRowContext(ctx, `SELECT pg_try_advisory_lock($1)`, l.key).Scan(&locked); err != nil {
+		conn.Close()
+		return nil, false, fmt.Errorf("pg_try_advisory_lock: %w", err)
+	}
+	if !locked {
+		conn.Close()
+		return nil, false, nil
+	}
+
+	unlock := func() {
+		// The caller's context may already be cancelled at shutdown; the
+		// lock still has to be released.
+		ctx, cancel := context.WithTimeout(context.Background(), unlockTimeout)
+		defer cancel()
+		if _, err := conn.ExecContext(ctx, `SELECT pg_advisory_unlock($1)`, l.key); err != nil {
+			l.logger.Warn("maintenance_unlock_failed", slog.Any("error", err))
+			// Throw the connection away instead of pooling it with the lock
+			// still held; closing the session releases the lock.
+			_ = conn.Raw(func(any) error { return driver.ErrBadConn })
+		}
+		conn.Close()
+	}
+	return unlock, true, nil
+}
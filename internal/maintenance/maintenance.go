@@ -0,0 +1,221 @@
+// Package maintenance runs periodic cleanup tasks in batches, one instance at
+// a time.
+package maintenance
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+const (
+	defaultInterval   = 10 * time.Minute
+	defaultBatchSize  = 1000
+	defaultTimeBudget = 30 * time.Second
+)
+
+type Config struct {
+	Interval time.Duration
+	// BatchSize caps the rows one batch touches, so no single statement
+	// holds locks for long.
+	BatchSize int
+	// TimeBudget caps how long one task may run per round. Work left over
+	// waits for the next round.
+	TimeBudget time.Duration
+}
+
+// Task is one kind of cleanup. Batch processes at most limit rows that are
+// due at now and returns how many it processed; fewer than limit means the
+// task is done for this round.
+type Task struct {
+	Name  string
+	Batch func(ctx context.Context, now time.Time, limit int) (int64, error)
+}
+
+// Locker makes sure only one instance runs maintenance at a time. TryLock
+// reports false, without an error, when another instance holds the lock.
+type Locker interface {
+	TryLock(ctx context.Context) (unlock func(), ok bool, err error)
+}
+
+// TaskStats describes one task's history since the process started.
+type TaskStats struct {
+	Name           string
+	Runs           uint64
+	Errors         uint64
+	BudgetExceeded uint64
+	Rows           int64
+	LastRunAt      time.Time
+	LastDuration   time.Duration
+	LastRows       int64
+	LastError      string
+}
+
+type Report struct {
+	// LockSkips counts rounds skipped because another instance held the
+	// lock.
+	LockSkips uint64
+	Tasks     []TaskStats
+}
+
+type Runner struct {
+	cfg    Config
+	locker Locker
+	tasks  []Task
+	logger *slog.Logger
+	now    func() time.Time
+
+	mu        sync.Mutex
+	lockSkips uint64
+	stats     map[string]*TaskStats
+}
+
+func NewRunner(cfg Config, locker Locker, tasks []Task, logger *slog.Logger) *Runner {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	if cfg.Interval <= 0 {
+		cfg.Interval = defaultInterval
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = defaultBatchSize
+	}
+	if cfg.TimeBudget <= 0 {
+		cfg.TimeBudget = defaultTimeBudget
+	}
+
+	stats := make(map[string]*TaskStats, len(tasks))
+	for _, task := range tasks {
+		stats[task.Name] = &TaskStats{Name: task.Name}
+	}
+	return &Runner{
+		cfg:    cfg,
+		locker: locker,
+		tasks:  tasks,
+		logger: logger,
+		now:    time.Now,
+		stats:  stats,
+	}
+}
+
+func (r *Runner) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		if err := r.RunOnce(ctx); err != nil && ctx.Err() == nil {
+			r.logger.Error("maintenance_round_failed", slog.Any("error", err))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce runs every task once if this instance gets the lock. A failing
+// task is logged and does not stop the others.
+func (r *Runner) RunOnce(ctx context.Context) error {
+	unlock, ok, err := r.locker.TryLock(ctx)
+	if err != nil {
+		return fmt.Errorf("take maintenance lock: %w", err)
+	}
+	if !ok {
+		r.mu.Lock()
+		r.lockSkips++
+		r.mu.Unlock()
+		r.logger.Debug("maintenance_skipped", slog.String("reason", "locked by another instance"))
+		return nil
+	}
+	defer unlock()
+
+	for _, task := range r.tasks {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		r.runTask(ctx, task)
+	}
+	return nil
+}
+
+func (r *Runner) runTask(ctx context.Context, task Task) {
+	started := r.now()
+	budgetCtx, cancel := context.WithTimeout(ctx, r.cfg.TimeBudget)
+	defer cancel()
+
+	var (
+		rows           int64
+		batches        int
+		err            error
+		budgetExceeded bool
+	)
+	for {
+		var n int64
+		n, err = task.Batch(budgetCtx, started, r.cfg.BatchSize)
+		rows += n
+		batches++
+		if err != nil {
+			if ctx.Err() == nil && errors.Is(budgetCtx.Err(), context.DeadlineExceeded) {
+				budgetExceeded, err = true, nil
+			}
+			break
+		}
+		if n < int64(r.cfg.BatchSize) {
+			break
+		}
+		if budgetCtx.Err() != nil {
+			budgetExceeded = ctx.Err() == nil
+			break
+		}
+	}
+	duration := r.now().Sub(started)
+
+	r.mu.Lock()
+	stats := r.stats[task.Name]
+	stats.Runs++
+	stats.Rows += rows
+	stats.LastRunAt = started
+	stats.LastDuration = duration
+	stats.LastRows = rows
+	stats.LastError = ""
+	if err != nil {
+		stats.Errors++
+		stats.LastError = err.Error()
+	}
+	if budgetExceeded {
+		stats.BudgetExceeded++
+	}
+	r.mu.Unlock()
+
+	attrs := []any{
+		slog.String("task", task.Name),
+		slog.Int64("rows", rows),
+		slog.Int("batches", batches),
+		slog.Duration("duration", duration),
+		slog.Bool("budget_exceeded", budgetExceeded),
+	}
+	if err != nil {
+		r.logger.Error("maintenance_task_failed", append(attrs, slog.Any("error", err))...)
+		return
+	}
+	if rows > 0 || budgetExceeded {
+		r.logger.Info("maintenance_task_finished", attrs...)
+	}
+}
+
+// Report returns the counters of every task in registration order.
+func (r *Runner) Report() Report {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	report := Report{LockSkips: r.lockSkips, Tasks: make([]TaskStats, 0, len(r.tasks))}
+	for _, task := range r.tasks {
+		report.Tasks = append(report.Tasks, *r.stats[task.Name])
+	}
+	return report
+}
@@ -0,0 +1,111 @@
+package maintenance
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+type fakeLocker struct {
+	held     bool
+	locks    int
+	unlocked int
+}
+
+func (l *fakeLocker) TryLock(context.Context) (func(), bool, error) {
+	if l.held {
+		return nil, false, nil
+	}
+	l.locks++
+	return func() { l.unlocked++ }, true, nil
+}
+
+func newTestRunner(cfg Config, locker Locker, tasks ...Task) *Runner {
+	return NewRunner(cfg, locker, tasks, slog.New(slog.NewTextHandler(io.Discard, nil)))
+}
+
+func TestRunOnceRunsBatchesUntilShort(t *testing.T) {
+	remaining := int64(25)
+	var limits []int
+	task := Task{Name: "rows", Batch: func(_ context.Context, _ time.Time, limit int) (int64, error) {
+		limits = append(limits, limit)
+		n := min(remaining, int64(limit))
+		remaining -= n
+		return n, nil
+	}}
+	failing := Task{Name: "broken", Batch: func(context.Context, time.Time, int) (int64, error) {
+		return 0, errors.New("boom")
+	}}
+	after := 0
+	last := Task{Name: "after", Batch: func(context.Context, time.Time, int) (int64, error) {
+		after++
+		return 0, nil
+	}}
+
+	locker := &fakeLocker{}
+	runner := newTestRunner(Config{BatchSize: 10}, locker, task, failing, last)
+	if err := runner.RunOnce(context.Background()); err != nil {
+		t.Fatalf("RunOnce: %v", err)
+	}
+
+	if len(limits) != 3 || remaining != 0 {
+		t.Fatalf("batches=%v remaining=%d, want 3 batches of 10", limits, remaining)
+	}
+	if after != 1 {
+		t.Fatal("a failing task stopped the tasks after it")
+	}
+	if locker.locks != 1 || locker.unlocked != 1 {
+		t.Fatalf("locks=%d unlocked=%d, want one of each", locker.locks, locker.unlocked)
+	}
+
+	report := runner.Report()
+	if len(report.Tasks) != 3 || report.Tasks[0].Name != "rows" || report.Tasks[0].Rows != 25 || report.Tasks[0].Runs != 1 {
+		t.Fatalf("report=%+v", report)
+	}
+	if report.Tasks[1].Errors != 1 || report.Tasks[1].LastError != "boom" {
+		t.Fatalf("broken task stats=%+v", report.Tasks[1])
+	}
+}
+
+func TestRunOnceSkipsWhenLocked(t *testing.T) {
+	ran := false
+	task := Task{Name: "rows", Batch: func(context.Context, time.Time, int) (int64, error) {
+		ran = true
+		return 0, nil
+	}}
+	runner := newTestRunner(Config{}, &fakeLocker{held: true}, task)
+
+	if err := runner.RunOnce(context.Background()); err != nil {
+		t.Fatalf("RunOnce: %v", err)
+	}
+	if ran {
+		t.Fatal("task ran without the lock")
+	}
+	if report := runner.Report(); report.LockSkips != 1 || report.Tasks[0].Runs != 0 {
+		t.Fatalf("report=%+v", report)
+	}
+}
+
+func TestRunOnceStopsAtTimeBudget(t *testing.T) {
+	batches := 0
+	task := Task{Name: "slow", Batch: func(ctx context.Context, _ time.Time, limit int) (int64, error) {
+		batches++
+		if batches == 1 {
+			return int64(limit), nil
+		}
+		<-ctx.Done()
+		return 0, ctx.Err()
+	}}
+	runner := newTestRunner(Config{BatchSize: 5, TimeBudget: 20 * time.Millisecond}, &fakeLocker{}, task)
+
+	if err := runner.RunOnce(context.Background()); err != nil {
+		t.Fatalf("RunOnce: %v", err)
+	}
+	stats := runner.Report().Tasks[0]
+	if stats.BudgetExceeded != 1 || stats.Errors != 0 || stats.LastRows != 5 {
+		t.Fatalf("stats=%+v, want budget exceeded without an error", stats)
+	}
+}
@@ -0,0 +1,20 @@
+package pii
+
+import "testing"
+
+func TestRedact(t *testing.T) {
+	tests := map[string]string{
+		"邮箱 ada@example.com，手机 138 0013 8000":  "邮箱 <email>，手机 <phone>",
+		"联系我13800138000":                       "联系我<phone>",
+		"call +1 415 555 0100 or 415-555-0100": "call <phone> or <phone>",
+		"身份证 11010519491231002X":               "身份证 <id>",
+		"SSN 078-05-1120":                      "SSN <id>",
+		"2026-03-01 前每周 5 小时，预算 20000 元":       "2026-03-01 前每周 5 小时，预算 20000 元",
+		"order 12345678 shipped":               "order 12345678 shipped",
+	}
+	for input, want := range tests {
+		if got := Redact(input); got != want {
+			t.Errorf("Redact(%q)=%q, want %q", input, got, want)
+		}
+	}
+}
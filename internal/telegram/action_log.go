@@ -0,0 +1,65 @@
+package telegram
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+const (
+	ActionClarifyRound = "clarify_round"
+
+	ActionStatusOK = "ok"
+)
+
+// ActionLog records one agent action on a goal in agent_action_logs.
+type ActionLog struct {
+	GoalID    string
+	Action    string
+	Status    string
+	ErrorCode string
+	Payload   map[string]any
+	CreatedAt time.Time
+}
+
+func (s *SQLStore) RecordActionLog(ctx context.Context, entry ActionLog) error {
+	payload := entry.Payload
+	if payload == nil {
+		payload = map[string]any{}
+	}
+	payloadJSON, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("marshal action log payload: %w", err)
+	}
+
+	_, err = s.db.ExecContext(
+		ctx,
+		`INSERT INTO agent_action_logs(goal_id, action, status, error_code, payload, created_at)
+		 VALUES ($1, $2, $3, $4, $5::jsonb, NOW())`,
+		entry.GoalID,
+		entry.Action,
+		entry.Status,
+		entry.ErrorCode,
+		payloadJSON,
+	)
+	if err != nil {
+		return fmt.Errorf("insert agent action log: %w", err)
+	}
+	return nil
+}
+
+func (s *MemoryStore) RecordActionLog(_ context.Context, entry ActionLog) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entry.CreatedAt = s.now()
+	s.actionLogs = append(s.actionLogs, entry)
+	return nil
+}
+
+// ActionLogs returns a copy of the recorded action logs, oldest first.
+func (s *MemoryStore) ActionLogs() []ActionLog {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]ActionLog(nil), s.actionLogs...)
+}
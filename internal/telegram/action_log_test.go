@@ -0,0 +1,121 @@
+package telegram
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/congregalis/aiden/internal/flags"
+)
+
+type staticFlags []flags.Flag
+
+func (s staticFlags) ListFlags(context.Context) ([]flags.Flag, error) {
+	return s, nil
+}
+
+func TestWorkerRecordsFlagEvaluationsOnActionLog(t *testing.T) {
+	evaluator := flags.NewEvaluator(staticFlags{
+		{Key: flags.LLMExtraction},
+		{Key: flags.PlanGeneration, RolloutPercent: 100},
+	}, "test", nil)
+	if err := evaluator.Refresh(context.Background()); err != nil {
+		t.Fatalf("refresh flags: %v", err)
+	}
+
+	store := NewMemoryStore()
+	client := &scriptedClient{
+		updates: [][]Update{{
+			{
+				UpdateID: 1,
+				Message: &Message{
+					MessageID: 31,
+					Chat:      Chat{ID: 20031},
+					Text:      "我想在3个月内通过Go面试，成功标准是1.完成3个项目 2.刷100题 3.通过面试，我是零基础，每周10小时，工作日晚上学习，限制是经常加班，风险是容易拖延。",
+				},
+			},
+		}},
+	}
+
+	if err := runWorkerWithConfigUntilSendCount(t, WorkerConfig{Flags: evaluator}, client, store, 1); err != nil {
+		t.Fatalf("worker run failed: %v", err)
+	}
+
+	logs := store.ActionLogs()
+	if len(logs) != 1 {
+		t.Fatalf("action logs=%d, want 1", len(logs))
+	}
+	entry := logs[0]
+	if entry.Action != ActionClarifyRound || entry.Status != ActionStatusOK || entry.Payload["state"] != string(StateReview) {
+		t.Fatalf("unexpected action log: %+v", entry)
+	}
+	recorded, _ := entry.Payload["flags"].(map[string]any)
+	plan, _ := recorded[string(flags.PlanGeneration)].(map[string]any)
+	if plan["enabled"] != true || plan["reason"] != flags.ReasonRollout {
+		t.Fatalf("plan_generation evaluation=%v, want enabled by rollout", plan)
+	}
+}
+
+func TestWorkerWithoutFlagsRecordsNoActionLog(t *testing.T) {
+	store := NewMemoryStore()
+	client := &scriptedClient{
+		updates: [][]Update{{
+			{UpdateID: 1, Message: &Message{MessageID: 32, Chat: Chat{ID: 20032}, Text: "/goal"}},
+		}},
+	}
+
+	if err := runWorkerUntilSendCount(t, client, store, 1); err != nil {
+		t.Fatalf("worker run failed: %v", err)
+	}
+	if logs := store.ActionLogs(); len(logs) != 0 {
+		t.Fatalf("action logs=%+v, want none", logs)
+	}
+}
+
+type stubExtractor []string
+
+func (s stubExtractor) ExtractSlots(context.Context, string) ([]string, error) {
+	return s, nil
+}
+
+type stubPlanner string
+
+func (s stubPlanner) GeneratePlan(context.Context, string, PlanningSession) (string, error) {
+	return string(s), nil
+}
+
+func TestFlagsSwitchExtractionAndPlanGeneration(t *testing.T) {
+	reply := func(rollout int) string {
+		t.Helper()
+		evaluator := flags.NewEvaluator(staticFlags{
+			{Key: flags.LLMExtraction, RolloutPercent: rollout},
+			{Key: flags.PlanGeneration, RolloutPercent: rollout},
+		}, "test", nil)
+		if err := evaluator.Refresh(context.Background()); err != nil {
+			t.Fatalf("refresh flags: %v", err)
+		}
+		client := &scriptedClient{
+			updates: [][]Update{{
+				{UpdateID: 1, Message: &Message{MessageID: 33, Chat: Chat{ID: 20033}, Text: "我想学游泳"}},
+			}},
+		}
+		cfg := WorkerConfig{
+			Flags:         evaluator,
+			SlotExtractor: stubExtractor(requiredSlotOrder),
+			PlanGenerator: stubPlanner("第 1 周：熟悉水性"),
+		}
+		if err := runWorkerWithConfigUntilSendCount(t, cfg, client, NewMemoryStore(), 1); err != nil {
+			t.Fatalf("worker run failed: %v", err)
+		}
+		return client.SentMessages()[0].Text
+	}
+
+	on := reply(100)
+	if !strings.HasPrefix(on, zh(MsgReviewReady)) || !strings.HasSuffix(on, "第 1 周：熟悉水性") {
+		t.Fatalf("reply with flags on=%q, want review with the generated plan", on)
+	}
+	off := reply(0)
+	if strings.Contains(off, zh(MsgReviewReady)) || strings.Contains(off, "第 1 周") {
+		t.Fatalf("reply with flags off=%q, want keyword extraction and no plan", off)
+	}
+}
@@ -0,0 +1,562 @@
+package telegram
+
+import (
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ErrSessionNotFound    = errors.New("planning session not found")
+	ErrInvalidChatQuery   = errors.New("chat must be a chat ID or a masked chat ID like 12***89")
+	ErrInvalidTurnsCursor = errors.New("invalid cursor")
+)
+
+var maskedChatIDPattern = regexp.MustCompile(`^(-\d|\d{2})\*{3}(\d{2})$`)
+
+// AdminUser is a user as seen by operators: the chat ID is only ever exposed
+// masked.
+type AdminUser struct {
+	ID            string
+	ChatIDMasked  string
+	Language      string
+	Timezone      string
+	IsReachable   bool
+	CurrentGoalID string
+	GoalCount     int
+	CreatedAt     time.Time
+}
+
+type GoalProfile struct {
+	ID                string
+	VersionNo         int
+	TemplateVersion   string
+	CompletenessScore int
+	ConfirmationState string
+	OpenQuestions     json.RawMessage
+	Profile           json.RawMessage
+	Markdown          string
+	CreatedAt         time.Time
+}
+
+type AdminGoal struct {
+	Goal
+	UserChatIDMasked string
+	ActiveProfileID  string
+	UpdatedAt        time.Time
+	Session          *PlanningSession
+	// Profiles holds every plan version of the goal, newest first.
+	Profiles []GoalProfile
+}
+
+type AdminTurn struct {
+	ConversationTurn
+	CreatedAt time.Time
+	UndoneAt  *time.Time
+}
+
+type TurnPage struct {
+	Turns []AdminTurn
+	// NextCursor is empty on the last page.
+	NextCursor string
+}
+
+// AuditEntry records one admin mutation and who made it.
+type AuditEntry struct {
+	ID         int64
+	Actor      string
+	Action     string
+	TargetType string
+	TargetID   string
+	Payload    map[string]any
+	TraceID    string
+	CreatedAt  time.Time
+}
+
+// SessionTransition forces a session into State outside the normal
+// conversation flow. Reset also clears slots and skipped slots and marks the
+// session's turns undone, so later recomputation starts from scratch.
+type SessionTransition struct {
+	State  PlanningState
+	Reset  bool
+	Reason string
+}
+
+// SearchUsers lists users newest first. chatQuery may be empty, a full chat ID
+// or a masked chat ID as shown in logs and admin responses.
+func (s *SQLStore) SearchUsers(ctx context.Context, chatQuery string, limit int) ([]AdminUser, error) {
+	where, args, err := chatQueryCondition(strings.TrimSpace(chatQuery))
+	if err != nil {
+		return nil, err
+	}
+	args = append(args, limit)
+
+	rows, err := s.db.QueryContext(
+		ctx,
+		`SELECT u.id, u.telegram_chat_id, u.language, u.timezone, u.is_reachable,
+		        COALESCE(u.current_goal_id::text, ''), u.created_at,
+		        (SELECT COUNT(*) FROM goals g WHERE g.user_id = u.id)
+		 FROM users u
+		 WHERE `+where+`
+		 ORDER BY u.created_at DESC, u.id DESC
+		 LIMIT $`+strconv.Itoa(len(args)),
+		args...,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("search users: %w", err)
+	}
+	defer rows.Close()
+
+	users := make([]AdminUser, 0)
+	for rows.Next() {
+		user, err := scanAdminUser(rows)
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, user)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate users: %w", err)
+	}
+
+	return users, nil
+}
+
+func chatQueryCondition(query string) (string, []any, error) {
+	if query == "" {
+		return "TRUE", nil, nil
+	}
+	if chatID, err := strconv.ParseInt(query, 10, 64); err == nil {
+		return "u.telegram_chat_id = $1", []any{chatID}, nil
+	}
+	match := maskedChatIDPattern.FindStringSubmatch(query)
+	if match == nil {
+		return "", nil, ErrInvalidChatQuery
+	}
+	// MaskChatID keeps the first and last two characters of IDs longer than
+	// four, so the middle is at least one digit.
+	return "u.telegram_chat_id::text LIKE $1 AND length(u.telegram_chat_id::text) > 4",
+		[]any{match[1] + "%" + match[2]}, nil
+}
+
+// GetAdminUser returns a user together with all their goals.
+func (s *SQLStore) GetAdminUser(ctx context.Context, userID string) (AdminUser, []Goal, bool, error) {
+	rows, err := s.db.QueryContext(
+		ctx,
+		`SELECT u.id, u.telegram_chat_id, u.language, u.timezone, u.is_reachable,
+		        COALESCE(u.current_goal_id::text, ''), u.created_at,
+		        (SELECT COUNT(*) FROM goals g WHERE g.user_id = u.id)
+		 FROM users u
+		 WHERE u.id = $1`,
+		userID,
+	)
+	if err != nil {
+		return AdminUser{}, nil, false, fmt.Errorf("query user %s: %w", userID, err)
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return AdminUser{}, nil, false, fmt.Errorf("query user %s: %w", userID, err)
+		}
+		return AdminUser{}, nil, false, nil
+	}
+	user, err := scanAdminUser(rows)
+	if err != nil {
+		return AdminUser{}, nil, false, err
+	}
+	rows.Close()
+
+	goals, err := s.ListGoalsByUserID(ctx, userID)
+	if err != nil {
+		return AdminUser{}, nil, false, err
+	}
+	return user, goals, true, nil
+}
+
+func scanAdminUser(rows *sql.Rows) (AdminUser, error) {
+	var (
+		user   AdminUser
+		chatID int64
+	)
+	if err := rows.Scan(
+		&user.ID,
+		&chatID,
+		&user.Language,
+		&user.Timezone,
+		&user.IsReachable,
+		&user.CurrentGoalID,
+		&user.CreatedAt,
+		&user.GoalCount,
+	); err != nil {
+		return AdminUser{}, fmt.Errorf("scan user: %w", err)
+	}
+	user.ChatIDMasked = MaskChatID(chatID)
+	return user, nil
+}
+
+// GetAdminGoal loads a goal with its session and every profile version.
+func (s *SQLStore) GetAdminGoal(ctx context.Context, goalID string) (AdminGoal, bool, error) {
+	var (
+		goal   AdminGoal
+		chatID int64
+	)
+	err := s.db.QueryRowContext(
+		ctx,
+		`SELECT g.id, g.user_id, g.title, g.status, g.created_at, g.updated_at,
+		        COALESCE(g.active_profile_id::text, ''), u.telegram_chat_id
+		 FROM goals g
+		 JOIN users u ON u.id = g.user_id
+		 WHERE g.id = $1`,
+		goalID,
+	).Scan(
+		&goal.ID,
+		&goal.UserID,
+		&goal.Title,
+		&goal.Status,
+		&goal.CreatedAt,
+		&goal.UpdatedAt,
+		&goal.ActiveProfileID,
+		&chatID,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return AdminGoal{}, false, nil
+	}
+	if err != nil {
+		return AdminGoal{}, false, fmt.Errorf("query goal %s: %w", goalID, err)
+	}
+	goal.UserChatIDMasked = MaskChatID(chatID)
+
+	session, err := s.findPlanningSessionByGoalID(ctx, goalID)
+	switch {
+	case err == nil:
+		goal.Session = &session
+	case !errors.Is(err, sql.ErrNoRows):
+		return AdminGoal{}, false, fmt.Errorf("find planning session by goal id %s: %w", goalID, err)
+	}
+
+	profiles, err := s.listGoalProfiles(ctx, goalID)
+	if err != nil {
+		return AdminGoal{}, false, err
+	}
+	goal.Profiles = profiles
+	return goal, true, nil
+}
+
+func (s *SQLStore) listGoalProfiles(ctx context.Context, goalID string) ([]GoalProfile, error) {
+	rows, err := s.db.QueryContext(
+		ctx,
+		`SELECT id, version_no, template_version, completeness_score, confirmation_state,
+		        open_questions, profile_json, profile_markdown, created_at
+		 FROM goal_profiles
+		 WHERE goal_id = $1
+		 ORDER BY version_no DESC, created_at DESC`,
+		goalID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("query goal profiles: %w", err)
+	}
+	defer rows.Close()
+
+	profiles := make([]GoalProfile, 0)
+	for rows.Next() {
+		var (
+			profile           GoalProfile
+			openQuestionsJSON []byte
+			profileJSON       []byte
+		)
+		if err := rows.Scan(
+			&profile.ID,
+			&profile.VersionNo,
+			&profile.TemplateVersion,
+			&profile.CompletenessScore,
+			&profile.ConfirmationState,
+			&openQuestionsJSON,
+			&profileJSON,
+			&profile.Markdown,
+			&profile.CreatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("scan goal profile: %w", err)
+		}
+		profile.OpenQuestions = json.RawMessage(openQuestionsJSON)
+		profile.Profile = json.RawMessage(profileJSON)
+		profiles = append(profiles, profile)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate goal profiles: %w", err)
+	}
+
+	return profiles, nil
+}
+
+// ListSessionTurns pages through every turn of a session, undone ones
+// included, oldest first.
+func (s *SQLStore) ListSessionTurns(ctx context.Context, sessionID, cursor string, limit int) (TurnPage, error) {
+	afterTime, afterID, err := decodeTurnsCursor(cursor)
+	if err != nil {
+		return TurnPage{}, err
+	}
+
+	rows, err := s.db.QueryContext(
+		ctx,
+		`SELECT id, session_id, role, content, intent, intent_confidence, intent_ranking,
+		        telegram_message_id, revision, created_at, undone_at
+		 FROM conversation_turns
+		 WHERE session_id = $1
+		   AND ($2::timestamptz IS NULL OR (created_at, id) > ($2, $3::uuid))
+		 ORDER BY created_at, id
+		 LIMIT $4`,
+		sessionID,
+		afterTime,
+		afterID,
+		limit+1,
+	)
+	if err != nil {
+		return TurnPage{}, fmt.Errorf("query session turns: %w", err)
+	}
+	defer rows.Close()
+
+	turns := make([]AdminTurn, 0, limit)
+	for rows.Next() {
+		var (
+			turn        AdminTurn
+			confidence  sql.NullFloat64
+			rankingJSON []byte
+			undoneAt    sql.NullTime
+		)
+		if err := rows.Scan(
+			&turn.ID,
+			&turn.SessionID,
+			&turn.Role,
+			&turn.Content,
+			&turn.Intent,
+			&confidence,
+			&rankingJSON,
+			&turn.TelegramMessageID,
+			&turn.Revision,
+			&turn.CreatedAt,
+			&undoneAt,
+		); err != nil {
+			return TurnPage{}, fmt.Errorf("scan conversation turn: %w", err)
+		}
+		if confidence.Valid {
+			value := confidence.Float64
+			turn.IntentConfidence = &value
+		}
+		if len(rankingJSON) > 0 {
+			if err := json.Unmarshal(rankingJSON, &turn.IntentRanking); err != nil {
+				return TurnPage{}, fmt.Errorf("unmarshal intent ranking: %w", err)
+			}
+		}
+		if undoneAt.Valid {
+			turn.UndoneAt = &undoneAt.Time
+		}
+		turns = append(turns, turn)
+	}
+	if err := rows.Err(); err != nil {
+		return TurnPage{}, fmt.Errorf("iterate conversation turns: %w", err)
+	}
+
+	page := TurnPage{Turns: turns}
+	if len(turns) > limit {
+		page.Turns = turns[:limit]
+		last := page.Turns[limit-1]
+		page.NextCursor = encodeTurnsCursor(last.CreatedAt, last.ID)
+	}
+	return page, nil
+}
+
+func encodeTurnsCursor(createdAt time.Time, id string) string {
+	raw := strconv.FormatInt(createdAt.UnixMicro(), 10) + "|" + id
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// decodeTurnsCursor returns nil values for an empty cursor so the query
+// starts at the first turn.
+func decodeTurnsCursor(cursor string) (any, any, error) {
+	if cursor == "" {
+		return nil, nil, nil
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, nil, ErrInvalidTurnsCursor
+	}
+	micros, id, ok := strings.Cut(string(raw), "|")
+	if !ok || id == "" {
+		return nil, nil, ErrInvalidTurnsCursor
+	}
+	value, err := strconv.ParseInt(micros, 10, 64)
+	if err != nil {
+		return nil, nil, ErrInvalidTurnsCursor
+	}
+	return time.UnixMicro(value).UTC(), id, nil
+}
+
+// ForceSessionState applies an admin transition and writes its audit entry
+// in the same transaction, so no mutation goes unrecorded.
+func (s *SQLStore) ForceSessionState(ctx context.Context, sessionID string, transition SessionTransition, audit AuditEntry) (PlanningSession, error) {
+	resetSlotsJSON, err := json.Marshal(NormalizeSlotCompletion(DefaultSlotCompletion()))
+	if err != nil {
+		return PlanningSession{}, fmt.Errorf("marshal slot completion: %w", err)
+	}
+
+	var session PlanningSession
+	err = s.withinSQLTx(ctx, func(tx *SQLStore) error {
+		var (
+			goalID   string
+			previous string
+		)
+		err := tx.db.QueryRowContext(
+			ctx,
+			`SELECT goal_id, state FROM planning_sessions WHERE id = $1 FOR UPDATE`,
+			sessionID,
+		).Scan(&goalID, &previous)
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrSessionNotFound
+		}
+		if err != nil {
+			return fmt.Errorf("lock planning session %s: %w", sessionID, err)
+		}
+
+		if transition.Reset {
+			if _, err := tx.db.ExecContext(
+				ctx,
+				`UPDATE planning_sessions
+				 SET slot_completion = $2::jsonb,
+				     skipped_slots = '[]'::jsonb
+				 WHERE id = $1`,
+				sessionID,
+				resetSlotsJSON,
+			); err != nil {
+				return fmt.Errorf("reset planning session %s: %w", sessionID, err)
+			}
+			if _, err := tx.db.ExecContext(
+				ctx,
+				`UPDATE conversation_turns
+				 SET undone_at = NOW()
+				 WHERE session_id = $1 AND undone_at IS NULL`,
+				sessionID,
+			); err != nil {
+				return fmt.Errorf("undo turns of planning session %s: %w", sessionID, err)
+			}
+		}
+
+		if _, err := tx.db.ExecContext(
+			ctx,
+			`UPDATE planning_sessions
+			 SET state = $2,
+			     pending_action = '',
+			     updated_at = NOW()
+			 WHERE id = $1`,
+			sessionID,
+			string(transition.State),
+		); err != nil {
+			return fmt.Errorf("update planning session %s state: %w", sessionID, err)
+		}
+
+		audit.Payload = map[string]any{
+			"goal_id": goalID,
+			"from":    previous,
+			"to":      string(transition.State),
+			"reset":   transition.Reset,
+			"reason":  transition.Reason,
+		}
+		if err := tx.RecordAudit(ctx, audit); err != nil {
+			return err
+		}
+
+		session, err = tx.findPlanningSessionByGoalID(ctx, goalID)
+		if err != nil {
+			return fmt.Errorf("reload planning session %s: %w", sessionID, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return PlanningSession{}, err
+	}
+	return session, nil
+}
+
+func (s *SQLStore) RecordAudit(ctx context.Context, entry AuditEntry) error {
+	payload := entry.Payload
+	if payload == nil {
+		payload = map[string]any{}
+	}
+	payloadJSON, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("marshal audit payload: %w", err)
+	}
+
+	_, err = s.db.ExecContext(
+		ctx,
+		`INSERT INTO admin_audit_logs(actor, action, target_type, target_id, payload, trace_id, created_at)
+		 VALUES ($1, $2, $3, $4, $5::jsonb, $6, NOW())`,
+		entry.Actor,
+		entry.Action,
+		entry.TargetType,
+		entry.TargetID,
+		payloadJSON,
+		entry.TraceID,
+	)
+	if err != nil {
+		return fmt.Errorf("insert admin audit log: %w", err)
+	}
+	return nil
+}
+
+// ListAuditEntries returns the newest entries first, optionally only those
+// for one target.
+func (s *SQLStore) ListAuditEntries(ctx context.Context, targetType, targetID string, limit int) ([]AuditEntry, error) {
+	rows, err := s.db.QueryContext(
+		ctx,
+		`SELECT id, actor, action, target_type, target_id, payload, trace_id, created_at
+		 FROM admin_audit_logs
+		 WHERE ($1 = '' OR target_type = $1)
+		   AND ($2 = '' OR target_id = $2)
+		 ORDER BY created_at DESC, id DESC
+		 LIMIT $3`,
+		targetType,
+		targetID,
+		limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("query admin audit logs: %w", err)
+	}
+	defer rows.Close()
+
+	entries := make([]AuditEntry, 0)
+	for rows.Next() {
+		var (
+			entry       AuditEntry
+			payloadJSON []byte
+		)
+		if err := rows.Scan(
+			&entry.ID,
+			&entry.Actor,
+			&entry.Action,
+			&entry.TargetType,
+			&entry.TargetID,
+			&payloadJSON,
+			&entry.TraceID,
+			&entry.CreatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("scan admin audit log: %w", err)
+		}
+		if err := json.Unmarshal(payloadJSON, &entry.Payload); err != nil {
+			return nil, fmt.Errorf("unmarshal admin audit payload: %w", err)
+		}
+		entries = append(entries, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate admin audit logs: %w", err)
+	}
+
+	return entries, nil
+}
@@ -0,0 +1,45 @@
+package telegram
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestChatQueryCondition(t *testing.T) {
+	where, args, err := chatQueryCondition(MaskChatID(123456789))
+	if err != nil || args[0] != "12%89" || where == "" {
+		t.Fatalf("masked query=(%q, %v, %v)", where, args, err)
+	}
+
+	_, args, err = chatQueryCondition(MaskChatID(-1001234567890))
+	if err != nil || args[0] != "-1%90" {
+		t.Fatalf("masked group query args=%v err=%v", args, err)
+	}
+
+	_, args, err = chatQueryCondition("-100123")
+	if err != nil || args[0] != int64(-100123) {
+		t.Fatalf("full chat id args=%v err=%v", args, err)
+	}
+
+	if _, _, err := chatQueryCondition("12**89"); !errors.Is(err, ErrInvalidChatQuery) {
+		t.Fatalf("err=%v, want ErrInvalidChatQuery", err)
+	}
+}
+
+func TestTurnsCursorRoundTrip(t *testing.T) {
+	createdAt := time.Date(2026, 3, 1, 8, 30, 0, 123456000, time.UTC)
+	cursor := encodeTurnsCursor(createdAt, "0b7d6a52-3f7e-4c55-9a8e-1f2d3c4b5a69")
+
+	gotTime, gotID, err := decodeTurnsCursor(cursor)
+	if err != nil || !gotTime.(time.Time).Equal(createdAt) || gotID != "0b7d6a52-3f7e-4c55-9a8e-1f2d3c4b5a69" {
+		t.Fatalf("decode=(%v, %v, %v)", gotTime, gotID, err)
+	}
+
+	if _, _, err := decodeTurnsCursor("not base64!"); !errors.Is(err, ErrInvalidTurnsCursor) {
+		t.Fatalf("err=%v, want ErrInvalidTurnsCursor", err)
+	}
+	if gotTime, gotID, err := decodeTurnsCursor(""); gotTime != nil || gotID != nil || err != nil {
+		t.Fatalf("empty cursor=(%v, %v, %v)", gotTime, gotID, err)
+	}
+}
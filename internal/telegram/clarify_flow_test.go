@@ -9,7 +9,7 @@ import (
 )
 
 func TestIntentRouterRoutesExpectedIntents(t *testing.T) {
-	router := NewIntentRouter()
+	router := NewIntentRouter(nil)
 
 	if got := router.Route("/goal", StateIdle); got.Intent != IntentClarifyGoal {
 		t.Fatalf("intent for /goal=%q, want %q", got.Intent, IntentClarifyGoal)
@@ -25,7 +25,7 @@ func TestIntentRouterRoutesExpectedIntents(t *testing.T) {
 }
 
 func TestWorkerGoalMovesSessionToClarifyingAndSavesTurns(t *testing.T) {
-	store := newMemoryStore()
+	store := NewMemoryStore()
 	client := &scriptedClient{
 		updates: [][]Update{{
 			{UpdateID: 1, Message: &Message{MessageID: 21, Chat: Chat{ID: 20001}, Text: "/goal"}},
@@ -40,7 +40,7 @@ func TestWorkerGoalMovesSessionToClarifyingAndSavesTurns(t *testing.T) {
 	if !ok {
 		t.Fatalf("expected user to exist")
 	}
-	goal, found, err := store.GetActiveGoalByUserID(context.Background(), user.ID)
+	goal, found, err := store.GetCurrentGoalByUserID(context.Background(), user.ID)
 	if err != nil {
 		t.Fatalf("get active goal failed: %v", err)
 	}
@@ -69,7 +69,7 @@ func TestWorkerGoalMovesSessionToClarifyingAndSavesTurns(t *testing.T) {
 }
 
 func TestWorkerMovesToReviewWhenRequiredSlotsComplete(t *testing.T) {
-	store := newMemoryStore()
+	store := NewMemoryStore()
 	client := &scriptedClient{
 		updates: [][]Update{{
 			{
@@ -88,20 +88,20 @@ func TestWorkerMovesToReviewWhenRequiredSlotsComplete(t *testing.T) {
 	}
 
 	user, _ := store.UserByChatID(20002)
-	goal, _, _ := store.GetActiveGoalByUserID(context.Background(), user.ID)
+	goal, _, _ := store.GetCurrentGoalByUserID(context.Background(), user.ID)
 	session, _ := store.SessionByGoalID(goal.ID)
 	if session.State != StateReview {
 		t.Fatalf("session state=%q, want %q", session.State, StateReview)
 	}
 
 	sent := client.SentMessages()
-	if len(sent) != 1 || !strings.Contains(sent[0].Text, ReplyReviewReady) {
+	if len(sent) != 1 || !strings.Contains(sent[0].Text, zh(MsgReviewReady)) {
 		t.Fatalf("reply=%q, want review ready", sent[0].Text)
 	}
 }
 
 func TestWorkerReviewConfirmationMovesToConfirmed(t *testing.T) {
-	store := newMemoryStore()
+	store := NewMemoryStore()
 	client := &scriptedClient{
 		updates: [][]Update{{
 			{
@@ -121,20 +121,20 @@ func TestWorkerReviewConfirmationMovesToConfirmed(t *testing.T) {
 	}
 
 	user, _ := store.UserByChatID(20003)
-	goal, _, _ := store.GetActiveGoalByUserID(context.Background(), user.ID)
+	goal, _, _ := store.GetCurrentGoalByUserID(context.Background(), user.ID)
 	session, _ := store.SessionByGoalID(goal.ID)
 	if session.State != StateConfirmed {
 		t.Fatalf("session state=%q, want %q", session.State, StateConfirmed)
 	}
 
 	sent := client.SentMessages()
-	if got := sent[len(sent)-1].Text; got != ReplyPlanConfirmed {
-		t.Fatalf("confirm reply=%q, want %q", got, ReplyPlanConfirmed)
+	if got := sent[len(sent)-1].Text; got != zh(MsgPlanConfirmed) {
+		t.Fatalf("confirm reply=%q, want %q", got, zh(MsgPlanConfirmed))
 	}
 }
 
 func TestWorkerReviewModificationReturnsToClarifying(t *testing.T) {
-	store := newMemoryStore()
+	store := NewMemoryStore()
 	client := &scriptedClient{
 		updates: [][]Update{{
 			{
@@ -154,7 +154,7 @@ func TestWorkerReviewModificationReturnsToClarifying(t *testing.T) {
 	}
 
 	user, _ := store.UserByChatID(20004)
-	goal, _, _ := store.GetActiveGoalByUserID(context.Background(), user.ID)
+	goal, _, _ := store.GetCurrentGoalByUserID(context.Background(), user.ID)
 	session, _ := store.SessionByGoalID(goal.ID)
 	if session.State != StateClarifying {
 		t.Fatalf("session state=%q, want %q", session.State, StateClarifying)
@@ -167,7 +167,7 @@ func TestWorkerReviewModificationReturnsToClarifying(t *testing.T) {
 }
 
 func TestWorkerLimitsFollowUpQuestionsToTwo(t *testing.T) {
-	store := newMemoryStore()
+	store := NewMemoryStore()
 	client := &scriptedClient{
 		updates: [][]Update{{
 			{UpdateID: 1, Message: &Message{MessageID: 27, Chat: Chat{ID: 20005}, Text: "我想学Go"}},
@@ -186,7 +186,7 @@ func TestWorkerLimitsFollowUpQuestionsToTwo(t *testing.T) {
 }
 
 func TestWorkerAddsSummaryEveryThreeTurns(t *testing.T) {
-	store := newMemoryStore()
+	store := NewMemoryStore()
 	client := &scriptedClient{
 		updates: [][]Update{{
 			{UpdateID: 1, Message: &Message{MessageID: 28, Chat: Chat{ID: 20006}, Text: "/goal"}},
@@ -209,8 +209,8 @@ func TestWorkerAddsSummaryEveryThreeTurns(t *testing.T) {
 	}
 }
 
-func TestWorkerTimeoutResetsSessionWithReminder(t *testing.T) {
-	store := newMemoryStore()
+func TestWorkerTimeoutResumesSessionWithRecap(t *testing.T) {
+	store := NewMemoryStore()
 	user, _, err := store.FindOrCreateUserByChatID(context.Background(), 20007)
 	if err != nil {
 		t.Fatalf("seed user failed: %v", err)
@@ -245,8 +245,11 @@ func TestWorkerTimeoutResetsSessionWithReminder(t *testing.T) {
 	}
 
 	reply := client.SentMessages()[0].Text
-	if !strings.Contains(reply, ReplySessionTimeout) {
-		t.Fatalf("reply=%q, want timeout reminder", reply)
+	if !strings.HasPrefix(reply, zh(MsgSessionResumed)) {
+		t.Fatalf("reply=%q, want resume recap", reply)
+	}
+	if !strings.Contains(reply, "待补齐：") {
+		t.Fatalf("reply=%q, want slot recap", reply)
 	}
 
 	updated, _ := store.SessionByGoalID(goal.ID)
@@ -256,7 +259,7 @@ func TestWorkerTimeoutResetsSessionWithReminder(t *testing.T) {
 }
 
 func TestFallbackIntentKeepsContext(t *testing.T) {
-	store := newMemoryStore()
+	store := NewMemoryStore()
 	client := &scriptedClient{
 		updates: [][]Update{{
 			{UpdateID: 1, Message: &Message{MessageID: 32, Chat: Chat{ID: 20008}, Text: "我想3个月学会Go并完成项目"}},
@@ -269,7 +272,7 @@ func TestFallbackIntentKeepsContext(t *testing.T) {
 	}
 
 	user, _ := store.UserByChatID(20008)
-	goal, _, _ := store.GetActiveGoalByUserID(context.Background(), user.ID)
+	goal, _, _ := store.GetCurrentGoalByUserID(context.Background(), user.ID)
 	session, _ := store.SessionByGoalID(goal.ID)
 	if session.State != StateClarifying {
 		t.Fatalf("session state=%q, want %q", session.State, StateClarifying)
@@ -279,7 +282,7 @@ func TestFallbackIntentKeepsContext(t *testing.T) {
 	}
 
 	secondReply := client.SentMessages()[1].Text
-	if secondReply != ReplyFallbackGuidance {
-		t.Fatalf("fallback reply=%q, want %q", secondReply, ReplyFallbackGuidance)
+	if secondReply != zh(MsgFallbackGuidance) {
+		t.Fatalf("fallback reply=%q, want %q", secondReply, zh(MsgFallbackGuidance))
 	}
 }
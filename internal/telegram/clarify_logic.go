@@ -2,7 +2,6 @@ package telegram
 
 import (
 	"fmt"
-	"regexp"
 	"strings"
 )
 
@@ -10,46 +9,35 @@ const (
 	maxFollowUpQuestionsPerTurn = 2
 )
 
-var (
-	reHoursOrMinutes = regexp.MustCompile(`\d+\s*(小时|h|hr|分钟|min)`)
-	reSuccessHints   = regexp.MustCompile(`(\d+\s*条|三条|四条|五条|[1-5][.、])`)
-)
-
-func UpdateSlotCompletionFromText(slotCompletion map[string]bool, text string) map[string]bool {
-	normalized := NormalizeSlotCompletion(slotCompletion)
-	trimmed := strings.TrimSpace(text)
-	if trimmed == "" {
-		return normalized
-	}
-
-	command := ParseCommand(trimmed)
-	if command.IsCommand {
-		return normalized
-	}
-
-	if detectMainGoal(trimmed) {
-		normalized[SlotMainGoal] = true
-	}
-	if detectSuccessCriteria(trimmed) {
-		normalized[SlotSuccessCriteria] = true
-	}
-	if detectCurrentLevel(trimmed) {
-		normalized[SlotCurrentLevel] = true
-	}
-	if detectTimeBudget(trimmed) {
-		normalized[SlotTimeBudget] = true
-	}
-	if detectConstraints(trimmed) {
-		normalized[SlotConstraints] = true
+// RecomputeSlotCompletion replays slot extraction over the user turns of a
+// session, so a corrected or undone turn can also clear slots it used to fill.
+// Redacted turns contribute the slots recorded when their text was replaced.
+// Skipped slots stay complete.
+func RecomputeSlotCompletion(rules *Rules, turns []ConversationTurn, skippedSlots []string) map[string]bool {
+	slotCompletion := NormalizeSlotCompletion(nil)
+	for _, turn := range turns {
+		if turn.Role != ConversationRoleUser || turn.Intent != IntentClarifyGoal {
+			continue
+		}
+		if turn.Redacted {
+			for _, slot := range turn.RedactedSlots {
+				if _, ok := slotCompletion[slot]; ok {
+					slotCompletion[slot] = true
+				}
+			}
+			continue
+		}
+		slotCompletion = rules.UpdateSlotCompletion(slotCompletion, turn.Content)
 	}
-	if detectRiskFlags(trimmed) || normalized[SlotConstraints] {
-		normalized[SlotRiskFlags] = true
+	for _, slot := range skippedSlots {
+		if _, ok := slotCompletion[slot]; ok {
+			slotCompletion[slot] = true
+		}
 	}
-
-	return normalized
+	return slotCompletion
 }
 
-func BuildFollowUpQuestions(missingSlots []string, limit int) []string {
+func BuildFollowUpQuestions(lang string, missingSlots []string, limit int) []string {
 	if limit <= 0 {
 		return nil
 	}
@@ -59,7 +47,7 @@ func BuildFollowUpQuestions(missingSlots []string, limit int) []string {
 
 	questions := make([]string, 0, limit)
 	for _, slot := range missingSlots {
-		question := followUpQuestionBySlot(slot)
+		question := followUpQuestionBySlot(lang, slot)
 		if question == "" {
 			continue
 		}
@@ -71,20 +59,20 @@ func BuildFollowUpQuestions(missingSlots []string, limit int) []string {
 	return questions
 }
 
-func FormatFollowUpQuestions(questions []string) string {
+func FormatFollowUpQuestions(lang string, questions []string) string {
 	if len(questions) == 0 {
 		return ""
 	}
 
 	var builder strings.Builder
-	builder.WriteString("我先补齐关键信息：")
+	builder.WriteString(tr(lang, MsgFollowUpIntro))
 	for i, question := range questions {
 		builder.WriteString(fmt.Sprintf("\n%d) %s", i+1, question))
 	}
 	return builder.String()
 }
 
-func BuildProgressSummary(slotCompletion map[string]bool) string {
+func BuildProgressSummary(lang string, slotCompletion map[string]bool) string {
 	normalized := NormalizeSlotCompletion(slotCompletion)
 	missing := MissingRequiredSlots(normalized)
 	filledCount := len(requiredSlotOrder) - len(missing)
@@ -92,88 +80,37 @@ func BuildProgressSummary(slotCompletion map[string]bool) string {
 	filledLabels := make([]string, 0, len(requiredSlotOrder))
 	for _, slot := range requiredSlotOrder {
 		if normalized[slot] {
-			filledLabels = append(filledLabels, SlotLabel(slot))
+			filledLabels = append(filledLabels, SlotLabel(lang, slot))
 		}
 	}
 	missingLabels := make([]string, 0, len(missing))
 	for _, slot := range missing {
-		missingLabels = append(missingLabels, SlotLabel(slot))
+		missingLabels = append(missingLabels, SlotLabel(lang, slot))
 	}
 
-	filledText := "无"
-	if len(filledLabels) > 0 {
-		filledText = strings.Join(filledLabels, "、")
-	}
-	missingText := "无"
-	if len(missingLabels) > 0 {
-		missingText = strings.Join(missingLabels, "、")
-	}
-
-	return fmt.Sprintf("【当前摘要】已补齐 %d/%d 项：%s；待补齐：%s。\n当前版本你是否满意，还是继续优化？",
+	return tr(lang, MsgProgressSummaryTitle) + tr(lang, MsgProgressSummary,
 		filledCount,
 		len(requiredSlotOrder),
-		filledText,
-		missingText,
+		joinOrNone(lang, filledLabels),
+		joinOrNone(lang, missingLabels),
 	)
 }
 
-func followUpQuestionBySlot(slot string) string {
+func followUpQuestionBySlot(lang, slot string) string {
 	switch slot {
 	case SlotMainGoal:
-		return "你希望在什么时间前达成什么主目标？"
+		return tr(lang, MsgQuestionMainGoal)
 	case SlotSuccessCriteria:
-		return "请给我 3-5 条可验收的成功标准（尽量量化）。"
+		return tr(lang, MsgQuestionSuccessCriteria)
 	case SlotCurrentLevel:
-		return "你当前水平如何（零基础/入门/有项目经验）？"
+		return tr(lang, MsgQuestionCurrentLevel)
 	case SlotTimeBudget:
-		return "你每周可投入多少小时，或有哪些固定学习时段？"
+		return tr(lang, MsgQuestionTimeBudget)
 	case SlotConstraints:
-		return "有哪些约束会影响执行（如加班、设备、可用时段）？"
+		return tr(lang, MsgQuestionConstraints)
 	case SlotRiskFlags:
-		return "你担心哪些风险会影响坚持（如出差、拖延、突发事务）？"
+		return tr(lang, MsgQuestionRiskFlags)
 	default:
 		return ""
 	}
 }
-
-func detectMainGoal(text string) bool {
-	if len([]rune(strings.TrimSpace(text))) < 6 {
-		return false
-	}
-	return containsAny(text, []string{"目标", "我想", "希望", "计划", "完成", "学会", "掌握", "通过", "提升"})
-}
-
-func detectSuccessCriteria(text string) bool {
-	if reSuccessHints.MatchString(text) {
-		return true
-	}
-	return containsAny(text, []string{"成功标准", "验收", "里程碑", "达到", "完成", "通过"})
-}
-
-func detectCurrentLevel(text string) bool {
-	return containsAny(text, []string{
-		"零基础", "新手", "入门", "初级", "中级", "高级",
-		"不会", "有经验", "做过项目", "基础薄弱",
-	})
-}
-
-func detectTimeBudget(text string) bool {
-	if reHoursOrMinutes.MatchString(text) {
-		return true
-	}
-	return containsAny(text, []string{
-		"每周", "每天", "工作日", "周末", "晚上", "早上", "午休", "通勤",
-	})
-}
-
-func detectConstraints(text string) bool {
-	return containsAny(text, []string{
-		"只能", "没时间", "限制", "约束", "加班", "带娃", "出差", "设备", "网络", "时间不固定",
-	})
-}
-
-func detectRiskFlags(text string) bool {
-	return containsAny(text, []string{
-		"风险", "担心", "拖延", "中断", "坚持不下去", "突发", "不稳定", "焦虑", "压力",
-	})
-}
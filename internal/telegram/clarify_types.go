@@ -1,5 +1,12 @@
 package telegram
 
+import (
+	"slices"
+	"strings"
+
+	"github.com/congregalis/aiden/internal/i18n"
+)
+
 type PlanningState string
 
 const (
@@ -22,17 +29,59 @@ func (s PlanningState) IsFinal() bool {
 	return s == StateConfirmed
 }
 
+func (s PlanningState) Label(lang string) string {
+	switch s {
+	case StateClarifying:
+		return tr(lang, MsgStateClarifying)
+	case StateReview:
+		return tr(lang, MsgStateReview)
+	case StateConfirmed:
+		return tr(lang, MsgStateConfirmed)
+	default:
+		return tr(lang, MsgStateIdle)
+	}
+}
+
 const (
 	IntentClarifyGoal     = "clarify_goal"
 	IntentConfirmPlan     = "confirm_plan"
 	IntentFallbackUnknown = "fallback_unknown"
+	IntentShowStatus      = "show_status"
+	IntentResetSession    = "reset_session"
+	IntentUndoTurn        = "undo_turn"
+	IntentSkipSlot        = "skip_slot"
+	// IntentDisambiguate is returned when the two best intents score too close
+	// to pick one; the bot asks the user instead of guessing.
+	IntentDisambiguate = "disambiguate"
 )
 
+// IntentResult carries the routed intent. Slot is only set for IntentSkipSlot
+// and is empty when the user did not name a slot that could be recognised.
+// Ranking lists every rule-scored intent, best first; it is empty for
+// commands and control phrases.
 type IntentResult struct {
 	Intent     string
 	Confidence float64
+	Slot       string
+	Ranking    []IntentScore
 }
 
+type IntentScore struct {
+	Intent string  `json:"intent"`
+	Score  float64 `json:"score"`
+}
+
+func IsSessionControlIntent(intent string) bool {
+	switch intent {
+	case IntentShowStatus, IntentResetSession, IntentUndoTurn, IntentSkipSlot:
+		return true
+	default:
+		return false
+	}
+}
+
+const PendingActionReset = "reset"
+
 const (
 	ConversationRoleUser      = "user"
 	ConversationRoleAssistant = "assistant"
@@ -57,6 +106,43 @@ var requiredSlotOrder = []string{
 	SlotRiskFlags,
 }
 
+// RequiredSlots returns the slots a plan needs, in the order they are asked.
+func RequiredSlots() []string {
+	return slices.Clone(requiredSlotOrder)
+}
+
+// skippableSlots can be marked as intentionally left empty with /skip.
+var skippableSlots = []string{
+	SlotConstraints,
+	SlotRiskFlags,
+}
+
+func IsSkippableSlot(slotKey string) bool {
+	for _, key := range skippableSlots {
+		if key == slotKey {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseSlotKey accepts a slot key such as risk_flags or its label in any
+// supported language.
+func ParseSlotKey(raw string) (string, bool) {
+	normalized := strings.ToLower(strings.TrimSpace(raw))
+	for _, key := range requiredSlotOrder {
+		if normalized == key {
+			return key, true
+		}
+		for _, lang := range i18n.Locales() {
+			if normalized == strings.ToLower(SlotLabel(lang, key)) {
+				return key, true
+			}
+		}
+	}
+	return "", false
+}
+
 func DefaultSlotCompletion() map[string]bool {
 	result := make(map[string]bool, len(requiredSlotOrder))
 	for _, key := range requiredSlotOrder {
@@ -90,20 +176,20 @@ func IsRequiredSlotsComplete(slotCompletion map[string]bool) bool {
 	return len(MissingRequiredSlots(slotCompletion)) == 0
 }
 
-func SlotLabel(slotKey string) string {
+func SlotLabel(lang, slotKey string) string {
 	switch slotKey {
 	case SlotMainGoal:
-		return "主目标"
+		return tr(lang, MsgSlotMainGoal)
 	case SlotSuccessCriteria:
-		return "成功标准"
+		return tr(lang, MsgSlotSuccessCriteria)
 	case SlotCurrentLevel:
-		return "当前水平"
+		return tr(lang, MsgSlotCurrentLevel)
 	case SlotTimeBudget:
-		return "时间预算"
+		return tr(lang, MsgSlotTimeBudget)
 	case SlotConstraints:
-		return "约束条件"
+		return tr(lang, MsgSlotConstraints)
 	case SlotRiskFlags:
-		return "风险项"
+		return tr(lang, MsgSlotRiskFlags)
 	default:
 		return slotKey
 	}
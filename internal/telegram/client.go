@@ -7,7 +7,9 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"mime/multipart"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -18,8 +20,13 @@ type Client interface {
 	GetMe(context.Context) (BotUser, error)
 	GetUpdates(context.Context, GetUpdatesParams) ([]Update, error)
 	SendMessage(context.Context, OutgoingMessage) (Message, error)
+	SendDocument(context.Context, OutgoingDocument) (Message, error)
+	GetFile(context.Context, string) (File, error)
+	DownloadFile(context.Context, File, int64) ([]byte, error)
 }
 
+var ErrFileTooLarge = errors.New("telegram: file exceeds download limit")
+
 type HTTPClient struct {
 	baseURL    string
 	token      string
@@ -107,18 +114,117 @@ func (c *HTTPClient) SendMessage(ctx context.Context, message OutgoingMessage) (
 	return result, nil
 }
 
+func (c *HTTPClient) SendDocument(ctx context.Context, document OutgoingDocument) (Message, error) {
+	var payload bytes.Buffer
+	form := multipart.NewWriter(&payload)
+	fields := map[string]string{"chat_id": strconv.FormatInt(document.ChatID, 10)}
+	if document.Caption != "" {
+		fields["caption"] = document.Caption
+	}
+	if document.ReplyToMessageID > 0 {
+		fields["reply_to_message_id"] = strconv.FormatInt(document.ReplyToMessageID, 10)
+	}
+	for name, value := range fields {
+		if err := form.WriteField(name, value); err != nil {
+			return Message{}, fmt.Errorf("build telegram sendDocument request: %w", err)
+		}
+	}
+	file, err := form.CreateFormFile("document", document.FileName)
+	if err != nil {
+		return Message{}, fmt.Errorf("build telegram sendDocument request: %w", err)
+	}
+	if _, err := file.Write(document.Content); err != nil {
+		return Message{}, fmt.Errorf("build telegram sendDocument request: %w", err)
+	}
+	if err := form.Close(); err != nil {
+		return Message{}, fmt.Errorf("build telegram sendDocument request: %w", err)
+	}
+
+	body, statusCode, err := c.post(ctx, "sendDocument", form.FormDataContentType(), &payload)
+	if err != nil {
+		return Message{}, err
+	}
+
+	result, err := decodeResult[Message](statusCode, body)
+	if err != nil {
+		return Message{}, fmt.Errorf("telegram sendDocument: %w", err)
+	}
+
+	return result, nil
+}
+
+func (c *HTTPClient) GetFile(ctx context.Context, fileID string) (File, error) {
+	body, statusCode, err := c.postJSON(ctx, "getFile", map[string]any{"file_id": fileID})
+	if err != nil {
+		return File{}, err
+	}
+
+	result, err := decodeResult[File](statusCode, body)
+	if err != nil {
+		return File{}, fmt.Errorf("telegram getFile: %w", err)
+	}
+
+	return result, nil
+}
+
+// DownloadFile fetches the content of a file resolved by GetFile. Files larger
+// than maxBytes are rejected with ErrFileTooLarge.
+func (c *HTTPClient) DownloadFile(ctx context.Context, file File, maxBytes int64) ([]byte, error) {
+	if file.FilePath == "" {
+		return nil, fmt.Errorf("telegram download file %s: empty file path", file.FileID)
+	}
+	if maxBytes > 0 && file.FileSize > maxBytes {
+		return nil, ErrFileTooLarge
+	}
+
+	url := fmt.Sprintf("%s/file/bot%s/%s", c.baseURL, c.token, strings.TrimLeft(file.FilePath, "/"))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("build telegram download request: %w", err)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("telegram download file: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return nil, fmt.Errorf("telegram download file: %w", parseAPIError(resp.StatusCode, body))
+	}
+
+	reader := io.Reader(resp.Body)
+	if maxBytes > 0 {
+		reader = io.LimitReader(resp.Body, maxBytes+1)
+	}
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, fmt.Errorf("read telegram file: %w", err)
+	}
+	if maxBytes > 0 && int64(len(data)) > maxBytes {
+		return nil, ErrFileTooLarge
+	}
+
+	return data, nil
+}
+
 func (c *HTTPClient) postJSON(ctx context.Context, method string, payload map[string]any) ([]byte, int, error) {
 	jsonBody, err := json.Marshal(payload)
 	if err != nil {
 		return nil, 0, fmt.Errorf("marshal telegram %s request: %w", method, err)
 	}
 
+	return c.post(ctx, method, "application/json", bytes.NewReader(jsonBody))
+}
+
+func (c *HTTPClient) post(ctx context.Context, method, contentType string, payload io.Reader) ([]byte, int, error) {
 	url := fmt.Sprintf("%s/bot%s/%s", c.baseURL, c.token, method)
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(jsonBody))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, payload)
 	if err != nil {
 		return nil, 0, fmt.Errorf("build telegram %s request: %w", method, err)
 	}
-	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Content-Type", contentType)
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
@@ -146,12 +252,7 @@ func decodeResult[T any](statusCode int, body []byte) (T, error) {
 	}
 
 	if !envelope.OK {
-		return zero, &APIError{
-			StatusCode:  statusCode,
-			ErrorCode:   envelope.ErrorCode,
-			Description: envelope.Description,
-			RetryAfter:  retryAfterFromParameters(envelope.Parameters),
-		}
+		return zero, newAPIError(statusCode, envelope.ErrorCode, envelope.Description, envelope.Parameters)
 	}
 
 	return envelope.Result, nil
@@ -173,19 +274,71 @@ func parseAPIError(statusCode int, body []byte) error {
 		}
 	}
 
-	return &APIError{
+	return newAPIError(statusCode, envelope.ErrorCode, envelope.Description, envelope.Parameters)
+}
+
+func newAPIError(statusCode, errorCode int, description string, params *ResponseParameters) *APIError {
+	apiErr := &APIError{
 		StatusCode:  statusCode,
-		ErrorCode:   envelope.ErrorCode,
-		Description: envelope.Description,
-		RetryAfter:  retryAfterFromParameters(envelope.Parameters),
+		ErrorCode:   errorCode,
+		Description: description,
+		RetryAfter:  retryAfterFromParameters(params),
+	}
+	if params != nil {
+		apiErr.MigrateToChatID = params.MigrateToChatID
 	}
+	return apiErr
+}
+
+var (
+	ErrBotBlocked   = errors.New("telegram: bot blocked or user deactivated")
+	ErrChatNotFound = errors.New("telegram: chat not found")
+	ErrBadRequest   = errors.New("telegram: bad request")
+)
+
+// ChatMigratedError is returned when a group was upgraded to a supergroup and
+// messages must be sent to MigrateToChatID instead.
+type ChatMigratedError struct {
+	MigrateToChatID int64
+}
+
+func (e *ChatMigratedError) Error() string {
+	return fmt.Sprintf("telegram: chat migrated to %d", e.MigrateToChatID)
 }
 
 type APIError struct {
-	StatusCode  int
-	ErrorCode   int
-	Description string
-	RetryAfter  time.Duration
+	StatusCode      int
+	ErrorCode       int
+	Description     string
+	RetryAfter      time.Duration
+	MigrateToChatID int64
+}
+
+// Unwrap exposes the typed error for the failure so callers can use errors.Is
+// and errors.As instead of inspecting status codes and descriptions.
+func (e *APIError) Unwrap() error {
+	if e == nil {
+		return nil
+	}
+
+	code := e.ErrorCode
+	if code == 0 {
+		code = e.StatusCode
+	}
+	description := strings.ToLower(e.Description)
+
+	switch {
+	case e.MigrateToChatID != 0:
+		return &ChatMigratedError{MigrateToChatID: e.MigrateToChatID}
+	case code == http.StatusForbidden:
+		return ErrBotBlocked
+	case code == http.StatusBadRequest && strings.Contains(description, "chat not found"):
+		return ErrChatNotFound
+	case code == http.StatusBadRequest:
+		return ErrBadRequest
+	default:
+		return nil
+	}
 }
 
 func (e *APIError) Error() string {
@@ -198,6 +351,20 @@ func (e *APIError) Error() string {
 	return fmt.Sprintf("telegram api error (status=%d, code=%d): %s", e.StatusCode, e.ErrorCode, e.Description)
 }
 
+func IsChatMigrated(err error) (int64, bool) {
+	var migrated *ChatMigratedError
+	if !errors.As(err, &migrated) {
+		return 0, false
+	}
+	return migrated.MigrateToChatID, true
+}
+
+// IsChatUnreachable reports whether Telegram will keep refusing messages for
+// the chat until the user contacts the bot again.
+func IsChatUnreachable(err error) bool {
+	return errors.Is(err, ErrBotBlocked) || errors.Is(err, ErrChatNotFound)
+}
+
 func IsRateLimitError(err error) (time.Duration, bool) {
 	var apiErr *APIError
 	if !errors.As(err, &apiErr) {
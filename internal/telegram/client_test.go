@@ -0,0 +1,73 @@
+package telegram
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func TestAPIErrorClassification(t *testing.T) {
+	tests := []struct {
+		name        string
+		body        string
+		status      int
+		target      error
+		unreachable bool
+	}{
+		{
+			name:        "blocked by user",
+			status:      http.StatusForbidden,
+			body:        `{"ok":false,"error_code":403,"description":"Forbidden: bot was blocked by the user"}`,
+			target:      ErrBotBlocked,
+			unreachable: true,
+		},
+		{
+			name:        "user deactivated",
+			status:      http.StatusForbidden,
+			body:        `{"ok":false,"error_code":403,"description":"Forbidden: user is deactivated"}`,
+			target:      ErrBotBlocked,
+			unreachable: true,
+		},
+		{
+			name:        "chat not found",
+			status:      http.StatusBadRequest,
+			body:        `{"ok":false,"error_code":400,"description":"Bad Request: chat not found"}`,
+			target:      ErrChatNotFound,
+			unreachable: true,
+		},
+		{
+			name:   "bad request",
+			status: http.StatusBadRequest,
+			body:   `{"ok":false,"error_code":400,"description":"Bad Request: message text is empty"}`,
+			target: ErrBadRequest,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := parseAPIError(tc.status, []byte(tc.body))
+			if !errors.Is(err, tc.target) {
+				t.Fatalf("errors.Is(%v, %v)=false", err, tc.target)
+			}
+			if got := IsChatUnreachable(err); got != tc.unreachable {
+				t.Fatalf("IsChatUnreachable()=%v, want %v", got, tc.unreachable)
+			}
+		})
+	}
+}
+
+func TestAPIErrorChatMigrated(t *testing.T) {
+	body := `{"ok":false,"error_code":400,"description":"Bad Request: group chat was upgraded to a supergroup chat","parameters":{"migrate_to_chat_id":-1001234567890}}`
+	err := parseAPIError(http.StatusBadRequest, []byte(body))
+
+	chatID, ok := IsChatMigrated(err)
+	if !ok {
+		t.Fatalf("IsChatMigrated() = false for %v", err)
+	}
+	if chatID != -1001234567890 {
+		t.Fatalf("migrate_to_chat_id=%d, want -1001234567890", chatID)
+	}
+	if errors.Is(err, ErrBadRequest) {
+		t.Fatalf("migration should not be reported as a plain bad request")
+	}
+}
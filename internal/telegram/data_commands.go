@@ -0,0 +1,129 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+)
+
+// deletionConfirmWindow is how long a /deleteme request waits for its two
+// confirmations before it expires.
+const deletionConfirmWindow = 10 * time.Minute
+
+// deletionAuditActor marks audit entries for deletions the user asked for
+// themselves.
+const deletionAuditActor = "user"
+
+// replyForMyData only validates /mydata. A valid request gets no text reply;
+// enqueueUserExport queues the document instead.
+func replyForMyData(user User, command Command) string {
+	if _, ok := myDataFormat(command); !ok {
+		return tr(user.Language, MsgMyDataUsage)
+	}
+	return ""
+}
+
+func myDataFormat(command Command) (string, bool) {
+	if len(command.Args) > 1 {
+		return "", false
+	}
+	return ParseExportFormat(strings.Join(command.Args, ""))
+}
+
+// myDataRequest reports whether message is a /mydata command that should
+// produce an export, and in which format.
+func myDataRequest(message IncomingMessage) (string, bool) {
+	if message.Edited || message.Attachment != nil {
+		return "", false
+	}
+	command := ParseCommand(message.Text)
+	if !command.IsCommand || command.Name != "mydata" {
+		return "", false
+	}
+	return myDataFormat(command)
+}
+
+// enqueueUserExport queues the export in the update's transaction. The
+// dispatcher builds and uploads the document, so a slow upload does not hold
+// up polling and a crash after the commit does not lose the request.
+func enqueueUserExport(ctx context.Context, store Store, message IncomingMessage, format string) error {
+	user, _, err := store.FindOrCreateUserByChatID(ctx, message.ChatID)
+	if err != nil {
+		return fmt.Errorf("find user for export: %w", err)
+	}
+	if _, err := store.EnqueueOutgoingMessage(ctx, OutgoingMessage{
+		ChatID:           message.ChatID,
+		Text:             tr(user.Language, MsgMyDataCaption),
+		ReplyToMessageID: message.MessageID,
+		Export: &OutboxExport{
+			UserID:   user.ID,
+			Format:   format,
+			Language: user.Language,
+		},
+	}); err != nil {
+		return fmt.Errorf("enqueue user export: %w", err)
+	}
+	return nil
+}
+
+// handleDeleteMe runs the /deleteme flow: /deleteme arms a request, the first
+// /deleteme confirm asks once more and the second one deletes everything.
+// The request expires after deletionConfirmWindow.
+func (w *Worker) handleDeleteMe(ctx context.Context, store Store, user User, command Command) (string, error) {
+	lang := user.Language
+	if len(command.Args) > 1 {
+		return tr(lang, MsgDeleteMeUsage), nil
+	}
+
+	now := w.now()
+	pending := user.DeletionRequestedAt != nil && now.Sub(*user.DeletionRequestedAt) <= deletionConfirmWindow
+
+	switch strings.ToLower(strings.Join(command.Args, "")) {
+	case "":
+		if err := store.SetUserDeletionRequest(ctx, user.ID, &now, 0); err != nil {
+			return "", fmt.Errorf("set user deletion request: %w", err)
+		}
+		return tr(lang, MsgDeleteMeWarning, int(deletionConfirmWindow/time.Minute)), nil
+	case "cancel":
+		if user.DeletionRequestedAt == nil {
+			return tr(lang, MsgDeleteMeNotRequested), nil
+		}
+		if err := store.SetUserDeletionRequest(ctx, user.ID, nil, 0); err != nil {
+			return "", fmt.Errorf("clear user deletion request: %w", err)
+		}
+		return tr(lang, MsgDeleteMeCancelled), nil
+	case "confirm":
+		if !pending {
+			return tr(lang, MsgDeleteMeNotRequested), nil
+		}
+		if user.DeletionConfirmations == 0 {
+			if err := store.SetUserDeletionRequest(ctx, user.ID, user.DeletionRequestedAt, 1); err != nil {
+				return "", fmt.Errorf("set user deletion request: %w", err)
+			}
+			return tr(lang, MsgDeleteMeConfirmAgain), nil
+		}
+	default:
+		return tr(lang, MsgDeleteMeUsage), nil
+	}
+
+	// The audit entry is a tombstone: the user's UUID and row counts only,
+	// nothing that leads back to the Telegram account.
+	summary, err := store.DeleteUserData(ctx, user.ID, AuditEntry{
+		Actor:      deletionAuditActor,
+		Action:     "user.delete",
+		TargetType: "user",
+		TargetID:   user.ID,
+	})
+	if err != nil {
+		return "", fmt.Errorf("delete user data: %w", err)
+	}
+
+	w.logger.Info("user_deleted",
+		slog.String("user_id", user.ID),
+		slog.Int64("goals", summary.Goals),
+		slog.Int64("turns", summary.Turns),
+	)
+	return tr(lang, MsgDeleteMeDone), nil
+}
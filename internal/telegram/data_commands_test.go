@@ -0,0 +1,196 @@
+package telegram
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"io"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWorkerMyDataSendsExportDocument(t *testing.T) {
+	store := NewMemoryStore()
+	chat := Chat{ID: 93001}
+	texts := []string{"我想三个月内学会 Go", "/mydata pdf", "/mydata", "/help"}
+	batches := make([][]Update, 0, len(texts))
+	for i, text := range texts {
+		batches = append(batches, []Update{{UpdateID: int64(i + 1), Message: &Message{MessageID: int64(i + 1), Chat: chat, Text: text}}})
+	}
+	client := &scriptedClient{updates: batches}
+
+	if err := runWorkerUntilSendCount(t, client, store, 3); err != nil {
+		t.Fatalf("worker run failed: %v", err)
+	}
+
+	sent := client.SentMessages()
+	if sent[1].Text != zh(MsgMyDataUsage) {
+		t.Fatalf("reply to bad format=%q, want usage", sent[1].Text)
+	}
+	if sent[2].Text != zh(MsgHelp) {
+		t.Fatalf("reply after /mydata=%q, want help (no text reply to /mydata)", sent[2].Text)
+	}
+
+	documents := client.SentDocuments()
+	if len(documents) != 1 {
+		t.Fatalf("documents=%d, want 1", len(documents))
+	}
+	document := documents[0]
+	if document.ChatID != chat.ID || document.ReplyToMessageID != 3 || document.Caption != zh(MsgMyDataCaption) {
+		t.Fatalf("unexpected document: %+v", document)
+	}
+	if !strings.HasPrefix(document.FileName, "aiden-export-") || !strings.HasSuffix(document.FileName, ".json") {
+		t.Fatalf("file name=%q", document.FileName)
+	}
+
+	var export UserExport
+	if err := json.Unmarshal(document.Content, &export); err != nil {
+		t.Fatalf("decode export: %v", err)
+	}
+	if export.User.TelegramChatID != chat.ID || len(export.Goals) != 1 {
+		t.Fatalf("unexpected export: %+v", export)
+	}
+	if turns := export.Goals[0].Turns; len(turns) == 0 || turns[0].Content != texts[0] {
+		t.Fatalf("export turns=%+v, want the first message", turns)
+	}
+}
+
+func TestWorkerDeleteMeNeedsTwoConfirmations(t *testing.T) {
+	store := NewMemoryStore()
+	chat := Chat{ID: 93002}
+	texts := []string{"我想三个月内学会 Go", "/deleteme confirm", "/deleteme", "/deleteme confirm", "/deleteme confirm"}
+	// One run per message, so every reply leaves the outbox before the
+	// deletion scrubs it.
+	sent := make([]OutgoingMessage, 0, len(texts))
+	for i, text := range texts {
+		client := &scriptedClient{updates: [][]Update{{{UpdateID: int64(i + 1), Message: &Message{MessageID: int64(i + 1), Chat: chat, Text: text}}}}}
+		if err := runWorkerUntilSendCount(t, client, store, 1); err != nil {
+			t.Fatalf("worker run for %q failed: %v", text, err)
+		}
+		sent = append(sent, client.SentMessages()...)
+	}
+
+	want := []string{
+		zh(MsgDeleteMeNotRequested),
+		zh(MsgDeleteMeWarning, 10),
+		zh(MsgDeleteMeConfirmAgain),
+		zh(MsgDeleteMeDone),
+	}
+	for i, text := range want {
+		if sent[i+1].Text != text {
+			t.Fatalf("reply %d=%q, want %q", i+1, sent[i+1].Text, text)
+		}
+	}
+
+	if _, ok := store.UserByChatID(chat.ID); ok {
+		t.Fatal("user still exists after deletion")
+	}
+	if goals := store.GoalCreateCount(); goals != 1 {
+		t.Fatalf("goal create count=%d, want 1", goals)
+	}
+	for _, message := range store.OutboxMessages() {
+		if message.Text != zh(MsgDeleteMeDone) {
+			t.Fatalf("outbox kept %q after deletion", message.Text)
+		}
+	}
+
+	audits := store.AuditEntries()
+	if len(audits) != 1 {
+		t.Fatalf("audit entries=%d, want 1", len(audits))
+	}
+	audit := audits[0]
+	if audit.Actor != deletionAuditActor || audit.Action != "user.delete" || audit.TargetType != "user" {
+		t.Fatalf("unexpected audit entry: %+v", audit)
+	}
+	if audit.Payload["goals"] != int64(1) || audit.Payload["dedup_rows"] != int64(5) {
+		t.Fatalf("audit payload=%v", audit.Payload)
+	}
+	encoded, err := json.Marshal(audit)
+	if err != nil {
+		t.Fatalf("marshal audit: %v", err)
+	}
+	if strings.Contains(string(encoded), strconv.FormatInt(chat.ID, 10)) {
+		t.Fatalf("audit entry leaks the chat ID: %s", encoded)
+	}
+}
+
+func TestWorkerDeleteMeRequestExpires(t *testing.T) {
+	store := NewMemoryStore()
+	chat := Chat{ID: 93003}
+	now := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	clock := func() time.Time { return now }
+	store.SetClock(clock)
+
+	worker := NewWorker(WorkerConfig{Now: clock}, &scriptedClient{}, store, nil)
+	user, _, err := store.FindOrCreateUserByChatID(t.Context(), chat.ID)
+	if err != nil {
+		t.Fatalf("seed user: %v", err)
+	}
+	requestedAt := now.Add(-deletionConfirmWindow - time.Second)
+	if err := store.SetUserDeletionRequest(t.Context(), user.ID, &requestedAt, 1); err != nil {
+		t.Fatalf("seed deletion request: %v", err)
+	}
+	user, _ = store.UserByChatID(chat.ID)
+
+	reply, err := worker.handleDeleteMe(t.Context(), store, user, ParseCommand("/deleteme confirm"))
+	if err != nil {
+		t.Fatalf("handleDeleteMe: %v", err)
+	}
+	if reply != zh(MsgDeleteMeNotRequested) {
+		t.Fatalf("reply=%q, want not requested", reply)
+	}
+	if _, ok := store.UserByChatID(chat.ID); !ok {
+		t.Fatal("expired request deleted the user")
+	}
+}
+
+func TestEncodeUserExportZIP(t *testing.T) {
+	export := UserExport{
+		ExportedAt: time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC),
+		User:       ExportedUser{ID: "user-1", TelegramChatID: 42, Language: "en"},
+		Goals: []ExportedGoal{{
+			ID:       "goal-1",
+			Profiles: []ExportedProfile{{VersionNo: 2, Markdown: "# Plan"}},
+			Turns:    []ExportedTurn{{Role: "user", Content: "learn Go"}, {Role: "assistant", Content: "by when?"}},
+		}},
+	}
+
+	fileName, content, err := EncodeUserExport(export, ExportFormatZIP)
+	if err != nil {
+		t.Fatalf("EncodeUserExport: %v", err)
+	}
+	if fileName != "aiden-export-20260301.zip" {
+		t.Fatalf("file name=%q", fileName)
+	}
+
+	archive, err := zip.NewReader(bytes.NewReader(content), int64(len(content)))
+	if err != nil {
+		t.Fatalf("open zip: %v", err)
+	}
+	files := make(map[string]string)
+	for _, file := range archive.File {
+		reader, err := file.Open()
+		if err != nil {
+			t.Fatalf("open %s: %v", file.Name, err)
+		}
+		data, err := io.ReadAll(reader)
+		reader.Close()
+		if err != nil {
+			t.Fatalf("read %s: %v", file.Name, err)
+		}
+		files[file.Name] = string(data)
+	}
+
+	if files["plans/goal-1-v2.md"] != "# Plan" {
+		t.Fatalf("plan file=%q", files["plans/goal-1-v2.md"])
+	}
+	if files["conversations/goal-1.txt"] != "user: learn Go\nassistant: by when?\n" {
+		t.Fatalf("transcript=%q", files["conversations/goal-1.txt"])
+	}
+	var decoded UserExport
+	if err := json.Unmarshal([]byte(files["data.json"]), &decoded); err != nil || decoded.User.ID != "user-1" {
+		t.Fatalf("data.json=%q (%v)", files["data.json"], err)
+	}
+}
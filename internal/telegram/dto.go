@@ -1,14 +1,55 @@
 package telegram
 
+// MapUpdateToIncomingMessage flattens a Telegram update. A caption is treated
+// as the message text so captioned photos and documents reach the router.
 func MapUpdateToIncomingMessage(update Update) (IncomingMessage, bool) {
-	if update.Message == nil {
+	message := update.Message
+	edited := false
+	if message == nil {
+		message = update.EditedMessage
+		edited = true
+	}
+	if message == nil {
 		return IncomingMessage{}, false
 	}
 
-	return IncomingMessage{
+	incoming := IncomingMessage{
 		UpdateID:  update.UpdateID,
-		MessageID: update.Message.MessageID,
-		ChatID:    update.Message.Chat.ID,
-		Text:      update.Message.Text,
-	}, true
+		MessageID: message.MessageID,
+		ChatID:    message.Chat.ID,
+		Kind:      messageKind(message),
+		Text:      message.Text,
+		Voice:     message.Voice,
+		Document:  message.Document,
+		Edited:    edited,
+	}
+	if incoming.Text == "" {
+		incoming.Text = message.Caption
+	}
+	if message.From != nil {
+		incoming.FromUserID = message.From.ID
+		incoming.LanguageCode = message.From.LanguageCode
+	}
+	if message.ReplyToMessage != nil {
+		incoming.ReplyToMessageID = message.ReplyToMessage.MessageID
+	}
+
+	return incoming, true
+}
+
+func messageKind(message *Message) string {
+	switch {
+	case message.Voice != nil:
+		return MessageKindVoice
+	case message.Document != nil:
+		return MessageKindDocument
+	case len(message.Photo) > 0:
+		return MessageKindPhoto
+	case message.Sticker != nil:
+		return MessageKindSticker
+	case message.Text != "":
+		return MessageKindText
+	default:
+		return MessageKindOther
+	}
 }
@@ -0,0 +1,69 @@
+package telegram
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestMapUpdateDecodesEditedMessage(t *testing.T) {
+	incoming, ok := MapUpdateToIncomingMessage(Update{
+		UpdateID:      7,
+		EditedMessage: &Message{MessageID: 8, Chat: Chat{ID: 9}, Text: "修改后的目标"},
+	})
+	if !ok {
+		t.Fatalf("expected edited update to map")
+	}
+	if !incoming.Edited || incoming.MessageID != 8 || incoming.Text != "修改后的目标" {
+		t.Fatalf("incoming=%+v, want edited message 8", incoming)
+	}
+}
+
+func TestWorkerReplaysEditedLatestTurn(t *testing.T) {
+	store := NewMemoryStore()
+	chat := Chat{ID: 70001}
+	client := &scriptedClient{
+		updates: [][]Update{
+			{{UpdateID: 1, Message: &Message{MessageID: 31, Chat: chat, Text: "我想三个月内学会游泳"}}},
+			{{UpdateID: 2, Message: &Message{MessageID: 32, Chat: chat, Text: "我想每周练习三次"}}},
+			{{UpdateID: 3, EditedMessage: &Message{MessageID: 31, Chat: chat, Text: "我想三个月内学会蝶泳"}}},
+			{{UpdateID: 4, EditedMessage: &Message{MessageID: 32, Chat: chat, Text: "我现在零基础，还没想好时间"}}},
+		},
+	}
+
+	if err := runWorkerUntilSendCount(t, client, store, 3); err != nil {
+		t.Fatalf("worker run failed: %v", err)
+	}
+
+	sent := client.SentMessages()
+	if len(sent) != 3 {
+		t.Fatalf("sent=%d, want 3 (edit of an older turn is not replayed)", len(sent))
+	}
+	if !strings.HasPrefix(sent[2].Text, zh(MsgEditApplied)) || sent[2].ReplyToMessageID != 32 {
+		t.Fatalf("edit reply=%+v, want edit notice replying to message 32", sent[2])
+	}
+
+	user, _ := store.UserByChatID(chat.ID)
+	goal, _, err := store.GetCurrentGoalByUserID(context.Background(), user.ID)
+	if err != nil {
+		t.Fatalf("get active goal failed: %v", err)
+	}
+	session, _ := store.SessionByGoalID(goal.ID)
+	if session.SlotCompletion[SlotTimeBudget] {
+		t.Fatalf("time budget should be cleared after the edit: %+v", session.SlotCompletion)
+	}
+	if !session.SlotCompletion[SlotMainGoal] || !session.SlotCompletion[SlotCurrentLevel] {
+		t.Fatalf("slot completion=%+v, want main goal and current level", session.SlotCompletion)
+	}
+
+	turns, err := store.ListUserTurns(context.Background(), session.ID)
+	if err != nil {
+		t.Fatalf("list user turns failed: %v", err)
+	}
+	if turns[0].Revision != 0 || turns[0].Content != "我想三个月内学会游泳" {
+		t.Fatalf("older turn=%+v, want untouched", turns[0])
+	}
+	if turns[1].Revision != 1 || turns[1].Content != "我现在零基础，还没想好时间" {
+		t.Fatalf("latest turn=%+v, want revision 1 with edited content", turns[1])
+	}
+}
@@ -0,0 +1,165 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+)
+
+const goalTitleMaxRunes = 30
+
+// handleGoalCommand serves /goals and the /goal new|switch|archive
+// subcommands. Goals are numbered by creation order, starting at 1.
+func (w *Worker) handleGoalCommand(ctx context.Context, store Store, user User, message IncomingMessage, command Command) (string, error) {
+	if command.Name == "goals" {
+		return w.listGoals(ctx, store, user)
+	}
+
+	switch subcommand := strings.ToLower(command.Args[0]); subcommand {
+	case "new":
+		goal, err := store.CreateGoalDraft(ctx, user.ID)
+		if err != nil {
+			return "", fmt.Errorf("create goal draft: %w", err)
+		}
+		w.logger.Info("goal_started",
+			slog.String("goal_id", goal.ID),
+			slog.String("user_id", goal.UserID),
+			slog.String("goal_status", goal.Status),
+			slog.String("chat_id_masked", MaskChatID(message.ChatID)),
+		)
+		return w.handleClarifyRound(ctx, store, user, message, goal)
+
+	case "switch", "archive":
+		goals, err := store.ListGoalsByUserID(ctx, user.ID)
+		if err != nil {
+			return "", fmt.Errorf("list goals by user id: %w", err)
+		}
+		index, ok := parseGoalNumber(command.Args[1:], len(goals))
+		if !ok {
+			return tr(user.Language, MsgGoalNumberInvalid), nil
+		}
+		if subcommand == "switch" {
+			return w.switchGoal(ctx, store, user, goals[index], index+1)
+		}
+		return w.archiveGoal(ctx, store, user.Language, goals[index], index+1)
+
+	default:
+		return tr(user.Language, MsgGoalUsage), nil
+	}
+}
+
+func (w *Worker) listGoals(ctx context.Context, store Store, user User) (string, error) {
+	goals, err := store.ListGoalsByUserID(ctx, user.ID)
+	if err != nil {
+		return "", fmt.Errorf("list goals by user id: %w", err)
+	}
+	lang := user.Language
+	if len(goals) == 0 {
+		return tr(lang, MsgNoGoals), nil
+	}
+	current, _, err := store.GetCurrentGoalByUserID(ctx, user.ID)
+	if err != nil {
+		return "", fmt.Errorf("get current goal by user id: %w", err)
+	}
+
+	var builder strings.Builder
+	builder.WriteString(tr(lang, MsgGoalListTitle))
+	for i, goal := range goals {
+		builder.WriteString("\n" + tr(lang, MsgGoalListItem, i+1, goalTitle(lang, goal), goalStatusLabel(lang, goal.Status)))
+		if goal.ID == current.ID {
+			builder.WriteString(tr(lang, MsgGoalListCurrent))
+		}
+	}
+	builder.WriteString("\n\n")
+	builder.WriteString(tr(lang, MsgGoalUsage))
+	return builder.String(), nil
+}
+
+func (w *Worker) switchGoal(ctx context.Context, store Store, user User, goal Goal, number int) (string, error) {
+	lang := user.Language
+	if goal.Status == GoalStatusArchived {
+		return tr(lang, MsgGoalArchivedAlready, number), nil
+	}
+	if err := store.SetCurrentGoal(ctx, user.ID, goal.ID); err != nil {
+		return "", fmt.Errorf("set current goal: %w", err)
+	}
+	session, _, err := store.GetOrCreatePlanningSession(ctx, goal.ID)
+	if err != nil {
+		return "", fmt.Errorf("get or create planning session: %w", err)
+	}
+
+	w.logger.Info("goal_switched",
+		slog.String("goal_id", goal.ID),
+		slog.String("user_id", user.ID),
+	)
+	return tr(lang, MsgGoalSwitched, number, goalTitle(lang, goal)) + "\n\n" + FormatSessionStatus(lang, session), nil
+}
+
+func (w *Worker) archiveGoal(ctx context.Context, store Store, lang string, goal Goal, number int) (string, error) {
+	if goal.Status == GoalStatusArchived {
+		return tr(lang, MsgGoalArchivedAlready, number), nil
+	}
+	if err := store.ArchiveGoal(ctx, goal.ID); err != nil {
+		return "", fmt.Errorf("archive goal: %w", err)
+	}
+
+	w.logger.Info("goal_archived",
+		slog.String("goal_id", goal.ID),
+		slog.String("user_id", goal.UserID),
+	)
+	return tr(lang, MsgGoalArchived, number, goalTitle(lang, goal)), nil
+}
+
+// syncGoalWithRound names an untitled goal after the first message that
+// states it, and makes the goal the user's single active goal once the plan
+// is confirmed.
+func (w *Worker) syncGoalWithRound(ctx context.Context, store Store, goal Goal, text string, intent IntentResult, session PlanningSession) error {
+	if goal.Title == "" && intent.Intent == IntentClarifyGoal && w.rules.Current().SlotMatches(SlotMainGoal, text) && !ParseCommand(text).IsCommand {
+		if err := store.UpdateGoalTitle(ctx, goal.ID, previewText(text, goalTitleMaxRunes)); err != nil {
+			return fmt.Errorf("update goal title: %w", err)
+		}
+	}
+
+	if session.State == StateConfirmed && goal.Status != GoalStatusActive {
+		if err := store.ActivateGoal(ctx, goal.ID); err != nil && !errors.Is(err, ErrGoalNotFound) {
+			return fmt.Errorf("activate goal: %w", err)
+		}
+		w.logger.Info("goal_activated",
+			slog.String("goal_id", goal.ID),
+			slog.String("user_id", goal.UserID),
+		)
+	}
+	return nil
+}
+
+func parseGoalNumber(args []string, count int) (int, bool) {
+	if len(args) == 0 {
+		return 0, false
+	}
+	number, err := strconv.Atoi(strings.TrimPrefix(args[0], "#"))
+	if err != nil || number < 1 || number > count {
+		return 0, false
+	}
+	return number - 1, true
+}
+
+func goalTitle(lang string, goal Goal) string {
+	if goal.Title == "" {
+		return tr(lang, MsgGoalUntitled)
+	}
+	return goal.Title
+}
+
+func goalStatusLabel(lang, status string) string {
+	switch status {
+	case GoalStatusActive:
+		return tr(lang, MsgGoalStatusActive)
+	case GoalStatusArchived:
+		return tr(lang, MsgGoalStatusArchived)
+	default:
+		return tr(lang, MsgGoalStatusDraft)
+	}
+}
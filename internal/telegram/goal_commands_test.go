@@ -0,0 +1,86 @@
+package telegram
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+const fullGoalDescription = "我想在3个月内通过Go面试，成功标准是1.完成3个项目 2.刷100题 3.通过面试，我是零基础，每周10小时，工作日晚上学习，限制是经常加班，风险是容易拖延。"
+
+func TestWorkerManagesMultipleGoals(t *testing.T) {
+	store := NewMemoryStore()
+	chat := Chat{ID: 90001}
+	texts := []string{
+		"我想三个月内学会游泳",
+		"/goal new",
+		"我想一年内通过日语N2",
+		"/goals",
+		"/goal switch 1",
+		"/goal archive 2",
+		"/goal switch 2",
+		"/goals",
+	}
+	batches := make([][]Update, 0, len(texts))
+	for i, text := range texts {
+		batches = append(batches, []Update{{UpdateID: int64(i + 1), Message: &Message{MessageID: int64(i + 1), Chat: chat, Text: text}}})
+	}
+	client := &scriptedClient{updates: batches}
+
+	if err := runWorkerUntilSendCount(t, client, store, len(texts)); err != nil {
+		t.Fatalf("worker run failed: %v", err)
+	}
+	sent := client.SentMessages()
+
+	if sent[1].Text != zh(MsgGoal) {
+		t.Fatalf("/goal new reply=%q, want %q", sent[1].Text, zh(MsgGoal))
+	}
+	if want := "2. 我想一年内通过日语N2（草稿） ← 当前"; !strings.Contains(sent[3].Text, want) {
+		t.Fatalf("/goals reply=%q, want it to contain %q", sent[3].Text, want)
+	}
+	if !strings.HasPrefix(sent[4].Text, "已切换到目标 #1：我想三个月内学会游泳") {
+		t.Fatalf("switch reply=%q", sent[4].Text)
+	}
+	if !strings.HasPrefix(sent[5].Text, "已归档目标 #2") {
+		t.Fatalf("archive reply=%q", sent[5].Text)
+	}
+	if want := "目标 #2 已归档"; !strings.HasPrefix(sent[6].Text, want) {
+		t.Fatalf("switch to archived reply=%q, want prefix %q", sent[6].Text, want)
+	}
+	if want := "1. 我想三个月内学会游泳（草稿） ← 当前\n2. 我想一年内通过日语N2（已归档）"; !strings.Contains(sent[7].Text, want) {
+		t.Fatalf("/goals reply=%q, want it to contain %q", sent[7].Text, want)
+	}
+
+	user, _ := store.UserByChatID(chat.ID)
+	current, _, _ := store.GetCurrentGoalByUserID(context.Background(), user.ID)
+	if current.Title != "我想三个月内学会游泳" {
+		t.Fatalf("current goal=%+v, want the first goal", current)
+	}
+}
+
+func TestWorkerKeepsSingleActiveGoal(t *testing.T) {
+	store := NewMemoryStore()
+	chat := Chat{ID: 90002}
+	texts := []string{fullGoalDescription, "确认", "/goal new", fullGoalDescription, "确认"}
+	batches := make([][]Update, 0, len(texts))
+	for i, text := range texts {
+		batches = append(batches, []Update{{UpdateID: int64(i + 1), Message: &Message{MessageID: int64(i + 1), Chat: chat, Text: text}}})
+	}
+	client := &scriptedClient{updates: batches}
+
+	if err := runWorkerUntilSendCount(t, client, store, len(texts)); err != nil {
+		t.Fatalf("worker run failed: %v", err)
+	}
+
+	user, _ := store.UserByChatID(chat.ID)
+	goals, err := store.ListGoalsByUserID(context.Background(), user.ID)
+	if err != nil {
+		t.Fatalf("list goals failed: %v", err)
+	}
+	if len(goals) != 2 {
+		t.Fatalf("goals=%d, want 2", len(goals))
+	}
+	if goals[0].Status != GoalStatusDraft || goals[1].Status != GoalStatusActive {
+		t.Fatalf("statuses=%q/%q, want only the newest goal active", goals[0].Status, goals[1].Status)
+	}
+}
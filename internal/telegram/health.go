@@ -0,0 +1,63 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+// workerHealth is what the polling loop reports about itself for the health
+// endpoints. The loop beats at the top of every cycle and after every
+// update, so a beat older than a long poll plus backoff means it is stuck.
+type workerHealth struct {
+	heartbeat     atomic.Int64
+	polled        atomic.Bool
+	failureStreak atomic.Int64
+}
+
+func (h *workerHealth) beat(now time.Time) {
+	h.heartbeat.Store(now.UnixNano())
+}
+
+func (h *workerHealth) recordPoll(failureStreak int) {
+	if failureStreak == 0 {
+		h.polled.Store(true)
+	}
+	h.failureStreak.Store(int64(failureStreak))
+}
+
+// CheckHeartbeat fails when the polling loop has not beaten for longer than
+// timeout. Orchestrators should restart the process when it does.
+func (w *Worker) CheckHeartbeat(timeout time.Duration) error {
+	last := time.Unix(0, w.health.heartbeat.Load())
+	if since := w.now().Sub(last); since > timeout {
+		return fmt.Errorf("worker heartbeat is %s old", since.Round(time.Second))
+	}
+	return nil
+}
+
+// CheckPolling fails until the first successful getUpdates, and again once
+// maxFailures cycles in a row have failed.
+func (w *Worker) CheckPolling(maxFailures int) error {
+	if !w.health.polled.Load() {
+		return fmt.Errorf("no successful getUpdates yet")
+	}
+	if streak := w.health.failureStreak.Load(); streak >= int64(maxFailures) {
+		return fmt.Errorf("last %d getUpdates calls failed", streak)
+	}
+	return nil
+}
+
+// CheckOutboxBacklog fails when more than limit messages are waiting to be
+// sent.
+func CheckOutboxBacklog(ctx context.Context, store OutboxStore, limit int64) error {
+	pending, err := store.CountPendingOutgoingMessages(ctx)
+	if err != nil {
+		return err
+	}
+	if pending > limit {
+		return fmt.Errorf("%d pending outgoing messages, limit %d", pending, limit)
+	}
+	return nil
+}
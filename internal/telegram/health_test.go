@@ -0,0 +1,120 @@
+package telegram
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+func TestWorkerHealthChecks(t *testing.T) {
+	now := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	worker := NewWorker(WorkerConfig{Now: func() time.Time { return now }}, &scriptedClient{}, NewMemoryStore(), nil)
+
+	if err := worker.CheckHeartbeat(time.Minute); err != nil {
+		t.Fatalf("fresh worker heartbeat: %v", err)
+	}
+	if err := worker.CheckPolling(3); err == nil {
+		t.Fatal("worker is ready before its first getUpdates")
+	}
+
+	worker.health.recordPoll(0)
+	if err := worker.CheckPolling(3); err != nil {
+		t.Fatalf("after a successful poll: %v", err)
+	}
+	worker.health.recordPoll(2)
+	if err := worker.CheckPolling(3); err != nil {
+		t.Fatalf("after two failures: %v", err)
+	}
+	worker.health.recordPoll(3)
+	if err := worker.CheckPolling(3); err == nil {
+		t.Fatal("still ready after three failed polls in a row")
+	}
+
+	now = now.Add(2 * time.Minute)
+	if err := worker.CheckHeartbeat(time.Minute); err == nil {
+		t.Fatal("stale heartbeat passed")
+	}
+	worker.health.beat(now)
+	if err := worker.CheckHeartbeat(time.Minute); err != nil {
+		t.Fatalf("after a beat: %v", err)
+	}
+}
+
+func TestWorkerRunMarksPollingReady(t *testing.T) {
+	store := NewMemoryStore()
+	client := &scriptedClient{updates: [][]Update{{{UpdateID: 1, Message: &Message{MessageID: 1, Chat: Chat{ID: 94001}, Text: "/help"}}}}}
+	worker := NewWorker(WorkerConfig{PollTimeoutSec: 1}, client, store, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- worker.Run(ctx) }()
+
+	deadline := time.After(2 * time.Second)
+	for worker.CheckPolling(1) != nil {
+		select {
+		case <-deadline:
+			t.Fatal("polling never became ready")
+		case <-time.After(5 * time.Millisecond):
+		}
+	}
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("worker run: %v", err)
+	}
+}
+
+func TestWorkerApplyRuntimeConfigReachesNextPoll(t *testing.T) {
+	client := &scriptedClient{}
+	worker := NewWorker(WorkerConfig{PollTimeoutSec: 1, AllowedUpdates: []string{"message"}}, client, NewMemoryStore(), slog.New(slog.NewTextHandler(io.Discard, nil)))
+	worker.ApplyRuntimeConfig(RuntimeConfig{
+		AllowedUpdates: []string{"message", "edited_message"},
+		RateLimit:      RateLimitConfig{GlobalPerSecond: 5, GlobalBurst: 2},
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- worker.Run(ctx) }()
+	deadline := time.After(2 * time.Second)
+	for {
+		client.mu.Lock()
+		polls := len(client.allowed)
+		client.mu.Unlock()
+		if polls > 0 {
+			break
+		}
+		select {
+		case <-deadline:
+			t.Fatal("worker never polled")
+		case <-time.After(5 * time.Millisecond):
+		}
+	}
+	cancel()
+	<-done
+
+	allowed := client.allowed[0]
+	if len(allowed) != 2 || allowed[1] != "edited_message" {
+		t.Fatalf("allowed updates=%v, want the applied list", allowed)
+	}
+	if rate := worker.limiter.global.rate; rate != 5 {
+		t.Fatalf("global rate=%v, want 5", rate)
+	}
+}
+
+func TestCheckOutboxBacklog(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+	for i := range 3 {
+		if _, err := store.EnqueueOutgoingMessage(ctx, OutgoingMessage{ChatID: int64(i + 1), Text: "hi"}); err != nil {
+			t.Fatalf("enqueue: %v", err)
+		}
+	}
+
+	if err := CheckOutboxBacklog(ctx, store, 3); err != nil {
+		t.Fatalf("backlog at the limit: %v", err)
+	}
+	if err := CheckOutboxBacklog(ctx, store, 2); err == nil {
+		t.Fatal("backlog over the limit passed")
+	}
+}
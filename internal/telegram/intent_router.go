@@ -1,14 +1,27 @@
 package telegram
 
-import "strings"
+import (
+	"strings"
+	"unicode/utf8"
 
-type IntentRouter struct{}
+	"github.com/congregalis/aiden/internal/i18n"
+)
 
-func NewIntentRouter() IntentRouter {
-	return IntentRouter{}
+type IntentRouter struct {
+	rules *RuleSet
+}
+
+// NewIntentRouter classifies free text with the live rules in set, or with
+// the embedded defaults when set is nil.
+func NewIntentRouter(rules *RuleSet) IntentRouter {
+	return IntentRouter{rules: rules}
 }
 
 func (r IntentRouter) Route(text string, state PlanningState) IntentResult {
+	if control, ok := r.RouteControl(text); ok {
+		return control
+	}
+
 	command := ParseCommand(text)
 	if command.IsCommand {
 		switch command.Name {
@@ -26,56 +39,201 @@ func (r IntentRouter) Route(text string, state PlanningState) IntentResult {
 		return IntentResult{Intent: IntentFallbackUnknown, Confidence: 0.1}
 	}
 
-	if containsAny(trimmed, confirmSignals) {
-		return IntentResult{Intent: IntentConfirmPlan, Confidence: 0.92}
+	result, ok := r.rules.Current().ClassifyIntent(trimmed, state)
+	if ok {
+		return result
 	}
 
-	if containsAny(trimmed, clarifySignals) || len([]rune(trimmed)) >= 8 {
-		return IntentResult{Intent: IntentClarifyGoal, Confidence: 0.78}
+	result.Intent = IntentFallbackUnknown
+	result.Confidence = 0.35
+	if state == StateReview {
+		result.Confidence = 0.45
 	}
+	return result
+}
 
-	if state == StateReview {
-		return IntentResult{Intent: IntentFallbackUnknown, Confidence: 0.45}
+// RouteControl recognises the session control commands and their short
+// natural-language forms. Longer sentences are left to clarification so that
+// a goal like "我想重新开始学钢琴" is not taken as /reset.
+func (r IntentRouter) RouteControl(text string) (IntentResult, bool) {
+	command := ParseCommand(text)
+	if command.IsCommand {
+		switch command.Name {
+		case "status":
+			return IntentResult{Intent: IntentShowStatus, Confidence: 1}, true
+		case "reset":
+			return IntentResult{Intent: IntentResetSession, Confidence: 1}, true
+		case "undo":
+			return IntentResult{Intent: IntentUndoTurn, Confidence: 1}, true
+		case "skip":
+			result := IntentResult{Intent: IntentSkipSlot, Confidence: 1}
+			if len(command.Args) > 0 {
+				result.Slot, _ = ParseSlotKey(strings.Join(command.Args, " "))
+			}
+			return result, true
+		default:
+			return IntentResult{}, false
+		}
+	}
+
+	trimmed := strings.TrimRight(strings.TrimSpace(text), "。.!！?？~～")
+	switch {
+	case statusSignals.matches(trimmed):
+		return IntentResult{Intent: IntentShowStatus, Confidence: 0.9}, true
+	case resetSignals.matches(trimmed):
+		return IntentResult{Intent: IntentResetSession, Confidence: 0.9}, true
+	case undoSignals.matches(trimmed):
+		return IntentResult{Intent: IntentUndoTurn, Confidence: 0.9}, true
+	}
+	if slot, ok := parseSkipPhrase(trimmed); ok {
+		return IntentResult{Intent: IntentSkipSlot, Confidence: 0.85, Slot: slot}, true
+	}
+	return IntentResult{}, false
+}
+
+// keywordSet holds keywords per locale. Matching always uses every locale so
+// that users who mix languages are still understood.
+type keywordSet map[string][]string
+
+func (k keywordSet) contains(text string) bool {
+	for _, keywords := range k {
+		if containsAny(text, keywords) {
+			return true
+		}
+	}
+	return false
+}
+
+func (k keywordSet) matches(text string) bool {
+	for _, phrases := range k {
+		if matchesAny(text, phrases) {
+			return true
+		}
 	}
+	return false
+}
+
+var statusSignals = keywordSet{
+	i18n.ZhCN: {"状态", "当前状态", "进度", "当前进度", "查看进度", "现在到哪了"},
+	i18n.En:   {"status", "progress", "show status", "show progress", "where are we"},
+}
+
+var resetSignals = keywordSet{
+	i18n.ZhCN: {"重置", "重来", "重新开始", "从头开始"},
+	i18n.En:   {"reset", "restart", "start over", "start again"},
+}
+
+var undoSignals = keywordSet{
+	i18n.ZhCN: {"撤销", "撤回", "撤销上一条", "上一条说错了"},
+	i18n.En:   {"undo", "undo that", "take that back"},
+}
+
+var skipPrefixes = keywordSet{
+	i18n.ZhCN: {"跳过"},
+	i18n.En:   {"skip"},
+}
 
-	return IntentResult{Intent: IntentFallbackUnknown, Confidence: 0.35}
+// parseSkipPhrase recognises "跳过风险项" or "skip risks". The prefix must be
+// followed by a known slot, or stand alone, so "跳过早餐也能学习" and
+// "skipping breakfast" are still treated as clarification. A Latin prefix
+// also needs a space before the slot; a Chinese one may be followed by the
+// start of a slot label, as in "跳过风险".
+func parseSkipPhrase(text string) (string, bool) {
+	lower := strings.ToLower(text)
+	for lang, prefixes := range skipPrefixes {
+		for _, prefix := range prefixes {
+			if !strings.HasPrefix(lower, prefix) {
+				continue
+			}
+			rest := strings.TrimSpace(lower[len(prefix):])
+			if rest == "" {
+				return "", true
+			}
+			slot, known := ParseSlotKey(rest)
+			if !known && lang == i18n.ZhCN {
+				slot, known = slotByLabelPrefix(lang, rest)
+			}
+			if known && (lang == i18n.ZhCN || lower[len(prefix)] == ' ') {
+				return slot, true
+			}
+		}
+	}
+	return "", false
 }
 
-var confirmSignals = []string{
-	"确认",
-	"同意",
-	"就这样",
-	"没问题",
-	"可以开始",
-	"开始执行",
-	"ok",
-	"yes",
-	"confirm",
+// slotByLabelPrefix finds the slot whose label starts with prefix, which must
+// be at least two runes long.
+func slotByLabelPrefix(lang, prefix string) (string, bool) {
+	if utf8.RuneCountInString(prefix) < 2 {
+		return "", false
+	}
+	for _, key := range requiredSlotOrder {
+		if strings.HasPrefix(SlotLabel(lang, key), prefix) {
+			return key, true
+		}
+	}
+	return "", false
 }
 
-var clarifySignals = []string{
-	"目标",
-	"我想",
-	"计划",
-	"每周",
-	"小时",
-	"分钟",
-	"约束",
-	"限制",
-	"水平",
-	"标准",
-	"修改",
-	"调整",
-	"优化",
-	"补充",
+func matchesAny(text string, phrases []string) bool {
+	lower := strings.ToLower(text)
+	for _, phrase := range phrases {
+		if lower == strings.ToLower(phrase) {
+			return true
+		}
+	}
+	return false
 }
 
 func containsAny(text string, keywords []string) bool {
 	lower := strings.ToLower(text)
 	for _, keyword := range keywords {
-		if strings.Contains(lower, strings.ToLower(keyword)) {
+		if containsKeyword(lower, strings.ToLower(keyword)) {
 			return true
 		}
 	}
 	return false
 }
+
+// containsKeyword matches CJK keywords anywhere but Latin keywords only on
+// word boundaries, so "ok" does not match "book".
+func containsKeyword(text, keyword string) bool {
+	return matchKeyword(text, keyword, nil)
+}
+
+// matchKeyword is containsKeyword that also skips occurrences for which
+// negated, when set, reports true given the text before the occurrence.
+func matchKeyword(text, keyword string, negated func(before string) bool) bool {
+	ascii := isASCII(keyword)
+	for offset := 0; offset < len(text); {
+		idx := strings.Index(text[offset:], keyword)
+		if idx < 0 {
+			return false
+		}
+		start := offset + idx
+		end := start + len(keyword)
+		if (!ascii || isWordBoundary(text, start-1) && isWordBoundary(text, end)) &&
+			(negated == nil || !negated(text[:start])) {
+			return true
+		}
+		offset = start + 1
+	}
+	return false
+}
+
+func isWordBoundary(text string, index int) bool {
+	if index < 0 || index >= len(text) {
+		return true
+	}
+	c := text[index]
+	return !(c >= 'a' && c <= 'z' || c >= '0' && c <= '9' || c == '_')
+}
+
+func isASCII(text string) bool {
+	for i := 0; i < len(text); i++ {
+		if text[i] >= 0x80 {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,55 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/congregalis/aiden/internal/i18n"
+)
+
+// handleLanguageCommand shows or changes users.language: /lang, /lang en,
+// /lang zh. The confirmation is already written in the new language.
+func (w *Worker) handleLanguageCommand(ctx context.Context, store Store, user User, command Command) (string, error) {
+	if len(command.Args) == 0 {
+		return tr(user.Language, MsgLangCurrent, i18n.DisplayName(user.Language)), nil
+	}
+
+	lang, ok := i18n.Normalize(strings.Join(command.Args, " "))
+	if !ok {
+		return tr(user.Language, MsgLangUsage), nil
+	}
+	if lang != user.Language {
+		if err := store.SetUserLanguage(ctx, user.ID, lang); err != nil {
+			return "", fmt.Errorf("set user language: %w", err)
+		}
+		w.logger.Info("user_language_updated",
+			slog.String("user_id", user.ID),
+			slog.String("language", lang),
+		)
+	}
+	return tr(lang, MsgLangUpdated, i18n.DisplayName(lang)), nil
+}
+
+// adoptClientLanguage switches a newly registered user to their Telegram
+// client language when it is supported.
+func adoptClientLanguage(ctx context.Context, store Store, user User, message IncomingMessage) (User, error) {
+	lang, ok := i18n.Normalize(message.LanguageCode)
+	if !ok || lang == user.Language {
+		return user, nil
+	}
+	if err := store.SetUserLanguage(ctx, user.ID, lang); err != nil {
+		return User{}, fmt.Errorf("set user language: %w", err)
+	}
+	user.Language = lang
+	return user, nil
+}
+
+// languageHint picks a reply language before the sender is looked up.
+func languageHint(message IncomingMessage) string {
+	if lang, ok := i18n.Normalize(message.LanguageCode); ok {
+		return lang
+	}
+	return i18n.DefaultLocale
+}
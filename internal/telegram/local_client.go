@@ -0,0 +1,158 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+var errLocalNoFiles = errors.New("telegram: local client has no files")
+
+// LocalClient is an in-process Client: messages typed with Send become
+// updates for the worker, and everything the worker sends is delivered to
+// Replies. It lets the real Worker run without a bot token.
+type LocalClient struct {
+	mu            sync.Mutex
+	pending       []Update
+	wake          chan struct{}
+	replies       chan OutgoingMessage
+	nextUpdateID  int64
+	nextMessageID int64
+	lastByChat    map[int64]Message
+	documents     []OutgoingDocument
+}
+
+func NewLocalClient() *LocalClient {
+	return &LocalClient{
+		wake:       make(chan struct{}, 1),
+		replies:    make(chan OutgoingMessage, 64),
+		lastByChat: make(map[int64]Message),
+	}
+}
+
+// Send queues a text message from chatID. languageCode mimics the Telegram
+// client language and may be empty.
+func (c *LocalClient) Send(chatID int64, languageCode, text string) {
+	c.mu.Lock()
+	c.nextMessageID++
+	message := Message{
+		MessageID: c.nextMessageID,
+		From:      &TelegramUser{ID: chatID, FirstName: "local", LanguageCode: languageCode},
+		Chat:      Chat{ID: chatID, Type: "private"},
+		Text:      text,
+	}
+	c.lastByChat[chatID] = message
+	c.enqueueLocked(Update{Message: &message})
+	c.mu.Unlock()
+}
+
+// Edit replaces the text of the last message sent from chatID, as if the
+// user edited it in Telegram. It reports false when there is nothing to edit.
+func (c *LocalClient) Edit(chatID int64, text string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	last, ok := c.lastByChat[chatID]
+	if !ok {
+		return false
+	}
+	last.Text = text
+	c.lastByChat[chatID] = last
+	c.enqueueLocked(Update{EditedMessage: &last})
+	return true
+}
+
+func (c *LocalClient) enqueueLocked(update Update) {
+	c.nextUpdateID++
+	update.UpdateID = c.nextUpdateID
+	c.pending = append(c.pending, update)
+	select {
+	case c.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Replies delivers the messages the worker sends, in order.
+func (c *LocalClient) Replies() <-chan OutgoingMessage {
+	return c.replies
+}
+
+func (c *LocalClient) GetMe(context.Context) (BotUser, error) {
+	return BotUser{ID: 1, IsBot: true, FirstName: "Aiden", Username: "aiden_local_bot"}, nil
+}
+
+// GetUpdates long-polls like the Bot API: it returns updates after the
+// offset, or none once TimeoutSec passes.
+func (c *LocalClient) GetUpdates(ctx context.Context, params GetUpdatesParams) ([]Update, error) {
+	timeout := time.NewTimer(time.Duration(params.TimeoutSec) * time.Second)
+	defer timeout.Stop()
+
+	for {
+		c.mu.Lock()
+		kept := c.pending[:0]
+		for _, update := range c.pending {
+			if update.UpdateID >= params.Offset {
+				kept = append(kept, update)
+			}
+		}
+		c.pending = kept
+		if len(kept) > 0 {
+			updates := append([]Update(nil), kept...)
+			c.mu.Unlock()
+			return updates, nil
+		}
+		c.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-timeout.C:
+			return nil, nil
+		case <-c.wake:
+		}
+	}
+}
+
+func (c *LocalClient) SendMessage(ctx context.Context, message OutgoingMessage) (Message, error) {
+	select {
+	case c.replies <- message:
+	case <-ctx.Done():
+		return Message{}, ctx.Err()
+	}
+
+	c.mu.Lock()
+	c.nextMessageID++
+	id := c.nextMessageID
+	c.mu.Unlock()
+	return Message{MessageID: id, Chat: Chat{ID: message.ChatID, Type: "private"}, Text: message.Text}, nil
+}
+
+// SendDocument keeps the file for Documents and delivers its caption to
+// Replies with a line naming the file.
+func (c *LocalClient) SendDocument(ctx context.Context, document OutgoingDocument) (Message, error) {
+	c.mu.Lock()
+	c.documents = append(c.documents, document)
+	c.mu.Unlock()
+
+	text := fmt.Sprintf("[%s, %d bytes]", document.FileName, len(document.Content))
+	if document.Caption != "" {
+		text += "\n" + document.Caption
+	}
+	return c.SendMessage(ctx, OutgoingMessage{ChatID: document.ChatID, Text: text, ReplyToMessageID: document.ReplyToMessageID})
+}
+
+// Documents returns every file the worker has sent so far.
+func (c *LocalClient) Documents() []OutgoingDocument {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]OutgoingDocument(nil), c.documents...)
+}
+
+func (c *LocalClient) GetFile(context.Context, string) (File, error) {
+	return File{}, errLocalNoFiles
+}
+
+func (c *LocalClient) DownloadFile(context.Context, File, int64) ([]byte, error) {
+	return nil, errLocalNoFiles
+}
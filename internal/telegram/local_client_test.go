@@ -0,0 +1,70 @@
+package telegram
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestLocalClientRunsWorkerWithFakeClock(t *testing.T) {
+	var (
+		mu     sync.Mutex
+		offset time.Duration
+	)
+	now := func() time.Time {
+		mu.Lock()
+		defer mu.Unlock()
+		return time.Now().Add(offset)
+	}
+	store := NewMemoryStore()
+	store.SetClock(now)
+	client := NewLocalClient()
+	worker := NewWorker(WorkerConfig{
+		PollTimeoutSec: 1,
+		Session:        SessionConfig{Timeout: time.Hour},
+		Now:            now,
+	}, client, store, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- worker.Run(ctx) }()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	receive := func() OutgoingMessage {
+		t.Helper()
+		select {
+		case reply := <-client.Replies():
+			return reply
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for a reply")
+			return OutgoingMessage{}
+		}
+	}
+
+	client.Send(3001, "en", "/status")
+	if reply := receive(); reply.ChatID != 3001 || reply.Text == "" {
+		t.Fatalf("reply=%+v", reply)
+	}
+
+	client.Send(3001, "en", "/goal")
+	receive()
+
+	mu.Lock()
+	offset = 2 * time.Hour
+	mu.Unlock()
+	client.Send(3001, "en", "I want to learn to swim")
+	if reply := receive(); !strings.Contains(reply.Text, "Welcome back") {
+		t.Fatalf("reply after clock jump=%q, want a recap", reply.Text)
+	}
+
+	if client.Edit(3002, "hello") {
+		t.Fatal("edit succeeded for a chat that never sent a message")
+	}
+}
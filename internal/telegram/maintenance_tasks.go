@@ -0,0 +1,172 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/congregalis/aiden/internal/maintenance"
+)
+
+// MinDedupHorizon keeps dedup rows past Telegram's 24h redelivery window,
+// with an hour to spare for clock skew and slow restarts.
+const MinDedupHorizon = 25 * time.Hour
+
+type MaintenanceConfig struct {
+	// DedupHorizon is how long message_dedup rows are kept.
+	DedupHorizon time.Duration
+	// ActionLogHorizon is how long agent_action_logs rows are kept; 0 keeps
+	// them forever.
+	ActionLogHorizon time.Duration
+	// ConfirmationTTL is how long a pending /reset confirmation stays armed.
+	ConfirmationTTL time.Duration
+	// SessionTimeout is the configured session timeout. Users can raise
+	// theirs with /timeout; sessions still within their owner's timeout are
+	// never touched.
+	SessionTimeout time.Duration
+	// ArchiveAfter archives draft goals whose planning session has been idle
+	// this long; 0 keeps them.
+	ArchiveAfter time.Duration
+	Retention    RetentionConfig
+}
+
+// MaintenanceStore is what the cleanup tasks need from storage.
+type MaintenanceStore interface {
+	DeleteMessageDedupBefore(ctx context.Context, cutoff time.Time, limit int) (int64, error)
+	DeleteActionLogsBefore(ctx context.Context, cutoff time.Time, limit int) (int64, error)
+	ClearExpiredConfirmations(ctx context.Context, resetBefore, deletionBefore time.Time, limit int) (int64, error)
+	RedactConversationTurns(ctx context.Context, cutoff time.Time, sessionTimeout time.Duration, limit int, redact TurnRedactor) (int, error)
+	ArchiveIdleDraftGoals(ctx context.Context, cutoff time.Time, sessionTimeout time.Duration, limit int) (int64, error)
+}
+
+// MaintenanceTasks lists the periodic cleanups for maintenance.Runner.
+// Tasks whose horizon is 0 are left out.
+func MaintenanceTasks(store MaintenanceStore, cfg MaintenanceConfig) []maintenance.Task {
+	dedupHorizon := max(cfg.DedupHorizon, MinDedupHorizon)
+	tasks := []maintenance.Task{
+		{
+			Name: "message_dedup",
+			Batch: func(ctx context.Context, now time.Time, limit int) (int64, error) {
+				return store.DeleteMessageDedupBefore(ctx, now.Add(-dedupHorizon), limit)
+			},
+		},
+		{
+			Name: "expired_confirmations",
+			Batch: func(ctx context.Context, now time.Time, limit int) (int64, error) {
+				return store.ClearExpiredConfirmations(ctx, now.Add(-cfg.ConfirmationTTL), now.Add(-deletionConfirmWindow), limit)
+			},
+		},
+	}
+	if cfg.ActionLogHorizon > 0 {
+		tasks = append(tasks, maintenance.Task{
+			Name: "agent_action_logs",
+			Batch: func(ctx context.Context, now time.Time, limit int) (int64, error) {
+				return store.DeleteActionLogsBefore(ctx, now.Add(-cfg.ActionLogHorizon), limit)
+			},
+		})
+	}
+	if cfg.ArchiveAfter > 0 {
+		tasks = append(tasks, maintenance.Task{
+			Name: "idle_goals",
+			Batch: func(ctx context.Context, now time.Time, limit int) (int64, error) {
+				return store.ArchiveIdleDraftGoals(ctx, now.Add(-cfg.ArchiveAfter), cfg.SessionTimeout, limit)
+			},
+		})
+	}
+	if cfg.Retention.TextTTL > 0 {
+		redact := NewTurnRedactor(cfg.Retention.HashSalt, cfg.Retention.Rules)
+		tasks = append(tasks, maintenance.Task{
+			Name: "turn_redaction",
+			Batch: func(ctx context.Context, now time.Time, limit int) (int64, error) {
+				redacted, err := store.RedactConversationTurns(ctx, now.Add(-cfg.Retention.TextTTL), cfg.SessionTimeout, limit, redact)
+				return int64(redacted), err
+			},
+		})
+	}
+	return tasks
+}
+
+func (s *SQLStore) DeleteMessageDedupBefore(ctx context.Context, cutoff time.Time, limit int) (int64, error) {
+	deleted, err := s.execCount(
+		ctx,
+		`DELETE FROM message_dedup
+		 WHERE update_id IN (
+		     SELECT update_id
+		     FROM message_dedup
+		     WHERE received_at < $1
+		     ORDER BY received_at
+		     LIMIT $2
+		 )`,
+		cutoff,
+		limit,
+	)
+	if err != nil {
+		return deleted, fmt.Errorf("delete message dedup rows: %w", err)
+	}
+	return deleted, nil
+}
+
+func (s *SQLStore) DeleteActionLogsBefore(ctx context.Context, cutoff time.Time, limit int) (int64, error) {
+	deleted, err := s.execCount(
+		ctx,
+		`DELETE FROM agent_action_logs
+		 WHERE id IN (
+		     SELECT id
+		     FROM agent_action_logs
+		     WHERE created_at < $1
+		     ORDER BY created_at
+		     LIMIT $2
+		 )`,
+		cutoff,
+		limit,
+	)
+	if err != nil {
+		return deleted, fmt.Errorf("delete agent action logs: %w", err)
+	}
+	return deleted, nil
+}
+
+// ClearExpiredConfirmations disarms /reset confirmations whose session has
+// not moved since resetBefore and /deleteme requests made before
+// deletionBefore. Neither touches updated_at, so session timeouts are not
+// affected. limit applies to each kind separately, so the sum only drops
+// below limit once both are done.
+func (s *SQLStore) ClearExpiredConfirmations(ctx context.Context, resetBefore, deletionBefore time.Time, limit int) (int64, error) {
+	resets, err := s.execCount(
+		ctx,
+		`UPDATE planning_sessions
+		 SET pending_action = ''
+		 WHERE id IN (
+		     SELECT id
+		     FROM planning_sessions
+		     WHERE pending_action <> '' AND updated_at < $1
+		     LIMIT $2
+		     FOR UPDATE SKIP LOCKED
+		 )`,
+		resetBefore,
+		limit,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("clear expired pending actions: %w", err)
+	}
+
+	deletions, err := s.execCount(
+		ctx,
+		`UPDATE users
+		 SET deletion_requested_at = NULL,
+		     deletion_confirmations = 0
+		 WHERE id IN (
+		     SELECT id
+		     FROM users
+		     WHERE deletion_requested_at < $1
+		     LIMIT $2
+		     FOR UPDATE SKIP LOCKED
+		 )`,
+		deletionBefore,
+		limit,
+	)
+	if err != nil {
+		return resets, fmt.Errorf("clear expired deletion requests: %w", err)
+	}
+	return resets + deletions, nil
+}
@@ -0,0 +1,155 @@
+package telegram
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/congregalis/aiden/internal/maintenance"
+)
+
+func runMaintenanceTask(t *testing.T, tasks []maintenance.Task, name string, now time.Time, limit int) int64 {
+	t.Helper()
+	for _, task := range tasks {
+		if task.Name != name {
+			continue
+		}
+		var total int64
+		for {
+			n, err := task.Batch(context.Background(), now, limit)
+			if err != nil {
+				t.Fatalf("%s: %v", name, err)
+			}
+			total += n
+			if n < int64(limit) {
+				return total
+			}
+		}
+	}
+	t.Fatalf("no task named %q", name)
+	return 0
+}
+
+func TestMaintenanceTasksOnlyIncludeEnabledCleanups(t *testing.T) {
+	names := func(tasks []maintenance.Task) []string {
+		out := make([]string, 0, len(tasks))
+		for _, task := range tasks {
+			out = append(out, task.Name)
+		}
+		return out
+	}
+
+	got := names(MaintenanceTasks(NewMemoryStore(), MaintenanceConfig{DedupHorizon: 72 * time.Hour, ConfirmationTTL: time.Hour}))
+	if len(got) != 2 || got[0] != "message_dedup" || got[1] != "expired_confirmations" {
+		t.Fatalf("tasks=%v", got)
+	}
+	got = names(MaintenanceTasks(NewMemoryStore(), MaintenanceConfig{
+		ActionLogHorizon: time.Hour,
+		ArchiveAfter:     time.Hour,
+		Retention:        RetentionConfig{TextTTL: time.Hour, HashSalt: "salt"},
+	}))
+	if len(got) != 5 || got[2] != "agent_action_logs" || got[3] != "idle_goals" || got[4] != "turn_redaction" {
+		t.Fatalf("tasks=%v", got)
+	}
+}
+
+func TestMaintenanceDeletesDedupPastHorizon(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+	now := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	store.SetClock(func() time.Time { return now })
+	for updateID := int64(1); updateID <= 3; updateID++ {
+		if _, err := store.MarkMessageDedup(ctx, updateID, 42); err != nil {
+			t.Fatalf("mark dedup: %v", err)
+		}
+	}
+	now = now.Add(48 * time.Hour)
+	if _, err := store.MarkMessageDedup(ctx, 4, 42); err != nil {
+		t.Fatalf("mark dedup: %v", err)
+	}
+
+	// A horizon inside Telegram's redelivery window is raised to the minimum.
+	tasks := MaintenanceTasks(store, MaintenanceConfig{DedupHorizon: time.Hour})
+	if deleted := runMaintenanceTask(t, tasks, "message_dedup", now, 2); deleted != 3 {
+		t.Fatalf("deleted=%d, want 3", deleted)
+	}
+	if store.DedupCount() != 1 {
+		t.Fatalf("dedup rows left=%d, want 1", store.DedupCount())
+	}
+	if isNew, _ := store.MarkMessageDedup(ctx, 4, 42); isNew {
+		t.Fatal("recent update lost its dedup row")
+	}
+}
+
+func TestMaintenanceClearsExpiredConfirmations(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+	now := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	store.SetClock(func() time.Time { return now })
+
+	user, _, _ := store.FindOrCreateUserByChatID(ctx, 42)
+	goal, _ := store.CreateGoalDraft(ctx, user.ID)
+	session, _, _ := store.GetOrCreatePlanningSession(ctx, goal.ID)
+	session.PendingAction = PendingActionReset
+	if err := store.UpdatePlanningSession(ctx, session); err != nil {
+		t.Fatalf("update session: %v", err)
+	}
+	requestedAt := now
+	if err := store.SetUserDeletionRequest(ctx, user.ID, &requestedAt, 1); err != nil {
+		t.Fatalf("set deletion request: %v", err)
+	}
+
+	tasks := MaintenanceTasks(store, MaintenanceConfig{ConfirmationTTL: time.Hour})
+	if cleared := runMaintenanceTask(t, tasks, "expired_confirmations", now.Add(5*time.Minute), 10); cleared != 0 {
+		t.Fatalf("cleared=%d before anything expired", cleared)
+	}
+	if cleared := runMaintenanceTask(t, tasks, "expired_confirmations", now.Add(2*time.Hour), 10); cleared != 2 {
+		t.Fatalf("cleared=%d, want the reset and the deletion request", cleared)
+	}
+
+	session, _, _ = store.GetOrCreatePlanningSession(ctx, goal.ID)
+	user, _ = store.UserByChatID(42)
+	if session.PendingAction != "" || user.DeletionRequestedAt != nil || user.DeletionConfirmations != 0 {
+		t.Fatalf("session=%+v user=%+v, want both disarmed", session, user)
+	}
+}
+
+func TestMaintenanceRedactsOldTurns(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+	now := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	store.SetClock(func() time.Time { return now })
+
+	for _, content := range []string{"电话 13800138000", "每周 5 小时"} {
+		if err := store.SaveConversationTurn(ctx, ConversationTurn{SessionID: "session-1", Role: "user", Content: content}); err != nil {
+			t.Fatalf("save turn: %v", err)
+		}
+	}
+	now = now.Add(20 * 24 * time.Hour)
+	if err := store.SaveConversationTurn(ctx, ConversationTurn{SessionID: "session-1", Role: "user", Content: "recent"}); err != nil {
+		t.Fatalf("save turn: %v", err)
+	}
+	now = now.Add(15 * 24 * time.Hour)
+
+	tasks := MaintenanceTasks(store, MaintenanceConfig{Retention: RetentionConfig{TextTTL: 30 * 24 * time.Hour, HashSalt: "salt"}})
+	if redacted := runMaintenanceTask(t, tasks, "turn_redaction", now, 1); redacted != 2 {
+		t.Fatalf("redacted=%d, want 2", redacted)
+	}
+
+	turns, _ := store.ListUserTurns(ctx, "session-1")
+	want := []string{"电话 <phone>", "每周 5 小时", "recent"}
+	for i, turn := range turns {
+		if turn.Content != want[i] {
+			t.Fatalf("turn %d content=%q, want %q", i, turn.Content, want[i])
+		}
+	}
+	if _, ok := store.TurnContentHash(turns[0].ID); !ok {
+		t.Fatal("redacted turn has no hash")
+	}
+	if _, ok := store.TurnContentHash(turns[2].ID); ok {
+		t.Fatal("recent turn was redacted")
+	}
+	if redacted := runMaintenanceTask(t, tasks, "turn_redaction", now, 1); redacted != 0 {
+		t.Fatalf("second run redacted=%d, want nothing left", redacted)
+	}
+}
@@ -0,0 +1,208 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"path"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const AttachmentKindDocument = "document"
+
+const (
+	defaultMaxDownloadBytes   = 20 << 20
+	maxAttachmentContentBytes = 64 << 10
+	voiceTranscriptionTimeout = 30 * time.Second
+	documentDownloadTimeout   = 30 * time.Second
+	documentMimeTypePDF       = "application/pdf"
+	documentMimeTypePlainText = "text/plain"
+	documentMimeTypeMarkdown  = "text/markdown"
+)
+
+var ErrTranscriptionUnavailable = errors.New("transcription unavailable")
+
+type VoiceNote struct {
+	Data     []byte
+	MimeType string
+	Duration time.Duration
+}
+
+// Transcriber turns a voice note into text that can enter the clarify flow.
+type Transcriber interface {
+	Transcribe(context.Context, VoiceNote) (string, error)
+}
+
+// StubTranscriber is a local stand-in for a speech-to-text provider. It returns
+// Text for every voice note, or ErrTranscriptionUnavailable when Text is empty.
+type StubTranscriber struct {
+	Text string
+}
+
+func NewStubTranscriber(text string) StubTranscriber {
+	return StubTranscriber{Text: strings.TrimSpace(text)}
+}
+
+func (t StubTranscriber) Transcribe(context.Context, VoiceNote) (string, error) {
+	if t.Text == "" {
+		return "", ErrTranscriptionUnavailable
+	}
+	return t.Text, nil
+}
+
+type GoalAttachment struct {
+	ID                   string
+	GoalID               string
+	Kind                 string
+	TelegramFileID       string
+	TelegramFileUniqueID string
+	FileName             string
+	MimeType             string
+	FileSize             int64
+	ContentText          string
+}
+
+func isSupportedDocument(document *Document) bool {
+	if document == nil {
+		return false
+	}
+
+	mimeType := strings.ToLower(strings.TrimSpace(document.MimeType))
+	switch mimeType {
+	case documentMimeTypePDF, documentMimeTypePlainText, documentMimeTypeMarkdown:
+		return true
+	}
+
+	switch strings.ToLower(path.Ext(document.FileName)) {
+	case ".pdf", ".txt", ".md":
+		return true
+	default:
+		return false
+	}
+}
+
+func isPlainTextDocument(document *Document) bool {
+	mimeType := strings.ToLower(strings.TrimSpace(document.MimeType))
+	if strings.HasPrefix(mimeType, "text/") {
+		return true
+	}
+	ext := strings.ToLower(path.Ext(document.FileName))
+	return ext == ".txt" || ext == ".md"
+}
+
+// prepareMedia resolves voice notes and documents before the update is
+// processed, so no network calls happen while the store transaction is open.
+func (w *Worker) prepareMedia(ctx context.Context, message IncomingMessage) IncomingMessage {
+	switch message.Kind {
+	case MessageKindVoice:
+		if message.Voice == nil {
+			return message
+		}
+		text, err := w.transcribeVoice(ctx, message.Voice)
+		if err != nil {
+			// A caption still carries the message without the transcript.
+			w.logger.Warn("voice_transcription_failed",
+				slog.Int64("update_id", message.UpdateID),
+				slog.String("chat_id_masked", MaskChatID(message.ChatID)),
+				slog.Any("error", err),
+			)
+			return message
+		}
+		message.Transcript = text
+		switch caption := strings.TrimSpace(message.Text); {
+		case caption == "":
+			message.Text = text
+		case text != "":
+			message.Text = caption + "\n" + text
+		}
+
+	case MessageKindDocument:
+		if !isSupportedDocument(message.Document) {
+			return message
+		}
+		attachment, err := w.buildDocumentAttachment(ctx, message.Document)
+		if err != nil {
+			w.logger.Warn("document_attachment_failed",
+				slog.Int64("update_id", message.UpdateID),
+				slog.String("chat_id_masked", MaskChatID(message.ChatID)),
+				slog.Any("error", err),
+			)
+			return message
+		}
+		message.Attachment = &attachment
+	}
+
+	return message
+}
+
+func (w *Worker) transcribeVoice(ctx context.Context, voice *Voice) (string, error) {
+	if w.transcriber == nil {
+		return "", ErrTranscriptionUnavailable
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, voiceTranscriptionTimeout)
+	defer cancel()
+
+	file, err := w.client.GetFile(ctx, voice.FileID)
+	if err != nil {
+		return "", fmt.Errorf("get voice file: %w", err)
+	}
+	data, err := w.client.DownloadFile(ctx, file, w.maxDownloadBytes)
+	if err != nil {
+		return "", fmt.Errorf("download voice file: %w", err)
+	}
+
+	text, err := w.transcriber.Transcribe(ctx, VoiceNote{
+		Data:     data,
+		MimeType: voice.MimeType,
+		Duration: time.Duration(voice.Duration) * time.Second,
+	})
+	if err != nil {
+		return "", fmt.Errorf("transcribe voice: %w", err)
+	}
+
+	return strings.TrimSpace(text), nil
+}
+
+func (w *Worker) buildDocumentAttachment(ctx context.Context, document *Document) (GoalAttachment, error) {
+	attachment := GoalAttachment{
+		Kind:                 AttachmentKindDocument,
+		TelegramFileID:       document.FileID,
+		TelegramFileUniqueID: document.FileUniqueID,
+		FileName:             document.FileName,
+		MimeType:             document.MimeType,
+		FileSize:             document.FileSize,
+	}
+	if !isPlainTextDocument(document) {
+		return attachment, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, documentDownloadTimeout)
+	defer cancel()
+
+	file, err := w.client.GetFile(ctx, document.FileID)
+	if err != nil {
+		return GoalAttachment{}, fmt.Errorf("get document file: %w", err)
+	}
+	data, err := w.client.DownloadFile(ctx, file, w.maxDownloadBytes)
+	if err != nil {
+		return GoalAttachment{}, fmt.Errorf("download document file: %w", err)
+	}
+
+	attachment.ContentText = truncateUTF8(string(data), maxAttachmentContentBytes)
+	return attachment, nil
+}
+
+func truncateUTF8(text string, maxBytes int) string {
+	if len(text) <= maxBytes {
+		return strings.ToValidUTF8(text, "")
+	}
+	cut := maxBytes
+	for cut > 0 && !utf8.RuneStart(text[cut]) {
+		cut--
+	}
+	return strings.ToValidUTF8(text[:cut], "")
+}
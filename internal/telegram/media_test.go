@@ -0,0 +1,193 @@
+package telegram
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestMapUpdateUsesCaptionAsText(t *testing.T) {
+	incoming, ok := MapUpdateToIncomingMessage(Update{
+		UpdateID: 1,
+		Message: &Message{
+			MessageID: 2,
+			Chat:      Chat{ID: 3},
+			Caption:   "我想三个月内学会游泳",
+			Photo:     []PhotoSize{{FileID: "photo-1"}},
+		},
+	})
+	if !ok {
+		t.Fatalf("expected update to map")
+	}
+	if incoming.Kind != MessageKindPhoto {
+		t.Fatalf("kind=%q, want %q", incoming.Kind, MessageKindPhoto)
+	}
+	if incoming.Text != "我想三个月内学会游泳" {
+		t.Fatalf("text=%q, want caption", incoming.Text)
+	}
+}
+
+func TestWorkerTranscribesVoiceIntoClarifyFlow(t *testing.T) {
+	store := NewMemoryStore()
+	client := &scriptedClient{
+		updates: [][]Update{{
+			{UpdateID: 1, Message: &Message{
+				MessageID: 11,
+				Chat:      Chat{ID: 60001},
+				Voice:     &Voice{FileID: "voice-1", FileUniqueID: "voice-u1", Duration: 3, MimeType: "audio/ogg"},
+			}},
+		}},
+		files: map[string][]byte{"voice-1": []byte("ogg")},
+	}
+
+	cfg := WorkerConfig{Transcriber: NewStubTranscriber("我想在六个月内跑完半程马拉松")}
+	if err := runWorkerWithConfigUntilSendCount(t, cfg, client, store, 1); err != nil {
+		t.Fatalf("worker run failed: %v", err)
+	}
+
+	reply := client.SentMessages()[0].Text
+	if !strings.HasPrefix(reply, "（语音识别：我想在六个月内跑完半程马拉松）") {
+		t.Fatalf("reply=%q, want transcript prefix", reply)
+	}
+	if _, ok := store.UserByChatID(60001); !ok {
+		t.Fatalf("expected voice sender to be registered")
+	}
+}
+
+func TestWorkerTranscribesVoiceWithCaption(t *testing.T) {
+	store := NewMemoryStore()
+	chat := Chat{ID: 60003}
+	client := &scriptedClient{
+		updates: [][]Update{{
+			{UpdateID: 1, Message: &Message{
+				MessageID: 13,
+				Chat:      chat,
+				Caption:   "我想学游泳",
+				Voice:     &Voice{FileID: "voice-3", FileUniqueID: "voice-u3", Duration: 3, MimeType: "audio/ogg"},
+			}},
+		}},
+		files: map[string][]byte{"voice-3": []byte("ogg")},
+	}
+
+	cfg := WorkerConfig{Transcriber: NewStubTranscriber("我现在零基础，每周能练三次")}
+	if err := runWorkerWithConfigUntilSendCount(t, cfg, client, store, 1); err != nil {
+		t.Fatalf("worker run failed: %v", err)
+	}
+
+	reply := client.SentMessages()[0].Text
+	if !strings.HasPrefix(reply, zh(MsgVoiceTranscribed, "我现在零基础，每周能练三次")+"\n") {
+		t.Fatalf("reply=%q, want the transcript echoed without the caption", reply)
+	}
+
+	user, _ := store.UserByChatID(chat.ID)
+	goal, _, err := store.GetCurrentGoalByUserID(context.Background(), user.ID)
+	if err != nil {
+		t.Fatalf("get current goal failed: %v", err)
+	}
+	session, _ := store.SessionByGoalID(goal.ID)
+	turns, err := store.ListUserTurns(context.Background(), session.ID)
+	if err != nil {
+		t.Fatalf("list user turns failed: %v", err)
+	}
+	if len(turns) != 1 || turns[0].Content != "我想学游泳\n我现在零基础，每周能练三次" {
+		t.Fatalf("turns=%+v, want the caption followed by the transcript", turns)
+	}
+	if !session.SlotCompletion[SlotCurrentLevel] {
+		t.Fatalf("slot completion=%+v, want the transcript to fill the current level", session.SlotCompletion)
+	}
+}
+
+func TestWorkerRepliesWhenVoiceCannotBeTranscribed(t *testing.T) {
+	store := NewMemoryStore()
+	client := &scriptedClient{
+		updates: [][]Update{{
+			{UpdateID: 1, Message: &Message{
+				MessageID: 12,
+				Chat:      Chat{ID: 60002},
+				Voice:     &Voice{FileID: "voice-2", FileUniqueID: "voice-u2", Duration: 2},
+			}},
+		}},
+		files: map[string][]byte{"voice-2": []byte("ogg")},
+	}
+
+	if err := runWorkerUntilSendCount(t, client, store, 1); err != nil {
+		t.Fatalf("worker run failed: %v", err)
+	}
+	if got := client.SentMessages()[0].Text; got != zh(MsgVoiceUnavailable) {
+		t.Fatalf("reply=%q, want %q", got, zh(MsgVoiceUnavailable))
+	}
+}
+
+func TestWorkerAttachesDocumentToActiveGoal(t *testing.T) {
+	store := NewMemoryStore()
+	document := &Document{FileID: "doc-1", FileUniqueID: "doc-u1", FileName: "plan.md", MimeType: "text/markdown"}
+	client := &scriptedClient{
+		updates: [][]Update{
+			{{UpdateID: 1, Message: &Message{MessageID: 21, Chat: Chat{ID: 60003}, Document: document}}},
+			{{UpdateID: 2, Message: &Message{MessageID: 22, Chat: Chat{ID: 60003}, Document: document}}},
+			{{UpdateID: 3, Message: &Message{MessageID: 23, Chat: Chat{ID: 60003}, Document: &Document{
+				FileID: "doc-2", FileUniqueID: "doc-u2", FileName: "photo.zip", MimeType: "application/zip",
+			}}}},
+		},
+		files: map[string][]byte{"doc-1": []byte("# 训练计划\n每周三次")},
+	}
+
+	if err := runWorkerUntilSendCount(t, client, store, 3); err != nil {
+		t.Fatalf("worker run failed: %v", err)
+	}
+
+	sent := client.SentMessages()
+	if want := "已将「plan.md」作为参考资料附加到当前目标。"; sent[0].Text != want {
+		t.Fatalf("reply=%q, want %q", sent[0].Text, want)
+	}
+	if sent[2].Text != zh(MsgDocumentUnsupported) {
+		t.Fatalf("reply=%q, want %q", sent[2].Text, zh(MsgDocumentUnsupported))
+	}
+
+	attachments := store.Attachments()
+	if len(attachments) != 1 {
+		t.Fatalf("attachments=%d, want 1", len(attachments))
+	}
+	if attachments[0].GoalID == "" || attachments[0].ContentText != "# 训练计划\n每周三次" {
+		t.Fatalf("attachment=%+v, want goal id and extracted text", attachments[0])
+	}
+}
+
+type countingTranscriber struct {
+	calls int
+}
+
+func (t *countingTranscriber) Transcribe(context.Context, VoiceNote) (string, error) {
+	t.calls++
+	return "我想学游泳", nil
+}
+
+func TestWorkerSkipsMediaForDuplicateUpdate(t *testing.T) {
+	store := NewMemoryStore()
+	ctx := context.Background()
+	if _, err := store.MarkMessageDedup(ctx, 7, 60003); err != nil {
+		t.Fatalf("MarkMessageDedup() returned error: %v", err)
+	}
+
+	transcriber := &countingTranscriber{}
+	client := &scriptedClient{files: map[string][]byte{"voice-3": []byte("ogg")}}
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	worker := NewWorker(WorkerConfig{Transcriber: transcriber}, client, store, logger)
+
+	err := worker.handleUpdate(ctx, Update{UpdateID: 7, Message: &Message{
+		MessageID: 13,
+		Chat:      Chat{ID: 60003},
+		Voice:     &Voice{FileID: "voice-3", FileUniqueID: "voice-u3", Duration: 2},
+	}})
+	if err != nil {
+		t.Fatalf("handleUpdate() returned error: %v", err)
+	}
+	if transcriber.calls != 0 {
+		t.Fatalf("transcriber calls=%d, want 0 for a duplicate update", transcriber.calls)
+	}
+	if len(store.OutboxMessages()) != 0 {
+		t.Fatalf("expected no reply for a duplicate update")
+	}
+}
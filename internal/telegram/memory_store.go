@@ -0,0 +1,964 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// MemoryStore is an in-process Store for tests and for running the worker
+// locally without Postgres. It keeps everything in memory and is lost on
+// exit.
+type MemoryStore struct {
+	mu               sync.Mutex
+	lastUpdateID     int64
+	dedup            map[int64]dedupEntry
+	usersByChatID    map[int64]User
+	goals            []Goal
+	currentGoalByUID map[string]string
+	sessionsByGoalID map[string]PlanningSession
+	turns            []ConversationTurn
+	undoneTurns      map[string]struct{}
+	turnCreatedAt    map[string]time.Time
+	redactedTurns    map[string]string
+	attachments      []GoalAttachment
+	outbox           []OutboxMessage
+	audits           []AuditEntry
+	actionLogs       []ActionLog
+	nextUserID       int
+	nextGoalID       int
+	nextSessionID    int
+	nextTurnID       int
+	nextAttachmentID int
+	nextOutboxID     int64
+	now              func() time.Time
+}
+
+type dedupEntry struct {
+	chatID     int64
+	receivedAt time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		dedup:            make(map[int64]dedupEntry),
+		usersByChatID:    make(map[int64]User),
+		currentGoalByUID: make(map[string]string),
+		sessionsByGoalID: make(map[string]PlanningSession),
+		turns:            make([]ConversationTurn, 0),
+		undoneTurns:      make(map[string]struct{}),
+		turnCreatedAt:    make(map[string]time.Time),
+		redactedTurns:    make(map[string]string),
+		now:              time.Now,
+	}
+}
+
+// SetClock replaces the clock used for timestamps, so callers that fake time
+// (the chat REPL, scenario tests) see consistent UpdatedAt values.
+func (s *MemoryStore) SetClock(now func() time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.now = now
+}
+
+func (s *MemoryStore) WithinTx(_ context.Context, fn func(Store) error) error {
+	return fn(s)
+}
+
+func (s *MemoryStore) LoadLastUpdateID(context.Context) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.lastUpdateID, nil
+}
+
+func (s *MemoryStore) SaveLastUpdateID(_ context.Context, lastUpdateID int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lastUpdateID = lastUpdateID
+	return nil
+}
+
+func (s *MemoryStore) MarkMessageDedup(_ context.Context, updateID, chatID int64) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, exists := s.dedup[updateID]; exists {
+		return false, nil
+	}
+	s.dedup[updateID] = dedupEntry{chatID: chatID, receivedAt: s.now()}
+	return true, nil
+}
+
+func (s *MemoryStore) IsUpdateProcessed(_ context.Context, updateID int64) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, exists := s.dedup[updateID]
+	return exists, nil
+}
+
+func (s *MemoryStore) FindOrCreateUserByChatID(_ context.Context, chatID int64) (User, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if existing, ok := s.usersByChatID[chatID]; ok {
+		return existing, false, nil
+	}
+
+	s.nextUserID++
+	created := User{
+		ID:             fmt.Sprintf("user-%d", s.nextUserID),
+		TelegramChatID: chatID,
+		Language:       "zh-CN",
+		Timezone:       "Asia/Shanghai",
+		IsReachable:    true,
+	}
+	s.usersByChatID[chatID] = created
+	return created, true, nil
+}
+
+func (s *MemoryStore) SetUserLanguage(_ context.Context, userID, language string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for chatID, user := range s.usersByChatID {
+		if user.ID == userID {
+			user.Language = language
+			s.usersByChatID[chatID] = user
+			return nil
+		}
+	}
+	return fmt.Errorf("user %s not found", userID)
+}
+
+func (s *MemoryStore) SetUserSessionTimeout(_ context.Context, userID string, timeout time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for chatID, user := range s.usersByChatID {
+		if user.ID == userID {
+			user.SessionTimeout = timeout
+			s.usersByChatID[chatID] = user
+			return nil
+		}
+	}
+	return fmt.Errorf("user %s not found", userID)
+}
+
+func (s *MemoryStore) SetUserDeletionRequest(_ context.Context, userID string, requestedAt *time.Time, confirmations int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for chatID, user := range s.usersByChatID {
+		if user.ID == userID {
+			user.DeletionRequestedAt = requestedAt
+			user.DeletionConfirmations = confirmations
+			s.usersByChatID[chatID] = user
+			return nil
+		}
+	}
+	return ErrUserNotFound
+}
+
+func (s *MemoryStore) ExportUserData(_ context.Context, userID string) (UserExport, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, ok := s.userByIDLocked(userID)
+	if !ok {
+		return UserExport{}, false, nil
+	}
+	export := UserExport{
+		ExportedAt: s.now().UTC(),
+		User: ExportedUser{
+			ID:                    user.ID,
+			TelegramChatID:        user.TelegramChatID,
+			Language:              user.Language,
+			Timezone:              user.Timezone,
+			SessionTimeoutMinutes: int(user.SessionTimeout / time.Minute),
+		},
+		Goals: make([]ExportedGoal, 0),
+	}
+	for _, goal := range s.goals {
+		if goal.UserID != userID {
+			continue
+		}
+		exported := ExportedGoal{
+			ID:          goal.ID,
+			Title:       goal.Title,
+			Status:      goal.Status,
+			CreatedAt:   goal.CreatedAt,
+			Profiles:    make([]ExportedProfile, 0),
+			Turns:       make([]ExportedTurn, 0),
+			Attachments: make([]ExportedAttachment, 0),
+		}
+		if session, ok := s.sessionsByGoalID[goal.ID]; ok {
+			exported.Session = exportedSession(session)
+			for _, turn := range s.turns {
+				if turn.SessionID == session.ID {
+					_, undone := s.undoneTurns[turn.ID]
+					_, redacted := s.redactedTurns[turn.ID]
+					exported.Turns = append(exported.Turns, ExportedTurn{
+						Role:      turn.Role,
+						Content:   turn.Content,
+						Intent:    turn.Intent,
+						Revision:  turn.Revision,
+						Undone:    undone,
+						Redacted:  redacted,
+						CreatedAt: s.turnCreatedAt[turn.ID],
+					})
+				}
+			}
+		}
+		for _, attachment := range s.attachments {
+			if attachment.GoalID == goal.ID {
+				exported.Attachments = append(exported.Attachments, ExportedAttachment{
+					FileName:    attachment.FileName,
+					MimeType:    attachment.MimeType,
+					FileSize:    attachment.FileSize,
+					ContentText: attachment.ContentText,
+				})
+			}
+		}
+		export.Goals = append(export.Goals, exported)
+	}
+	return export, true, nil
+}
+
+func (s *MemoryStore) DeleteUserData(_ context.Context, userID string, audit AuditEntry) (DeletionSummary, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, ok := s.userByIDLocked(userID)
+	if !ok {
+		return DeletionSummary{}, ErrUserNotFound
+	}
+
+	var summary DeletionSummary
+	sessionIDs := make(map[string]bool)
+	goalIDs := make(map[string]bool)
+	keptGoals := s.goals[:0]
+	for _, goal := range s.goals {
+		if goal.UserID != userID {
+			keptGoals = append(keptGoals, goal)
+			continue
+		}
+		summary.Goals++
+		goalIDs[goal.ID] = true
+		if session, ok := s.sessionsByGoalID[goal.ID]; ok {
+			sessionIDs[session.ID] = true
+			delete(s.sessionsByGoalID, goal.ID)
+		}
+	}
+	s.goals = keptGoals
+
+	keptTurns := s.turns[:0]
+	for _, turn := range s.turns {
+		if sessionIDs[turn.SessionID] {
+			summary.Turns++
+			delete(s.undoneTurns, turn.ID)
+			delete(s.turnCreatedAt, turn.ID)
+			delete(s.redactedTurns, turn.ID)
+			continue
+		}
+		keptTurns = append(keptTurns, turn)
+	}
+	s.turns = keptTurns
+
+	keptAttachments := s.attachments[:0]
+	for _, attachment := range s.attachments {
+		if !goalIDs[attachment.GoalID] {
+			keptAttachments = append(keptAttachments, attachment)
+		}
+	}
+	s.attachments = keptAttachments
+
+	keptActionLogs := s.actionLogs[:0]
+	for _, entry := range s.actionLogs {
+		if !goalIDs[entry.GoalID] {
+			keptActionLogs = append(keptActionLogs, entry)
+		}
+	}
+	s.actionLogs = keptActionLogs
+
+	for updateID, entry := range s.dedup {
+		if entry.chatID == user.TelegramChatID {
+			delete(s.dedup, updateID)
+			summary.DedupRows++
+		}
+	}
+	keptOutbox := s.outbox[:0]
+	for _, message := range s.outbox {
+		if message.ChatID == user.TelegramChatID {
+			summary.OutboxRows++
+			continue
+		}
+		keptOutbox = append(keptOutbox, message)
+	}
+	s.outbox = keptOutbox
+
+	delete(s.usersByChatID, user.TelegramChatID)
+	delete(s.currentGoalByUID, userID)
+
+	audit.Payload = summary.auditPayload()
+	audit.CreatedAt = s.now()
+	s.audits = append(s.audits, audit)
+	return summary, nil
+}
+
+func (s *MemoryStore) userByIDLocked(userID string) (User, bool) {
+	for _, user := range s.usersByChatID {
+		if user.ID == userID {
+			return user, true
+		}
+	}
+	return User{}, false
+}
+
+func (s *MemoryStore) MarkChatReachable(_ context.Context, chatID int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if user, ok := s.usersByChatID[chatID]; ok {
+		user.IsReachable = true
+		s.usersByChatID[chatID] = user
+	}
+	return nil
+}
+
+func (s *MemoryStore) MarkChatUnreachable(_ context.Context, chatID int64, reason string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if user, ok := s.usersByChatID[chatID]; ok {
+		user.IsReachable = false
+		s.usersByChatID[chatID] = user
+	}
+	for i := range s.outbox {
+		if s.outbox[i].ChatID == chatID && s.outbox[i].Status == OutboxStatusPending {
+			s.outbox[i].Status = OutboxStatusDead
+			s.outbox[i].LastError = reason
+		}
+	}
+	return nil
+}
+
+func (s *MemoryStore) MigrateChatID(_ context.Context, fromChatID, toChatID int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, taken := s.usersByChatID[toChatID]; taken {
+		return ErrChatIDTaken
+	}
+	if user, ok := s.usersByChatID[fromChatID]; ok {
+		delete(s.usersByChatID, fromChatID)
+		user.TelegramChatID = toChatID
+		s.usersByChatID[toChatID] = user
+	}
+	for i := range s.outbox {
+		if s.outbox[i].ChatID == fromChatID && s.outbox[i].Status == OutboxStatusPending {
+			s.outbox[i].ChatID = toChatID
+		}
+	}
+	return nil
+}
+
+func (s *MemoryStore) GetCurrentGoalByUserID(_ context.Context, userID string) (Goal, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	index, ok := s.goalIndexLocked(s.currentGoalByUID[userID])
+	if !ok || s.goals[index].Status == GoalStatusArchived {
+		return Goal{}, false, nil
+	}
+
+	return s.goals[index], true, nil
+}
+
+func (s *MemoryStore) CreateGoalDraft(_ context.Context, userID string) (Goal, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.nextGoalID++
+	goal := Goal{
+		ID:        fmt.Sprintf("goal-%d", s.nextGoalID),
+		UserID:    userID,
+		Title:     "",
+		Status:    GoalStatusDraft,
+		CreatedAt: s.now(),
+	}
+	s.goals = append(s.goals, goal)
+	s.currentGoalByUID[userID] = goal.ID
+	return goal, nil
+}
+
+func (s *MemoryStore) ListGoalsByUserID(_ context.Context, userID string) ([]Goal, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	out := make([]Goal, 0)
+	for _, goal := range s.goals {
+		if goal.UserID == userID {
+			out = append(out, goal)
+		}
+	}
+	return out, nil
+}
+
+func (s *MemoryStore) SetCurrentGoal(_ context.Context, userID, goalID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	index, ok := s.goalIndexLocked(goalID)
+	if !ok || s.goals[index].UserID != userID || s.goals[index].Status == GoalStatusArchived {
+		return ErrGoalNotFound
+	}
+	s.currentGoalByUID[userID] = goalID
+	return nil
+}
+
+func (s *MemoryStore) UpdateGoalTitle(_ context.Context, goalID, title string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	index, ok := s.goalIndexLocked(goalID)
+	if !ok {
+		return ErrGoalNotFound
+	}
+	s.goals[index].Title = title
+	return nil
+}
+
+func (s *MemoryStore) ActivateGoal(_ context.Context, goalID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	index, ok := s.goalIndexLocked(goalID)
+	if !ok || s.goals[index].Status == GoalStatusArchived {
+		return ErrGoalNotFound
+	}
+	for i := range s.goals {
+		if s.goals[i].UserID == s.goals[index].UserID && s.goals[i].Status == GoalStatusActive {
+			s.goals[i].Status = GoalStatusDraft
+		}
+	}
+	s.goals[index].Status = GoalStatusActive
+	return nil
+}
+
+func (s *MemoryStore) ArchiveGoal(_ context.Context, goalID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	index, ok := s.goalIndexLocked(goalID)
+	if !ok {
+		return ErrGoalNotFound
+	}
+	s.goals[index].Status = GoalStatusArchived
+	for userID, current := range s.currentGoalByUID {
+		if current == goalID {
+			delete(s.currentGoalByUID, userID)
+		}
+	}
+	return nil
+}
+
+func (s *MemoryStore) ArchiveIdleDraftGoals(_ context.Context, cutoff time.Time, sessionTimeout time.Duration, limit int) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var archived int64
+	for i := range s.goals {
+		if archived >= int64(limit) {
+			break
+		}
+		session, ok := s.sessionsByGoalID[s.goals[i].ID]
+		if !ok || s.goals[i].Status != GoalStatusDraft || session.State == StateConfirmed || !session.UpdatedAt.Before(cutoff) {
+			continue
+		}
+		if s.sessionResumableLocked(session.ID, sessionTimeout) {
+			continue
+		}
+		s.goals[i].Status = GoalStatusArchived
+		for userID, current := range s.currentGoalByUID {
+			if current == s.goals[i].ID {
+				delete(s.currentGoalByUID, userID)
+			}
+		}
+		archived++
+	}
+	return archived, nil
+}
+
+func (s *MemoryStore) goalIndexLocked(goalID string) (int, bool) {
+	for i, goal := range s.goals {
+		if goal.ID == goalID {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+func (s *MemoryStore) GetOrCreatePlanningSession(_ context.Context, goalID string) (PlanningSession, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if existing, ok := s.sessionsByGoalID[goalID]; ok {
+		return existing, false, nil
+	}
+
+	s.nextSessionID++
+	created := PlanningSession{
+		ID:             fmt.Sprintf("session-%d", s.nextSessionID),
+		GoalID:         goalID,
+		State:          StateIdle,
+		SlotCompletion: DefaultSlotCompletion(),
+		TurnCount:      0,
+		LastIntent:     "",
+		UpdatedAt:      s.now(),
+	}
+	s.sessionsByGoalID[goalID] = created
+	return created, true, nil
+}
+
+func (s *MemoryStore) IncrementPlanningSessionTurn(_ context.Context, sessionID string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	session, key, ok := s.findSessionByID(sessionID)
+	if !ok {
+		return 0, fmt.Errorf("planning session %s not found", sessionID)
+	}
+
+	session.TurnCount++
+	session.UpdatedAt = s.now()
+	s.sessionsByGoalID[key] = session
+	return session.TurnCount, nil
+}
+
+func (s *MemoryStore) UpdatePlanningSession(_ context.Context, updated PlanningSession) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.sessionsByGoalID[updated.GoalID]; !ok {
+		return fmt.Errorf("planning session %s not found", updated.ID)
+	}
+
+	updated.SlotCompletion = NormalizeSlotCompletion(updated.SlotCompletion)
+	updated.UpdatedAt = s.now()
+	s.sessionsByGoalID[updated.GoalID] = updated
+	return nil
+}
+
+func (s *MemoryStore) SaveConversationTurn(_ context.Context, turn ConversationTurn) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.nextTurnID++
+	turn.ID = fmt.Sprintf("turn-%d", s.nextTurnID)
+	s.turns = append(s.turns, turn)
+	s.turnCreatedAt[turn.ID] = s.now()
+	return nil
+}
+
+func (s *MemoryStore) RedactConversationTurns(_ context.Context, cutoff time.Time, sessionTimeout time.Duration, limit int, redact TurnRedactor) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	redacted := 0
+	for i := range s.turns {
+		if redacted >= limit {
+			break
+		}
+		turn := &s.turns[i]
+		if _, done := s.redactedTurns[turn.ID]; done || !s.turnCreatedAt[turn.ID].Before(cutoff) {
+			continue
+		}
+		if s.sessionResumableLocked(turn.SessionID, sessionTimeout) {
+			continue
+		}
+		result := redact(turn.Content)
+		turn.Content = result.Summary
+		turn.Redacted = true
+		turn.RedactedSlots = result.Slots
+		s.redactedTurns[turn.ID] = result.Hash
+		redacted++
+	}
+	return redacted, nil
+}
+
+// sessionResumableLocked reports whether the session was touched within its
+// owner's timeout, falling back to sessionTimeout.
+func (s *MemoryStore) sessionResumableLocked(sessionID string, sessionTimeout time.Duration) bool {
+	for goalID, session := range s.sessionsByGoalID {
+		if session.ID != sessionID {
+			continue
+		}
+		timeout := sessionTimeout
+		if i, ok := s.goalIndexLocked(goalID); ok {
+			if user, ok := s.userByIDLocked(s.goals[i].UserID); ok && user.SessionTimeout > 0 {
+				timeout = user.SessionTimeout
+			}
+		}
+		return s.now().Sub(session.UpdatedAt) < timeout
+	}
+	return false
+}
+
+func (s *MemoryStore) DeleteMessageDedupBefore(_ context.Context, cutoff time.Time, limit int) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var deleted int64
+	for updateID, entry := range s.dedup {
+		if deleted >= int64(limit) {
+			break
+		}
+		if entry.receivedAt.Before(cutoff) {
+			delete(s.dedup, updateID)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+func (s *MemoryStore) DeleteActionLogsBefore(_ context.Context, cutoff time.Time, limit int) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var deleted int64
+	kept := s.actionLogs[:0]
+	for _, entry := range s.actionLogs {
+		if deleted < int64(limit) && entry.CreatedAt.Before(cutoff) {
+			deleted++
+			continue
+		}
+		kept = append(kept, entry)
+	}
+	s.actionLogs = kept
+	return deleted, nil
+}
+
+func (s *MemoryStore) ClearExpiredConfirmations(_ context.Context, resetBefore, deletionBefore time.Time, limit int) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var resets, deletions int64
+	for goalID, session := range s.sessionsByGoalID {
+		if resets >= int64(limit) {
+			break
+		}
+		if session.PendingAction != "" && session.UpdatedAt.Before(resetBefore) {
+			session.PendingAction = ""
+			s.sessionsByGoalID[goalID] = session
+			resets++
+		}
+	}
+	for chatID, user := range s.usersByChatID {
+		if deletions >= int64(limit) {
+			break
+		}
+		if user.DeletionRequestedAt != nil && user.DeletionRequestedAt.Before(deletionBefore) {
+			user.DeletionRequestedAt = nil
+			user.DeletionConfirmations = 0
+			s.usersByChatID[chatID] = user
+			deletions++
+		}
+	}
+	return resets + deletions, nil
+}
+
+func (s *MemoryStore) DedupCount() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.dedup)
+}
+
+// TurnContentHash returns the hash a redacted turn keeps of its original
+// text.
+func (s *MemoryStore) TurnContentHash(turnID string) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	hash, ok := s.redactedTurns[turnID]
+	return hash, ok
+}
+
+func (s *MemoryStore) GetLatestUserTurn(_ context.Context, sessionID string) (ConversationTurn, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := len(s.turns) - 1; i >= 0; i-- {
+		if s.isActiveUserTurn(s.turns[i], sessionID) {
+			return s.turns[i], true, nil
+		}
+	}
+	return ConversationTurn{}, false, nil
+}
+
+func (s *MemoryStore) ListUserTurns(_ context.Context, sessionID string) ([]ConversationTurn, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([]ConversationTurn, 0)
+	for _, turn := range s.turns {
+		if s.isActiveUserTurn(turn, sessionID) {
+			out = append(out, turn)
+		}
+	}
+	return out, nil
+}
+
+func (s *MemoryStore) UndoLastClarifyTurn(_ context.Context, sessionID string) (ConversationTurn, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := len(s.turns) - 1; i >= 0; i-- {
+		if s.isActiveUserTurn(s.turns[i], sessionID) && s.turns[i].Intent == IntentClarifyGoal {
+			s.undoneTurns[s.turns[i].ID] = struct{}{}
+			return s.turns[i], true, nil
+		}
+	}
+	return ConversationTurn{}, false, nil
+}
+
+func (s *MemoryStore) ListRecentTurns(_ context.Context, sessionID string, limit int) ([]ConversationTurn, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([]ConversationTurn, 0)
+	for _, turn := range s.turns {
+		if _, undone := s.undoneTurns[turn.ID]; turn.SessionID == sessionID && !undone {
+			out = append(out, turn)
+		}
+	}
+	if len(out) > limit {
+		out = out[len(out)-limit:]
+	}
+	return out, nil
+}
+
+func (s *MemoryStore) isActiveUserTurn(turn ConversationTurn, sessionID string) bool {
+	_, undone := s.undoneTurns[turn.ID]
+	return turn.SessionID == sessionID && turn.Role == ConversationRoleUser && !undone
+}
+
+func (s *MemoryStore) ReviseConversationTurn(_ context.Context, turn ConversationTurn) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range s.turns {
+		if s.turns[i].ID == turn.ID {
+			s.turns[i].Content = turn.Content
+			s.turns[i].Intent = turn.Intent
+			s.turns[i].IntentConfidence = turn.IntentConfidence
+			s.turns[i].IntentRanking = turn.IntentRanking
+			s.turns[i].Redacted = false
+			s.turns[i].RedactedSlots = nil
+			delete(s.redactedTurns, turn.ID)
+			s.turns[i].Revision++
+			return s.turns[i].Revision, nil
+		}
+	}
+	return 0, fmt.Errorf("conversation turn %s not found", turn.ID)
+}
+
+func (s *MemoryStore) SaveGoalAttachment(_ context.Context, attachment GoalAttachment) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, existing := range s.attachments {
+		if existing.GoalID == attachment.GoalID && existing.TelegramFileUniqueID == attachment.TelegramFileUniqueID {
+			return nil
+		}
+	}
+	s.nextAttachmentID++
+	attachment.ID = fmt.Sprintf("attachment-%d", s.nextAttachmentID)
+	s.attachments = append(s.attachments, attachment)
+	return nil
+}
+
+func (s *MemoryStore) Attachments() []GoalAttachment {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([]GoalAttachment, len(s.attachments))
+	copy(out, s.attachments)
+	return out
+}
+
+func (s *MemoryStore) EnqueueOutgoingMessage(_ context.Context, message OutgoingMessage) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	s.nextOutboxID++
+	id := s.nextOutboxID
+	stored := OutboxMessage{
+		ID:               id,
+		ChatID:           message.ChatID,
+		Text:             message.Text,
+		ReplyToMessageID: message.ReplyToMessageID,
+		Export:           message.Export,
+		Status:           OutboxStatusPending,
+		NextAttemptAt:    now,
+		CreatedAt:        now,
+		UpdatedAt:        now,
+	}
+	if user, ok := s.usersByChatID[message.ChatID]; ok && !user.IsReachable {
+		stored.Status = OutboxStatusDead
+		stored.LastError = outboxUnreachableError
+	}
+	s.outbox = append(s.outbox, stored)
+	return id, nil
+}
+
+func (s *MemoryStore) ClaimDueOutgoingMessages(_ context.Context, limit int, lease time.Duration) ([]OutboxMessage, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	seenChats := make(map[int64]struct{})
+	out := make([]OutboxMessage, 0)
+	for i := range s.outbox {
+		message := &s.outbox[i]
+		if message.Status != OutboxStatusPending {
+			continue
+		}
+		if _, seen := seenChats[message.ChatID]; seen {
+			continue
+		}
+		seenChats[message.ChatID] = struct{}{}
+		if message.NextAttemptAt.After(now) {
+			continue
+		}
+		message.NextAttemptAt = now.Add(lease)
+		message.UpdatedAt = now
+		out = append(out, *message)
+		if len(out) >= limit {
+			break
+		}
+	}
+	return out, nil
+}
+
+func (s *MemoryStore) MarkOutgoingMessageSent(_ context.Context, id int64) error {
+	return s.updateOutbox(id, func(message *OutboxMessage) {
+		sentAt := s.now()
+		message.Status = OutboxStatusSent
+		message.Attempts++
+		message.LastError = ""
+		message.SentAt = &sentAt
+	})
+}
+
+func (s *MemoryStore) RescheduleOutgoingMessage(_ context.Context, id int64, nextAttemptAt time.Time, lastError string) error {
+	return s.updateOutbox(id, func(message *OutboxMessage) {
+		message.Attempts++
+		message.NextAttemptAt = nextAttemptAt
+		message.LastError = lastError
+	})
+}
+
+func (s *MemoryStore) DeferOutgoingMessage(_ context.Context, id int64, nextAttemptAt time.Time, lastError string) error {
+	return s.updateOutbox(id, func(message *OutboxMessage) {
+		message.NextAttemptAt = nextAttemptAt
+		message.LastError = lastError
+	})
+}
+
+func (s *MemoryStore) MarkOutgoingMessageDead(_ context.Context, id int64, lastError string) error {
+	return s.updateOutbox(id, func(message *OutboxMessage) {
+		message.Status = OutboxStatusDead
+		message.Attempts++
+		message.LastError = lastError
+	})
+}
+
+func (s *MemoryStore) ListDeadOutgoingMessages(_ context.Context, limit int) ([]OutboxMessage, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	out := make([]OutboxMessage, 0)
+	for i := len(s.outbox) - 1; i >= 0 && len(out) < limit; i-- {
+		if s.outbox[i].Status == OutboxStatusDead {
+			out = append(out, s.outbox[i])
+		}
+	}
+	return out, nil
+}
+
+func (s *MemoryStore) CountPendingOutgoingMessages(context.Context) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var count int64
+	for _, message := range s.outbox {
+		if message.Status == OutboxStatusPending {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (s *MemoryStore) updateOutbox(id int64, apply func(*OutboxMessage)) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := range s.outbox {
+		if s.outbox[i].ID == id {
+			apply(&s.outbox[i])
+			s.outbox[i].UpdatedAt = s.now()
+			return nil
+		}
+	}
+	return fmt.Errorf("outgoing message %d not found", id)
+}
+
+func (s *MemoryStore) OutboxMessages() []OutboxMessage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([]OutboxMessage, len(s.outbox))
+	copy(out, s.outbox)
+	return out
+}
+
+func (s *MemoryStore) AuditEntries() []AuditEntry {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]AuditEntry(nil), s.audits...)
+}
+
+func (s *MemoryStore) LastUpdateID() int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.lastUpdateID
+}
+
+func (s *MemoryStore) GoalCreateCount() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.nextGoalID
+}
+
+func (s *MemoryStore) UserByChatID(chatID int64) (User, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	user, ok := s.usersByChatID[chatID]
+	return user, ok
+}
+
+func (s *MemoryStore) SessionByGoalID(goalID string) (PlanningSession, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	session, ok := s.sessionsByGoalID[goalID]
+	return session, ok
+}
+
+func (s *MemoryStore) ConversationTurnsBySessionID(sessionID string) []ConversationTurn {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([]ConversationTurn, 0, len(s.turns))
+	for _, turn := range s.turns {
+		if turn.SessionID == sessionID {
+			out = append(out, turn)
+		}
+	}
+	return out
+}
+
+func (s *MemoryStore) findSessionByID(sessionID string) (PlanningSession, string, bool) {
+	for goalID, session := range s.sessionsByGoalID {
+		if session.ID == sessionID {
+			return session, goalID, true
+		}
+	}
+	return PlanningSession{}, "", false
+}
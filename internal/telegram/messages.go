@@ -0,0 +1,342 @@
+package telegram
+
+import "github.com/congregalis/aiden/internal/i18n"
+
+// Message IDs for the bot's replies. Texts live in the per-locale tables
+// below and are rendered with tr/trn in the user's language.
+const (
+	MsgStart          = "start"
+	MsgStartBack      = "start_back"
+	MsgGoal           = "goal"
+	MsgHelp           = "help"
+	MsgNonText        = "non_text"
+	MsgUnknownCommand = "unknown_command"
+	MsgNaturalMessage = "natural_message"
+	MsgReviewReady    = "review_ready"
+	MsgPlanConfirmed  = "plan_confirmed"
+
+	MsgReviewReopened            = "review_reopened"
+	MsgReviewReopenedNoQuestion  = "review_reopened_no_question"
+	MsgSessionReopened           = "session_reopened"
+	MsgSessionReopenedNoQuestion = "session_reopened_no_question"
+	MsgFollowUpIntro             = "follow_up_intro"
+	MsgProgressSummaryTitle      = "progress_summary_title"
+	MsgProgressSummary           = "progress_summary"
+	MsgNone                      = "none"
+
+	MsgVoiceUnavailable       = "voice_unavailable"
+	MsgVoiceTranscribed       = "voice_transcribed"
+	MsgDocumentUnsupported    = "document_unsupported"
+	MsgDocumentAttached       = "document_attached"
+	MsgDocumentAttachedNoName = "document_attached_no_name"
+	MsgEditApplied            = "edit_applied"
+
+	MsgNoActiveGoal    = "no_active_goal"
+	MsgResetConfirm    = "reset_confirm"
+	MsgResetDone       = "reset_done"
+	MsgResetCancelled  = "reset_cancelled"
+	MsgUndoDone        = "undo_done"
+	MsgUndoNothing     = "undo_nothing"
+	MsgSkipDone        = "skip_done"
+	MsgSkipUsage       = "skip_usage"
+	MsgSkipNotAllowed  = "skip_not_allowed"
+	MsgSkipAlreadyDone = "skip_already_done"
+	MsgSessionStatus   = "session_status"
+	MsgTurnCount       = "turn_count"
+
+	MsgNoGoals             = "no_goals"
+	MsgGoalUsage           = "goal_usage"
+	MsgGoalNumberInvalid   = "goal_number_invalid"
+	MsgGoalSwitched        = "goal_switched"
+	MsgGoalArchived        = "goal_archived"
+	MsgGoalArchivedAlready = "goal_archived_already"
+	MsgGoalListTitle       = "goal_list_title"
+	MsgGoalListItem        = "goal_list_item"
+	MsgGoalListCurrent     = "goal_list_current"
+	MsgGoalUntitled        = "goal_untitled"
+	MsgGoalStatusDraft     = "goal_status_draft"
+	MsgGoalStatusActive    = "goal_status_active"
+	MsgGoalStatusArchived  = "goal_status_archived"
+
+	MsgFallbackGuidance = "fallback_guidance"
+	MsgReviewFallback   = "review_fallback"
+	MsgDisambiguate     = "disambiguate"
+	MsgOptionConfirm    = "option_confirm"
+	MsgOptionClarify    = "option_clarify"
+	MsgSessionResumed   = "session_resumed"
+	MsgRecapSlots       = "recap_slots"
+	MsgRecapTurns       = "recap_turns"
+	MsgRecapTurnLine    = "recap_turn_line"
+	MsgSpeakerUser      = "speaker_user"
+	MsgSpeakerBot       = "speaker_bot"
+
+	MsgTimeoutCurrent = "timeout_current"
+	MsgTimeoutDefault = "timeout_default"
+	MsgTimeoutUpdated = "timeout_updated"
+	MsgTimeoutUsage   = "timeout_usage"
+	MsgHours          = "hours"
+	MsgMinutes        = "minutes"
+
+	MsgLangCurrent = "lang_current"
+	MsgLangUpdated = "lang_updated"
+	MsgLangUsage   = "lang_usage"
+
+	MsgMyDataCaption        = "mydata_caption"
+	MsgMyDataFailed         = "mydata_failed"
+	MsgMyDataUsage          = "mydata_usage"
+	MsgDeleteMeWarning      = "deleteme_warning"
+	MsgDeleteMeConfirmAgain = "deleteme_confirm_again"
+	MsgDeleteMeDone         = "deleteme_done"
+	MsgDeleteMeCancelled    = "deleteme_cancelled"
+	MsgDeleteMeNotRequested = "deleteme_not_requested"
+	MsgDeleteMeUsage        = "deleteme_usage"
+
+	MsgStateIdle       = "state_idle"
+	MsgStateClarifying = "state_clarifying"
+	MsgStateReview     = "state_review"
+	MsgStateConfirmed  = "state_confirmed"
+
+	MsgSlotMainGoal        = "slot_main_goal"
+	MsgSlotSuccessCriteria = "slot_success_criteria"
+	MsgSlotCurrentLevel    = "slot_current_level"
+	MsgSlotTimeBudget      = "slot_time_budget"
+	MsgSlotConstraints     = "slot_constraints"
+	MsgSlotRiskFlags       = "slot_risk_flags"
+	MsgSlotOption          = "slot_option"
+
+	MsgQuestionMainGoal        = "question_main_goal"
+	MsgQuestionSuccessCriteria = "question_success_criteria"
+	MsgQuestionCurrentLevel    = "question_current_level"
+	MsgQuestionTimeBudget      = "question_time_budget"
+	MsgQuestionConstraints     = "question_constraints"
+	MsgQuestionRiskFlags       = "question_risk_flags"
+)
+
+var messages = i18n.MustNewCatalog(i18n.DefaultLocale, map[string]map[string]i18n.Message{
+	i18n.ZhCN: zhCNMessages,
+	i18n.En:   enMessages,
+})
+
+func tr(lang, id string, args ...any) string {
+	return messages.T(lang, id, args...)
+}
+
+func trn(lang, id string, count int, args ...any) string {
+	return messages.N(lang, id, count, args...)
+}
+
+var zhCNMessages = map[string]i18n.Message{
+	MsgStart:          {Other: "欢迎来到 Aiden！我已完成初始化（默认语言 zh-CN，默认时区 Asia/Shanghai）。发送 /goal 开始目标澄清。"},
+	MsgStartBack:      {Other: "欢迎回来！发送 /goal 继续目标澄清，或发送 /help 查看可用命令。"},
+	MsgGoal:           {Other: "好的，我们开始目标澄清。请先告诉我：你希望在什么时间前达成什么目标？"},
+	MsgHelp:           {Other: "当前可用命令：/start、/goal、/goals、/status、/undo、/skip、/reset、/timeout、/lang、/mydata、/deleteme、/help。你也可以直接用自然语言告诉我你的目标。"},
+	MsgNonText:        {Other: "我暂时看不懂图片或贴纸，请发送文字、语音，或 PDF/TXT 参考资料。"},
+	MsgUnknownCommand: {Other: "这个命令会在后续里程碑开放。当前可用：/start、/goal、/goals、/status、/undo、/skip、/reset、/timeout、/lang、/mydata、/deleteme、/help。"},
+	MsgNaturalMessage: {Other: "收到，我已进入自然语言澄清入口。你可以继续描述目标细节，或发送 /goal 切换到命令入口。"},
+	MsgReviewReady:    {Other: "关键信息已补齐，我已切换到 review。回复“确认”即可完成澄清；如需修改，请直接告诉我你要调整的内容。"},
+	MsgPlanConfirmed:  {Other: "已确认，当前会话状态更新为 confirmed。接下来我会按这个目标继续推进。"},
+
+	MsgReviewReopened:            {Other: "已收到修改意见，我已切回 clarifying。"},
+	MsgReviewReopenedNoQuestion:  {Other: "已收到修改意见，我已切回 clarifying。请补充你希望调整的重点。"},
+	MsgSessionReopened:           {Other: "我已重新打开澄清会话。"},
+	MsgSessionReopenedNoQuestion: {Other: "我已重新打开澄清会话，请告诉我你想调整的目标内容。"},
+	MsgFollowUpIntro:             {Other: "我先补齐关键信息："},
+	MsgProgressSummaryTitle:      {Other: "【当前摘要】"},
+	MsgProgressSummary:           {Other: "已补齐 %d/%d 项：%s；待补齐：%s。\n当前版本你是否满意，还是继续优化？"},
+	MsgNone:                      {Other: "无"},
+
+	MsgVoiceUnavailable:       {Other: "暂时无法识别这条语音，请改用文字描述。"},
+	MsgVoiceTranscribed:       {Other: "（语音识别：%s）"},
+	MsgDocumentUnsupported:    {Other: "目前只支持 PDF、TXT 或 Markdown 文档作为参考资料。"},
+	MsgDocumentAttached:       {Other: "已将「%s」作为参考资料附加到当前目标。"},
+	MsgDocumentAttachedNoName: {Other: "已将这份文档作为参考资料附加到当前目标。"},
+	MsgEditApplied:            {Other: "已按你修改后的消息更新目标信息。"},
+
+	MsgNoActiveGoal:    {Other: "你还没有当前目标，发送 /goal 开始目标澄清，或发送 /goals 切换已有目标。"},
+	MsgResetConfirm:    {Other: "确定要归档当前目标草稿并重新开始吗？回复“确认”继续，发送其他内容则取消。"},
+	MsgResetDone:       {Other: "已归档当前目标草稿。我们重新开始：你希望在什么时间前达成什么目标？"},
+	MsgResetCancelled:  {Other: "已取消重置，当前目标保持不变。"},
+	MsgUndoDone:        {Other: "已撤销上一条补充「%s」带来的信息变更。"},
+	MsgUndoNothing:     {Other: "没有可以撤销的补充内容。"},
+	MsgSkipDone:        {Other: "好的，「%s」这一项先留空。"},
+	MsgSkipUsage:       {Other: "请指定要跳过的项目，例如 /skip risk_flags。可跳过：%s。"},
+	MsgSkipNotAllowed:  {Other: "「%s」是必填信息，不能跳过。可跳过：%s。"},
+	MsgSkipAlreadyDone: {Other: "「%s」已经补齐，无需跳过。"},
+	MsgSessionStatus:   {Other: "【当前状态】%s（%s）\n已补齐：%s\n待补齐：%s\n已跳过：%s\n对话轮次：%s"},
+	MsgTurnCount:       {Other: "%s"},
+
+	MsgNoGoals:             {Other: "你还没有任何目标，发送 /goal 开始目标澄清。"},
+	MsgGoalUsage:           {Other: "可用：/goal new 新建目标，/goal switch <编号> 切换目标，/goal archive <编号> 归档目标。"},
+	MsgGoalNumberInvalid:   {Other: "没有找到这个目标编号，发送 /goals 查看你的目标列表。"},
+	MsgGoalSwitched:        {Other: "已切换到目标 #%d：%s"},
+	MsgGoalArchived:        {Other: "已归档目标 #%d：%s。发送 /goal new 可以开始新的目标。"},
+	MsgGoalArchivedAlready: {Other: "目标 #%d 已归档，无法再切换或归档。"},
+	MsgGoalListTitle:       {Other: "你的目标："},
+	MsgGoalListItem:        {Other: "%d. %s（%s）"},
+	MsgGoalListCurrent:     {Other: " ← 当前"},
+	MsgGoalUntitled:        {Other: "未命名目标"},
+	MsgGoalStatusDraft:     {Other: "草稿"},
+	MsgGoalStatusActive:    {Other: "进行中"},
+	MsgGoalStatusArchived:  {Other: "已归档"},
+
+	MsgFallbackGuidance: {Other: "我这条没有完全理解。你可以直接补充：主目标、成功标准、当前水平、时间预算或约束；我会保留当前上下文继续澄清。"},
+	MsgReviewFallback:   {Other: "如果你认可当前版本，请回复“确认”；如果要改动，直接说“修改 + 你的新要求”。我会保留上下文。"},
+	MsgDisambiguate:     {Other: "我不太确定你的意思，你是想：\n%s"},
+	MsgOptionConfirm:    {Other: "确认当前计划（回复“确认”）"},
+	MsgOptionClarify:    {Other: "补充或修改目标（直接说出要改的内容）"},
+	MsgSessionResumed:   {Other: "欢迎回来！距离上次澄清已经有一段时间了，我们从这里继续："},
+	MsgRecapSlots:       {Other: "已补齐：%s\n待补齐：%s"},
+	MsgRecapTurns:       {Other: "最近的对话："},
+	MsgRecapTurnLine:    {Other: "- %s：%s"},
+	MsgSpeakerUser:      {Other: "你"},
+	MsgSpeakerBot:       {Other: "我"},
+
+	MsgTimeoutCurrent: {Other: "你的会话超时为 %s，超过这个时间再回来时我会先帮你回顾进度。发送 /timeout default 恢复默认值。"},
+	MsgTimeoutDefault: {Other: "你正在使用默认会话超时：%s。发送 /timeout 12h 可以自定义。"},
+	MsgTimeoutUpdated: {Other: "已将会话超时设置为 %s。"},
+	MsgTimeoutUsage:   {Other: "用法：/timeout 12h、/timeout 90m、/timeout 8（小时）或 /timeout default，范围 10 分钟到 30 天。"},
+	MsgHours:          {Other: "%s 小时"},
+	MsgMinutes:        {Other: "%s 分钟"},
+
+	MsgLangCurrent: {Other: "当前语言：%s。发送 /lang en 切换到英文，/lang zh 切换到中文。"},
+	MsgLangUpdated: {Other: "已切换为%s。"},
+	MsgLangUsage:   {Other: "用法：/lang zh 或 /lang en。"},
+
+	MsgMyDataCaption:        {Other: "这是你在 Aiden 保存的全部数据：账号、目标、计划和对话记录。"},
+	MsgMyDataFailed:         {Other: "导出数据失败了，请稍后再试。"},
+	MsgMyDataUsage:          {Other: "用法：/mydata 或 /mydata json 导出 JSON，/mydata zip 导出 ZIP。"},
+	MsgDeleteMeWarning:      {Other: "⚠️ 这会永久删除你的账号、所有目标、计划和对话记录，且无法恢复。建议先发送 /mydata 导出一份。确认删除请在 %d 分钟内发送 /deleteme confirm，放弃请发送 /deleteme cancel。"},
+	MsgDeleteMeConfirmAgain: {Other: "最后确认：再次发送 /deleteme confirm 后数据会立即删除。放弃请发送 /deleteme cancel。"},
+	MsgDeleteMeDone:         {Other: "你的数据已全部删除。再见！如果想重新开始，随时发送 /start。"},
+	MsgDeleteMeCancelled:    {Other: "已取消删除，你的数据保持不变。"},
+	MsgDeleteMeNotRequested: {Other: "没有待确认的删除请求，或请求已过期。请先发送 /deleteme。"},
+	MsgDeleteMeUsage:        {Other: "用法：/deleteme 发起删除，/deleteme confirm 确认，/deleteme cancel 取消。"},
+
+	MsgStateIdle:       {Other: "未开始"},
+	MsgStateClarifying: {Other: "澄清中"},
+	MsgStateReview:     {Other: "待确认"},
+	MsgStateConfirmed:  {Other: "已确认"},
+
+	MsgSlotMainGoal:        {Other: "主目标"},
+	MsgSlotSuccessCriteria: {Other: "成功标准"},
+	MsgSlotCurrentLevel:    {Other: "当前水平"},
+	MsgSlotTimeBudget:      {Other: "时间预算"},
+	MsgSlotConstraints:     {Other: "约束条件"},
+	MsgSlotRiskFlags:       {Other: "风险项"},
+	MsgSlotOption:          {Other: "%s（%s）"},
+
+	MsgQuestionMainGoal:        {Other: "你希望在什么时间前达成什么主目标？"},
+	MsgQuestionSuccessCriteria: {Other: "请给我 3-5 条可验收的成功标准（尽量量化）。"},
+	MsgQuestionCurrentLevel:    {Other: "你当前水平如何（零基础/入门/有项目经验）？"},
+	MsgQuestionTimeBudget:      {Other: "你每周可投入多少小时，或有哪些固定学习时段？"},
+	MsgQuestionConstraints:     {Other: "有哪些约束会影响执行（如加班、设备、可用时段）？"},
+	MsgQuestionRiskFlags:       {Other: "你担心哪些风险会影响坚持（如出差、拖延、突发事务）？"},
+}
+
+var enMessages = map[string]i18n.Message{
+	MsgStart:          {Other: "Welcome to Aiden! You're all set up (language: English, time zone: Asia/Shanghai). Send /goal to start clarifying your goal."},
+	MsgStartBack:      {Other: "Welcome back! Send /goal to continue clarifying your goal, or /help to see the available commands."},
+	MsgGoal:           {Other: "Great, let's clarify your goal. First: what do you want to achieve, and by when?"},
+	MsgHelp:           {Other: "Available commands: /start, /goal, /goals, /status, /undo, /skip, /reset, /timeout, /lang, /mydata, /deleteme, /help. You can also just tell me about your goal in your own words."},
+	MsgNonText:        {Other: "I can't read images or stickers yet. Please send text, a voice message, or a PDF/TXT reference."},
+	MsgUnknownCommand: {Other: "That command isn't available yet. Available: /start, /goal, /goals, /status, /undo, /skip, /reset, /timeout, /lang, /mydata, /deleteme, /help."},
+	MsgNaturalMessage: {Other: "Got it, let's clarify in plain language. Keep describing your goal, or send /goal to use the command flow."},
+	MsgReviewReady:    {Other: "I have everything I need and switched to review. Reply \"confirm\" to finish, or tell me what you'd like to change."},
+	MsgPlanConfirmed:  {Other: "Confirmed, the session is now confirmed. I'll keep working toward this goal with you."},
+
+	MsgReviewReopened:            {Other: "Got your changes, I've switched back to clarifying."},
+	MsgReviewReopenedNoQuestion:  {Other: "Got your changes, I've switched back to clarifying. Tell me what you'd like to adjust."},
+	MsgSessionReopened:           {Other: "I've reopened the clarification session."},
+	MsgSessionReopenedNoQuestion: {Other: "I've reopened the clarification session. Tell me what you'd like to change about your goal."},
+	MsgFollowUpIntro:             {Other: "A few things I still need:"},
+	MsgProgressSummaryTitle:      {Other: "[Summary] "},
+	MsgProgressSummary:           {Other: "%d/%d items filled: %s; still missing: %s.\nAre you happy with this version, or shall we keep refining it?"},
+	MsgNone:                      {Other: "none"},
+
+	MsgVoiceUnavailable:       {Other: "I couldn't transcribe that voice message. Please type it instead."},
+	MsgVoiceTranscribed:       {Other: "(Transcript: %s)"},
+	MsgDocumentUnsupported:    {Other: "Only PDF, TXT or Markdown documents can be attached as references."},
+	MsgDocumentAttached:       {Other: "Attached \"%s\" to your current goal as a reference."},
+	MsgDocumentAttachedNoName: {Other: "Attached this document to your current goal as a reference."},
+	MsgEditApplied:            {Other: "Updated your goal details from the edited message."},
+
+	MsgNoActiveGoal:    {Other: "You don't have a current goal. Send /goal to start one, or /goals to switch to an existing goal."},
+	MsgResetConfirm:    {Other: "Archive the current goal draft and start over? Reply \"confirm\" to continue; anything else cancels."},
+	MsgResetDone:       {Other: "Archived the current goal draft. Let's start over: what do you want to achieve, and by when?"},
+	MsgResetCancelled:  {Other: "Reset cancelled, your current goal is unchanged."},
+	MsgUndoDone:        {Other: "Undid the changes from your last message \"%s\"."},
+	MsgUndoNothing:     {Other: "There is nothing to undo."},
+	MsgSkipDone:        {Other: "OK, leaving \"%s\" empty for now."},
+	MsgSkipUsage:       {Other: "Tell me which item to skip, e.g. /skip risk_flags. Skippable: %s."},
+	MsgSkipNotAllowed:  {Other: "\"%s\" is required and can't be skipped. Skippable: %s."},
+	MsgSkipAlreadyDone: {Other: "\"%s\" is already filled in, no need to skip it."},
+	MsgSessionStatus:   {Other: "[Status] %s (%s)\nFilled: %s\nMissing: %s\nSkipped: %s\nConversation: %s"},
+	MsgTurnCount:       {One: "%s turn", Other: "%s turns"},
+
+	MsgNoGoals:             {Other: "You don't have any goals yet. Send /goal to start one."},
+	MsgGoalUsage:           {Other: "Options: /goal new to start a goal, /goal switch <number> to switch, /goal archive <number> to archive."},
+	MsgGoalNumberInvalid:   {Other: "I couldn't find that goal number. Send /goals to see your goals."},
+	MsgGoalSwitched:        {Other: "Switched to goal #%d: %s"},
+	MsgGoalArchived:        {Other: "Archived goal #%d: %s. Send /goal new to start a new one."},
+	MsgGoalArchivedAlready: {Other: "Goal #%d is archived and can't be switched to or archived again."},
+	MsgGoalListTitle:       {Other: "Your goals:"},
+	MsgGoalListItem:        {Other: "%d. %s (%s)"},
+	MsgGoalListCurrent:     {Other: " ← current"},
+	MsgGoalUntitled:        {Other: "Untitled goal"},
+	MsgGoalStatusDraft:     {Other: "draft"},
+	MsgGoalStatusActive:    {Other: "active"},
+	MsgGoalStatusArchived:  {Other: "archived"},
+
+	MsgFallbackGuidance: {Other: "I didn't quite get that. You can tell me more about your main goal, success criteria, current level, time budget or constraints; I'll keep the context."},
+	MsgReviewFallback:   {Other: "If this version works for you, reply \"confirm\"; to change it, say \"change\" plus what you want. I'll keep the context."},
+	MsgDisambiguate:     {Other: "I'm not sure what you meant. Do you want to:\n%s"},
+	MsgOptionConfirm:    {Other: "confirm the current plan (reply \"confirm\")"},
+	MsgOptionClarify:    {Other: "add to or change your goal (just tell me what to change)"},
+	MsgSessionResumed:   {Other: "Welcome back! It's been a while since we last talked, so here's where we left off:"},
+	MsgRecapSlots:       {Other: "Filled: %s\nMissing: %s"},
+	MsgRecapTurns:       {Other: "Recent messages:"},
+	MsgRecapTurnLine:    {Other: "- %s: %s"},
+	MsgSpeakerUser:      {Other: "You"},
+	MsgSpeakerBot:       {Other: "Me"},
+
+	MsgTimeoutCurrent: {Other: "Your session timeout is %s. If you come back after that, I'll start with a recap. Send /timeout default to restore the default."},
+	MsgTimeoutDefault: {Other: "You're using the default session timeout: %s. Send /timeout 12h to customize it."},
+	MsgTimeoutUpdated: {Other: "Session timeout set to %s."},
+	MsgTimeoutUsage:   {Other: "Usage: /timeout 12h, /timeout 90m, /timeout 8 (hours) or /timeout default, between 10 minutes and 30 days."},
+	MsgHours:          {One: "%s hour", Other: "%s hours"},
+	MsgMinutes:        {One: "%s minute", Other: "%s minutes"},
+
+	MsgLangCurrent: {Other: "Current language: %s. Send /lang zh for Chinese or /lang en for English."},
+	MsgLangUpdated: {Other: "Language switched to %s."},
+	MsgLangUsage:   {Other: "Usage: /lang zh or /lang en."},
+
+	MsgMyDataCaption:        {Other: "Here is everything Aiden stores about you: your account, goals, plans and conversations."},
+	MsgMyDataFailed:         {Other: "Exporting your data failed. Please try again later."},
+	MsgMyDataUsage:          {Other: "Usage: /mydata or /mydata json for JSON, /mydata zip for a ZIP archive."},
+	MsgDeleteMeWarning:      {Other: "⚠️ This permanently deletes your account, all goals, plans and conversations. It cannot be undone. Consider sending /mydata first to keep a copy. To go ahead, send /deleteme confirm within %d minutes; send /deleteme cancel to keep your data."},
+	MsgDeleteMeConfirmAgain: {Other: "Final check: your data is deleted as soon as you send /deleteme confirm again. Send /deleteme cancel to keep it."},
+	MsgDeleteMeDone:         {Other: "All your data has been deleted. Goodbye! Send /start any time to begin again."},
+	MsgDeleteMeCancelled:    {Other: "Deletion cancelled. Your data is unchanged."},
+	MsgDeleteMeNotRequested: {Other: "There is no pending deletion request, or it has expired. Send /deleteme first."},
+	MsgDeleteMeUsage:        {Other: "Usage: /deleteme to start, /deleteme confirm to confirm, /deleteme cancel to cancel."},
+
+	MsgStateIdle:       {Other: "not started"},
+	MsgStateClarifying: {Other: "clarifying"},
+	MsgStateReview:     {Other: "awaiting confirmation"},
+	MsgStateConfirmed:  {Other: "confirmed"},
+
+	MsgSlotMainGoal:        {Other: "Main goal"},
+	MsgSlotSuccessCriteria: {Other: "Success criteria"},
+	MsgSlotCurrentLevel:    {Other: "Current level"},
+	MsgSlotTimeBudget:      {Other: "Time budget"},
+	MsgSlotConstraints:     {Other: "Constraints"},
+	MsgSlotRiskFlags:       {Other: "Risks"},
+	MsgSlotOption:          {Other: "%s (%s)"},
+
+	MsgQuestionMainGoal:        {Other: "What main goal do you want to reach, and by when?"},
+	MsgQuestionSuccessCriteria: {Other: "Give me 3-5 success criteria I can check against (numbers help)."},
+	MsgQuestionCurrentLevel:    {Other: "What's your current level (complete beginner / some basics / project experience)?"},
+	MsgQuestionTimeBudget:      {Other: "How many hours a week can you put in, or which time slots are fixed for learning?"},
+	MsgQuestionConstraints:     {Other: "Any constraints that affect execution (overtime, devices, available time slots)?"},
+	MsgQuestionRiskFlags:       {Other: "What risks might stop you from sticking with it (travel, procrastination, emergencies)?"},
+}
@@ -0,0 +1,111 @@
+package telegram
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/congregalis/aiden/internal/i18n"
+)
+
+func zh(id string, args ...any) string {
+	return tr(i18n.ZhCN, id, args...)
+}
+
+func TestMessageCatalogIsComplete(t *testing.T) {
+	for _, lang := range i18n.Locales() {
+		if missing := messages.Missing(lang); len(missing) > 0 {
+			t.Fatalf("locale %s is missing messages: %v", lang, missing)
+		}
+	}
+}
+
+func TestFormatTimeoutPluralizes(t *testing.T) {
+	if got := formatTimeout(i18n.En, time.Hour); got != "1 hour" {
+		t.Fatalf("formatTimeout(en, 1h)=%q", got)
+	}
+	if got := formatTimeout(i18n.En, 90*time.Minute); got != "90 minutes" {
+		t.Fatalf("formatTimeout(en, 90m)=%q", got)
+	}
+	if got := formatTimeout(i18n.ZhCN, time.Hour); got != "1 小时" {
+		t.Fatalf("formatTimeout(zh-CN, 1h)=%q", got)
+	}
+}
+
+func TestIntentRouterUnderstandsEnglish(t *testing.T) {
+	router := NewIntentRouter(nil)
+
+	tests := []struct {
+		text   string
+		intent string
+		slot   string
+	}{
+		{text: "Looks good!", intent: IntentConfirmPlan},
+		{text: "I want to read a book every week", intent: IntentClarifyGoal},
+		{text: "status", intent: IntentShowStatus},
+		{text: "start over", intent: IntentResetSession},
+		{text: "Undo.", intent: IntentUndoTurn},
+		{text: "skip risks", intent: IntentSkipSlot, slot: SlotRiskFlags},
+		{text: "skipping breakfast is a constraint", intent: IntentClarifyGoal},
+	}
+	for _, tt := range tests {
+		got := router.Route(tt.text, StateClarifying)
+		if got.Intent != tt.intent || got.Slot != tt.slot {
+			t.Fatalf("Route(%q)=%+v, want intent %q slot %q", tt.text, got, tt.intent, tt.slot)
+		}
+	}
+}
+
+func TestWorkerClarifiesInEnglish(t *testing.T) {
+	store := NewMemoryStore()
+	from := &TelegramUser{ID: 7, FirstName: "Sam", LanguageCode: "en-US"}
+	chat := Chat{ID: 92001}
+	texts := []string{
+		"/start",
+		"I want to pass the JLPT N2 within a year",
+		"Success criteria: 1. finish two textbooks 2. pass a mock exam. I'm a beginner and can study 6 hours per week",
+		"I only have evenings, and I'm worried I'll procrastinate",
+		"looks good",
+		"/lang zh",
+		"/status",
+	}
+	batches := make([][]Update, 0, len(texts))
+	for i, text := range texts {
+		batches = append(batches, []Update{{UpdateID: int64(i + 1), Message: &Message{MessageID: int64(i + 1), From: from, Chat: chat, Text: text}}})
+	}
+	client := &scriptedClient{updates: batches}
+
+	if err := runWorkerUntilSendCount(t, client, store, len(texts)); err != nil {
+		t.Fatalf("worker run failed: %v", err)
+	}
+	sent := client.SentMessages()
+
+	if want := tr(i18n.En, MsgStart); sent[0].Text != want {
+		t.Fatalf("/start reply=%q, want %q", sent[0].Text, want)
+	}
+	if !strings.HasPrefix(sent[1].Text, tr(i18n.En, MsgFollowUpIntro)) {
+		t.Fatalf("second reply=%q, want English follow-up questions", sent[1].Text)
+	}
+	if !strings.HasPrefix(sent[3].Text, tr(i18n.En, MsgReviewReady)) {
+		t.Fatalf("fourth reply=%q, want review prompt", sent[3].Text)
+	}
+	if want := tr(i18n.En, MsgPlanConfirmed); sent[4].Text != want {
+		t.Fatalf("confirm reply=%q, want %q", sent[4].Text, want)
+	}
+	if want := "已切换为简体中文。"; sent[5].Text != want {
+		t.Fatalf("/lang reply=%q, want %q", sent[5].Text, want)
+	}
+	if !strings.HasPrefix(sent[6].Text, "【当前状态】已确认") {
+		t.Fatalf("/status reply=%q, want Chinese status", sent[6].Text)
+	}
+
+	user, _ := store.UserByChatID(chat.ID)
+	if user.Language != i18n.ZhCN {
+		t.Fatalf("language=%q, want %q", user.Language, i18n.ZhCN)
+	}
+	goal, _, _ := store.GetCurrentGoalByUserID(context.Background(), user.ID)
+	if goal.Status != GoalStatusActive {
+		t.Fatalf("goal status=%q, want active", goal.Status)
+	}
+}
@@ -0,0 +1,419 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+const (
+	OutboxStatusPending = "pending"
+	OutboxStatusSent    = "sent"
+	OutboxStatusDead    = "dead"
+)
+
+// outboxUnreachableError is recorded on messages enqueued for a chat that has
+// already blocked the bot; they are stored dead instead of being sent.
+const outboxUnreachableError = "chat unreachable"
+
+const (
+	defaultOutboxPollInterval = time.Second
+	defaultOutboxBatchSize    = 50
+	defaultOutboxMaxAttempts  = 8
+	defaultOutboxRetryBase    = 2 * time.Second
+	defaultOutboxRetryMax     = 5 * time.Minute
+	// defaultOutboxClaimLease is how long a claimed batch belongs to one
+	// dispatcher; rows it has not reached by then are left to the next claim.
+	defaultOutboxClaimLease  = time.Minute
+	maxOutboxLastErrorLength = 500
+)
+
+type OutboxMessage struct {
+	ID               int64
+	ChatID           int64
+	Text             string
+	ReplyToMessageID int64
+	Status           string
+	Attempts         int
+	LastError        string
+	NextAttemptAt    time.Time
+	SentAt           *time.Time
+	CreatedAt        time.Time
+	UpdatedAt        time.Time
+	Export           *OutboxExport
+}
+
+// OutboxExport asks the dispatcher to build a user's /mydata export and send
+// it as a document. Only the request is stored, never the export itself.
+type OutboxExport struct {
+	UserID   string `json:"user_id"`
+	Format   string `json:"format"`
+	Language string `json:"language"`
+}
+
+func (m OutboxMessage) Outgoing() OutgoingMessage {
+	return OutgoingMessage{
+		ChatID:           m.ChatID,
+		Text:             m.Text,
+		ReplyToMessageID: m.ReplyToMessageID,
+		Export:           m.Export,
+	}
+}
+
+type OutboxStore interface {
+	EnqueueOutgoingMessage(context.Context, OutgoingMessage) (int64, error)
+	ClaimDueOutgoingMessages(context.Context, int, time.Duration) ([]OutboxMessage, error)
+	MarkOutgoingMessageSent(context.Context, int64) error
+	RescheduleOutgoingMessage(context.Context, int64, time.Time, string) error
+	DeferOutgoingMessage(context.Context, int64, time.Time, string) error
+	MarkOutgoingMessageDead(context.Context, int64, string) error
+	ListDeadOutgoingMessages(context.Context, int) ([]OutboxMessage, error)
+	CountPendingOutgoingMessages(context.Context) (int64, error)
+	MarkChatUnreachable(context.Context, int64, string) error
+	MigrateChatID(context.Context, int64, int64) error
+	ExportUserData(context.Context, string) (UserExport, bool, error)
+}
+
+type OutboxConfig struct {
+	PollInterval time.Duration
+	BatchSize    int
+	MaxAttempts  int
+}
+
+type OutboxDispatcher struct {
+	store        OutboxStore
+	sender       Sender
+	logger       *slog.Logger
+	pollInterval time.Duration
+	batchSize    int
+	maxAttempts  int
+	retryBase    time.Duration
+	retryMax     time.Duration
+	claimLease   time.Duration
+	wake         chan struct{}
+	now          func() time.Time
+	mu           sync.Mutex
+	pausedUntil  time.Time
+}
+
+func NewOutboxDispatcher(cfg OutboxConfig, store OutboxStore, sender Sender, logger *slog.Logger) *OutboxDispatcher {
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	pollInterval := cfg.PollInterval
+	if pollInterval <= 0 {
+		pollInterval = defaultOutboxPollInterval
+	}
+	batchSize := cfg.BatchSize
+	if batchSize <= 0 {
+		batchSize = defaultOutboxBatchSize
+	}
+	maxAttempts := cfg.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = defaultOutboxMaxAttempts
+	}
+
+	return &OutboxDispatcher{
+		store:        store,
+		sender:       sender,
+		logger:       logger,
+		pollInterval: pollInterval,
+		batchSize:    batchSize,
+		maxAttempts:  maxAttempts,
+		retryBase:    defaultOutboxRetryBase,
+		retryMax:     defaultOutboxRetryMax,
+		claimLease:   defaultOutboxClaimLease,
+		wake:         make(chan struct{}, 1),
+		now:          time.Now,
+	}
+}
+
+// Notify wakes the dispatcher after new rows have been committed.
+func (d *OutboxDispatcher) Notify() {
+	select {
+	case d.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (d *OutboxDispatcher) Run(ctx context.Context) {
+	d.logger.Info("telegram outbox dispatcher started",
+		slog.Duration("poll_interval", d.pollInterval),
+		slog.Int("batch_size", d.batchSize),
+		slog.Int("max_attempts", d.maxAttempts),
+	)
+
+	ticker := time.NewTicker(d.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		d.mu.Lock()
+		pause := d.pausedUntil.Sub(d.now())
+		d.mu.Unlock()
+		if pause > 0 {
+			if !sleepWithContext(ctx, pause) {
+				d.logger.Info("telegram outbox dispatcher stopped")
+				return
+			}
+		}
+
+		sent, err := d.DispatchOnce(ctx)
+		if err != nil && ctx.Err() == nil {
+			d.logger.Warn("outbox_dispatch_failed", slog.Any("error", err))
+		}
+
+		// Sending a chat's head row may have uncovered the next row of that chat.
+		if err == nil && sent > 0 {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			d.logger.Info("telegram outbox dispatcher stopped")
+			return
+		case <-d.wake:
+		case <-ticker.C:
+		}
+	}
+}
+
+// DispatchOnce claims one batch of due messages and returns how many were
+// sent. The batch holds at most one row per chat, so each row gets its own
+// goroutine: a chat waiting for its bucket does not hold up the others, and
+// the limiter grants the waiting chats round-robin.
+func (d *OutboxDispatcher) DispatchOnce(ctx context.Context) (int, error) {
+	leaseEnd := d.now().Add(d.claimLease)
+	due, err := d.store.ClaimDueOutgoingMessages(ctx, d.batchSize, d.claimLease)
+	if err != nil {
+		return 0, fmt.Errorf("claim due outgoing messages: %w", err)
+	}
+
+	// Past the lease another instance may claim these rows, so stop waiting
+	// for the limiter by then.
+	sendCtx, cancel := context.WithDeadline(ctx, leaseEnd)
+	defer cancel()
+
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		sent int
+		errs []error
+	)
+	for _, message := range due {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ok, err := d.dispatch(ctx, sendCtx, message)
+			mu.Lock()
+			defer mu.Unlock()
+			if ok {
+				sent++
+			}
+			if err != nil {
+				errs = append(errs, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	return sent, errors.Join(errs...)
+}
+
+// dispatch sends one claimed row and records the outcome. It reports whether
+// the message was sent.
+func (d *OutboxDispatcher) dispatch(ctx, sendCtx context.Context, message OutboxMessage) (bool, error) {
+	sendErr := d.send(sendCtx, message)
+	if sendErr == nil {
+		if err := d.store.MarkOutgoingMessageSent(ctx, message.ID); err != nil {
+			return true, fmt.Errorf("mark outgoing message %d sent: %w", message.ID, err)
+		}
+		return true, nil
+	}
+	if ctx.Err() != nil {
+		return false, ctx.Err()
+	}
+	if sendCtx.Err() != nil {
+		// The lease ran out; the row is due again for whoever claims it.
+		return false, nil
+	}
+
+	attempts := message.Attempts + 1
+	lastError := truncateOutboxError(sendErr)
+
+	if retryAfter, isRateLimited := IsRateLimitError(sendErr); isRateLimited {
+		// A 429 says nothing about the message itself, so it does not count
+		// towards maxAttempts.
+		nextAttemptAt := d.now().Add(retryAfter)
+		if err := d.store.DeferOutgoingMessage(ctx, message.ID, nextAttemptAt, lastError); err != nil {
+			return false, fmt.Errorf("reschedule outgoing message %d: %w", message.ID, err)
+		}
+		d.pauseUntil(nextAttemptAt)
+		d.logger.Warn("outbox_rate_limited",
+			slog.Int64("outbox_id", message.ID),
+			slog.String("chat_id_masked", MaskChatID(message.ChatID)),
+			slog.Duration("retry_after", retryAfter),
+			slog.Int64("send_queue_depth", d.sender.limiter.QueueDepth()),
+		)
+		// Telegram rate limits are bot-wide: the limiter pause holds back the
+		// rest of the batch and Run waits before claiming the next one.
+		return false, nil
+	}
+
+	if toChatID, migrated := IsChatMigrated(sendErr); migrated {
+		migrateErr := d.store.MigrateChatID(ctx, message.ChatID, toChatID)
+		if errors.Is(migrateErr, ErrChatIDTaken) {
+			// Retrying cannot help; an operator has to merge the two users.
+			if err := d.store.MarkOutgoingMessageDead(ctx, message.ID, truncateOutboxError(migrateErr)); err != nil {
+				return false, fmt.Errorf("mark outgoing message %d dead: %w", message.ID, err)
+			}
+			d.logger.Error("telegram_chat_migration_conflict",
+				slog.Int64("outbox_id", message.ID),
+				slog.String("chat_id_masked", MaskChatID(message.ChatID)),
+				slog.String("new_chat_id_masked", MaskChatID(toChatID)),
+			)
+			return false, nil
+		}
+		if migrateErr != nil {
+			return false, fmt.Errorf("migrate chat for outgoing message %d: %w", message.ID, migrateErr)
+		}
+		if err := d.store.DeferOutgoingMessage(ctx, message.ID, d.now(), lastError); err != nil {
+			return false, fmt.Errorf("reschedule outgoing message %d: %w", message.ID, err)
+		}
+		d.logger.Info("telegram_chat_migrated",
+			slog.Int64("outbox_id", message.ID),
+			slog.String("chat_id_masked", MaskChatID(message.ChatID)),
+			slog.String("new_chat_id_masked", MaskChatID(toChatID)),
+		)
+		return false, nil
+	}
+
+	if IsChatUnreachable(sendErr) {
+		if err := d.store.MarkOutgoingMessageDead(ctx, message.ID, lastError); err != nil {
+			return false, fmt.Errorf("mark outgoing message %d dead: %w", message.ID, err)
+		}
+		if err := d.store.MarkChatUnreachable(ctx, message.ChatID, lastError); err != nil {
+			return false, fmt.Errorf("mark chat unreachable for outgoing message %d: %w", message.ID, err)
+		}
+		d.logger.Warn("telegram_chat_unreachable",
+			slog.Int64("outbox_id", message.ID),
+			slog.String("chat_id_masked", MaskChatID(message.ChatID)),
+			slog.Any("error", sendErr),
+		)
+		return false, nil
+	}
+
+	wait, retryable := d.sender.retryDecision(sendErr, d.retryDelay(attempts))
+	// The user was deleted after asking for an export; nobody is left to tell.
+	userGone := errors.Is(sendErr, ErrUserNotFound)
+	if !retryable || userGone || attempts >= d.maxAttempts {
+		if err := d.store.MarkOutgoingMessageDead(ctx, message.ID, lastError); err != nil {
+			return false, fmt.Errorf("mark outgoing message %d dead: %w", message.ID, err)
+		}
+		if message.Export != nil && !userGone {
+			if err := d.reportExportFailure(ctx, message); err != nil {
+				return false, err
+			}
+		}
+		d.logger.Error("outbox_message_dead",
+			slog.Int64("outbox_id", message.ID),
+			slog.String("chat_id_masked", MaskChatID(message.ChatID)),
+			slog.Int("attempts", attempts),
+			slog.Bool("retryable", retryable),
+			slog.Any("error", sendErr),
+		)
+		return false, nil
+	}
+
+	if err := d.store.RescheduleOutgoingMessage(ctx, message.ID, d.now().Add(wait), lastError); err != nil {
+		return false, fmt.Errorf("reschedule outgoing message %d: %w", message.ID, err)
+	}
+	d.logger.Warn("outbox_message_retry_scheduled",
+		slog.Int64("outbox_id", message.ID),
+		slog.String("chat_id_masked", MaskChatID(message.ChatID)),
+		slog.Int("attempts", attempts),
+		slog.Duration("wait", wait),
+		slog.Any("error", sendErr),
+	)
+	return false, nil
+}
+
+// send makes one delivery attempt. Export rows are built from the user's
+// current data right before the upload.
+func (d *OutboxDispatcher) send(ctx context.Context, message OutboxMessage) error {
+	if message.Export == nil {
+		return d.sender.SendOnce(ctx, message.Outgoing())
+	}
+
+	export, found, err := d.store.ExportUserData(ctx, message.Export.UserID)
+	if err != nil {
+		return fmt.Errorf("export user data: %w", err)
+	}
+	if !found {
+		return ErrUserNotFound
+	}
+	fileName, content, err := EncodeUserExport(export, message.Export.Format)
+	if err != nil {
+		return fmt.Errorf("encode user export: %w", err)
+	}
+	if err := d.sender.SendDocumentOnce(ctx, OutgoingDocument{
+		ChatID:           message.ChatID,
+		FileName:         fileName,
+		Content:          content,
+		Caption:          message.Text,
+		ReplyToMessageID: message.ReplyToMessageID,
+	}); err != nil {
+		return err
+	}
+
+	d.logger.Info("user_export_sent",
+		slog.Int64("outbox_id", message.ID),
+		slog.String("user_id", message.Export.UserID),
+		slog.String("format", message.Export.Format),
+		slog.Int("bytes", len(content)),
+	)
+	return nil
+}
+
+// reportExportFailure tells the user that their export gave up.
+func (d *OutboxDispatcher) reportExportFailure(ctx context.Context, message OutboxMessage) error {
+	if _, err := d.store.EnqueueOutgoingMessage(ctx, OutgoingMessage{
+		ChatID:           message.ChatID,
+		Text:             tr(message.Export.Language, MsgMyDataFailed),
+		ReplyToMessageID: message.ReplyToMessageID,
+	}); err != nil {
+		return fmt.Errorf("enqueue export failure reply for outgoing message %d: %w", message.ID, err)
+	}
+	return nil
+}
+
+func (d *OutboxDispatcher) pauseUntil(until time.Time) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if until.After(d.pausedUntil) {
+		d.pausedUntil = until
+	}
+}
+
+func (d *OutboxDispatcher) retryDelay(attempts int) time.Duration {
+	delay := d.retryBase
+	for i := 1; i < attempts; i++ {
+		delay *= 2
+		if delay >= d.retryMax {
+			return d.retryMax
+		}
+	}
+	return delay
+}
+
+func truncateOutboxError(err error) string {
+	message := err.Error()
+	runes := []rune(message)
+	if len(runes) <= maxOutboxLastErrorLength {
+		return message
+	}
+	return string(runes[:maxOutboxLastErrorLength])
+}
@@ -0,0 +1,262 @@
+package telegram
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+)
+
+const outboxSelectColumns = `id, chat_id, text, reply_to_message_id, status, attempts, last_error,
+		        next_attempt_at, sent_at, created_at, updated_at, export`
+
+// EnqueueOutgoingMessage stores messages for chats marked unreachable as dead
+// right away, so the dispatcher never sends to a chat that blocked the bot.
+func (s *SQLStore) EnqueueOutgoingMessage(ctx context.Context, message OutgoingMessage) (int64, error) {
+	var exportJSON []byte
+	if message.Export != nil {
+		raw, err := json.Marshal(message.Export)
+		if err != nil {
+			return 0, fmt.Errorf("marshal outgoing export: %w", err)
+		}
+		exportJSON = raw
+	}
+
+	var id int64
+	err := s.db.QueryRowContext(
+		ctx,
+		`INSERT INTO outgoing_messages(
+		    chat_id,
+		    text,
+		    reply_to_message_id,
+		    status,
+		    last_error,
+		    next_attempt_at,
+		    created_at,
+		    updated_at,
+		    export
+		 )
+		 SELECT $1, $2, $3,
+		        CASE WHEN unreachable THEN 'dead' ELSE 'pending' END,
+		        CASE WHEN unreachable THEN $4 ELSE '' END,
+		        NOW(), NOW(), NOW(), $5::jsonb
+		 FROM (
+		     SELECT EXISTS (
+		         SELECT 1 FROM users WHERE telegram_chat_id = $1 AND NOT is_reachable
+		     ) AS unreachable
+		 ) AS chat
+		 RETURNING id`,
+		message.ChatID,
+		message.Text,
+		message.ReplyToMessageID,
+		outboxUnreachableError,
+		exportJSON,
+	).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("insert outgoing message: %w", err)
+	}
+
+	return id, nil
+}
+
+// ClaimDueOutgoingMessages only considers the oldest pending row of each
+// chat, so a message waiting for a retry is never overtaken by a later one.
+// Claimed rows are pushed lease into the future; the UPDATE re-checks
+// next_attempt_at after waiting for a concurrent claim, so two instances
+// never get the same row. If the claimer dies, the rows are due again once
+// the lease ends and only the message that was in flight can be sent twice.
+func (s *SQLStore) ClaimDueOutgoingMessages(ctx context.Context, limit int, lease time.Duration) ([]OutboxMessage, error) {
+	rows, err := s.db.QueryContext(
+		ctx,
+		`WITH due AS (
+		     SELECT id AS due_id
+		     FROM (
+		         SELECT DISTINCT ON (chat_id) id, next_attempt_at
+		         FROM outgoing_messages
+		         WHERE status = 'pending'
+		         ORDER BY chat_id, id
+		     ) AS head
+		     WHERE next_attempt_at <= NOW()
+		     ORDER BY id
+		     LIMIT $1
+		 )
+		 UPDATE outgoing_messages
+		 SET next_attempt_at = NOW() + $2::float8 * INTERVAL '1 second',
+		     updated_at = NOW()
+		 FROM due
+		 WHERE id = due.due_id
+		   AND status = 'pending'
+		   AND next_attempt_at <= NOW()
+		 RETURNING `+outboxSelectColumns,
+		limit,
+		lease.Seconds(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("claim due outgoing messages: %w", err)
+	}
+
+	messages, err := scanOutboxMessages(rows)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(messages, func(i, j int) bool { return messages[i].ID < messages[j].ID })
+	return messages, nil
+}
+
+// CountPendingOutgoingMessages counts every pending row, including those
+// waiting for a retry.
+func (s *SQLStore) CountPendingOutgoingMessages(ctx context.Context) (int64, error) {
+	var count int64
+	if err := s.db.QueryRowContext(
+		ctx,
+		`SELECT COUNT(*) FROM outgoing_messages WHERE status = 'pending'`,
+	).Scan(&count); err != nil {
+		return 0, fmt.Errorf("count pending outgoing messages: %w", err)
+	}
+	return count, nil
+}
+
+func (s *SQLStore) MarkOutgoingMessageSent(ctx context.Context, id int64) error {
+	return s.updateOutgoingMessage(
+		ctx,
+		id,
+		`UPDATE outgoing_messages
+		 SET status = 'sent',
+		     attempts = attempts + 1,
+		     last_error = '',
+		     sent_at = NOW(),
+		     updated_at = NOW()
+		 WHERE id = $1`,
+		id,
+	)
+}
+
+func (s *SQLStore) RescheduleOutgoingMessage(ctx context.Context, id int64, nextAttemptAt time.Time, lastError string) error {
+	return s.updateOutgoingMessage(
+		ctx,
+		id,
+		`UPDATE outgoing_messages
+		 SET attempts = attempts + 1,
+		     last_error = $2,
+		     next_attempt_at = $3,
+		     updated_at = NOW()
+		 WHERE id = $1`,
+		id,
+		lastError,
+		nextAttemptAt,
+	)
+}
+
+// DeferOutgoingMessage pushes a message back without counting an attempt,
+// for delays that say nothing about whether it can be delivered.
+func (s *SQLStore) DeferOutgoingMessage(ctx context.Context, id int64, nextAttemptAt time.Time, lastError string) error {
+	return s.updateOutgoingMessage(
+		ctx,
+		id,
+		`UPDATE outgoing_messages
+		 SET last_error = $2,
+		     next_attempt_at = $3,
+		     updated_at = NOW()
+		 WHERE id = $1`,
+		id,
+		lastError,
+		nextAttemptAt,
+	)
+}
+
+func (s *SQLStore) MarkOutgoingMessageDead(ctx context.Context, id int64, lastError string) error {
+	return s.updateOutgoingMessage(
+		ctx,
+		id,
+		`UPDATE outgoing_messages
+		 SET status = 'dead',
+		     attempts = attempts + 1,
+		     last_error = $2,
+		     updated_at = NOW()
+		 WHERE id = $1`,
+		id,
+		lastError,
+	)
+}
+
+func (s *SQLStore) ListDeadOutgoingMessages(ctx context.Context, limit int) ([]OutboxMessage, error) {
+	rows, err := s.db.QueryContext(
+		ctx,
+		`SELECT `+outboxSelectColumns+`
+		 FROM outgoing_messages
+		 WHERE status = 'dead'
+		 ORDER BY updated_at DESC, id DESC
+		 LIMIT $1`,
+		limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("query dead outgoing messages: %w", err)
+	}
+
+	return scanOutboxMessages(rows)
+}
+
+func (s *SQLStore) updateOutgoingMessage(ctx context.Context, id int64, query string, args ...any) error {
+	result, err := s.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("update outgoing message %d: %w", id, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("read update outgoing message rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("outgoing message %d not found", id)
+	}
+
+	return nil
+}
+
+func scanOutboxMessages(rows *sql.Rows) ([]OutboxMessage, error) {
+	defer rows.Close()
+
+	messages := make([]OutboxMessage, 0)
+	for rows.Next() {
+		var (
+			message    OutboxMessage
+			sentAt     sql.NullTime
+			exportJSON []byte
+		)
+		if err := rows.Scan(
+			&message.ID,
+			&message.ChatID,
+			&message.Text,
+			&message.ReplyToMessageID,
+			&message.Status,
+			&message.Attempts,
+			&message.LastError,
+			&message.NextAttemptAt,
+			&sentAt,
+			&message.CreatedAt,
+			&message.UpdatedAt,
+			&exportJSON,
+		); err != nil {
+			return nil, fmt.Errorf("scan outgoing message: %w", err)
+		}
+		if len(exportJSON) > 0 {
+			message.Export = &OutboxExport{}
+			if err := json.Unmarshal(exportJSON, message.Export); err != nil {
+				return nil, fmt.Errorf("decode outgoing message %d export: %w", message.ID, err)
+			}
+		}
+		if sentAt.Valid {
+			sentAtValue := sentAt.Time
+			message.SentAt = &sentAtValue
+		}
+		messages = append(messages, message)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate outgoing messages: %w", err)
+	}
+
+	return messages, nil
+}
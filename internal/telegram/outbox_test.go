@@ -0,0 +1,414 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestOutboxDispatcherReschedulesOnRateLimit(t *testing.T) {
+	store := NewMemoryStore()
+	ctx := context.Background()
+	if _, err := store.EnqueueOutgoingMessage(ctx, OutgoingMessage{ChatID: 1, Text: "hello"}); err != nil {
+		t.Fatalf("enqueue failed: %v", err)
+	}
+
+	rateLimited := &APIError{StatusCode: http.StatusTooManyRequests, RetryAfter: 3 * time.Millisecond}
+	client := &sendStubClient{errors: []error{rateLimited}}
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	dispatcher := NewOutboxDispatcher(OutboxConfig{}, store, NewSender(client, logger), logger)
+
+	sent, err := dispatcher.DispatchOnce(ctx)
+	if err != nil {
+		t.Fatalf("DispatchOnce() returned error: %v", err)
+	}
+	if sent != 0 {
+		t.Fatalf("sent=%d, want 0", sent)
+	}
+	if client.sendAttempts != 1 {
+		t.Fatalf("send attempts=%d, want 1: retries belong to next_attempt_at", client.sendAttempts)
+	}
+
+	pending := store.OutboxMessages()[0]
+	if pending.Status != OutboxStatusPending {
+		t.Fatalf("status=%q, want %q", pending.Status, OutboxStatusPending)
+	}
+	if pending.Attempts != 0 {
+		t.Fatalf("attempts=%d, want 0: rate limits do not count as attempts", pending.Attempts)
+	}
+	if !pending.NextAttemptAt.After(pending.CreatedAt) {
+		t.Fatalf("next attempt was not pushed back: %v", pending.NextAttemptAt)
+	}
+
+	time.Sleep(5 * time.Millisecond)
+	if sent, err = dispatcher.DispatchOnce(ctx); err != nil || sent != 1 {
+		t.Fatalf("second DispatchOnce()=(%d, %v), want (1, nil)", sent, err)
+	}
+	if got := store.OutboxMessages()[0].Status; got != OutboxStatusSent {
+		t.Fatalf("status=%q, want %q", got, OutboxStatusSent)
+	}
+}
+
+func TestOutboxDispatcherSendsAfterMoreRateLimitsThanMaxAttempts(t *testing.T) {
+	store := NewMemoryStore()
+	ctx := context.Background()
+	if _, err := store.EnqueueOutgoingMessage(ctx, OutgoingMessage{ChatID: 1, Text: "hello"}); err != nil {
+		t.Fatalf("enqueue failed: %v", err)
+	}
+
+	const maxAttempts = 3
+	rateLimited := &APIError{StatusCode: http.StatusTooManyRequests, RetryAfter: time.Millisecond}
+	client := &sendStubClient{errors: []error{rateLimited, rateLimited, rateLimited}}
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	dispatcher := NewOutboxDispatcher(OutboxConfig{MaxAttempts: maxAttempts}, store, NewSender(client, logger), logger)
+
+	for i := 0; i < maxAttempts; i++ {
+		if sent, err := dispatcher.DispatchOnce(ctx); err != nil || sent != 0 {
+			t.Fatalf("DispatchOnce() #%d=(%d, %v), want (0, nil)", i+1, sent, err)
+		}
+		if status := store.OutboxMessages()[0].Status; status != OutboxStatusPending {
+			t.Fatalf("status after 429 #%d=%q, want %q", i+1, status, OutboxStatusPending)
+		}
+		time.Sleep(3 * time.Millisecond)
+	}
+
+	if sent, err := dispatcher.DispatchOnce(ctx); err != nil || sent != 1 {
+		t.Fatalf("DispatchOnce() after the rate limits=(%d, %v), want (1, nil)", sent, err)
+	}
+	message := store.OutboxMessages()[0]
+	if message.Status != OutboxStatusSent || message.Attempts != 1 {
+		t.Fatalf("outbox row=%+v, want sent on the first counted attempt", message)
+	}
+}
+
+func TestOutboxDispatcherSchedulesServerErrorRetryWithoutInlineRetries(t *testing.T) {
+	store := NewMemoryStore()
+	ctx := context.Background()
+	if _, err := store.EnqueueOutgoingMessage(ctx, OutgoingMessage{ChatID: 1, Text: "hello"}); err != nil {
+		t.Fatalf("enqueue failed: %v", err)
+	}
+
+	client := &sendStubClient{errors: []error{&APIError{StatusCode: http.StatusBadGateway}}}
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	dispatcher := NewOutboxDispatcher(OutboxConfig{}, store, NewSender(client, logger), logger)
+
+	if sent, err := dispatcher.DispatchOnce(ctx); err != nil || sent != 0 {
+		t.Fatalf("DispatchOnce()=(%d, %v), want (0, nil)", sent, err)
+	}
+	if client.sendAttempts != 1 {
+		t.Fatalf("send attempts=%d, want 1", client.sendAttempts)
+	}
+	pending := store.OutboxMessages()[0]
+	if pending.Status != OutboxStatusPending || pending.Attempts != 1 || !pending.NextAttemptAt.After(pending.CreatedAt) {
+		t.Fatalf("outbox row=%+v, want a scheduled retry", pending)
+	}
+}
+
+func TestOutboxDispatcherDeadLettersNonRetryableErrors(t *testing.T) {
+	store := NewMemoryStore()
+	ctx := context.Background()
+	if _, err := store.EnqueueOutgoingMessage(ctx, OutgoingMessage{ChatID: 1, Text: "first"}); err != nil {
+		t.Fatalf("enqueue failed: %v", err)
+	}
+	if _, err := store.EnqueueOutgoingMessage(ctx, OutgoingMessage{ChatID: 1, Text: "second"}); err != nil {
+		t.Fatalf("enqueue failed: %v", err)
+	}
+
+	client := &sendStubClient{errors: []error{
+		&APIError{StatusCode: http.StatusBadRequest, Description: "bad request"},
+	}}
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	dispatcher := NewOutboxDispatcher(OutboxConfig{}, store, NewSender(client, logger), logger)
+
+	if _, err := dispatcher.DispatchOnce(ctx); err != nil {
+		t.Fatalf("DispatchOnce() returned error: %v", err)
+	}
+	if _, err := dispatcher.DispatchOnce(ctx); err != nil {
+		t.Fatalf("DispatchOnce() returned error: %v", err)
+	}
+
+	dead, err := store.ListDeadOutgoingMessages(ctx, 10)
+	if err != nil {
+		t.Fatalf("list dead failed: %v", err)
+	}
+	if len(dead) != 1 || dead[0].Text != "first" {
+		t.Fatalf("dead letters=%+v, want only first message", dead)
+	}
+	if got := store.OutboxMessages()[1].Status; got != OutboxStatusSent {
+		t.Fatalf("second message status=%q, want %q", got, OutboxStatusSent)
+	}
+}
+
+func TestWorkerQueuesReplyInOutbox(t *testing.T) {
+	store := NewMemoryStore()
+	client := &scriptedClient{
+		updates: [][]Update{{
+			{UpdateID: 1, Message: &Message{MessageID: 41, Chat: Chat{ID: 40001}, Text: "/help"}},
+		}},
+	}
+
+	if err := runWorkerUntilSendCount(t, client, store, 1); err != nil {
+		t.Fatalf("worker run failed: %v", err)
+	}
+
+	outbox := store.OutboxMessages()
+	if len(outbox) != 1 {
+		t.Fatalf("outbox rows=%d, want 1", len(outbox))
+	}
+	if outbox[0].Text != zh(MsgHelp) || outbox[0].ReplyToMessageID != 41 {
+		t.Fatalf("outbox row=%+v, want help reply to message 41", outbox[0])
+	}
+}
+
+func TestOutboxDispatcherMarksBlockedChatUnreachable(t *testing.T) {
+	store := NewMemoryStore()
+	ctx := context.Background()
+	user, _, err := store.FindOrCreateUserByChatID(ctx, 50001)
+	if err != nil {
+		t.Fatalf("seed user failed: %v", err)
+	}
+	for _, text := range []string{"first", "second"} {
+		if _, err := store.EnqueueOutgoingMessage(ctx, OutgoingMessage{ChatID: user.TelegramChatID, Text: text}); err != nil {
+			t.Fatalf("enqueue failed: %v", err)
+		}
+	}
+
+	client := &sendStubClient{errors: []error{
+		&APIError{StatusCode: http.StatusForbidden, ErrorCode: http.StatusForbidden, Description: "Forbidden: bot was blocked by the user"},
+	}}
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	dispatcher := NewOutboxDispatcher(OutboxConfig{}, store, NewSender(client, logger), logger)
+
+	if _, err := dispatcher.DispatchOnce(ctx); err != nil {
+		t.Fatalf("DispatchOnce() returned error: %v", err)
+	}
+
+	if updated, _ := store.UserByChatID(50001); updated.IsReachable {
+		t.Fatalf("expected user to be marked unreachable")
+	}
+	for _, message := range store.OutboxMessages() {
+		if message.Status != OutboxStatusDead {
+			t.Fatalf("message %q status=%q, want %q", message.Text, message.Status, OutboxStatusDead)
+		}
+	}
+	if client.sendAttempts != 1 {
+		t.Fatalf("send attempts=%d, want 1", client.sendAttempts)
+	}
+}
+
+func TestEnqueueOutgoingMessageSkipsUnreachableChat(t *testing.T) {
+	store := NewMemoryStore()
+	ctx := context.Background()
+	if _, _, err := store.FindOrCreateUserByChatID(ctx, 50004); err != nil {
+		t.Fatalf("seed user failed: %v", err)
+	}
+	if err := store.MarkChatUnreachable(ctx, 50004, "blocked"); err != nil {
+		t.Fatalf("MarkChatUnreachable() returned error: %v", err)
+	}
+	if _, err := store.EnqueueOutgoingMessage(ctx, OutgoingMessage{ChatID: 50004, Text: "reminder"}); err != nil {
+		t.Fatalf("enqueue failed: %v", err)
+	}
+
+	client := &sendStubClient{}
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	dispatcher := NewOutboxDispatcher(OutboxConfig{}, store, NewSender(client, logger), logger)
+	if sent, err := dispatcher.DispatchOnce(ctx); err != nil || sent != 0 {
+		t.Fatalf("DispatchOnce()=(%d, %v), want (0, nil)", sent, err)
+	}
+	if client.sendAttempts != 0 {
+		t.Fatalf("send attempts=%d, want 0 for an unreachable chat", client.sendAttempts)
+	}
+	message := store.OutboxMessages()[0]
+	if message.Status != OutboxStatusDead || message.LastError != outboxUnreachableError {
+		t.Fatalf("outbox row=%+v, want dead with %q", message, outboxUnreachableError)
+	}
+
+	if err := store.MarkChatReachable(ctx, 50004); err != nil {
+		t.Fatalf("MarkChatReachable() returned error: %v", err)
+	}
+	if _, err := store.EnqueueOutgoingMessage(ctx, OutgoingMessage{ChatID: 50004, Text: "welcome back"}); err != nil {
+		t.Fatalf("enqueue failed: %v", err)
+	}
+	if sent, err := dispatcher.DispatchOnce(ctx); err != nil || sent != 1 {
+		t.Fatalf("DispatchOnce() after reachable=(%d, %v), want (1, nil)", sent, err)
+	}
+}
+
+func TestOutboxDispatcherFollowsChatMigration(t *testing.T) {
+	store := NewMemoryStore()
+	ctx := context.Background()
+	if _, _, err := store.FindOrCreateUserByChatID(ctx, -50002); err != nil {
+		t.Fatalf("seed user failed: %v", err)
+	}
+	if _, err := store.EnqueueOutgoingMessage(ctx, OutgoingMessage{ChatID: -50002, Text: "hello"}); err != nil {
+		t.Fatalf("enqueue failed: %v", err)
+	}
+
+	client := &sendStubClient{errors: []error{
+		&APIError{StatusCode: http.StatusBadRequest, ErrorCode: http.StatusBadRequest, MigrateToChatID: -1005000200},
+	}}
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	dispatcher := NewOutboxDispatcher(OutboxConfig{}, store, NewSender(client, logger), logger)
+
+	if _, err := dispatcher.DispatchOnce(ctx); err != nil {
+		t.Fatalf("DispatchOnce() returned error: %v", err)
+	}
+	if sent, err := dispatcher.DispatchOnce(ctx); err != nil || sent != 1 {
+		t.Fatalf("second DispatchOnce()=(%d, %v), want (1, nil)", sent, err)
+	}
+
+	if _, ok := store.UserByChatID(-1005000200); !ok {
+		t.Fatalf("expected user to move to the migrated chat id")
+	}
+	message := store.OutboxMessages()[0]
+	if message.ChatID != -1005000200 || message.Status != OutboxStatusSent {
+		t.Fatalf("outbox row=%+v, want sent to migrated chat", message)
+	}
+}
+
+func TestOutboxDispatcherDeadLettersMigrationOntoTakenChatID(t *testing.T) {
+	store := NewMemoryStore()
+	ctx := context.Background()
+	for _, chatID := range []int64{-50003, -1005000300} {
+		if _, _, err := store.FindOrCreateUserByChatID(ctx, chatID); err != nil {
+			t.Fatalf("seed user failed: %v", err)
+		}
+	}
+	if _, err := store.EnqueueOutgoingMessage(ctx, OutgoingMessage{ChatID: -50003, Text: "hello"}); err != nil {
+		t.Fatalf("enqueue failed: %v", err)
+	}
+
+	client := &sendStubClient{errors: []error{
+		&APIError{StatusCode: http.StatusBadRequest, ErrorCode: http.StatusBadRequest, MigrateToChatID: -1005000300},
+	}}
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	dispatcher := NewOutboxDispatcher(OutboxConfig{}, store, NewSender(client, logger), logger)
+
+	if _, err := dispatcher.DispatchOnce(ctx); err != nil {
+		t.Fatalf("DispatchOnce() returned error: %v", err)
+	}
+
+	if _, ok := store.UserByChatID(-50003); !ok {
+		t.Fatalf("expected the old user to keep its chat id")
+	}
+	message := store.OutboxMessages()[0]
+	if message.ChatID != -50003 || message.Status != OutboxStatusDead {
+		t.Fatalf("outbox row=%+v, want dead on the original chat", message)
+	}
+	if err := store.MigrateChatID(ctx, -50003, -1005000300); !errors.Is(err, ErrChatIDTaken) {
+		t.Fatalf("MigrateChatID() error=%v, want ErrChatIDTaken", err)
+	}
+}
+
+func TestClaimDueOutgoingMessagesHandsEachRowOutOnce(t *testing.T) {
+	store := NewMemoryStore()
+	ctx := context.Background()
+	for _, chatID := range []int64{1, 2} {
+		if _, err := store.EnqueueOutgoingMessage(ctx, OutgoingMessage{ChatID: chatID, Text: "hello"}); err != nil {
+			t.Fatalf("enqueue failed: %v", err)
+		}
+	}
+
+	first, err := store.ClaimDueOutgoingMessages(ctx, 10, time.Minute)
+	if err != nil || len(first) != 2 {
+		t.Fatalf("first claim=(%d rows, %v), want 2 rows", len(first), err)
+	}
+	second, err := store.ClaimDueOutgoingMessages(ctx, 10, time.Minute)
+	if err != nil || len(second) != 0 {
+		t.Fatalf("second claim=(%+v, %v), want nothing while the lease holds", second, err)
+	}
+
+	store.SetClock(func() time.Time { return time.Now().Add(2 * time.Minute) })
+	third, err := store.ClaimDueOutgoingMessages(ctx, 10, time.Minute)
+	if err != nil || len(third) != 2 || third[0].Attempts != 0 {
+		t.Fatalf("claim after the lease=(%+v, %v), want both rows back with no attempt counted", third, err)
+	}
+}
+
+func TestOutboxDispatcherKeepsSendingToOtherChatsWhileOneWaits(t *testing.T) {
+	store := NewMemoryStore()
+	ctx := context.Background()
+	for _, chatID := range []int64{1, 2} {
+		if _, err := store.EnqueueOutgoingMessage(ctx, OutgoingMessage{ChatID: chatID, Text: "hello"}); err != nil {
+			t.Fatalf("enqueue failed: %v", err)
+		}
+	}
+
+	limiter := NewRateLimiter(RateLimitConfig{PerChatPerSecond: 20, PerChatBurst: 1})
+	// Chat 1 has just used its token, so its next send waits about 50ms.
+	if err := limiter.Wait(ctx, 1); err != nil {
+		t.Fatalf("wait failed: %v", err)
+	}
+	client := &sendStubClient{}
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	dispatcher := NewOutboxDispatcher(OutboxConfig{}, store, NewSender(client, logger).WithRateLimiter(limiter), logger)
+
+	if sent, err := dispatcher.DispatchOnce(ctx); err != nil || sent != 2 {
+		t.Fatalf("DispatchOnce()=(%d, %v), want (2, nil)", sent, err)
+	}
+	if got := client.SentChats(); len(got) != 2 || got[0] != 2 || got[1] != 1 {
+		t.Fatalf("send order=%v, want chat 2 before the throttled chat 1", got)
+	}
+}
+
+func TestOutboxDispatcherReportsFailedExport(t *testing.T) {
+	store := NewMemoryStore()
+	ctx := context.Background()
+	user, _, err := store.FindOrCreateUserByChatID(ctx, 1)
+	if err != nil {
+		t.Fatalf("seed user: %v", err)
+	}
+	if _, err := store.EnqueueOutgoingMessage(ctx, OutgoingMessage{
+		ChatID: 1,
+		Text:   zh(MsgMyDataCaption),
+		Export: &OutboxExport{UserID: user.ID, Format: ExportFormatJSON, Language: user.Language},
+	}); err != nil {
+		t.Fatalf("enqueue failed: %v", err)
+	}
+
+	client := &sendStubClient{errors: []error{&APIError{StatusCode: http.StatusRequestEntityTooLarge}}}
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	dispatcher := NewOutboxDispatcher(OutboxConfig{}, store, NewSender(client, logger), logger)
+
+	if sent, err := dispatcher.DispatchOnce(ctx); err != nil || sent != 0 {
+		t.Fatalf("DispatchOnce()=(%d, %v), want (0, nil)", sent, err)
+	}
+	rows := store.OutboxMessages()
+	if len(rows) != 2 || rows[0].Status != OutboxStatusDead {
+		t.Fatalf("outbox=%+v, want the export dead and a failure reply", rows)
+	}
+	if rows[1].Export != nil || rows[1].Text != zh(MsgMyDataFailed) || rows[1].Status != OutboxStatusPending {
+		t.Fatalf("failure reply=%+v", rows[1])
+	}
+}
+
+func TestOutboxDispatcherDropsExportOfDeletedUser(t *testing.T) {
+	store := NewMemoryStore()
+	ctx := context.Background()
+	if _, err := store.EnqueueOutgoingMessage(ctx, OutgoingMessage{
+		ChatID: 1,
+		Text:   zh(MsgMyDataCaption),
+		Export: &OutboxExport{UserID: "deleted-user", Format: ExportFormatJSON},
+	}); err != nil {
+		t.Fatalf("enqueue failed: %v", err)
+	}
+
+	client := &sendStubClient{}
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	dispatcher := NewOutboxDispatcher(OutboxConfig{}, store, NewSender(client, logger), logger)
+
+	if sent, err := dispatcher.DispatchOnce(ctx); err != nil || sent != 0 {
+		t.Fatalf("DispatchOnce()=(%d, %v), want (0, nil)", sent, err)
+	}
+	if client.sendAttempts != 0 {
+		t.Fatalf("send attempts=%d, want 0", client.sendAttempts)
+	}
+	rows := store.OutboxMessages()
+	if len(rows) != 1 || rows[0].Status != OutboxStatusDead {
+		t.Fatalf("outbox=%+v, want only the dead export", rows)
+	}
+}
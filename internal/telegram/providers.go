@@ -0,0 +1,31 @@
+package telegram
+
+import (
+	"context"
+)
+
+// SlotExtractor finds the slots a clarification message fills. Users in the
+// llm_extraction rollout get it instead of the keyword rules.
+type SlotExtractor interface {
+	ExtractSlots(ctx context.Context, text string) ([]string, error)
+}
+
+// PlanGenerator drafts the first plan once a goal brief is ready for review.
+// Only users in the plan_generation rollout get a draft.
+type PlanGenerator interface {
+	GeneratePlan(ctx context.Context, lang string, session PlanningSession) (string, error)
+}
+
+// extractedSlotUpdate marks the slots an extractor returned, in the shape of
+// Rules.UpdateSlotCompletion.
+func extractedSlotUpdate(slots []string) func(map[string]bool, string) map[string]bool {
+	return func(slotCompletion map[string]bool, _ string) map[string]bool {
+		normalized := NormalizeSlotCompletion(slotCompletion)
+		for _, slot := range slots {
+			if _, ok := normalized[slot]; ok {
+				normalized[slot] = true
+			}
+		}
+		return normalized
+	}
+}
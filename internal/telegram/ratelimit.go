@@ -0,0 +1,347 @@
+package telegram
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const maxIdleChatBuckets = 1024
+
+type RateLimitConfig struct {
+	GlobalPerSecond  float64
+	GlobalBurst      int
+	PerChatPerSecond float64
+	PerChatBurst     int
+}
+
+type RateLimiterMetrics struct {
+	queueDepth atomic.Int64
+	waitCount  atomic.Uint64
+	pauseCount atomic.Uint64
+}
+
+// Snapshot returns the current queue depth, how many sends had to wait and how
+// many global pauses were triggered by 429 responses.
+func (m *RateLimiterMetrics) Snapshot() (int64, uint64, uint64) {
+	return m.queueDepth.Load(), m.waitCount.Load(), m.pauseCount.Load()
+}
+
+// RateLimiter enforces a bot-wide and a per-chat token bucket. Waiting sends
+// are granted round-robin across chats so one busy chat cannot starve others.
+// A nil *RateLimiter never blocks.
+type RateLimiter struct {
+	mu           sync.Mutex
+	now          func() time.Time
+	global       tokenBucket
+	perChatRate  float64
+	perChatBurst float64
+	chats        map[int64]*chatQueue
+	ring         []int64
+	pausedUntil  time.Time
+	metrics      *RateLimiterMetrics
+	// reconfigured is closed and replaced by SetLimits, so waiters asleep
+	// on the old rates recompute their delay.
+	reconfigured chan struct{}
+}
+
+type chatQueue struct {
+	bucket  tokenBucket
+	waiters []*rateWaiter
+}
+
+type rateWaiter struct {
+	ready   chan struct{}
+	granted bool
+}
+
+func NewRateLimiter(cfg RateLimitConfig) *RateLimiter {
+	if cfg.GlobalPerSecond <= 0 && cfg.PerChatPerSecond <= 0 {
+		return nil
+	}
+	return newRateLimiter(cfg)
+}
+
+// newRateLimiter always returns a limiter, even an unlimited one, so that
+// SetLimits can turn limits on later.
+func newRateLimiter(cfg RateLimitConfig) *RateLimiter {
+	now := time.Now()
+	return &RateLimiter{
+		now:          time.Now,
+		global:       newTokenBucket(cfg.GlobalPerSecond, cfg.GlobalBurst, now),
+		perChatRate:  cfg.PerChatPerSecond,
+		perChatBurst: burstOrOne(cfg.PerChatBurst),
+		chats:        make(map[int64]*chatQueue),
+		metrics:      &RateLimiterMetrics{},
+		reconfigured: make(chan struct{}),
+	}
+}
+
+func (l *RateLimiter) Metrics() *RateLimiterMetrics {
+	if l == nil {
+		return &RateLimiterMetrics{}
+	}
+	return l.metrics
+}
+
+func (l *RateLimiter) QueueDepth() int64 {
+	if l == nil {
+		return 0
+	}
+	return l.metrics.queueDepth.Load()
+}
+
+// Wait blocks until a send to chatID is allowed by both buckets.
+func (l *RateLimiter) Wait(ctx context.Context, chatID int64) error {
+	if l == nil {
+		return nil
+	}
+
+	waiter := &rateWaiter{ready: make(chan struct{})}
+
+	l.mu.Lock()
+	now := l.now()
+	queue := l.chatQueueLocked(chatID, now)
+	queue.waiters = append(queue.waiters, waiter)
+	if len(queue.waiters) == 1 {
+		l.ring = append(l.ring, chatID)
+	}
+	l.metrics.queueDepth.Add(1)
+	next := l.grantLocked(now)
+	reconfigured := l.reconfigured
+	l.mu.Unlock()
+
+	if waiter.granted {
+		return nil
+	}
+	l.metrics.waitCount.Add(1)
+
+	for {
+		timer := time.NewTimer(next)
+		select {
+		case <-waiter.ready:
+			timer.Stop()
+			return nil
+		case <-ctx.Done():
+			timer.Stop()
+			if l.cancel(chatID, waiter) {
+				return ctx.Err()
+			}
+			return nil
+		case <-reconfigured:
+			timer.Stop()
+			l.mu.Lock()
+			next = l.grantLocked(l.now())
+			reconfigured = l.reconfigured
+			l.mu.Unlock()
+		case <-timer.C:
+			l.mu.Lock()
+			next = l.grantLocked(l.now())
+			l.mu.Unlock()
+		}
+	}
+}
+
+// Pause stops all sends until d has elapsed. It is used when Telegram answers
+// with 429 and a retry_after hint.
+func (l *RateLimiter) Pause(d time.Duration) {
+	if l == nil || d <= 0 {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	until := l.now().Add(d)
+	if until.After(l.pausedUntil) {
+		l.pausedUntil = until
+	}
+	l.metrics.pauseCount.Add(1)
+}
+
+// SetLimits changes the rates and bursts in place. Queued sends keep their
+// order; tokens above a lowered burst are dropped.
+func (l *RateLimiter) SetLimits(cfg RateLimitConfig) {
+	if l == nil {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.global.reconfigure(cfg.GlobalPerSecond, cfg.GlobalBurst, now)
+	l.perChatRate = cfg.PerChatPerSecond
+	l.perChatBurst = burstOrOne(cfg.PerChatBurst)
+	for _, queue := range l.chats {
+		queue.bucket.reconfigure(cfg.PerChatPerSecond, cfg.PerChatBurst, now)
+	}
+	l.grantLocked(now)
+	close(l.reconfigured)
+	l.reconfigured = make(chan struct{})
+}
+
+func (l *RateLimiter) chatQueueLocked(chatID int64, now time.Time) *chatQueue {
+	if queue, ok := l.chats[chatID]; ok {
+		return queue
+	}
+
+	if len(l.chats) >= maxIdleChatBuckets {
+		for id, queue := range l.chats {
+			queue.bucket.refill(now)
+			if len(queue.waiters) == 0 && queue.bucket.full() {
+				delete(l.chats, id)
+			}
+		}
+	}
+
+	queue := &chatQueue{bucket: tokenBucket{
+		rate:   l.perChatRate,
+		burst:  l.perChatBurst,
+		tokens: l.perChatBurst,
+		last:   now,
+	}}
+	l.chats[chatID] = queue
+	return queue
+}
+
+// grantLocked hands out as many tokens as are available, visiting chats in
+// ring order, and returns how long until another grant may become possible.
+func (l *RateLimiter) grantLocked(now time.Time) time.Duration {
+	if now.Before(l.pausedUntil) {
+		return l.pausedUntil.Sub(now)
+	}
+
+	l.global.refill(now)
+	next := time.Duration(0)
+	for i := 0; i < len(l.ring); {
+		if !l.global.available() {
+			return l.global.wait()
+		}
+
+		chatID := l.ring[i]
+		queue := l.chats[chatID]
+		queue.bucket.refill(now)
+		if !queue.bucket.available() {
+			if wait := queue.bucket.wait(); next == 0 || wait < next {
+				next = wait
+			}
+			i++
+			continue
+		}
+
+		waiter := queue.waiters[0]
+		queue.waiters = queue.waiters[1:]
+		queue.bucket.take()
+		l.global.take()
+		waiter.granted = true
+		close(waiter.ready)
+		l.metrics.queueDepth.Add(-1)
+
+		l.ring = append(l.ring[:i], l.ring[i+1:]...)
+		if len(queue.waiters) > 0 {
+			l.ring = append(l.ring, chatID)
+		}
+	}
+
+	if next == 0 {
+		next = time.Millisecond
+	}
+	return next
+}
+
+func (l *RateLimiter) cancel(chatID int64, waiter *rateWaiter) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if waiter.granted {
+		return false
+	}
+
+	queue := l.chats[chatID]
+	for i, candidate := range queue.waiters {
+		if candidate == waiter {
+			queue.waiters = append(queue.waiters[:i], queue.waiters[i+1:]...)
+			break
+		}
+	}
+	if len(queue.waiters) == 0 {
+		for i, id := range l.ring {
+			if id == chatID {
+				l.ring = append(l.ring[:i], l.ring[i+1:]...)
+				break
+			}
+		}
+	}
+	l.metrics.queueDepth.Add(-1)
+	return true
+}
+
+// tokenBucket with rate <= 0 is unlimited.
+type tokenBucket struct {
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newTokenBucket(rate float64, burst int, now time.Time) tokenBucket {
+	capacity := burstOrOne(burst)
+	return tokenBucket{rate: rate, burst: capacity, tokens: capacity, last: now}
+}
+
+func (b *tokenBucket) refill(now time.Time) {
+	if b.rate <= 0 {
+		return
+	}
+	if elapsed := now.Sub(b.last); elapsed > 0 {
+		b.tokens += elapsed.Seconds() * b.rate
+		if b.tokens > b.burst {
+			b.tokens = b.burst
+		}
+	}
+	b.last = now
+}
+
+// reconfigure settles the tokens earned at the old rate, then switches. A
+// bucket that was unlimited starts full.
+func (b *tokenBucket) reconfigure(rate float64, burst int, now time.Time) {
+	b.refill(now)
+	if b.rate <= 0 {
+		b.tokens = burstOrOne(burst)
+	}
+	b.rate, b.burst, b.last = rate, burstOrOne(burst), now
+	if b.tokens > b.burst {
+		b.tokens = b.burst
+	}
+}
+
+func (b *tokenBucket) available() bool {
+	return b.rate <= 0 || b.tokens >= 1
+}
+
+func (b *tokenBucket) full() bool {
+	return b.rate <= 0 || b.tokens >= b.burst
+}
+
+func (b *tokenBucket) take() {
+	if b.rate > 0 {
+		b.tokens--
+	}
+}
+
+func (b *tokenBucket) wait() time.Duration {
+	if b.available() {
+		return 0
+	}
+	missing := 1 - b.tokens
+	return time.Duration(missing / b.rate * float64(time.Second))
+}
+
+func burstOrOne(burst int) float64 {
+	if burst < 1 {
+		return 1
+	}
+	return float64(burst)
+}
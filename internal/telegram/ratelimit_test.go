@@ -0,0 +1,186 @@
+package telegram
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRateLimiterNilNeverBlocks(t *testing.T) {
+	var limiter *RateLimiter
+	if err := limiter.Wait(context.Background(), 1); err != nil {
+		t.Fatalf("Wait() on nil limiter returned error: %v", err)
+	}
+	if NewRateLimiter(RateLimitConfig{}) != nil {
+		t.Fatalf("NewRateLimiter() with zero config should be disabled")
+	}
+}
+
+func TestRateLimiterEnforcesPerChatRate(t *testing.T) {
+	limiter := NewRateLimiter(RateLimitConfig{PerChatPerSecond: 20, PerChatBurst: 1})
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		if err := limiter.Wait(ctx, 1); err != nil {
+			t.Fatalf("Wait() returned error: %v", err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 90*time.Millisecond {
+		t.Fatalf("three sends took %v, want >= ~100ms at 20/s", elapsed)
+	}
+
+	otherStart := time.Now()
+	if err := limiter.Wait(ctx, 2); err != nil {
+		t.Fatalf("Wait() returned error: %v", err)
+	}
+	if elapsed := time.Since(otherStart); elapsed > 20*time.Millisecond {
+		t.Fatalf("other chat waited %v, want no per-chat delay", elapsed)
+	}
+}
+
+func TestRateLimiterSharesGlobalBudgetFairly(t *testing.T) {
+	limiter := NewRateLimiter(RateLimitConfig{GlobalPerSecond: 20, GlobalBurst: 1})
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	// Consume the initial token so every send below has to queue.
+	if err := limiter.Wait(ctx, 0); err != nil {
+		t.Fatalf("Wait() returned error: %v", err)
+	}
+
+	var (
+		mu    sync.Mutex
+		order []int64
+		wg    sync.WaitGroup
+	)
+	for i, chatID := range []int64{1, 1, 1, 2} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := limiter.Wait(ctx, chatID); err != nil {
+				t.Errorf("Wait() returned error: %v", err)
+				return
+			}
+			mu.Lock()
+			order = append(order, chatID)
+			mu.Unlock()
+		}()
+		waitForQueueDepth(t, limiter, int64(i+1))
+	}
+	wg.Wait()
+
+	want := []int64{1, 2, 1, 1}
+	if len(order) != len(want) {
+		t.Fatalf("grant order=%v, want %v", order, want)
+	}
+	for i := range want {
+		if order[i] != want[i] {
+			t.Fatalf("grant order=%v, want %v", order, want)
+		}
+	}
+}
+
+func TestRateLimiterPauseBlocksAllChats(t *testing.T) {
+	limiter := NewRateLimiter(RateLimitConfig{GlobalPerSecond: 1000, GlobalBurst: 10})
+	limiter.Pause(60 * time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	start := time.Now()
+	if err := limiter.Wait(ctx, 7); err != nil {
+		t.Fatalf("Wait() returned error: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+		t.Fatalf("send after pause waited %v, want >= 50ms", elapsed)
+	}
+
+	_, _, pauses := limiter.Metrics().Snapshot()
+	if pauses != 1 {
+		t.Fatalf("pause count=%d, want 1", pauses)
+	}
+}
+
+func TestRateLimiterWaitHonorsContext(t *testing.T) {
+	limiter := NewRateLimiter(RateLimitConfig{PerChatPerSecond: 0.1, PerChatBurst: 1})
+	if err := limiter.Wait(context.Background(), 1); err != nil {
+		t.Fatalf("Wait() returned error: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := limiter.Wait(ctx, 1); err == nil {
+		t.Fatalf("Wait() expected context error")
+	}
+	if depth := limiter.QueueDepth(); depth != 0 {
+		t.Fatalf("queue depth=%d after cancellation, want 0", depth)
+	}
+}
+
+func TestRateLimiterSetLimitsWakesWaiters(t *testing.T) {
+	limiter := NewRateLimiter(RateLimitConfig{PerChatPerSecond: 0.1, PerChatBurst: 1})
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if err := limiter.Wait(ctx, 1); err != nil {
+		t.Fatalf("Wait() returned error: %v", err)
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- limiter.Wait(ctx, 1) }()
+	waitForQueueDepth(t, limiter, 1)
+
+	start := time.Now()
+	limiter.SetLimits(RateLimitConfig{PerChatPerSecond: 100, PerChatBurst: 1})
+	if err := <-done; err != nil {
+		t.Fatalf("Wait() returned error: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("queued send waited %v after the limit was raised", elapsed)
+	}
+}
+
+func TestRateLimiterSetLimitsEnablesLimits(t *testing.T) {
+	limiter := newRateLimiter(RateLimitConfig{})
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	for range 3 {
+		if err := limiter.Wait(ctx, 1); err != nil {
+			t.Fatalf("unlimited Wait() returned error: %v", err)
+		}
+	}
+
+	limiter.SetLimits(RateLimitConfig{GlobalPerSecond: 0.1, GlobalBurst: 1})
+	if err := limiter.Wait(ctx, 1); err != nil {
+		t.Fatalf("first Wait() after enabling returned error: %v", err)
+	}
+	short, cancelShort := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancelShort()
+	if err := limiter.Wait(short, 2); err == nil {
+		t.Fatal("second Wait() was not limited")
+	}
+}
+
+func waitForQueueDepth(t *testing.T, limiter *RateLimiter, depth int64) {
+	t.Helper()
+
+	deadline := time.Now().Add(time.Second)
+	for limiter.QueueDepth() < depth {
+		if time.Now().After(deadline) {
+			t.Fatalf("queue depth=%d, want %d", limiter.QueueDepth(), depth)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestWorkerStatsReportsRateLimiterCounters(t *testing.T) {
+	worker := NewWorker(WorkerConfig{RateLimit: RateLimitConfig{PerChatPerSecond: 1}}, &sendStubClient{}, NewMemoryStore(), nil)
+	worker.limiter.Pause(time.Millisecond)
+	worker.metrics.RecordFailure()
+
+	if stats := worker.Stats(); stats.SendPauses != 1 || stats.PollFailures != 1 || stats.SendQueueDepth != 0 {
+		t.Fatalf("stats=%+v", stats)
+	}
+}
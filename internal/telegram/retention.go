@@ -0,0 +1,144 @@
+package telegram
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/congregalis/aiden/internal/pii"
+)
+
+// retentionSummaryRunes is how much of a redacted turn survives as its
+// summary.
+const retentionSummaryRunes = 40
+
+type RetentionConfig struct {
+	// TextTTL is the age at which turn text is replaced by a hash and a
+	// summary; 0 keeps it forever.
+	TextTTL  time.Duration
+	HashSalt string
+	// Rules matches the slots a turn filled before its text is replaced; nil
+	// uses the defaults.
+	Rules *RuleSet
+}
+
+// RedactedTurn is what is kept of a conversation turn once its text is gone.
+type RedactedTurn struct {
+	Summary string
+	Hash    string
+	// Slots are the slots the original text filled. The summary is too short
+	// to match them again when /undo or an edit replays the session.
+	Slots []string
+}
+
+// TurnRedactor turns the original text of a conversation turn into what
+// replaces it.
+type TurnRedactor func(content string) RedactedTurn
+
+// NewTurnRedactor hashes with HMAC-SHA256 keyed by salt, so the same text
+// always gives the same hash without the hash being reversible by guessing
+// common messages. The summary is the start of the text with PII masked.
+// Slots are matched with rules; nil uses the defaults.
+func NewTurnRedactor(salt string, rules *RuleSet) TurnRedactor {
+	key := []byte(salt)
+	return func(content string) RedactedTurn {
+		mac := hmac.New(sha256.New, key)
+		mac.Write([]byte(content))
+
+		slots := make([]string, 0)
+		for slot, filled := range rules.Current().UpdateSlotCompletion(nil, content) {
+			if filled {
+				slots = append(slots, slot)
+			}
+		}
+		sort.Strings(slots)
+
+		return RedactedTurn{
+			Summary: previewText(pii.Redact(content), retentionSummaryRunes),
+			Hash:    hex.EncodeToString(mac.Sum(nil)),
+			Slots:   slots,
+		}
+	}
+}
+
+// RedactConversationTurns redacts up to limit turns created before cutoff,
+// oldest first, and returns how many it changed. Turns of a session its owner
+// can still resume, per their /timeout or sessionTimeout, are left alone.
+// Rows locked by another sweeper are skipped.
+func (s *SQLStore) RedactConversationTurns(ctx context.Context, cutoff time.Time, sessionTimeout time.Duration, limit int, redact TurnRedactor) (int, error) {
+	redacted := 0
+	err := s.withinSQLTx(ctx, func(tx *SQLStore) error {
+		rows, err := tx.db.QueryContext(
+			ctx,
+			`SELECT ct.id, ct.content
+			 FROM conversation_turns ct
+			 JOIN planning_sessions ps ON ps.id = ct.session_id
+			 JOIN goals g ON g.id = ps.goal_id
+			 JOIN users u ON u.id = g.user_id
+			 WHERE ct.created_at < $1
+			   AND ct.redacted_at IS NULL
+			   AND ps.updated_at < NOW() - COALESCE(
+			       u.session_timeout_minutes * INTERVAL '1 minute',
+			       $3::float8 * INTERVAL '1 second'
+			   )
+			 ORDER BY ct.created_at, ct.id
+			 LIMIT $2
+			 FOR UPDATE OF ct SKIP LOCKED`,
+			cutoff,
+			limit,
+			sessionTimeout.Seconds(),
+		)
+		if err != nil {
+			return fmt.Errorf("query turns to redact: %w", err)
+		}
+		type pendingTurn struct{ id, content string }
+		var pending []pendingTurn
+		for rows.Next() {
+			var turn pendingTurn
+			if err := rows.Scan(&turn.id, &turn.content); err != nil {
+				rows.Close()
+				return fmt.Errorf("scan turn to redact: %w", err)
+			}
+			pending = append(pending, turn)
+		}
+		if err := rows.Err(); err != nil {
+			rows.Close()
+			return fmt.Errorf("iterate turns to redact: %w", err)
+		}
+		rows.Close()
+
+		for _, turn := range pending {
+			result := redact(turn.content)
+			slotsJSON, err := json.Marshal(result.Slots)
+			if err != nil {
+				return fmt.Errorf("marshal redacted slots: %w", err)
+			}
+			if _, err := tx.db.ExecContext(
+				ctx,
+				`UPDATE conversation_turns
+				 SET content = $2,
+				     content_hash = $3,
+				     redacted_slots = $4::jsonb,
+				     redacted_at = NOW()
+				 WHERE id = $1`,
+				turn.id,
+				result.Summary,
+				result.Hash,
+				slotsJSON,
+			); err != nil {
+				return fmt.Errorf("redact turn %s: %w", turn.id, err)
+			}
+		}
+		redacted = len(pending)
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return redacted, nil
+}
@@ -0,0 +1,124 @@
+package telegram
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/congregalis/aiden/internal/i18n"
+)
+
+func TestTurnRedactorHashesAndMasks(t *testing.T) {
+	const text = "我想三个月内学会游泳，有问题打我电话 13800138000，邮箱 ada@example.com，周末和工作日晚上都可以练习"
+	redact := NewTurnRedactor("salt-a", nil)
+
+	result := redact(text)
+	if strings.Contains(result.Summary, "13800138000") || !strings.Contains(result.Summary, "<phone>") {
+		t.Fatalf("summary=%q, want the phone number masked", result.Summary)
+	}
+	if got := []rune(result.Summary); len(got) != retentionSummaryRunes+1 || got[len(got)-1] != '…' {
+		t.Fatalf("summary=%q, want %d runes and an ellipsis", result.Summary, retentionSummaryRunes)
+	}
+	if len(result.Hash) != 64 {
+		t.Fatalf("hash=%q, want hex SHA-256", result.Hash)
+	}
+	if !reflect.DeepEqual(result.Slots, slotsFilledBy(text)) || len(result.Slots) == 0 {
+		t.Fatalf("slots=%v, want %v", result.Slots, slotsFilledBy(text))
+	}
+
+	if again := redact(text); again.Hash != result.Hash {
+		t.Fatal("same text gave a different hash")
+	}
+	if other := NewTurnRedactor("salt-b", nil)(text); other.Hash == result.Hash {
+		t.Fatal("different salts gave the same hash")
+	}
+}
+
+func TestUndoAfterRedactionKeepsSlotsOfRedactedTurns(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+	now := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	store.SetClock(func() time.Time { return now })
+
+	user, _, _ := store.FindOrCreateUserByChatID(ctx, 91004)
+	goal, _ := store.CreateGoalDraft(ctx, user.ID)
+	session, _, _ := store.GetOrCreatePlanningSession(ctx, goal.ID)
+	// The slot keywords sit past the 40 runes a redacted summary keeps.
+	const preface = "先交代一下背景：这件事我已经犹豫了很久，一直没能下定决心，今天终于想清楚了，所以"
+	for _, content := range []string{preface + "我想三个月内学会游泳，能连续游 500 米", preface + "我现在完全不会游泳，每周能练 3 小时"} {
+		if err := store.SaveConversationTurn(ctx, ConversationTurn{SessionID: session.ID, Role: ConversationRoleUser, Content: content, Intent: IntentClarifyGoal}); err != nil {
+			t.Fatalf("save turn: %v", err)
+		}
+	}
+	turns, _ := store.ListUserTurns(ctx, session.ID)
+	want := RecomputeSlotCompletion(DefaultRules(), turns, nil)
+	if len(MissingRequiredSlots(want)) == len(requiredSlotOrder) {
+		t.Fatalf("seed turns filled no slots")
+	}
+	session.SlotCompletion = want
+
+	now = now.Add(40 * 24 * time.Hour)
+	tasks := MaintenanceTasks(store, MaintenanceConfig{Retention: RetentionConfig{TextTTL: 30 * 24 * time.Hour, HashSalt: "salt"}})
+	if redacted := runMaintenanceTask(t, tasks, "turn_redaction", now, 10); redacted != 2 {
+		t.Fatalf("redacted=%d, want 2", redacted)
+	}
+	if err := store.SaveConversationTurn(ctx, ConversationTurn{SessionID: session.ID, Role: ConversationRoleUser, Content: "周末不方便", Intent: IntentClarifyGoal}); err != nil {
+		t.Fatalf("save turn: %v", err)
+	}
+
+	_, session, err := undoLastTurn(ctx, store, DefaultRules(), i18n.ZhCN, session)
+	if err != nil {
+		t.Fatalf("undo: %v", err)
+	}
+	if !reflect.DeepEqual(session.SlotCompletion, want) {
+		t.Fatalf("slots after undo=%v, want %v from the redacted turns", session.SlotCompletion, want)
+	}
+}
+
+func slotsFilledBy(text string) []string {
+	var slots []string
+	for slot, filled := range DefaultRules().UpdateSlotCompletion(nil, text) {
+		if filled {
+			slots = append(slots, slot)
+		}
+	}
+	sort.Strings(slots)
+	return slots
+}
+
+func TestRedactionSkipsSessionsWithinTheOwnersTimeout(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+	now := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	store.SetClock(func() time.Time { return now })
+
+	user, _, _ := store.FindOrCreateUserByChatID(ctx, 91005)
+	if err := store.SetUserSessionTimeout(ctx, user.ID, 30*24*time.Hour); err != nil {
+		t.Fatalf("set timeout: %v", err)
+	}
+	goal, _ := store.CreateGoalDraft(ctx, user.ID)
+	session, _, _ := store.GetOrCreatePlanningSession(ctx, goal.ID)
+	if err := store.SaveConversationTurn(ctx, ConversationTurn{SessionID: session.ID, Role: ConversationRoleUser, Content: "我想学游泳", Intent: IntentClarifyGoal}); err != nil {
+		t.Fatalf("save turn: %v", err)
+	}
+	now = now.Add(20 * 24 * time.Hour)
+	if err := store.UpdatePlanningSession(ctx, session); err != nil {
+		t.Fatalf("touch session: %v", err)
+	}
+
+	tasks := MaintenanceTasks(store, MaintenanceConfig{
+		SessionTimeout: 24 * time.Hour,
+		Retention:      RetentionConfig{TextTTL: 10 * 24 * time.Hour, HashSalt: "salt"},
+	})
+	now = now.Add(2 * 24 * time.Hour)
+	if redacted := runMaintenanceTask(t, tasks, "turn_redaction", now, 10); redacted != 0 {
+		t.Fatalf("redacted=%d inside the user's 30-day timeout, want 0", redacted)
+	}
+	now = now.Add(31 * 24 * time.Hour)
+	if redacted := runMaintenanceTask(t, tasks, "turn_redaction", now, 10); redacted != 1 {
+		t.Fatalf("redacted=%d once the user's timeout passed, want 1", redacted)
+	}
+}
@@ -1,6 +1,10 @@
 package telegram
 
-import "testing"
+import (
+	"testing"
+
+	"github.com/congregalis/aiden/internal/i18n"
+)
 
 func TestParseCommand(t *testing.T) {
 	tests := []struct {
@@ -35,14 +39,14 @@ func TestRouterReplyFor(t *testing.T) {
 		message  IncomingMessage
 		expected string
 	}{
-		{name: "help", message: IncomingMessage{Text: "/help"}, expected: ReplyHelp},
-		{name: "unknown command", message: IncomingMessage{Text: "/plan"}, expected: ReplyUnknownCommand},
-		{name: "natural language", message: IncomingMessage{Text: "我想在两个月内学会Go"}, expected: ReplyNaturalMessage},
+		{name: "help", message: IncomingMessage{Text: "/help"}, expected: zh(MsgHelp)},
+		{name: "unknown command", message: IncomingMessage{Text: "/plan"}, expected: zh(MsgUnknownCommand)},
+		{name: "natural language", message: IncomingMessage{Text: "我想在两个月内学会Go"}, expected: zh(MsgNaturalMessage)},
 	}
 
 	for _, tc := range tests {
 		t.Run(tc.name, func(t *testing.T) {
-			actual := router.ReplyFor(tc.message)
+			actual := router.ReplyFor(i18n.ZhCN, tc.message)
 			if actual != tc.expected {
 				t.Fatalf("ReplyFor()=%q, want %q", actual, tc.expected)
 			}
@@ -53,10 +57,10 @@ func TestRouterReplyFor(t *testing.T) {
 func TestRouterReplyForStart(t *testing.T) {
 	router := NewRouter()
 
-	if got := router.ReplyForStart(true); got != ReplyStart {
-		t.Fatalf("ReplyForStart(true)=%q, want %q", got, ReplyStart)
+	if got := router.ReplyForStart(i18n.ZhCN, true); got != zh(MsgStart) {
+		t.Fatalf("ReplyForStart(true)=%q, want %q", got, zh(MsgStart))
 	}
-	if got := router.ReplyForStart(false); got != ReplyStartBack {
-		t.Fatalf("ReplyForStart(false)=%q, want %q", got, ReplyStartBack)
+	if got := router.ReplyForStart(i18n.ZhCN, false); got != zh(MsgStartBack) {
+		t.Fatalf("ReplyForStart(false)=%q, want %q", got, zh(MsgStartBack))
 	}
 }
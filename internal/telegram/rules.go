@@ -0,0 +1,650 @@
+package telegram
+
+import (
+	"bytes"
+	"context"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unicode/utf8"
+
+	"gopkg.in/yaml.v3"
+)
+
+const rulesFileVersion = 1
+
+//go:embed default_rules.json
+var defaultRulesJSON []byte
+
+// RulesFile is the on-disk shape of the intent and slot rules. A rule fires
+// when every condition it sets holds; fired weights combine as independent
+// evidence, so the score of an intent or slot is 1 - Π(1 - weight).
+type RulesFile struct {
+	Version int `json:"version"`
+	// AmbiguityMargin is the smallest lead the best intent needs over the
+	// runner-up before the bot acts on it; 0 never asks.
+	AmbiguityMargin float64          `json:"ambiguity_margin"`
+	Negation        NegationSpec     `json:"negation"`
+	Intents         []IntentRuleSpec `json:"intents"`
+	Slots           []SlotRuleSpec   `json:"slots"`
+}
+
+// NegationSpec lists cues such as "不" or "not". A keyword of a negatable
+// rule does not count when a cue appears within Window runes before it in
+// the same clause.
+type NegationSpec struct {
+	Cues   []string `json:"cues"`
+	Window int      `json:"window"`
+}
+
+type IntentRuleSpec struct {
+	Intent          string             `json:"intent"`
+	Threshold       float64            `json:"threshold"`
+	StateThresholds map[string]float64 `json:"state_thresholds,omitempty"`
+	Rules           []RuleSpec         `json:"rules"`
+}
+
+type SlotRuleSpec struct {
+	Slot      string     `json:"slot"`
+	Threshold float64    `json:"threshold"`
+	ImpliedBy []string   `json:"implied_by,omitempty"`
+	Rules     []RuleSpec `json:"rules"`
+}
+
+type RuleSpec struct {
+	ID        string   `json:"id"`
+	Keywords  []string `json:"keywords,omitempty"`
+	Regex     string   `json:"regex,omitempty"`
+	MinRunes  int      `json:"min_runes,omitempty"`
+	Negatable bool     `json:"negatable,omitempty"`
+	Weight    float64  `json:"weight"`
+}
+
+// Rules is a validated, compiled RulesFile. It is immutable and safe to share.
+type Rules struct {
+	ambiguityMargin float64
+	negation        negation
+	intents         []intentRules
+	slots           []slotRules
+}
+
+type negation struct {
+	cues   []string
+	window int
+}
+
+type intentRules struct {
+	intent          string
+	threshold       float64
+	stateThresholds map[PlanningState]float64
+	rules           []compiledRule
+}
+
+type slotRules struct {
+	slot      string
+	threshold float64
+	impliedBy []string
+	rules     []compiledRule
+}
+
+type compiledRule struct {
+	id        string
+	keywords  []string
+	regex     *regexp.Regexp
+	minRunes  int
+	negatable bool
+	weight    float64
+}
+
+// RuleScore reports how one intent or slot scored against a message.
+type RuleScore struct {
+	Name      string   `json:"name"`
+	Score     float64  `json:"score"`
+	Threshold float64  `json:"threshold"`
+	Matched   bool     `json:"matched"`
+	Fired     []string `json:"fired,omitempty"`
+	ImpliedBy string   `json:"implied_by,omitempty"`
+}
+
+// RuleEvaluation is the dry-run result for a message.
+type RuleEvaluation struct {
+	Intent   string        `json:"intent"`
+	Slot     string        `json:"slot,omitempty"`
+	Score    float64       `json:"confidence"`
+	Ranking  []IntentScore `json:"ranking,omitempty"`
+	LoadedAt time.Time     `json:"rules_loaded_at"`
+	Source   string        `json:"rules_source"`
+	Intents  []RuleScore   `json:"intents"`
+	Slots    []RuleScore   `json:"slots"`
+}
+
+var classifiableIntents = map[string]bool{
+	IntentConfirmPlan: true,
+	IntentClarifyGoal: true,
+}
+
+var knownStates = map[PlanningState]bool{
+	StateIdle:       true,
+	StateClarifying: true,
+	StateReview:     true,
+	StateConfirmed:  true,
+}
+
+// DefaultRules returns the rules embedded in the binary.
+func DefaultRules() *Rules {
+	rules, err := ParseRules(defaultRulesJSON)
+	if err != nil {
+		panic(fmt.Sprintf("embedded default rules: %v", err))
+	}
+	return rules
+}
+
+// ParseRules decodes and validates a rules file. All problems are reported
+// at once, each prefixed with its location in the file.
+func ParseRules(data []byte) (*Rules, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	var file RulesFile
+	if err := decoder.Decode(&file); err != nil {
+		return nil, fmt.Errorf("decode rules: %w", err)
+	}
+	return CompileRules(file)
+}
+
+// ParseRulesFile parses data as YAML when path ends in .yaml or .yml and as
+// JSON otherwise. YAML goes through the same strict JSON decoding, so both
+// formats reject the same unknown fields.
+func ParseRulesFile(path string, data []byte) (*Rules, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		var doc any
+		if err := yaml.Unmarshal(data, &doc); err != nil {
+			return nil, fmt.Errorf("decode rules: %w", err)
+		}
+		converted, err := json.Marshal(doc)
+		if err != nil {
+			return nil, fmt.Errorf("decode rules: %w", err)
+		}
+		data = converted
+	}
+	return ParseRules(data)
+}
+
+func CompileRules(file RulesFile) (*Rules, error) {
+	var errs []error
+	fail := func(format string, args ...any) {
+		errs = append(errs, fmt.Errorf(format, args...))
+	}
+
+	if file.Version != rulesFileVersion {
+		fail("version: got %d, want %d", file.Version, rulesFileVersion)
+	}
+	if file.AmbiguityMargin < 0 || file.AmbiguityMargin >= 1 {
+		fail("ambiguity_margin must be in [0, 1), got %v", file.AmbiguityMargin)
+	}
+
+	rules := &Rules{ambiguityMargin: file.AmbiguityMargin}
+	for i, cue := range file.Negation.Cues {
+		cue = strings.ToLower(strings.TrimSpace(cue))
+		if cue == "" {
+			fail("negation.cues[%d] is empty", i)
+			continue
+		}
+		rules.negation.cues = append(rules.negation.cues, cue)
+	}
+	rules.negation.window = file.Negation.Window
+	if len(file.Negation.Cues) > 0 && file.Negation.Window <= 0 {
+		fail("negation.window must be > 0 when cues are set")
+	}
+
+	ruleIDs := make(map[string]string)
+	compile := func(path string, specs []RuleSpec) []compiledRule {
+		if len(specs) == 0 {
+			fail("%s.rules: at least one rule is required", path)
+		}
+		compiled := make([]compiledRule, 0, len(specs))
+		for i, spec := range specs {
+			where := fmt.Sprintf("%s.rules[%d]", path, i)
+			if spec.ID == "" {
+				fail("%s: id is required", where)
+			} else if previous, ok := ruleIDs[spec.ID]; ok {
+				fail("%s: duplicate id %q (also used at %s)", where, spec.ID, previous)
+			} else {
+				ruleIDs[spec.ID] = where
+				where = fmt.Sprintf("%s (%s)", where, spec.ID)
+			}
+
+			rule := compiledRule{id: spec.ID, minRunes: spec.MinRunes, negatable: spec.Negatable, weight: spec.Weight}
+			if spec.Weight <= 0 || spec.Weight > 1 {
+				fail("%s: weight must be in (0, 1], got %v", where, spec.Weight)
+			}
+			if spec.MinRunes < 0 {
+				fail("%s: min_runes must be >= 0", where)
+			}
+			for j, keyword := range spec.Keywords {
+				keyword = strings.ToLower(strings.TrimSpace(keyword))
+				if keyword == "" {
+					fail("%s: keywords[%d] is empty", where, j)
+					continue
+				}
+				rule.keywords = append(rule.keywords, keyword)
+			}
+			if spec.Regex != "" {
+				re, err := regexp.Compile(spec.Regex)
+				if err != nil {
+					fail("%s: regex: %w", where, err)
+				}
+				rule.regex = re
+			}
+			if len(spec.Keywords) == 0 && spec.Regex == "" && spec.MinRunes == 0 {
+				fail("%s: set at least one of keywords, regex or min_runes", where)
+			}
+			if spec.Negatable && (len(spec.Keywords) == 0 || len(file.Negation.Cues) == 0) {
+				fail("%s: negatable needs keywords and negation.cues", where)
+			}
+			compiled = append(compiled, rule)
+		}
+		return compiled
+	}
+
+	seenIntents := make(map[string]bool)
+	for i, spec := range file.Intents {
+		path := fmt.Sprintf("intents[%d]", i)
+		if !classifiableIntents[spec.Intent] {
+			fail("%s: unknown intent %q (want %s or %s)", path, spec.Intent, IntentConfirmPlan, IntentClarifyGoal)
+		} else if seenIntents[spec.Intent] {
+			fail("%s: duplicate intent %q", path, spec.Intent)
+		}
+		seenIntents[spec.Intent] = true
+		if !validThreshold(spec.Threshold) {
+			fail("%s: threshold must be in (0, 1], got %v", path, spec.Threshold)
+		}
+		intent := intentRules{
+			intent:          spec.Intent,
+			threshold:       spec.Threshold,
+			stateThresholds: make(map[PlanningState]float64, len(spec.StateThresholds)),
+			rules:           compile(path, spec.Rules),
+		}
+		for state, threshold := range spec.StateThresholds {
+			if !knownStates[PlanningState(state)] {
+				fail("%s.state_thresholds: unknown state %q", path, state)
+			}
+			if !validThreshold(threshold) {
+				fail("%s.state_thresholds[%s]: threshold must be in (0, 1], got %v", path, state, threshold)
+			}
+			intent.stateThresholds[PlanningState(state)] = threshold
+		}
+		rules.intents = append(rules.intents, intent)
+	}
+
+	seenSlots := make(map[string]bool)
+	for i, spec := range file.Slots {
+		path := fmt.Sprintf("slots[%d]", i)
+		if !isRequiredSlot(spec.Slot) {
+			fail("%s: unknown slot %q", path, spec.Slot)
+		} else if seenSlots[spec.Slot] {
+			fail("%s: duplicate slot %q", path, spec.Slot)
+		}
+		seenSlots[spec.Slot] = true
+		if !validThreshold(spec.Threshold) {
+			fail("%s: threshold must be in (0, 1], got %v", path, spec.Threshold)
+		}
+		for _, other := range spec.ImpliedBy {
+			if !isRequiredSlot(other) || other == spec.Slot {
+				fail("%s.implied_by: invalid slot %q", path, other)
+			}
+		}
+		rules.slots = append(rules.slots, slotRules{
+			slot:      spec.Slot,
+			threshold: spec.Threshold,
+			impliedBy: spec.ImpliedBy,
+			rules:     compile(path, spec.Rules),
+		})
+	}
+	for _, slot := range requiredSlotOrder {
+		if !seenSlots[slot] {
+			fail("slots: missing rules for %q", slot)
+		}
+	}
+
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("invalid rules: %w", errors.Join(errs...))
+	}
+	return rules, nil
+}
+
+func validThreshold(value float64) bool {
+	return value > 0 && value <= 1
+}
+
+func isRequiredSlot(slot string) bool {
+	for _, required := range requiredSlotOrder {
+		if slot == required {
+			return true
+		}
+	}
+	return false
+}
+
+func (r compiledRule) fires(text, lower string, runes int, neg negation) bool {
+	if r.minRunes > 0 && runes < r.minRunes {
+		return false
+	}
+	if r.regex != nil && !r.regex.MatchString(text) {
+		return false
+	}
+	if len(r.keywords) > 0 {
+		negated := neg.negates
+		if !r.negatable {
+			negated = nil
+		}
+		matched := false
+		for _, keyword := range r.keywords {
+			if matchKeyword(lower, keyword, negated) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	return true
+}
+
+// clauseBreaks end the scope of a negation cue, so "不错，确认" still confirms.
+const clauseBreaks = ",，.。!！?？;；、\n"
+
+// negates reports whether the text right before a keyword negates it.
+func (n negation) negates(before string) bool {
+	if i := strings.LastIndexAny(before, clauseBreaks); i >= 0 {
+		_, size := utf8.DecodeRuneInString(before[i:])
+		before = before[i+size:]
+	}
+	runes := []rune(before)
+	if len(runes) > n.window {
+		runes = runes[len(runes)-n.window:]
+	}
+	tail := string(runes)
+	for _, cue := range n.cues {
+		if containsKeyword(tail, cue) {
+			return true
+		}
+	}
+	return false
+}
+
+// score combines the weights of the fired rules and rounds the result so it
+// can be logged and compared without float noise.
+func (r *Rules) score(rules []compiledRule, text string) (float64, []string) {
+	text = strings.TrimSpace(text)
+	lower := strings.ToLower(text)
+	runes := len([]rune(text))
+	miss := 1.0
+	var fired []string
+	for _, rule := range rules {
+		if !rule.fires(text, lower, runes, r.negation) {
+			continue
+		}
+		fired = append(fired, rule.id)
+		miss *= 1 - rule.weight
+	}
+	return math.Round((1-miss)*10000) / 10000, fired
+}
+
+func (i intentRules) thresholdFor(state PlanningState) float64 {
+	if threshold, ok := i.stateThresholds[state]; ok {
+		return threshold
+	}
+	return i.threshold
+}
+
+// ClassifyIntent scores every intent and returns them ranked. The best intent
+// that reaches its threshold for the current state wins, unless the
+// runner-up, which also reaches its threshold, is within the ambiguity margin;
+// then the result is IntentDisambiguate. ok is false when no intent reaches
+// its threshold.
+func (r *Rules) ClassifyIntent(text string, state PlanningState) (IntentResult, bool) {
+	ranking := make([]IntentScore, 0, len(r.intents))
+	passing := make(map[string]bool, len(r.intents))
+	for _, intent := range r.intents {
+		score, _ := r.score(intent.rules, text)
+		ranking = append(ranking, IntentScore{Intent: intent.intent, Score: score})
+		passing[intent.intent] = score > 0 && score >= intent.thresholdFor(state)
+	}
+	sort.SliceStable(ranking, func(i, j int) bool {
+		return ranking[i].Score > ranking[j].Score
+	})
+
+	candidates := make([]IntentScore, 0, len(ranking))
+	for _, entry := range ranking {
+		if passing[entry.Intent] {
+			candidates = append(candidates, entry)
+		}
+	}
+	if len(candidates) == 0 {
+		return IntentResult{Ranking: ranking}, false
+	}
+
+	result := IntentResult{Intent: candidates[0].Intent, Confidence: candidates[0].Score, Ranking: ranking}
+	if len(candidates) > 1 && candidates[0].Score-candidates[1].Score < r.ambiguityMargin {
+		result.Intent = IntentDisambiguate
+	}
+	return result, true
+}
+
+// SlotMatches reports whether text on its own fills slot.
+func (r *Rules) SlotMatches(slot, text string) bool {
+	for _, s := range r.slots {
+		if s.slot == slot {
+			score, _ := r.score(s.rules, text)
+			return score > 0 && score >= s.threshold
+		}
+	}
+	return false
+}
+
+func (r *Rules) UpdateSlotCompletion(slotCompletion map[string]bool, text string) map[string]bool {
+	normalized := NormalizeSlotCompletion(slotCompletion)
+	trimmed := strings.TrimSpace(text)
+	if trimmed == "" || ParseCommand(trimmed).IsCommand {
+		return normalized
+	}
+
+	for _, s := range r.slots {
+		if r.SlotMatches(s.slot, trimmed) {
+			normalized[s.slot] = true
+		}
+	}
+	for _, s := range r.slots {
+		for _, other := range s.impliedBy {
+			if normalized[other] {
+				normalized[s.slot] = true
+			}
+		}
+	}
+	return normalized
+}
+
+// Evaluate scores text against every intent and slot rule without touching
+// any session. It is used by the admin dry-run endpoint.
+func (r *Rules) Evaluate(text string, state PlanningState) RuleEvaluation {
+	evaluation := RuleEvaluation{}
+	for _, intent := range r.intents {
+		score, fired := r.score(intent.rules, text)
+		threshold := intent.thresholdFor(state)
+		evaluation.Intents = append(evaluation.Intents, RuleScore{
+			Name:      intent.intent,
+			Score:     score,
+			Threshold: threshold,
+			Matched:   score > 0 && score >= threshold,
+			Fired:     fired,
+		})
+	}
+
+	matched := make(map[string]bool, len(r.slots))
+	for _, s := range r.slots {
+		score, fired := r.score(s.rules, text)
+		result := RuleScore{
+			Name:      s.slot,
+			Score:     score,
+			Threshold: s.threshold,
+			Matched:   score > 0 && score >= s.threshold,
+			Fired:     fired,
+		}
+		matched[s.slot] = result.Matched
+		evaluation.Slots = append(evaluation.Slots, result)
+	}
+	for i, s := range r.slots {
+		if evaluation.Slots[i].Matched {
+			continue
+		}
+		for _, other := range s.impliedBy {
+			if matched[other] {
+				evaluation.Slots[i].Matched = true
+				evaluation.Slots[i].ImpliedBy = other
+				break
+			}
+		}
+	}
+	return evaluation
+}
+
+// RuleSet holds the live rules. Readers always see a complete rule set; a
+// reload swaps it atomically.
+type RuleSet struct {
+	current atomic.Pointer[loadedRules]
+}
+
+type loadedRules struct {
+	rules    *Rules
+	source   string
+	loadedAt time.Time
+}
+
+func NewRuleSet(rules *Rules, source string) *RuleSet {
+	set := &RuleSet{}
+	set.Store(rules, source)
+	return set
+}
+
+func (s *RuleSet) Store(rules *Rules, source string) {
+	s.current.Store(&loadedRules{rules: rules, source: source, loadedAt: time.Now().UTC()})
+}
+
+// Current returns the live rules, or the embedded defaults for a nil set.
+func (s *RuleSet) Current() *Rules {
+	if s == nil {
+		return defaultRules()
+	}
+	return s.current.Load().rules
+}
+
+// DryRun routes text exactly like the worker would and reports which rules
+// fired for every intent and slot.
+func (s *RuleSet) DryRun(text string, state PlanningState) RuleEvaluation {
+	rules := s.Current()
+	evaluation := rules.Evaluate(text, state)
+	result := NewIntentRouter(s).Route(text, state)
+	evaluation.Intent = result.Intent
+	evaluation.Slot = result.Slot
+	evaluation.Score = result.Confidence
+	evaluation.Ranking = result.Ranking
+	if s != nil {
+		loaded := s.current.Load()
+		evaluation.LoadedAt = loaded.loadedAt
+		evaluation.Source = loaded.source
+	}
+	return evaluation
+}
+
+var defaultRules = sync.OnceValue(DefaultRules)
+
+// RulesLoader reloads a rules file on demand, on SIGHUP, or when the file
+// changes on disk. A file that fails validation is logged and the previous
+// rules stay live.
+type RulesLoader struct {
+	path     string
+	interval time.Duration
+	set      *RuleSet
+	logger   *slog.Logger
+	modTime  time.Time
+}
+
+func NewRulesLoader(path string, interval time.Duration, set *RuleSet, logger *slog.Logger) *RulesLoader {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &RulesLoader{path: path, interval: interval, set: set, logger: logger}
+}
+
+func (l *RulesLoader) Reload() error {
+	info, err := os.Stat(l.path)
+	if err != nil {
+		return fmt.Errorf("stat rules file: %w", err)
+	}
+	data, err := os.ReadFile(l.path)
+	if err != nil {
+		return fmt.Errorf("read rules file: %w", err)
+	}
+	// Remember the version even when it is invalid so the watcher does not
+	// report the same broken file on every tick.
+	l.modTime = info.ModTime()
+	rules, err := ParseRulesFile(l.path, data)
+	if err != nil {
+		return fmt.Errorf("%s: %w", l.path, err)
+	}
+	l.set.Store(rules, l.path)
+	l.logger.Info("rules_loaded", slog.String("path", l.path))
+	return nil
+}
+
+// Watch reloads on every value from signals and, when interval > 0, whenever
+// the file modification time changes.
+func (l *RulesLoader) Watch(ctx context.Context, signals <-chan os.Signal) {
+	var tick <-chan time.Time
+	if l.interval > 0 {
+		ticker := time.NewTicker(l.interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-signals:
+			l.reloadAndLog("signal")
+		case <-tick:
+			info, err := os.Stat(l.path)
+			if err != nil {
+				l.logger.Warn("rules_stat_failed", slog.String("path", l.path), slog.Any("error", err))
+				continue
+			}
+			if info.ModTime().Equal(l.modTime) {
+				continue
+			}
+			l.reloadAndLog("file_changed")
+		}
+	}
+}
+
+func (l *RulesLoader) reloadAndLog(trigger string) {
+	if err := l.Reload(); err != nil {
+		l.logger.Error("rules_reload_failed",
+			slog.String("trigger", trigger),
+			slog.Any("error", err),
+		)
+	}
+}
@@ -0,0 +1,287 @@
+package telegram
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/congregalis/aiden/internal/config"
+)
+
+const testRulesJSON = `{
+  "version": 1,
+  "intents": [
+    {"intent": "confirm_plan", "threshold": 0.5, "rules": [{"id": "yes", "keywords": ["yes", "好的"], "weight": 0.9}]},
+    {"intent": "clarify_goal", "threshold": 0.5, "state_thresholds": {"review": 0.8},
+     "rules": [{"id": "goal_word", "keywords": ["goal"], "weight": 0.9}, {"id": "long", "min_runes": 8, "weight": 0.6}]}
+  ],
+  "slots": [
+    {"slot": "main_goal", "threshold": 0.5, "rules": [{"id": "mg", "keywords": ["goal"], "weight": 1}]},
+    {"slot": "success_criteria", "threshold": 0.5, "rules": [{"id": "sc", "regex": "\\d+ checks", "weight": 1}]},
+    {"slot": "current_level", "threshold": 0.5, "rules": [{"id": "cl", "keywords": ["beginner"], "weight": 1}]},
+    {"slot": "time_budget", "threshold": 0.5, "rules": [{"id": "tb", "keywords": ["daily"], "weight": 1}]},
+    {"slot": "constraints", "threshold": 0.5, "rules": [{"id": "co", "keywords": ["only"], "weight": 1}]},
+    {"slot": "risk_flags", "threshold": 0.5, "implied_by": ["constraints"], "rules": [{"id": "rf", "keywords": ["risk"], "weight": 1}]}
+  ]
+}`
+
+func mustParseRules(t *testing.T, data string) *Rules {
+	t.Helper()
+	rules, err := ParseRules([]byte(data))
+	if err != nil {
+		t.Fatalf("parse rules: %v", err)
+	}
+	return rules
+}
+
+func TestRulesApplyStateThresholds(t *testing.T) {
+	rules := mustParseRules(t, testRulesJSON)
+	router := NewIntentRouter(NewRuleSet(rules, "test"))
+
+	if got := router.Route("something longer", StateClarifying); got.Intent != IntentClarifyGoal || got.Confidence != 0.6 {
+		t.Fatalf("clarifying route=%+v, want clarify_goal at 0.6", got)
+	}
+	if got := router.Route("something longer", StateReview); got.Intent != IntentFallbackUnknown {
+		t.Fatalf("review route=%+v, want fallback below the review threshold", got)
+	}
+	if got := router.Route("my goal is this", StateReview); got.Intent != IntentClarifyGoal {
+		t.Fatalf("review route=%+v, want clarify_goal", got)
+	}
+	if got := router.Route("yes", StateReview); got.Intent != IntentConfirmPlan {
+		t.Fatalf("confirm route=%+v", got)
+	}
+}
+
+func TestRulesUpdateSlotCompletion(t *testing.T) {
+	rules := mustParseRules(t, testRulesJSON)
+
+	slots := rules.UpdateSlotCompletion(nil, "Only 3 checks, daily")
+	for slot, want := range map[string]bool{
+		SlotMainGoal:        false,
+		SlotSuccessCriteria: true,
+		SlotTimeBudget:      true,
+		SlotConstraints:     true,
+		SlotRiskFlags:       true,
+	} {
+		if slots[slot] != want {
+			t.Fatalf("slot %s=%v, want %v (all: %v)", slot, slots[slot], want, slots)
+		}
+	}
+	if got := rules.UpdateSlotCompletion(nil, "/goal my goal"); got[SlotMainGoal] {
+		t.Fatalf("commands must not fill slots")
+	}
+}
+
+func TestRulesEvaluateReportsFiredRules(t *testing.T) {
+	set := NewRuleSet(mustParseRules(t, testRulesJSON), "test")
+	evaluation := set.DryRun("my goal, only evenings", StateClarifying)
+
+	if evaluation.Intent != IntentClarifyGoal || evaluation.Source != "test" {
+		t.Fatalf("evaluation=%+v", evaluation)
+	}
+	clarify := evaluation.Intents[1]
+	if !clarify.Matched || !slices.Equal(clarify.Fired, []string{"goal_word", "long"}) || clarify.Score != 0.96 {
+		t.Fatalf("clarify score=%+v", clarify)
+	}
+	risk := evaluation.Slots[5]
+	if !risk.Matched || risk.ImpliedBy != SlotConstraints || len(risk.Fired) != 0 {
+		t.Fatalf("risk score=%+v", risk)
+	}
+}
+
+func TestParseRulesReportsEveryProblem(t *testing.T) {
+	bad := `{
+  "version": 1,
+  "intents": [
+    {"intent": "greet", "threshold": 0.5, "rules": [{"id": "hi", "keywords": ["hi"], "weight": 1}]},
+    {"intent": "clarify_goal", "threshold": 0.5, "state_thresholds": {"planning": 0.7},
+     "rules": [{"id": "re", "regex": "([a-z", "weight": 1}, {"id": "empty", "weight": 0.5}, {"id": "heavy", "keywords": ["x"], "weight": 2}]}
+  ],
+  "slots": [
+    {"slot": "main_goal", "threshold": 0.5, "rules": [{"id": "hi", "keywords": ["goal"], "weight": 1}]}
+  ]
+}`
+	_, err := ParseRules([]byte(bad))
+	if err == nil {
+		t.Fatalf("expected invalid rules to be rejected")
+	}
+	for _, want := range []string{
+		`intents[0]: unknown intent "greet"`,
+		`intents[1].state_thresholds: unknown state "planning"`,
+		`intents[1].rules[0] (re): regex:`,
+		`intents[1].rules[1] (empty): set at least one of keywords, regex or min_runes`,
+		`intents[1].rules[2] (heavy): weight must be in (0, 1]`,
+		`slots[0].rules[0]: duplicate id "hi"`,
+		`slots: missing rules for "risk_flags"`,
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Fatalf("error=%v\nwant it to contain %q", err, want)
+		}
+	}
+
+	if _, err := ParseRules([]byte(`{"version": 1, "intnets": []}`)); err == nil || !strings.Contains(err.Error(), "intnets") {
+		t.Fatalf("expected unknown field to be rejected, got %v", err)
+	}
+}
+
+func TestRulesLoaderKeepsLastGoodRules(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rules.json")
+	if err := os.WriteFile(path, []byte(testRulesJSON), 0o600); err != nil {
+		t.Fatalf("write rules: %v", err)
+	}
+
+	set := NewRuleSet(DefaultRules(), "embedded")
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	loader := NewRulesLoader(path, 10*time.Millisecond, set, logger)
+	if err := loader.Reload(); err != nil {
+		t.Fatalf("reload: %v", err)
+	}
+	if got := NewIntentRouter(set).Route("好的", StateReview); got.Intent != IntentConfirmPlan || got.Confidence != 0.9 {
+		t.Fatalf("route after load=%+v", got)
+	}
+
+	if err := os.WriteFile(path, []byte(`{"version": 2}`), 0o600); err != nil {
+		t.Fatalf("write rules: %v", err)
+	}
+	if err := loader.Reload(); err == nil || !strings.Contains(err.Error(), "version: got 2, want 1") {
+		t.Fatalf("reload error=%v", err)
+	}
+	if got := NewIntentRouter(set).Route("好的", StateReview); got.Confidence != 0.9 {
+		t.Fatalf("invalid reload replaced live rules: %+v", got)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	signals := make(chan os.Signal, 1)
+	go loader.Watch(ctx, signals)
+
+	updated := strings.Replace(testRulesJSON, `"weight": 0.9}]},`, `"weight": 0.95}]},`, 1)
+	if err := os.WriteFile(path, []byte(updated), 0o600); err != nil {
+		t.Fatalf("write rules: %v", err)
+	}
+	signals <- os.Interrupt
+	deadline := time.Now().Add(2 * time.Second)
+	for NewIntentRouter(set).Route("好的", StateReview).Confidence != 0.95 {
+		if time.Now().After(deadline) {
+			t.Fatalf("rules were not reloaded")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestDefaultRulesRankIntentsWithNegation(t *testing.T) {
+	router := NewIntentRouter(nil)
+
+	tests := []struct {
+		text string
+		want string
+	}{
+		{text: "确认", want: IntentConfirmPlan},
+		{text: "不错，确认", want: IntentConfirmPlan},
+		{text: "不确认", want: IntentFallbackUnknown},
+		{text: "先别确认，我再想想", want: IntentClarifyGoal},
+		{text: "我不确定要不要确认", want: IntentClarifyGoal},
+		{text: "not ok", want: IntentFallbackUnknown},
+		{text: "don't confirm yet", want: IntentClarifyGoal},
+		{text: "确认，但还想调整一下每周时间", want: IntentDisambiguate},
+	}
+	for _, tt := range tests {
+		got := router.Route(tt.text, StateReview)
+		if got.Intent != tt.want {
+			t.Fatalf("Route(%q)=%+v, want %s", tt.text, got, tt.want)
+		}
+		if len(got.Ranking) != 2 || got.Ranking[0].Score < got.Ranking[1].Score {
+			t.Fatalf("Route(%q) ranking=%+v, want both intents best first", tt.text, got.Ranking)
+		}
+	}
+}
+
+func TestWorkerAsksWhenIntentsAreTooClose(t *testing.T) {
+	store := NewMemoryStore()
+	ctx := context.Background()
+	user, _, _ := store.FindOrCreateUserByChatID(ctx, 92001)
+	goal, _ := store.CreateGoalDraft(ctx, user.ID)
+	if err := store.SetCurrentGoal(ctx, user.ID, goal.ID); err != nil {
+		t.Fatalf("set current goal failed: %v", err)
+	}
+	session, _, _ := store.GetOrCreatePlanningSession(ctx, goal.ID)
+	store.mu.Lock()
+	session.State = StateReview
+	for _, slot := range requiredSlotOrder {
+		session.SlotCompletion[slot] = true
+	}
+	store.sessionsByGoalID[goal.ID] = session
+	store.mu.Unlock()
+
+	client := &scriptedClient{
+		updates: [][]Update{{
+			{UpdateID: 1, Message: &Message{MessageID: 1, Chat: Chat{ID: 92001}, Text: "确认，但还想调整一下每周时间"}},
+		}},
+	}
+	if err := runWorkerUntilSendCount(t, client, store, 1); err != nil {
+		t.Fatalf("worker run failed: %v", err)
+	}
+
+	want := zh(MsgDisambiguate, "1) "+zh(MsgOptionConfirm)+"\n2) "+zh(MsgOptionClarify))
+	if got := client.SentMessages()[0].Text; got != want {
+		t.Fatalf("reply=%q, want %q", got, want)
+	}
+	updated, _ := store.SessionByGoalID(goal.ID)
+	if updated.State != StateReview {
+		t.Fatalf("state=%s, want review to be kept", updated.State)
+	}
+
+	turn, found, _ := store.GetLatestUserTurn(ctx, session.ID)
+	if !found || turn.Intent != IntentDisambiguate || len(turn.IntentRanking) != 2 || turn.IntentRanking[0].Intent != IntentConfirmPlan {
+		t.Fatalf("user turn=%+v, want the ranking to be logged", turn)
+	}
+}
+
+func TestParseRulesFileAcceptsYAML(t *testing.T) {
+	// JSON is valid YAML, so the test rules exercise the YAML path too.
+	rules, err := ParseRulesFile("rules.yml", []byte(testRulesJSON))
+	if err != nil {
+		t.Fatalf("parse yaml rules: %v", err)
+	}
+	if got := NewIntentRouter(NewRuleSet(rules, "test")).Route("好的", StateReview); got.Intent != IntentConfirmPlan {
+		t.Fatalf("route=%+v", got)
+	}
+
+	if _, err := ParseRulesFile("rules.yaml", []byte("version: 1\nintnets: []\n")); err == nil || !strings.Contains(err.Error(), "intnets") {
+		t.Fatalf("err=%v, want the unknown field", err)
+	}
+}
+
+// The example config must start as is: its rules file has to exist and match
+// the built-in rules.
+func TestExampleConfigRulesFileLoads(t *testing.T) {
+	root := filepath.Join("..", "..")
+	t.Setenv("CONFIG_FILE", filepath.Join(root, "configs", "config.example.yaml"))
+	t.Setenv("DB_DSN", "postgres://aiden@localhost/aiden")
+	t.Setenv("TELEGRAM_BOT_TOKEN", "123:test")
+	t.Setenv("RETENTION_HASH_SALT", "salt")
+
+	cfg, err := config.Load(filepath.Join(t.TempDir(), ".env"))
+	if err != nil {
+		t.Fatalf("load example config: %v", err)
+	}
+	if cfg.Rules.File == "" {
+		t.Fatal("example config sets no rules file")
+	}
+
+	set := NewRuleSet(DefaultRules(), "embedded")
+	loader := NewRulesLoader(filepath.Join(root, cfg.Rules.File), 0, set, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if err := loader.Reload(); err != nil {
+		t.Fatalf("load example rules: %v", err)
+	}
+	if !reflect.DeepEqual(set.Current(), DefaultRules()) {
+		t.Fatalf("%s differs from default_rules.json", cfg.Rules.File)
+	}
+}
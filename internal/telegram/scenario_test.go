@@ -0,0 +1,423 @@
+package telegram
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/congregalis/aiden/internal/config"
+	"github.com/congregalis/aiden/internal/db"
+	"github.com/congregalis/aiden/internal/maintenance"
+	"gopkg.in/yaml.v3"
+)
+
+var updateGolden = flag.Bool("update", false, "rewrite scenario golden files with the current replies")
+
+const scenarioDir = "testdata/scenarios"
+
+// scenarioDSNEnv points the scenarios at a migrated Postgres database instead
+// of the memory store.
+const scenarioDSNEnv = "SCENARIO_DATABASE_DSN"
+
+// scenario is one conversation in testdata/scenarios/*.yaml. See the README
+// section on scenario tests for the format.
+type scenario struct {
+	Name           string         `yaml:"name"`
+	Description    string         `yaml:"description"`
+	Language       string         `yaml:"language"`
+	SessionTimeout time.Duration  `yaml:"session_timeout"`
+	ArchiveAfter   time.Duration  `yaml:"archive_after"`
+	Steps          []scenarioStep `yaml:"steps"`
+}
+
+type scenarioStep struct {
+	// Chat names the simulated user; steps without one use "user".
+	Chat     string          `yaml:"chat"`
+	Language string          `yaml:"language"`
+	Send     string          `yaml:"send"`
+	Edit     string          `yaml:"edit"`
+	Advance  time.Duration   `yaml:"advance"`
+	Expect   *scenarioExpect `yaml:"expect"`
+}
+
+type scenarioExpect struct {
+	Replies          *int     `yaml:"replies"`
+	ReplyContains    []string `yaml:"reply_contains"`
+	ReplyNotContains []string `yaml:"reply_not_contains"`
+	Golden           string   `yaml:"golden"`
+	State            string   `yaml:"state"`
+	Filled           []string `yaml:"filled"`
+	Missing          []string `yaml:"missing"`
+	Skipped          []string `yaml:"skipped"`
+	LastIntent       string   `yaml:"last_intent"`
+	Turns            *int     `yaml:"turns"`
+	Goals            *int     `yaml:"goals"`
+	GoalStatus       string   `yaml:"goal_status"`
+	GoalTitle        string   `yaml:"goal_title"`
+	UserLanguage     string   `yaml:"user_language"`
+	NoGoal           bool     `yaml:"no_goal"`
+}
+
+func TestScenarios(t *testing.T) {
+	paths, err := filepath.Glob(filepath.Join(scenarioDir, "*.yaml"))
+	if err != nil || len(paths) == 0 {
+		t.Fatalf("no scenarios in %s: %v", scenarioDir, err)
+	}
+
+	var conn *sql.DB
+	if dsn := os.Getenv(scenarioDSNEnv); dsn != "" {
+		conn, err = db.Open(config.DatabaseConfig{DSN: dsn})
+		if err != nil {
+			t.Fatalf("%s: %v", scenarioDSNEnv, err)
+		}
+		t.Cleanup(func() { conn.Close() })
+	}
+
+	for _, path := range paths {
+		sc, err := loadScenario(path)
+		if err != nil {
+			t.Fatalf("%s: %v", path, err)
+		}
+		t.Run(strings.TrimSuffix(filepath.Base(path), ".yaml"), func(t *testing.T) {
+			run := newScenarioRun(t, sc, conn)
+			for i, step := range sc.Steps {
+				run.step(i+1, step)
+			}
+		})
+	}
+}
+
+func loadScenario(path string) (scenario, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return scenario{}, err
+	}
+	var sc scenario
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&sc); err != nil {
+		return scenario{}, fmt.Errorf("parse: %w", err)
+	}
+	if len(sc.Steps) == 0 {
+		return scenario{}, fmt.Errorf("no steps")
+	}
+	for i, step := range sc.Steps {
+		actions := 0
+		for _, set := range []bool{step.Send != "", step.Edit != "", step.Advance != 0} {
+			if set {
+				actions++
+			}
+		}
+		if actions != 1 {
+			return scenario{}, fmt.Errorf("step %d: want exactly one of send, edit or advance", i+1)
+		}
+		if step.Expect == nil {
+			continue
+		}
+		for _, slot := range slices.Concat(step.Expect.Filled, step.Expect.Missing, step.Expect.Skipped) {
+			if !slices.Contains(RequiredSlots(), slot) {
+				return scenario{}, fmt.Errorf("step %d: unknown slot %q", i+1, slot)
+			}
+		}
+		if step.Expect.State != "" && string(ParsePlanningState(step.Expect.State)) != step.Expect.State {
+			return scenario{}, fmt.Errorf("step %d: unknown state %q", i+1, step.Expect.State)
+		}
+	}
+	return sc, nil
+}
+
+// scenarioClock runs at wall-clock speed plus the scenario's advances, so
+// timestamps written by Postgres stay comparable with the worker's clock.
+type scenarioClock struct {
+	mu     sync.Mutex
+	offset time.Duration
+}
+
+func (c *scenarioClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return time.Now().Add(c.offset)
+}
+
+func (c *scenarioClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.offset += d
+}
+
+type scenarioRun struct {
+	t        *testing.T
+	scenario scenario
+	ctx      context.Context
+	worker   *Worker
+	store    Store
+	client   *scriptedClient
+	clock    *scenarioClock
+	// maintenance holds the idle_goals task when the scenario sets
+	// archive_after; it runs after every clock advance.
+	maintenance []maintenance.Task
+
+	chatBase      int64
+	chats         map[string]int64
+	lastMessage   map[int64]Message
+	nextUpdateID  int64
+	nextMessageID int64
+	sentSeen      int
+}
+
+func newScenarioRun(t *testing.T, sc scenario, conn *sql.DB) *scenarioRun {
+	clock := &scenarioClock{}
+	var store Store
+	// Postgres keeps data between runs, so every run gets fresh chat IDs.
+	chatBase := int64(7_000_000_000)
+	if conn != nil {
+		store = NewSQLStore(conn)
+		chatBase += time.Now().UnixNano() % 1_000_000_000 * 10
+	} else {
+		memory := NewMemoryStore()
+		memory.SetClock(clock.Now)
+		store = memory
+	}
+
+	client := &scriptedClient{}
+	worker := NewWorker(WorkerConfig{
+		Session: SessionConfig{Timeout: sc.SessionTimeout},
+		Now:     clock.Now,
+	}, client, store, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	return &scenarioRun{
+		t:           t,
+		scenario:    sc,
+		ctx:         context.Background(),
+		worker:      worker,
+		store:       store,
+		client:      client,
+		clock:       clock,
+		maintenance: MaintenanceTasks(store.(MaintenanceStore), MaintenanceConfig{ArchiveAfter: sc.ArchiveAfter}),
+		chatBase:    chatBase,
+		chats:       make(map[string]int64),
+		lastMessage: make(map[int64]Message),
+	}
+}
+
+func (r *scenarioRun) chatID(name string) int64 {
+	if name == "" {
+		name = "user"
+	}
+	id, ok := r.chats[name]
+	if !ok {
+		id = r.chatBase + int64(len(r.chats))
+		r.chats[name] = id
+	}
+	return id
+}
+
+func (r *scenarioRun) step(n int, step scenarioStep) {
+	t := r.t
+	t.Helper()
+	chatID := r.chatID(step.Chat)
+	language := step.Language
+	if language == "" {
+		language = r.scenario.Language
+	}
+
+	switch {
+	case step.Advance != 0:
+		r.clock.Advance(step.Advance)
+		if r.scenario.ArchiveAfter > 0 {
+			runMaintenanceTask(t, r.maintenance, "idle_goals", r.clock.Now(), 100)
+		}
+	case step.Send != "":
+		r.nextMessageID++
+		message := Message{
+			MessageID: r.nextMessageID,
+			From:      &TelegramUser{ID: chatID, FirstName: "Scenario", LanguageCode: language},
+			Chat:      Chat{ID: chatID, Type: "private"},
+			Text:      step.Send,
+		}
+		r.lastMessage[chatID] = message
+		r.deliver(n, Update{Message: &message})
+	case step.Edit != "":
+		message, ok := r.lastMessage[chatID]
+		if !ok {
+			t.Fatalf("step %d: nothing to edit in chat %q", n, step.Chat)
+		}
+		message.Text = step.Edit
+		r.lastMessage[chatID] = message
+		r.deliver(n, Update{EditedMessage: &message})
+	}
+
+	replies := r.takeReplies(chatID)
+	if step.Expect != nil {
+		r.check(n, chatID, replies, *step.Expect)
+	}
+}
+
+func (r *scenarioRun) deliver(n int, update Update) {
+	r.nextUpdateID++
+	update.UpdateID = r.nextUpdateID
+	if err := r.worker.handleUpdate(r.ctx, update); err != nil {
+		r.t.Fatalf("step %d: handle update: %v", n, err)
+	}
+	if _, err := r.worker.dispatcher.DispatchOnce(r.ctx); err != nil {
+		r.t.Fatalf("step %d: dispatch outbox: %v", n, err)
+	}
+}
+
+func (r *scenarioRun) takeReplies(chatID int64) []string {
+	sent := r.client.SentMessages()
+	var replies []string
+	for _, message := range sent[r.sentSeen:] {
+		if message.ChatID == chatID {
+			replies = append(replies, message.Text)
+		}
+	}
+	r.sentSeen = len(sent)
+	return replies
+}
+
+func (r *scenarioRun) check(n int, chatID int64, replies []string, expect scenarioExpect) {
+	t := r.t
+	t.Helper()
+	text := strings.Join(replies, "\n---\n")
+
+	if expect.Replies != nil && len(replies) != *expect.Replies {
+		t.Errorf("step %d: got %d replies, want %d:\n%s", n, len(replies), *expect.Replies, text)
+	}
+	for _, want := range expect.ReplyContains {
+		if !strings.Contains(text, want) {
+			t.Errorf("step %d: reply does not contain %q:\n%s", n, want, text)
+		}
+	}
+	for _, unwanted := range expect.ReplyNotContains {
+		if strings.Contains(text, unwanted) {
+			t.Errorf("step %d: reply contains %q:\n%s", n, unwanted, text)
+		}
+	}
+	if expect.Golden != "" {
+		r.checkGolden(n, expect.Golden, text)
+	}
+
+	user, _, err := r.store.FindOrCreateUserByChatID(r.ctx, chatID)
+	if err != nil {
+		t.Fatalf("step %d: load user: %v", n, err)
+	}
+	if expect.UserLanguage != "" && user.Language != expect.UserLanguage {
+		t.Errorf("step %d: user language=%q, want %q", n, user.Language, expect.UserLanguage)
+	}
+	if expect.Goals != nil {
+		goals, err := r.store.ListGoalsByUserID(r.ctx, user.ID)
+		if err != nil {
+			t.Fatalf("step %d: list goals: %v", n, err)
+		}
+		if len(goals) != *expect.Goals {
+			t.Errorf("step %d: goals=%d, want %d", n, len(goals), *expect.Goals)
+		}
+	}
+
+	goal, found, err := r.store.GetCurrentGoalByUserID(r.ctx, user.ID)
+	if err != nil {
+		t.Fatalf("step %d: load current goal: %v", n, err)
+	}
+	if expect.NoGoal {
+		if found {
+			t.Errorf("step %d: current goal=%+v, want none", n, goal)
+		}
+		return
+	}
+	needsGoal := expect.GoalStatus != "" || expect.GoalTitle != "" || expect.State != "" || expect.LastIntent != "" ||
+		expect.Turns != nil || len(expect.Filled)+len(expect.Missing)+len(expect.Skipped) > 0
+	if !needsGoal {
+		return
+	}
+	if !found {
+		t.Fatalf("step %d: no current goal", n)
+	}
+	if expect.GoalStatus != "" && goal.Status != expect.GoalStatus {
+		t.Errorf("step %d: goal status=%q, want %q", n, goal.Status, expect.GoalStatus)
+	}
+	if expect.GoalTitle != "" && goal.Title != expect.GoalTitle {
+		t.Errorf("step %d: goal title=%q, want %q", n, goal.Title, expect.GoalTitle)
+	}
+
+	session, _, err := r.store.GetOrCreatePlanningSession(r.ctx, goal.ID)
+	if err != nil {
+		t.Fatalf("step %d: load session: %v", n, err)
+	}
+	if expect.State != "" && string(session.State) != expect.State {
+		t.Errorf("step %d: state=%q, want %q", n, session.State, expect.State)
+	}
+	if expect.LastIntent != "" && session.LastIntent != expect.LastIntent {
+		t.Errorf("step %d: last intent=%q, want %q", n, session.LastIntent, expect.LastIntent)
+	}
+	if expect.Turns != nil && session.TurnCount != *expect.Turns {
+		t.Errorf("step %d: turns=%d, want %d", n, session.TurnCount, *expect.Turns)
+	}
+	for _, slot := range expect.Filled {
+		if !session.SlotCompletion[slot] {
+			t.Errorf("step %d: slot %s is not filled", n, slot)
+		}
+	}
+	for _, slot := range expect.Missing {
+		if session.SlotCompletion[slot] {
+			t.Errorf("step %d: slot %s is filled, want missing", n, slot)
+		}
+	}
+	for _, slot := range expect.Skipped {
+		if !slices.Contains(session.SkippedSlots, slot) {
+			t.Errorf("step %d: slot %s is not skipped (skipped=%v)", n, slot, session.SkippedSlots)
+		}
+	}
+}
+
+func (r *scenarioRun) checkGolden(n int, name, text string) {
+	t := r.t
+	t.Helper()
+	path := filepath.Join(scenarioDir, "golden", name)
+	if *updateGolden {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("step %d: %v", n, err)
+		}
+		if err := os.WriteFile(path, []byte(text+"\n"), 0o644); err != nil {
+			t.Fatalf("step %d: write golden: %v", n, err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("step %d: read golden (run with -update to create it): %v", n, err)
+	}
+	if got := text + "\n"; got != string(want) {
+		t.Errorf("step %d: reply differs from %s (run with -update to accept):\n--- got\n%s--- want\n%s", n, path, got, want)
+	}
+}
+
+func TestLoadScenarioRejectsMistakes(t *testing.T) {
+	tests := map[string]string{
+		"steps:\n  - send: hi\n    expect:\n      stat: review\n":     "field stat not found",
+		"steps:\n  - send: hi\n    advance: 1h\n":                     "exactly one of send, edit or advance",
+		"steps:\n  - send: hi\n    expect:\n      filled: [budget]\n": `unknown slot "budget"`,
+		"name: empty\n": "no steps",
+	}
+	for content, want := range tests {
+		path := filepath.Join(t.TempDir(), "scenario.yaml")
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := loadScenario(path); err == nil || !strings.Contains(err.Error(), want) {
+			t.Fatalf("loadScenario(%q) error=%v, want %q", content, err, want)
+		}
+	}
+}
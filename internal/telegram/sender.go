@@ -18,6 +18,7 @@ const (
 
 type Sender struct {
 	client     Client
+	limiter    *RateLimiter
 	logger     *slog.Logger
 	maxRetries int
 	baseDelay  time.Duration
@@ -38,15 +39,69 @@ func NewSender(client Client, logger *slog.Logger) Sender {
 	}
 }
 
+// WithRateLimiter returns a copy of the sender that waits for limiter before
+// every attempt and pauses it when Telegram answers with 429.
+func (s Sender) WithRateLimiter(limiter *RateLimiter) Sender {
+	s.limiter = limiter
+	return s
+}
+
 func (s Sender) Send(ctx context.Context, message OutgoingMessage) error {
+	return s.sendWithRetry(ctx, message.ChatID, func() error {
+		_, err := s.client.SendMessage(ctx, message)
+		return err
+	})
+}
+
+// SendOnce makes a single attempt, still waiting for the rate limiter and
+// pausing it on 429. The outbox uses it because it schedules its own
+// retries through next_attempt_at.
+func (s Sender) SendOnce(ctx context.Context, message OutgoingMessage) error {
+	return s.sendOnce(ctx, message.ChatID, func() error {
+		_, err := s.client.SendMessage(ctx, message)
+		return err
+	})
+}
+
+// SendDocumentOnce uploads a file with a single attempt, like SendOnce.
+func (s Sender) SendDocumentOnce(ctx context.Context, document OutgoingDocument) error {
+	return s.sendOnce(ctx, document.ChatID, func() error {
+		_, err := s.client.SendDocument(ctx, document)
+		return err
+	})
+}
+
+func (s Sender) sendOnce(ctx context.Context, chatID int64, send func() error) error {
+	if err := s.limiter.Wait(ctx, chatID); err != nil {
+		return err
+	}
+	err := send()
+	if err == nil {
+		return nil
+	}
+	if retryAfter, isRateLimited := IsRateLimitError(err); isRateLimited {
+		s.limiter.Pause(retryAfter)
+	}
+	return fmt.Errorf("send message failed: %w", err)
+}
+
+func (s Sender) sendWithRetry(ctx context.Context, chatID int64, send func() error) error {
 	delay := s.baseDelay
 
 	for attempt := 0; ; attempt++ {
-		_, err := s.client.SendMessage(ctx, message)
+		if err := s.limiter.Wait(ctx, chatID); err != nil {
+			return err
+		}
+
+		err := send()
 		if err == nil {
 			return nil
 		}
 
+		if retryAfter, isRateLimited := IsRateLimitError(err); isRateLimited {
+			s.limiter.Pause(retryAfter)
+		}
+
 		waitDuration, retryable := s.retryDecision(err, delay)
 		if !retryable || attempt >= s.maxRetries {
 			return fmt.Errorf("send message failed: %w", err)
@@ -55,6 +110,7 @@ func (s Sender) Send(ctx context.Context, message OutgoingMessage) error {
 		s.logger.Warn("telegram send retry",
 			slog.Int("attempt", attempt+1),
 			slog.Duration("wait", waitDuration),
+			slog.Int64("send_queue_depth", s.limiter.QueueDepth()),
 			slog.Any("error", err),
 		)
 
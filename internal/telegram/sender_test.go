@@ -5,6 +5,7 @@ import (
 	"io"
 	"log/slog"
 	"net/http"
+	"sync"
 	"testing"
 	"time"
 )
@@ -51,8 +52,10 @@ func TestSenderStopsOnClientError(t *testing.T) {
 }
 
 type sendStubClient struct {
+	mu           sync.Mutex
 	errors       []error
 	sendAttempts int
+	sentChats    []int64
 }
 
 func (c *sendStubClient) GetMe(context.Context) (BotUser, error) {
@@ -63,9 +66,12 @@ func (c *sendStubClient) GetUpdates(context.Context, GetUpdatesParams) ([]Update
 	return nil, nil
 }
 
-func (c *sendStubClient) SendMessage(context.Context, OutgoingMessage) (Message, error) {
+func (c *sendStubClient) SendMessage(_ context.Context, message OutgoingMessage) (Message, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
 	idx := c.sendAttempts
 	c.sendAttempts++
+	c.sentChats = append(c.sentChats, message.ChatID)
 	if idx >= len(c.errors) {
 		return Message{}, nil
 	}
@@ -74,3 +80,21 @@ func (c *sendStubClient) SendMessage(context.Context, OutgoingMessage) (Message,
 	}
 	return Message{}, nil
 }
+
+func (c *sendStubClient) SentChats() []int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]int64(nil), c.sentChats...)
+}
+
+func (c *sendStubClient) SendDocument(ctx context.Context, document OutgoingDocument) (Message, error) {
+	return c.SendMessage(ctx, OutgoingMessage{ChatID: document.ChatID})
+}
+
+func (c *sendStubClient) GetFile(context.Context, string) (File, error) {
+	return File{}, nil
+}
+
+func (c *sendStubClient) DownloadFile(context.Context, File, int64) ([]byte, error) {
+	return nil, nil
+}
@@ -0,0 +1,227 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/congregalis/aiden/internal/i18n"
+)
+
+const undoPreviewRunes = 20
+
+// handleSessionControl serves /status, /reset, /undo and /skip. None of them
+// counts as a clarification turn, and none of them creates a goal.
+func (w *Worker) handleSessionControl(ctx context.Context, store Store, user User, message IncomingMessage, intent IntentResult) (string, error) {
+	goal, found, err := store.GetCurrentGoalByUserID(ctx, user.ID)
+	if err != nil {
+		return "", fmt.Errorf("get current goal by user id: %w", err)
+	}
+	if !found {
+		return tr(user.Language, MsgNoActiveGoal), nil
+	}
+
+	session, _, err := store.GetOrCreatePlanningSession(ctx, goal.ID)
+	if err != nil {
+		return "", fmt.Errorf("get or create planning session: %w", err)
+	}
+	session.SlotCompletion = NormalizeSlotCompletion(session.SlotCompletion)
+
+	lang := user.Language
+	var reply string
+	switch intent.Intent {
+	case IntentShowStatus:
+		reply = FormatSessionStatus(lang, session)
+	case IntentResetSession:
+		session.PendingAction = PendingActionReset
+		reply = tr(lang, MsgResetConfirm)
+	case IntentUndoTurn:
+		reply, session, err = undoLastTurn(ctx, store, w.rules.Current(), lang, session)
+		if err != nil {
+			return "", err
+		}
+	case IntentSkipSlot:
+		reply, session = skipSlot(lang, session, intent.Slot)
+	default:
+		return "", fmt.Errorf("unsupported session control intent %q", intent.Intent)
+	}
+
+	session.LastIntent = intent.Intent
+	if err := store.UpdatePlanningSession(ctx, session); err != nil {
+		return "", fmt.Errorf("update planning session: %w", err)
+	}
+	if err := saveTurnPair(ctx, store, session.ID, message, intent, reply); err != nil {
+		return "", err
+	}
+
+	w.logger.Info("session_control_handled",
+		slog.Int64("update_id", message.UpdateID),
+		slog.String("session_id", session.ID),
+		slog.String("intent", intent.Intent),
+		slog.String("state", string(session.State)),
+	)
+	return reply, nil
+}
+
+// resetGoal archives the goal once the user confirmed a pending /reset. The
+// next message starts a new draft through ensureCurrentGoal.
+func (w *Worker) resetGoal(ctx context.Context, store Store, lang string, message IncomingMessage, goal Goal, session PlanningSession, intent IntentResult) (string, error) {
+	session.PendingAction = ""
+	session.LastIntent = IntentResetSession
+	if err := store.UpdatePlanningSession(ctx, session); err != nil {
+		return "", fmt.Errorf("update planning session: %w", err)
+	}
+	if err := store.ArchiveGoal(ctx, goal.ID); err != nil {
+		return "", fmt.Errorf("archive goal: %w", err)
+	}
+	reply := tr(lang, MsgResetDone)
+	if err := saveTurnPair(ctx, store, session.ID, message, intent, reply); err != nil {
+		return "", err
+	}
+
+	w.logger.Info("goal_archived",
+		slog.String("goal_id", goal.ID),
+		slog.String("session_id", session.ID),
+		slog.String("chat_id_masked", MaskChatID(message.ChatID)),
+	)
+	return reply, nil
+}
+
+func undoLastTurn(ctx context.Context, store Store, rules *Rules, lang string, session PlanningSession) (string, PlanningSession, error) {
+	turn, found, err := store.UndoLastClarifyTurn(ctx, session.ID)
+	if err != nil {
+		return "", session, fmt.Errorf("undo last clarify turn: %w", err)
+	}
+	if !found {
+		return tr(lang, MsgUndoNothing), session, nil
+	}
+
+	turns, err := store.ListUserTurns(ctx, session.ID)
+	if err != nil {
+		return "", session, fmt.Errorf("list user turns: %w", err)
+	}
+	session.SlotCompletion = RecomputeSlotCompletion(rules, turns, session.SkippedSlots)
+
+	reply, session := replyForRecomputedSlots(lang, session, tr(lang, MsgUndoDone, previewText(turn.Content, undoPreviewRunes)))
+	return reply, session, nil
+}
+
+func skipSlot(lang string, session PlanningSession, slot string) (string, PlanningSession) {
+	options := skippableSlotOptions(lang)
+	if slot == "" {
+		return tr(lang, MsgSkipUsage, options), session
+	}
+	if !IsSkippableSlot(slot) {
+		return tr(lang, MsgSkipNotAllowed, SlotLabel(lang, slot), options), session
+	}
+	if containsString(session.SkippedSlots, slot) {
+		return tr(lang, MsgSkipDone, SlotLabel(lang, slot)), session
+	}
+	if session.SlotCompletion[slot] {
+		return tr(lang, MsgSkipAlreadyDone, SlotLabel(lang, slot)), session
+	}
+
+	session.SkippedSlots = append(append([]string(nil), session.SkippedSlots...), slot)
+	session.SlotCompletion[slot] = true
+	return replyForRecomputedSlots(lang, session, tr(lang, MsgSkipDone, SlotLabel(lang, slot)))
+}
+
+// replyForRecomputedSlots moves the session to the state implied by its slot
+// completion after an edit, undo or skip, and builds the follow-up reply.
+func replyForRecomputedSlots(lang string, session PlanningSession, notice string) (string, PlanningSession) {
+	if IsRequiredSlotsComplete(session.SlotCompletion) {
+		if session.State == StateConfirmed {
+			return notice + "\n\n" + BuildProgressSummary(lang, session.SlotCompletion), session
+		}
+		session.State = StateReview
+		return notice + "\n\n" + tr(lang, MsgReviewReady) + "\n\n" + BuildProgressSummary(lang, session.SlotCompletion), session
+	}
+
+	session.State = StateClarifying
+	questions := BuildFollowUpQuestions(lang, MissingRequiredSlots(session.SlotCompletion), maxFollowUpQuestionsPerTurn)
+	return notice + "\n" + FormatFollowUpQuestions(lang, questions), session
+}
+
+func FormatSessionStatus(lang string, session PlanningSession) string {
+	normalized := NormalizeSlotCompletion(session.SlotCompletion)
+
+	filled := make([]string, 0, len(requiredSlotOrder))
+	skipped := make([]string, 0, len(session.SkippedSlots))
+	for _, slot := range requiredSlotOrder {
+		switch {
+		case containsString(session.SkippedSlots, slot):
+			skipped = append(skipped, SlotLabel(lang, slot))
+		case normalized[slot]:
+			filled = append(filled, SlotLabel(lang, slot))
+		}
+	}
+	missing := make([]string, 0, len(requiredSlotOrder))
+	for _, slot := range MissingRequiredSlots(normalized) {
+		missing = append(missing, SlotLabel(lang, slot))
+	}
+
+	return tr(lang, MsgSessionStatus,
+		session.State.Label(lang),
+		ParsePlanningState(string(session.State)),
+		joinOrNone(lang, filled),
+		joinOrNone(lang, missing),
+		joinOrNone(lang, skipped),
+		trn(lang, MsgTurnCount, session.TurnCount, i18n.FormatNumber(lang, int64(session.TurnCount))),
+	)
+}
+
+func saveTurnPair(ctx context.Context, store Store, sessionID string, message IncomingMessage, intent IntentResult, reply string) error {
+	if err := store.SaveConversationTurn(ctx, ConversationTurn{
+		SessionID:         sessionID,
+		Role:              ConversationRoleUser,
+		Content:           message.Text,
+		Intent:            intent.Intent,
+		IntentConfidence:  &intent.Confidence,
+		IntentRanking:     intent.Ranking,
+		TelegramMessageID: message.MessageID,
+	}); err != nil {
+		return fmt.Errorf("save user conversation turn: %w", err)
+	}
+	if err := store.SaveConversationTurn(ctx, ConversationTurn{
+		SessionID: sessionID,
+		Role:      ConversationRoleAssistant,
+		Content:   reply,
+		Intent:    intent.Intent,
+	}); err != nil {
+		return fmt.Errorf("save assistant conversation turn: %w", err)
+	}
+	return nil
+}
+
+func skippableSlotOptions(lang string) string {
+	options := make([]string, 0, len(skippableSlots))
+	for _, slot := range skippableSlots {
+		options = append(options, tr(lang, MsgSlotOption, SlotLabel(lang, slot), slot))
+	}
+	return i18n.JoinList(lang, options)
+}
+
+func previewText(text string, maxRunes int) string {
+	runes := []rune(strings.TrimSpace(text))
+	if len(runes) <= maxRunes {
+		return string(runes)
+	}
+	return string(runes[:maxRunes]) + "…"
+}
+
+func joinOrNone(lang string, items []string) string {
+	if len(items) == 0 {
+		return tr(lang, MsgNone)
+	}
+	return i18n.JoinList(lang, items)
+}
+
+func containsString(items []string, target string) bool {
+	for _, item := range items {
+		if item == target {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,126 @@
+package telegram
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestIntentRouterRoutesSessionControl(t *testing.T) {
+	router := NewIntentRouter(nil)
+
+	cases := []struct {
+		text   string
+		intent string
+		slot   string
+	}{
+		{text: "/status", intent: IntentShowStatus},
+		{text: "进度？", intent: IntentShowStatus},
+		{text: "/reset@aiden_bot", intent: IntentResetSession},
+		{text: "重新开始", intent: IntentResetSession},
+		{text: "撤销", intent: IntentUndoTurn},
+		{text: "/skip risk_flags", intent: IntentSkipSlot, slot: SlotRiskFlags},
+		{text: "跳过约束条件", intent: IntentSkipSlot, slot: SlotConstraints},
+		{text: "跳过", intent: IntentSkipSlot},
+		{text: "跳过风险", intent: IntentSkipSlot, slot: SlotRiskFlags},
+		{text: "跳过早餐也能学习", intent: IntentClarifyGoal},
+		{text: "/skip", intent: IntentSkipSlot},
+	}
+	for _, tc := range cases {
+		got := router.Route(tc.text, StateClarifying)
+		if got.Intent != tc.intent || got.Slot != tc.slot {
+			t.Fatalf("Route(%q)=%+v, want intent %q slot %q", tc.text, got, tc.intent, tc.slot)
+		}
+	}
+
+	if _, ok := router.RouteControl("我想重新开始学钢琴"); ok {
+		t.Fatalf("a goal sentence should not be routed as a control command")
+	}
+}
+
+func TestWorkerStatusUndoAndSkip(t *testing.T) {
+	store := NewMemoryStore()
+	chat := Chat{ID: 80001}
+	client := &scriptedClient{
+		updates: [][]Update{
+			{{UpdateID: 1, Message: &Message{MessageID: 1, Chat: chat, Text: "我想三个月内学会游泳"}}},
+			{{UpdateID: 2, Message: &Message{MessageID: 2, Chat: chat, Text: "我现在零基础，每周能练三次"}}},
+			{{UpdateID: 3, Message: &Message{MessageID: 3, Chat: chat, Text: "/status"}}},
+			{{UpdateID: 4, Message: &Message{MessageID: 4, Chat: chat, Text: "/undo"}}},
+			{{UpdateID: 5, Message: &Message{MessageID: 5, Chat: chat, Text: "/skip main_goal"}}},
+			{{UpdateID: 6, Message: &Message{MessageID: 6, Chat: chat, Text: "跳过约束条件"}}},
+			{{UpdateID: 7, Message: &Message{MessageID: 7, Chat: chat, Text: "/status"}}},
+		},
+	}
+
+	if err := runWorkerUntilSendCount(t, client, store, 7); err != nil {
+		t.Fatalf("worker run failed: %v", err)
+	}
+	sent := client.SentMessages()
+
+	status := sent[2].Text
+	for _, want := range []string{"澄清中", "当前水平", "时间预算", "对话轮次：2"} {
+		if !strings.Contains(status, want) {
+			t.Fatalf("status=%q, want it to contain %q", status, want)
+		}
+	}
+	if !strings.HasPrefix(sent[3].Text, "已撤销上一条补充「我现在零基础，每周能练三次」") {
+		t.Fatalf("undo reply=%q", sent[3].Text)
+	}
+	if !strings.Contains(sent[4].Text, "是必填信息，不能跳过") {
+		t.Fatalf("skip required reply=%q", sent[4].Text)
+	}
+	if !strings.HasPrefix(sent[5].Text, "好的，「约束条件」这一项先留空。") {
+		t.Fatalf("skip reply=%q", sent[5].Text)
+	}
+	if !strings.Contains(sent[6].Text, "已跳过：约束条件") {
+		t.Fatalf("final status=%q, want skipped constraints", sent[6].Text)
+	}
+
+	user, _ := store.UserByChatID(chat.ID)
+	goal, _, _ := store.GetCurrentGoalByUserID(context.Background(), user.ID)
+	session, _ := store.SessionByGoalID(goal.ID)
+	if session.SlotCompletion[SlotCurrentLevel] || session.SlotCompletion[SlotTimeBudget] {
+		t.Fatalf("undo should clear slots of the undone turn: %+v", session.SlotCompletion)
+	}
+	if !session.SlotCompletion[SlotMainGoal] || !session.SlotCompletion[SlotConstraints] {
+		t.Fatalf("slot completion=%+v, want main goal and skipped constraints", session.SlotCompletion)
+	}
+	if session.TurnCount != 2 {
+		t.Fatalf("turn_count=%d, control commands should not count as turns", session.TurnCount)
+	}
+}
+
+func TestWorkerResetRequiresConfirmation(t *testing.T) {
+	store := NewMemoryStore()
+	chat := Chat{ID: 80002}
+	client := &scriptedClient{
+		updates: [][]Update{
+			{{UpdateID: 1, Message: &Message{MessageID: 1, Chat: chat, Text: "我想三个月内学会游泳"}}},
+			{{UpdateID: 2, Message: &Message{MessageID: 2, Chat: chat, Text: "/reset"}}},
+			{{UpdateID: 3, Message: &Message{MessageID: 3, Chat: chat, Text: "我现在零基础"}}},
+			{{UpdateID: 4, Message: &Message{MessageID: 4, Chat: chat, Text: "重新开始"}}},
+			{{UpdateID: 5, Message: &Message{MessageID: 5, Chat: chat, Text: "确认"}}},
+		},
+	}
+
+	if err := runWorkerUntilSendCount(t, client, store, 5); err != nil {
+		t.Fatalf("worker run failed: %v", err)
+	}
+	sent := client.SentMessages()
+
+	if sent[1].Text != zh(MsgResetConfirm) || sent[3].Text != zh(MsgResetConfirm) {
+		t.Fatalf("reset replies=%q / %q, want confirmation prompt", sent[1].Text, sent[3].Text)
+	}
+	if !strings.HasPrefix(sent[2].Text, zh(MsgResetCancelled)) {
+		t.Fatalf("reply=%q, want cancelled notice", sent[2].Text)
+	}
+	if sent[4].Text != zh(MsgResetDone) {
+		t.Fatalf("reply=%q, want %q", sent[4].Text, zh(MsgResetDone))
+	}
+
+	user, _ := store.UserByChatID(chat.ID)
+	if _, found, _ := store.GetCurrentGoalByUserID(context.Background(), user.ID); found {
+		t.Fatalf("expected the draft goal to be archived")
+	}
+}
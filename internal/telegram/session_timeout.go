@@ -0,0 +1,140 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/congregalis/aiden/internal/i18n"
+)
+
+const (
+	sessionRecapTurns     = 4
+	sessionRecapTurnRunes = 40
+	minUserSessionTimeout = 10 * time.Minute
+	maxUserSessionTimeout = 30 * 24 * time.Hour
+)
+
+type SessionConfig struct {
+	// Timeout is how long a session may stay idle before the next message
+	// resumes it with a recap. Users can override it with /timeout.
+	Timeout time.Duration
+}
+
+func (w *Worker) sessionTimeoutFor(user User) time.Duration {
+	if user.SessionTimeout > 0 {
+		return user.SessionTimeout
+	}
+	return w.sessionTimeout
+}
+
+func isSessionExpired(lastUpdatedAt time.Time, timeout time.Duration, now time.Time) bool {
+	if lastUpdatedAt.IsZero() || timeout <= 0 {
+		return false
+	}
+	return now.Sub(lastUpdatedAt) >= timeout
+}
+
+// BuildSessionRecap reminds a returning user where the clarification stopped,
+// using the slot completion and the last few turns of the session.
+func BuildSessionRecap(lang string, session PlanningSession, recentTurns []ConversationTurn) string {
+	normalized := NormalizeSlotCompletion(session.SlotCompletion)
+
+	filled := make([]string, 0, len(requiredSlotOrder))
+	for _, slot := range requiredSlotOrder {
+		if normalized[slot] {
+			filled = append(filled, SlotLabel(lang, slot))
+		}
+	}
+	missing := make([]string, 0, len(requiredSlotOrder))
+	for _, slot := range MissingRequiredSlots(normalized) {
+		missing = append(missing, SlotLabel(lang, slot))
+	}
+
+	var builder strings.Builder
+	builder.WriteString(tr(lang, MsgSessionResumed))
+	builder.WriteString("\n" + tr(lang, MsgRecapSlots, joinOrNone(lang, filled), joinOrNone(lang, missing)))
+	if len(recentTurns) > 0 {
+		builder.WriteString("\n" + tr(lang, MsgRecapTurns))
+		for _, turn := range recentTurns {
+			speaker := tr(lang, MsgSpeakerUser)
+			if turn.Role != ConversationRoleUser {
+				speaker = tr(lang, MsgSpeakerBot)
+			}
+			builder.WriteString("\n" + tr(lang, MsgRecapTurnLine, speaker, previewText(firstLine(turn.Content), sessionRecapTurnRunes)))
+		}
+	}
+	return builder.String()
+}
+
+// handleTimeoutCommand shows or changes the user's session timeout:
+// /timeout, /timeout 12h, /timeout 90m, /timeout 8 (hours) or /timeout default.
+func (w *Worker) handleTimeoutCommand(ctx context.Context, store Store, user User, command Command) (string, error) {
+	lang := user.Language
+	if len(command.Args) == 0 {
+		if user.SessionTimeout > 0 {
+			return tr(lang, MsgTimeoutCurrent, formatTimeout(lang, user.SessionTimeout)), nil
+		}
+		return tr(lang, MsgTimeoutDefault, formatTimeout(lang, w.sessionTimeout)), nil
+	}
+
+	timeout, ok := parseUserTimeout(command.Args[0])
+	if !ok {
+		return tr(lang, MsgTimeoutUsage), nil
+	}
+	if err := store.SetUserSessionTimeout(ctx, user.ID, timeout); err != nil {
+		return "", fmt.Errorf("set user session timeout: %w", err)
+	}
+
+	w.logger.Info("user_session_timeout_updated",
+		slog.String("user_id", user.ID),
+		slog.Duration("session_timeout", timeout),
+	)
+	if timeout == 0 {
+		return tr(lang, MsgTimeoutDefault, formatTimeout(lang, w.sessionTimeout)), nil
+	}
+	return tr(lang, MsgTimeoutUpdated, formatTimeout(lang, timeout)), nil
+}
+
+// parseUserTimeout returns 0 for "default". Bare numbers are hours.
+func parseUserTimeout(raw string) (time.Duration, bool) {
+	value := strings.ToLower(strings.TrimSpace(raw))
+	if value == "default" {
+		return 0, true
+	}
+
+	var timeout time.Duration
+	if hours, err := strconv.Atoi(value); err == nil {
+		timeout = time.Duration(hours) * time.Hour
+	} else {
+		parsed, err := time.ParseDuration(value)
+		if err != nil {
+			return 0, false
+		}
+		timeout = parsed
+	}
+
+	if timeout < minUserSessionTimeout || timeout > maxUserSessionTimeout {
+		return 0, false
+	}
+	return timeout.Truncate(time.Minute), true
+}
+
+func formatTimeout(lang string, timeout time.Duration) string {
+	if timeout%time.Hour == 0 {
+		hours := int(timeout / time.Hour)
+		return trn(lang, MsgHours, hours, i18n.FormatNumber(lang, int64(hours)))
+	}
+	minutes := int(timeout / time.Minute)
+	return trn(lang, MsgMinutes, minutes, i18n.FormatNumber(lang, int64(minutes)))
+}
+
+func firstLine(text string) string {
+	if idx := strings.IndexByte(text, '\n'); idx >= 0 {
+		return text[:idx]
+	}
+	return text
+}
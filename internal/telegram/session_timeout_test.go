@@ -0,0 +1,241 @@
+package telegram
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseUserTimeout(t *testing.T) {
+	tests := []struct {
+		raw  string
+		want time.Duration
+		ok   bool
+	}{
+		{raw: "12h", want: 12 * time.Hour, ok: true},
+		{raw: "90m", want: 90 * time.Minute, ok: true},
+		{raw: "8", want: 8 * time.Hour, ok: true},
+		{raw: "Default", want: 0, ok: true},
+		{raw: "5m", ok: false},
+		{raw: "31d", ok: false},
+		{raw: "721", ok: false},
+		{raw: "soon", ok: false},
+	}
+
+	for _, tt := range tests {
+		got, ok := parseUserTimeout(tt.raw)
+		if ok != tt.ok || got != tt.want {
+			t.Fatalf("parseUserTimeout(%q)=(%s, %v), want (%s, %v)", tt.raw, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestWorkerTimeoutCommand(t *testing.T) {
+	store := NewMemoryStore()
+	chat := Chat{ID: 91001}
+	texts := []string{"/timeout", "/timeout 90m", "/timeout", "/timeout 5m", "/timeout default"}
+	batches := make([][]Update, 0, len(texts))
+	for i, text := range texts {
+		batches = append(batches, []Update{{UpdateID: int64(i + 1), Message: &Message{MessageID: int64(i + 1), Chat: chat, Text: text}}})
+	}
+	client := &scriptedClient{updates: batches}
+
+	if err := runWorkerUntilSendCount(t, client, store, len(texts)); err != nil {
+		t.Fatalf("worker run failed: %v", err)
+	}
+
+	sent := client.SentMessages()
+	want := []string{
+		"你正在使用默认会话超时：24 小时。发送 /timeout 12h 可以自定义。",
+		"已将会话超时设置为 90 分钟。",
+		"你的会话超时为 90 分钟，超过这个时间再回来时我会先帮你回顾进度。发送 /timeout default 恢复默认值。",
+		zh(MsgTimeoutUsage),
+		"你正在使用默认会话超时：24 小时。发送 /timeout 12h 可以自定义。",
+	}
+	for i := range want {
+		if sent[i].Text != want[i] {
+			t.Fatalf("reply %d=%q, want %q", i, sent[i].Text, want[i])
+		}
+	}
+
+	user, _ := store.UserByChatID(chat.ID)
+	if user.SessionTimeout != 0 {
+		t.Fatalf("session timeout=%s, want default", user.SessionTimeout)
+	}
+}
+
+func TestWorkerUsesPerUserTimeoutForRecap(t *testing.T) {
+	store := NewMemoryStore()
+	ctx := context.Background()
+	user, _, err := store.FindOrCreateUserByChatID(ctx, 91002)
+	if err != nil {
+		t.Fatalf("seed user failed: %v", err)
+	}
+	if err := store.SetUserSessionTimeout(ctx, user.ID, time.Hour); err != nil {
+		t.Fatalf("seed timeout failed: %v", err)
+	}
+	goal, err := store.CreateGoalDraft(ctx, user.ID)
+	if err != nil {
+		t.Fatalf("seed goal failed: %v", err)
+	}
+	session, _, err := store.GetOrCreatePlanningSession(ctx, goal.ID)
+	if err != nil {
+		t.Fatalf("seed session failed: %v", err)
+	}
+	if err := saveTurnPair(ctx, store, session.ID, IncomingMessage{Text: "我想三个月内学会游泳"}, IntentResult{Intent: IntentClarifyGoal}, "好的，成功标准是什么？"); err != nil {
+		t.Fatalf("seed turns failed: %v", err)
+	}
+	store.mu.Lock()
+	session.State = StateClarifying
+	session.SlotCompletion[SlotMainGoal] = true
+	session.TurnCount = 1
+	session.UpdatedAt = time.Now().Add(-2 * time.Hour)
+	store.sessionsByGoalID[goal.ID] = session
+	store.mu.Unlock()
+
+	client := &scriptedClient{
+		updates: [][]Update{{
+			{UpdateID: 1, Message: &Message{MessageID: 1, Chat: Chat{ID: 91002}, Text: "嗯"}},
+		}},
+	}
+
+	if err := runWorkerUntilSendCount(t, client, store, 1); err != nil {
+		t.Fatalf("worker run failed: %v", err)
+	}
+
+	reply := client.SentMessages()[0].Text
+	for _, want := range []string{zh(MsgSessionResumed), "已补齐：主目标", "- 你：我想三个月内学会游泳", "- 我：好的，成功标准是什么？"} {
+		if !strings.Contains(reply, want) {
+			t.Fatalf("reply=%q, want it to contain %q", reply, want)
+		}
+	}
+
+	updated, _ := store.SessionByGoalID(goal.ID)
+	if updated.TurnCount != 2 {
+		t.Fatalf("turn count=%d, want 2", updated.TurnCount)
+	}
+}
+
+func TestBuildSessionRecapUsesTheLanguagesSeparator(t *testing.T) {
+	session := PlanningSession{SlotCompletion: map[string]bool{SlotMainGoal: true}}
+	turns := []ConversationTurn{
+		{Role: ConversationRoleUser, Content: "learn to swim"},
+		{Role: ConversationRoleAssistant, Content: "By when?"},
+	}
+
+	recap := BuildSessionRecap("en", session, turns)
+	for _, want := range []string{"\n- You: learn to swim", "\n- Me: By when?"} {
+		if !strings.Contains(recap, want) {
+			t.Fatalf("recap=%q, want %q", recap, want)
+		}
+	}
+	if strings.Contains(recap, "：") {
+		t.Fatalf("English recap has a full-width colon: %q", recap)
+	}
+}
+
+func TestIdleGoalsTaskArchivesIdleDrafts(t *testing.T) {
+	store := NewMemoryStore()
+	ctx := context.Background()
+	user, _, _ := store.FindOrCreateUserByChatID(ctx, 91003)
+
+	idle, _ := store.CreateGoalDraft(ctx, user.ID)
+	active, _ := store.CreateGoalDraft(ctx, user.ID)
+	if err := store.ActivateGoal(ctx, active.ID); err != nil {
+		t.Fatalf("activate goal failed: %v", err)
+	}
+	fresh, _ := store.CreateGoalDraft(ctx, user.ID)
+	for _, goal := range []Goal{idle, active, fresh} {
+		if _, _, err := store.GetOrCreatePlanningSession(ctx, goal.ID); err != nil {
+			t.Fatalf("seed session failed: %v", err)
+		}
+	}
+	if err := store.SetCurrentGoal(ctx, user.ID, idle.ID); err != nil {
+		t.Fatalf("set current goal failed: %v", err)
+	}
+	store.mu.Lock()
+	for _, goalID := range []string{idle.ID, active.ID} {
+		session := store.sessionsByGoalID[goalID]
+		session.UpdatedAt = time.Now().Add(-48 * time.Hour)
+		store.sessionsByGoalID[goalID] = session
+	}
+	store.mu.Unlock()
+
+	tasks := MaintenanceTasks(store, MaintenanceConfig{ArchiveAfter: 24 * time.Hour})
+	if archived := runMaintenanceTask(t, tasks, "idle_goals", time.Now(), 1); archived != 1 {
+		t.Fatalf("archived=%d, want 1", archived)
+	}
+
+	goals, _ := store.ListGoalsByUserID(ctx, user.ID)
+	wantStatus := []string{GoalStatusArchived, GoalStatusActive, GoalStatusDraft}
+	for i, goal := range goals {
+		if goal.Status != wantStatus[i] {
+			t.Fatalf("goal %d status=%q, want %q", i, goal.Status, wantStatus[i])
+		}
+	}
+	if _, found, _ := store.GetCurrentGoalByUserID(ctx, user.ID); found {
+		t.Fatalf("expected archived goal to be cleared as current goal")
+	}
+
+}
+
+func TestIdleGoalsTaskKeepsConfirmedGoalAfterSwitching(t *testing.T) {
+	store := NewMemoryStore()
+	ctx := context.Background()
+	user, _, _ := store.FindOrCreateUserByChatID(ctx, 91006)
+
+	var goals []Goal
+	for range 2 {
+		goal, _ := store.CreateGoalDraft(ctx, user.ID)
+		session, _, _ := store.GetOrCreatePlanningSession(ctx, goal.ID)
+		session.State = StateConfirmed
+		if err := store.UpdatePlanningSession(ctx, session); err != nil {
+			t.Fatalf("confirm session failed: %v", err)
+		}
+		if err := store.ActivateGoal(ctx, goal.ID); err != nil {
+			t.Fatalf("activate goal failed: %v", err)
+		}
+		goals = append(goals, goal)
+	}
+	if err := store.SetCurrentGoal(ctx, user.ID, goals[1].ID); err != nil {
+		t.Fatalf("set current goal failed: %v", err)
+	}
+
+	tasks := MaintenanceTasks(store, MaintenanceConfig{ArchiveAfter: 24 * time.Hour})
+	if archived := runMaintenanceTask(t, tasks, "idle_goals", time.Now().Add(48*time.Hour), 10); archived != 0 {
+		t.Fatalf("archived=%d, want the demoted confirmed goal kept", archived)
+	}
+	listed, _ := store.ListGoalsByUserID(ctx, user.ID)
+	if listed[0].Status != GoalStatusDraft || listed[1].Status != GoalStatusActive {
+		t.Fatalf("statuses=%q,%q, want draft,active", listed[0].Status, listed[1].Status)
+	}
+}
+
+func TestIdleGoalsTaskWaitsForTheUsersOwnTimeout(t *testing.T) {
+	store := NewMemoryStore()
+	ctx := context.Background()
+	now := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	store.SetClock(func() time.Time { return now })
+	user, _, _ := store.FindOrCreateUserByChatID(ctx, 91007)
+	if err := store.SetUserSessionTimeout(ctx, user.ID, 30*24*time.Hour); err != nil {
+		t.Fatalf("set timeout failed: %v", err)
+	}
+	goal, _ := store.CreateGoalDraft(ctx, user.ID)
+	if _, _, err := store.GetOrCreatePlanningSession(ctx, goal.ID); err != nil {
+		t.Fatalf("seed session failed: %v", err)
+	}
+
+	tasks := MaintenanceTasks(store, MaintenanceConfig{SessionTimeout: 24 * time.Hour, ArchiveAfter: 48 * time.Hour})
+	now = now.Add(3 * 24 * time.Hour)
+	if archived := runMaintenanceTask(t, tasks, "idle_goals", now, 10); archived != 0 {
+		t.Fatalf("archived=%d inside the user's 30-day timeout, want 0", archived)
+	}
+	now = now.Add(30 * 24 * time.Hour)
+	if archived, err := store.ArchiveIdleDraftGoals(ctx, now, 0, 0); err != nil || archived != 0 {
+		t.Fatalf("ArchiveIdleDraftGoals(limit=0)=(%d, %v), want nothing archived like SQL LIMIT 0", archived, err)
+	}
+	if archived := runMaintenanceTask(t, tasks, "idle_goals", now, 10); archived != 1 {
+		t.Fatalf("archived=%d once the user's timeout passed, want 1", archived)
+	}
+}
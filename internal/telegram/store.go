@@ -16,20 +16,48 @@ const (
 )
 
 type Store interface {
+	OutboxStore
+
+	WithinTx(context.Context, func(Store) error) error
 	LoadLastUpdateID(context.Context) (int64, error)
 	SaveLastUpdateID(context.Context, int64) error
 	MarkMessageDedup(context.Context, int64, int64) (bool, error)
+	IsUpdateProcessed(context.Context, int64) (bool, error)
 	FindOrCreateUserByChatID(context.Context, int64) (User, bool, error)
-	GetActiveGoalByUserID(context.Context, string) (Goal, bool, error)
+	MarkChatReachable(context.Context, int64) error
+	GetCurrentGoalByUserID(context.Context, string) (Goal, bool, error)
 	CreateGoalDraft(context.Context, string) (Goal, error)
 	GetOrCreatePlanningSession(context.Context, string) (PlanningSession, bool, error)
 	IncrementPlanningSessionTurn(context.Context, string) (int, error)
 	UpdatePlanningSession(context.Context, PlanningSession) error
 	SaveConversationTurn(context.Context, ConversationTurn) error
+	GetLatestUserTurn(context.Context, string) (ConversationTurn, bool, error)
+	ListUserTurns(context.Context, string) ([]ConversationTurn, error)
+	ReviseConversationTurn(context.Context, ConversationTurn) (int, error)
+	UndoLastClarifyTurn(context.Context, string) (ConversationTurn, bool, error)
+	ListGoalsByUserID(context.Context, string) ([]Goal, error)
+	SetCurrentGoal(context.Context, string, string) error
+	UpdateGoalTitle(context.Context, string, string) error
+	ActivateGoal(context.Context, string) error
+	ArchiveGoal(context.Context, string) error
+	SaveGoalAttachment(context.Context, GoalAttachment) error
+	SetUserSessionTimeout(context.Context, string, time.Duration) error
+	SetUserLanguage(context.Context, string, string) error
+	ListRecentTurns(context.Context, string, int) ([]ConversationTurn, error)
+	SetUserDeletionRequest(context.Context, string, *time.Time, int) error
+	DeleteUserData(context.Context, string, AuditEntry) (DeletionSummary, error)
+	RecordActionLog(context.Context, ActionLog) error
+}
+
+type dbtx interface {
+	ExecContext(context.Context, string, ...any) (sql.Result, error)
+	QueryContext(context.Context, string, ...any) (*sql.Rows, error)
+	QueryRowContext(context.Context, string, ...any) *sql.Row
 }
 
 type SQLStore struct {
-	db *sql.DB
+	db   dbtx
+	conn *sql.DB
 }
 
 type User struct {
@@ -37,13 +65,34 @@ type User struct {
 	TelegramChatID int64
 	Language       string
 	Timezone       string
+	IsReachable    bool
+	// SessionTimeout overrides the configured session timeout when > 0.
+	SessionTimeout time.Duration
+	// DeletionRequestedAt is set while a /deleteme request waits for
+	// confirmation; DeletionConfirmations counts the confirmations so far.
+	DeletionRequestedAt   *time.Time
+	DeletionConfirmations int
 }
 
+const (
+	GoalStatusDraft    = "draft"
+	GoalStatusActive   = "active"
+	GoalStatusArchived = "archived"
+)
+
+var ErrGoalNotFound = errors.New("goal not found")
+
+// ErrChatIDTaken means a chat migration target already belongs to a user,
+// usually because the new supergroup wrote to the bot before the migration
+// was seen.
+var ErrChatIDTaken = errors.New("migrated chat id already belongs to another user")
+
 type Goal struct {
-	ID     string
-	UserID string
-	Title  string
-	Status string
+	ID        string
+	UserID    string
+	Title     string
+	Status    string
+	CreatedAt time.Time
 }
 
 type PlanningSession struct {
@@ -53,19 +102,59 @@ type PlanningSession struct {
 	SlotCompletion map[string]bool
 	TurnCount      int
 	LastIntent     string
+	SkippedSlots   []string
+	PendingAction  string
 	UpdatedAt      time.Time
 }
 
 type ConversationTurn struct {
-	SessionID        string
-	Role             string
-	Content          string
-	Intent           string
-	IntentConfidence *float64
+	ID                string
+	SessionID         string
+	Role              string
+	Content           string
+	Intent            string
+	IntentConfidence  *float64
+	IntentRanking     []IntentScore
+	TelegramMessageID int64
+	Revision          int
+	// Redacted turns keep only a summary in Content; RedactedSlots are the
+	// slots their original text filled.
+	Redacted      bool
+	RedactedSlots []string
 }
 
 func NewSQLStore(db *sql.DB) *SQLStore {
-	return &SQLStore{db: db}
+	return &SQLStore{db: db, conn: db}
+}
+
+// WithinTx runs fn against a store bound to a single transaction. Calls made
+// on a store that is already inside a transaction join the outer one.
+func (s *SQLStore) WithinTx(ctx context.Context, fn func(Store) error) error {
+	return s.withinSQLTx(ctx, func(tx *SQLStore) error { return fn(tx) })
+}
+
+func (s *SQLStore) withinSQLTx(ctx context.Context, fn func(*SQLStore) error) error {
+	if s.conn == nil {
+		return fn(s)
+	}
+
+	tx, err := s.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+
+	if err := fn(&SQLStore{db: tx}); err != nil {
+		if rollbackErr := tx.Rollback(); rollbackErr != nil && !errors.Is(rollbackErr, sql.ErrTxDone) {
+			return errors.Join(err, fmt.Errorf("rollback transaction: %w", rollbackErr))
+		}
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit transaction: %w", err)
+	}
+
+	return nil
 }
 
 func (s *SQLStore) LoadLastUpdateID(ctx context.Context) (int64, error) {
@@ -122,6 +211,20 @@ func (s *SQLStore) MarkMessageDedup(ctx context.Context, updateID, chatID int64)
 	return rowsAffected == 1, nil
 }
 
+// IsUpdateProcessed is a read-only dedup check for skipping expensive work
+// early; MarkMessageDedup stays the authoritative guard.
+func (s *SQLStore) IsUpdateProcessed(ctx context.Context, updateID int64) (bool, error) {
+	var exists bool
+	if err := s.db.QueryRowContext(
+		ctx,
+		`SELECT EXISTS (SELECT 1 FROM message_dedup WHERE update_id = $1)`,
+		updateID,
+	).Scan(&exists); err != nil {
+		return false, fmt.Errorf("check message_dedup: %w", err)
+	}
+	return exists, nil
+}
+
 func (s *SQLStore) FindOrCreateUserByChatID(ctx context.Context, chatID int64) (User, bool, error) {
 	createdUser, err := s.insertUserIfNotExists(ctx, chatID)
 	if err == nil {
@@ -139,44 +242,199 @@ func (s *SQLStore) FindOrCreateUserByChatID(ctx context.Context, chatID int64) (
 	return existingUser, false, nil
 }
 
-func (s *SQLStore) GetActiveGoalByUserID(ctx context.Context, userID string) (Goal, bool, error) {
+func (s *SQLStore) SetUserLanguage(ctx context.Context, userID, language string) error {
+	result, err := s.db.ExecContext(
+		ctx,
+		`UPDATE users
+		 SET language = $2,
+		     updated_at = NOW()
+		 WHERE id = $1`,
+		userID,
+		language,
+	)
+	if err != nil {
+		return fmt.Errorf("update language for user id %s: %w", userID, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("read update language rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("user %s not found", userID)
+	}
+
+	return nil
+}
+
+// SetUserSessionTimeout stores a per-user session timeout; zero restores the
+// configured default.
+func (s *SQLStore) SetUserSessionTimeout(ctx context.Context, userID string, timeout time.Duration) error {
+	var minutes sql.NullInt64
+	if timeout > 0 {
+		minutes = sql.NullInt64{Int64: int64(timeout / time.Minute), Valid: true}
+	}
+
+	result, err := s.db.ExecContext(
+		ctx,
+		`UPDATE users
+		 SET session_timeout_minutes = $2,
+		     updated_at = NOW()
+		 WHERE id = $1`,
+		userID,
+		minutes,
+	)
+	if err != nil {
+		return fmt.Errorf("update session timeout for user id %s: %w", userID, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("read update session timeout rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("user %s not found", userID)
+	}
+
+	return nil
+}
+
+func (s *SQLStore) MarkChatReachable(ctx context.Context, chatID int64) error {
+	_, err := s.db.ExecContext(
+		ctx,
+		`UPDATE users
+		 SET is_reachable = TRUE,
+		     updated_at = NOW()
+		 WHERE telegram_chat_id = $1
+		   AND NOT is_reachable`,
+		chatID,
+	)
+	if err != nil {
+		return fmt.Errorf("mark chat %d reachable: %w", chatID, err)
+	}
+
+	return nil
+}
+
+// MarkChatUnreachable flags the user behind chatID and dead-letters whatever is
+// still queued for that chat, so nothing keeps hitting a 403.
+func (s *SQLStore) MarkChatUnreachable(ctx context.Context, chatID int64, reason string) error {
+	return s.withinSQLTx(ctx, func(tx *SQLStore) error {
+		if _, err := tx.db.ExecContext(
+			ctx,
+			`UPDATE users
+			 SET is_reachable = FALSE,
+			     updated_at = NOW()
+			 WHERE telegram_chat_id = $1`,
+			chatID,
+		); err != nil {
+			return fmt.Errorf("mark chat %d unreachable: %w", chatID, err)
+		}
+
+		if _, err := tx.db.ExecContext(
+			ctx,
+			`UPDATE outgoing_messages
+			 SET status = 'dead',
+			     last_error = $2,
+			     updated_at = NOW()
+			 WHERE chat_id = $1
+			   AND status = 'pending'`,
+			chatID,
+			reason,
+		); err != nil {
+			return fmt.Errorf("dead-letter pending messages for chat %d: %w", chatID, err)
+		}
+		return nil
+	})
+}
+
+// MigrateChatID moves a user and their pending outgoing messages to the chat
+// ID Telegram assigned after a group was upgraded to a supergroup. If another
+// user already has the new chat ID nothing is moved and ErrChatIDTaken is
+// returned.
+func (s *SQLStore) MigrateChatID(ctx context.Context, fromChatID, toChatID int64) error {
+	return s.withinSQLTx(ctx, func(tx *SQLStore) error {
+		var taken bool
+		if err := tx.db.QueryRowContext(
+			ctx,
+			`SELECT EXISTS (SELECT 1 FROM users WHERE telegram_chat_id = $1)`,
+			toChatID,
+		).Scan(&taken); err != nil {
+			return fmt.Errorf("check migrated chat id %d: %w", toChatID, err)
+		}
+		if taken {
+			return ErrChatIDTaken
+		}
+
+		if _, err := tx.db.ExecContext(
+			ctx,
+			`UPDATE users
+			 SET telegram_chat_id = $2,
+			     updated_at = NOW()
+			 WHERE telegram_chat_id = $1`,
+			fromChatID,
+			toChatID,
+		); err != nil {
+			return fmt.Errorf("migrate user chat id %d: %w", fromChatID, err)
+		}
+
+		if _, err := tx.db.ExecContext(
+			ctx,
+			`UPDATE outgoing_messages
+			 SET chat_id = $2,
+			     updated_at = NOW()
+			 WHERE chat_id = $1
+			   AND status = 'pending'`,
+			fromChatID,
+			toChatID,
+		); err != nil {
+			return fmt.Errorf("migrate pending messages for chat %d: %w", fromChatID, err)
+		}
+		return nil
+	})
+}
+
+// GetCurrentGoalByUserID returns the goal the user explicitly selected with
+// users.current_goal_id. Archived goals are never current.
+func (s *SQLStore) GetCurrentGoalByUserID(ctx context.Context, userID string) (Goal, bool, error) {
 	var goal Goal
 	err := s.db.QueryRowContext(
 		ctx,
-		`SELECT id, user_id, title, status
-		 FROM goals
-		 WHERE user_id = $1
-		   AND status IN ('active', 'draft')
-		 ORDER BY
-		   CASE status
-		     WHEN 'active' THEN 0
-		     WHEN 'draft' THEN 1
-		     ELSE 2
-		   END,
-		   updated_at DESC,
-		   created_at DESC
-		 LIMIT 1`,
+		`SELECT g.id, g.user_id, g.title, g.status, g.created_at
+		 FROM users u
+		 JOIN goals g ON g.id = u.current_goal_id
+		 WHERE u.id = $1
+		   AND g.status <> 'archived'`,
 		userID,
-	).Scan(&goal.ID, &goal.UserID, &goal.Title, &goal.Status)
+	).Scan(&goal.ID, &goal.UserID, &goal.Title, &goal.Status, &goal.CreatedAt)
 	if errors.Is(err, sql.ErrNoRows) {
 		return Goal{}, false, nil
 	}
 	if err != nil {
-		return Goal{}, false, fmt.Errorf("query active goal by user id %s: %w", userID, err)
+		return Goal{}, false, fmt.Errorf("query current goal by user id %s: %w", userID, err)
 	}
 
 	return goal, true, nil
 }
 
+// CreateGoalDraft inserts a draft goal and makes it the user's current goal.
 func (s *SQLStore) CreateGoalDraft(ctx context.Context, userID string) (Goal, error) {
 	var goal Goal
 	err := s.db.QueryRowContext(
 		ctx,
-		`INSERT INTO goals(user_id, title, status, created_at, updated_at)
-		 VALUES ($1, '', 'draft', NOW(), NOW())
-		 RETURNING id, user_id, title, status`,
+		`WITH created AS (
+		     INSERT INTO goals(user_id, title, status, created_at, updated_at)
+		     VALUES ($1, '', 'draft', NOW(), NOW())
+		     RETURNING id, user_id, title, status, created_at
+		 ), selected AS (
+		     UPDATE users
+		     SET current_goal_id = (SELECT id FROM created),
+		         updated_at = NOW()
+		     WHERE id = $1
+		 )
+		 SELECT id, user_id, title, status, created_at FROM created`,
 		userID,
-	).Scan(&goal.ID, &goal.UserID, &goal.Title, &goal.Status)
+	).Scan(&goal.ID, &goal.UserID, &goal.Title, &goal.Status, &goal.CreatedAt)
 	if err != nil {
 		return Goal{}, fmt.Errorf("insert goal draft for user id %s: %w", userID, err)
 	}
@@ -184,6 +442,187 @@ func (s *SQLStore) CreateGoalDraft(ctx context.Context, userID string) (Goal, er
 	return goal, nil
 }
 
+func (s *SQLStore) ListGoalsByUserID(ctx context.Context, userID string) ([]Goal, error) {
+	rows, err := s.db.QueryContext(
+		ctx,
+		`SELECT id, user_id, title, status, created_at
+		 FROM goals
+		 WHERE user_id = $1
+		 ORDER BY created_at, id`,
+		userID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("query goals by user id %s: %w", userID, err)
+	}
+	defer rows.Close()
+
+	goals := make([]Goal, 0)
+	for rows.Next() {
+		var goal Goal
+		if err := rows.Scan(&goal.ID, &goal.UserID, &goal.Title, &goal.Status, &goal.CreatedAt); err != nil {
+			return nil, fmt.Errorf("scan goal: %w", err)
+		}
+		goals = append(goals, goal)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate goals: %w", err)
+	}
+
+	return goals, nil
+}
+
+func (s *SQLStore) SetCurrentGoal(ctx context.Context, userID, goalID string) error {
+	return s.execGoalUpdate(
+		ctx,
+		fmt.Sprintf("set current goal %s", goalID),
+		`UPDATE users
+		 SET current_goal_id = $2,
+		     updated_at = NOW()
+		 WHERE id = $1
+		   AND EXISTS (
+		       SELECT 1 FROM goals
+		       WHERE id = $2 AND user_id = $1 AND status <> 'archived'
+		   )`,
+		userID,
+		goalID,
+	)
+}
+
+func (s *SQLStore) UpdateGoalTitle(ctx context.Context, goalID, title string) error {
+	return s.execGoalUpdate(
+		ctx,
+		fmt.Sprintf("update goal title %s", goalID),
+		`UPDATE goals
+		 SET title = $2,
+		     updated_at = NOW()
+		 WHERE id = $1`,
+		goalID,
+		title,
+	)
+}
+
+// ActivateGoal makes goalID the user's single active goal. Any previously
+// active goal goes back to draft first, so the partial unique index on active
+// goals is never violated.
+func (s *SQLStore) ActivateGoal(ctx context.Context, goalID string) error {
+	if _, err := s.db.ExecContext(
+		ctx,
+		`UPDATE goals
+		 SET status = 'draft',
+		     updated_at = NOW()
+		 WHERE user_id = (SELECT user_id FROM goals WHERE id = $1)
+		   AND status = 'active'
+		   AND id <> $1`,
+		goalID,
+	); err != nil {
+		return fmt.Errorf("demote active goals: %w", err)
+	}
+
+	return s.execGoalUpdate(
+		ctx,
+		fmt.Sprintf("activate goal %s", goalID),
+		`UPDATE goals
+		 SET status = 'active',
+		     updated_at = NOW()
+		 WHERE id = $1
+		   AND status <> 'archived'`,
+		goalID,
+	)
+}
+
+// ArchiveGoal archives a goal and clears it as the owner's current goal.
+func (s *SQLStore) ArchiveGoal(ctx context.Context, goalID string) error {
+	if err := s.execGoalUpdate(
+		ctx,
+		fmt.Sprintf("archive goal %s", goalID),
+		`UPDATE goals
+		 SET status = 'archived',
+		     updated_at = NOW()
+		 WHERE id = $1`,
+		goalID,
+	); err != nil {
+		return err
+	}
+
+	if _, err := s.db.ExecContext(
+		ctx,
+		`UPDATE users
+		 SET current_goal_id = NULL,
+		     updated_at = NOW()
+		 WHERE current_goal_id = $1`,
+		goalID,
+	); err != nil {
+		return fmt.Errorf("clear current goal %s: %w", goalID, err)
+	}
+
+	return nil
+}
+
+// ArchiveIdleDraftGoals archives draft goals whose planning session has not
+// been touched since before cutoff, at most limit per call. Active goals,
+// confirmed goals the user switched away from (demoted to draft), and sessions
+// still within their owner's /timeout or sessionTimeout are left alone.
+func (s *SQLStore) ArchiveIdleDraftGoals(ctx context.Context, cutoff time.Time, sessionTimeout time.Duration, limit int) (int64, error) {
+	var archived int64
+	err := s.db.QueryRowContext(
+		ctx,
+		`WITH idle AS (
+		     SELECT g.id
+		     FROM goals g
+		     JOIN planning_sessions ps ON ps.goal_id = g.id
+		     JOIN users u ON u.id = g.user_id
+		     WHERE g.status = 'draft'
+		       AND ps.state <> 'confirmed'
+		       AND ps.updated_at < $1
+		       AND ps.updated_at < NOW() - COALESCE(
+		           u.session_timeout_minutes * INTERVAL '1 minute',
+		           $3::float8 * INTERVAL '1 second'
+		       )
+		     ORDER BY ps.updated_at
+		     LIMIT $2
+		 ), archived AS (
+		     UPDATE goals g
+		     SET status = 'archived',
+		         updated_at = NOW()
+		     FROM idle
+		     WHERE g.id = idle.id
+		       AND g.status = 'draft'
+		     RETURNING g.id
+		 ), cleared AS (
+		     UPDATE users
+		     SET current_goal_id = NULL,
+		         updated_at = NOW()
+		     WHERE current_goal_id IN (SELECT id FROM archived)
+		 )
+		 SELECT COUNT(*) FROM archived`,
+		cutoff,
+		limit,
+		sessionTimeout.Seconds(),
+	).Scan(&archived)
+	if err != nil {
+		return 0, fmt.Errorf("archive idle draft goals: %w", err)
+	}
+
+	return archived, nil
+}
+
+func (s *SQLStore) execGoalUpdate(ctx context.Context, operation, query string, args ...any) error {
+	result, err := s.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("%s: %w", operation, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("read %s rows affected: %w", operation, err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("%s: %w", operation, ErrGoalNotFound)
+	}
+
+	return nil
+}
+
 func (s *SQLStore) GetOrCreatePlanningSession(ctx context.Context, goalID string) (PlanningSession, bool, error) {
 	created, err := s.insertPlanningSessionIfNotExists(ctx, goalID)
 	if err == nil {
@@ -227,6 +666,14 @@ func (s *SQLStore) UpdatePlanningSession(ctx context.Context, session PlanningSe
 	if err != nil {
 		return fmt.Errorf("marshal slot completion: %w", err)
 	}
+	skippedSlots := session.SkippedSlots
+	if skippedSlots == nil {
+		skippedSlots = []string{}
+	}
+	skippedSlotsJSON, err := json.Marshal(skippedSlots)
+	if err != nil {
+		return fmt.Errorf("marshal skipped slots: %w", err)
+	}
 
 	result, err := s.db.ExecContext(
 		ctx,
@@ -235,6 +682,8 @@ func (s *SQLStore) UpdatePlanningSession(ctx context.Context, session PlanningSe
 		     slot_completion = $3::jsonb,
 		     turn_count = $4,
 		     last_intent = $5,
+		     skipped_slots = $6::jsonb,
+		     pending_action = $7,
 		     updated_at = NOW()
 		 WHERE id = $1`,
 		session.ID,
@@ -242,6 +691,8 @@ func (s *SQLStore) UpdatePlanningSession(ctx context.Context, session PlanningSe
 		slotCompletionJSON,
 		session.TurnCount,
 		session.LastIntent,
+		skippedSlotsJSON,
+		session.PendingAction,
 	)
 	if err != nil {
 		return fmt.Errorf("update planning session %s: %w", session.ID, err)
@@ -259,7 +710,12 @@ func (s *SQLStore) UpdatePlanningSession(ctx context.Context, session PlanningSe
 }
 
 func (s *SQLStore) SaveConversationTurn(ctx context.Context, turn ConversationTurn) error {
-	_, err := s.db.ExecContext(
+	rankingJSON, err := marshalIntentRanking(turn.IntentRanking)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.db.ExecContext(
 		ctx,
 		`INSERT INTO conversation_turns(
 		    session_id,
@@ -267,14 +723,18 @@ func (s *SQLStore) SaveConversationTurn(ctx context.Context, turn ConversationTu
 		    content,
 		    intent,
 		    intent_confidence,
+		    intent_ranking,
+		    telegram_message_id,
 		    created_at
 		 )
-		 VALUES ($1, $2, $3, $4, $5, NOW())`,
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, NOW())`,
 		turn.SessionID,
 		turn.Role,
 		turn.Content,
 		turn.Intent,
 		turn.IntentConfidence,
+		rankingJSON,
+		turn.TelegramMessageID,
 	)
 	if err != nil {
 		return fmt.Errorf("insert conversation turn: %w", err)
@@ -283,6 +743,257 @@ func (s *SQLStore) SaveConversationTurn(ctx context.Context, turn ConversationTu
 	return nil
 }
 
+// ListRecentTurns returns the last limit turns of a session, oldest first.
+func (s *SQLStore) ListRecentTurns(ctx context.Context, sessionID string, limit int) ([]ConversationTurn, error) {
+	turns, err := s.queryConversationTurns(
+		ctx,
+		`SELECT `+conversationTurnColumns+`
+		 FROM (
+		     SELECT *
+		     FROM conversation_turns
+		     WHERE session_id = $1 AND undone_at IS NULL
+		     ORDER BY created_at DESC, id DESC
+		     LIMIT $2
+		 ) AS recent
+		 ORDER BY created_at, id`,
+		sessionID,
+		limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("query recent turns: %w", err)
+	}
+
+	return turns, nil
+}
+
+func (s *SQLStore) GetLatestUserTurn(ctx context.Context, sessionID string) (ConversationTurn, bool, error) {
+	turns, err := s.queryConversationTurns(
+		ctx,
+		`SELECT `+conversationTurnColumns+`
+		 FROM conversation_turns
+		 WHERE session_id = $1 AND role = 'user' AND undone_at IS NULL
+		 ORDER BY created_at DESC, id DESC
+		 LIMIT 1`,
+		sessionID,
+	)
+	if err != nil {
+		return ConversationTurn{}, false, fmt.Errorf("query latest user turn: %w", err)
+	}
+	if len(turns) == 0 {
+		return ConversationTurn{}, false, nil
+	}
+
+	return turns[0], true, nil
+}
+
+func (s *SQLStore) ListUserTurns(ctx context.Context, sessionID string) ([]ConversationTurn, error) {
+	turns, err := s.queryConversationTurns(
+		ctx,
+		`SELECT `+conversationTurnColumns+`
+		 FROM conversation_turns
+		 WHERE session_id = $1 AND role = 'user' AND undone_at IS NULL
+		 ORDER BY created_at, id`,
+		sessionID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("query user turns: %w", err)
+	}
+
+	return turns, nil
+}
+
+// ListLatestUserTurns returns the newest user turns across all sessions,
+// newest first. It backs the labeling export of aiden eval.
+func (s *SQLStore) ListLatestUserTurns(ctx context.Context, limit int) ([]ConversationTurn, error) {
+	turns, err := s.queryConversationTurns(
+		ctx,
+		`SELECT `+conversationTurnColumns+`
+		 FROM conversation_turns
+		 WHERE role = 'user' AND undone_at IS NULL
+		 ORDER BY created_at DESC, id DESC
+		 LIMIT $1`,
+		limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("query latest user turns: %w", err)
+	}
+
+	return turns, nil
+}
+
+// ReviseConversationTurn replaces the content of an edited turn and returns
+// its new revision number.
+func (s *SQLStore) ReviseConversationTurn(ctx context.Context, turn ConversationTurn) (int, error) {
+	rankingJSON, err := marshalIntentRanking(turn.IntentRanking)
+	if err != nil {
+		return 0, err
+	}
+
+	var revision int
+	err = s.db.QueryRowContext(
+		ctx,
+		`UPDATE conversation_turns
+		 SET content = $2,
+		     intent = $3,
+		     intent_confidence = $4,
+		     intent_ranking = $5,
+		     revision = revision + 1,
+		     edited_at = NOW(),
+		     content_hash = '',
+		     redacted_slots = '[]'::jsonb,
+		     redacted_at = NULL
+		 WHERE id = $1
+		 RETURNING revision`,
+		turn.ID,
+		turn.Content,
+		turn.Intent,
+		turn.IntentConfidence,
+		rankingJSON,
+	).Scan(&revision)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, fmt.Errorf("conversation turn %s not found", turn.ID)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("revise conversation turn: %w", err)
+	}
+
+	return revision, nil
+}
+
+// UndoLastClarifyTurn marks the latest clarify turn that is not already undone,
+// so it no longer counts when slot completion is recomputed.
+func (s *SQLStore) UndoLastClarifyTurn(ctx context.Context, sessionID string) (ConversationTurn, bool, error) {
+	turns, err := s.queryConversationTurns(
+		ctx,
+		`UPDATE conversation_turns
+		 SET undone_at = NOW()
+		 WHERE id = (
+		     SELECT id
+		     FROM conversation_turns
+		     WHERE session_id = $1
+		       AND role = 'user'
+		       AND intent = $2
+		       AND undone_at IS NULL
+		     ORDER BY created_at DESC, id DESC
+		     LIMIT 1
+		 )
+		 RETURNING `+conversationTurnColumns,
+		sessionID,
+		IntentClarifyGoal,
+	)
+	if err != nil {
+		return ConversationTurn{}, false, fmt.Errorf("undo last clarify turn: %w", err)
+	}
+	if len(turns) == 0 {
+		return ConversationTurn{}, false, nil
+	}
+
+	return turns[0], true, nil
+}
+
+const conversationTurnColumns = `id, session_id, role, content, intent, intent_confidence, intent_ranking, telegram_message_id, revision,
+		        redacted_at IS NOT NULL, redacted_slots`
+
+func (s *SQLStore) queryConversationTurns(ctx context.Context, query string, args ...any) ([]ConversationTurn, error) {
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	turns := make([]ConversationTurn, 0)
+	for rows.Next() {
+		var (
+			turn        ConversationTurn
+			confidence  sql.NullFloat64
+			rankingJSON []byte
+			slotsJSON   []byte
+		)
+		if err := rows.Scan(
+			&turn.ID,
+			&turn.SessionID,
+			&turn.Role,
+			&turn.Content,
+			&turn.Intent,
+			&confidence,
+			&rankingJSON,
+			&turn.TelegramMessageID,
+			&turn.Revision,
+			&turn.Redacted,
+			&slotsJSON,
+		); err != nil {
+			return nil, fmt.Errorf("scan conversation turn: %w", err)
+		}
+		if turn.Redacted {
+			if err := json.Unmarshal(slotsJSON, &turn.RedactedSlots); err != nil {
+				return nil, fmt.Errorf("unmarshal redacted slots: %w", err)
+			}
+		}
+		if confidence.Valid {
+			value := confidence.Float64
+			turn.IntentConfidence = &value
+		}
+		if len(rankingJSON) > 0 {
+			if err := json.Unmarshal(rankingJSON, &turn.IntentRanking); err != nil {
+				return nil, fmt.Errorf("unmarshal intent ranking: %w", err)
+			}
+		}
+		turns = append(turns, turn)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate conversation turns: %w", err)
+	}
+
+	return turns, nil
+}
+
+// marshalIntentRanking stores turns without a ranking (commands, assistant
+// replies) as NULL.
+func marshalIntentRanking(ranking []IntentScore) ([]byte, error) {
+	if len(ranking) == 0 {
+		return nil, nil
+	}
+	raw, err := json.Marshal(ranking)
+	if err != nil {
+		return nil, fmt.Errorf("marshal intent ranking: %w", err)
+	}
+	return raw, nil
+}
+
+// SaveGoalAttachment ignores a file that is already attached to the goal, so a
+// document forwarded twice does not create duplicates.
+func (s *SQLStore) SaveGoalAttachment(ctx context.Context, attachment GoalAttachment) error {
+	_, err := s.db.ExecContext(
+		ctx,
+		`INSERT INTO goal_attachments(
+		    goal_id,
+		    kind,
+		    telegram_file_id,
+		    telegram_file_unique_id,
+		    file_name,
+		    mime_type,
+		    file_size,
+		    content_text,
+		    created_at
+		 )
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, NOW())
+		 ON CONFLICT (goal_id, telegram_file_unique_id) DO NOTHING`,
+		attachment.GoalID,
+		attachment.Kind,
+		attachment.TelegramFileID,
+		attachment.TelegramFileUniqueID,
+		attachment.FileName,
+		attachment.MimeType,
+		attachment.FileSize,
+		attachment.ContentText,
+	)
+	if err != nil {
+		return fmt.Errorf("insert goal attachment: %w", err)
+	}
+
+	return nil
+}
+
 func (s *SQLStore) loadRuntimeStateValue(ctx context.Context, key string) (string, error) {
 	var value string
 	err := s.db.QueryRowContext(
@@ -323,35 +1034,52 @@ func parseRuntimeOffset(value string) (int64, error) {
 }
 
 func (s *SQLStore) insertUserIfNotExists(ctx context.Context, chatID int64) (User, error) {
-	var user User
-	err := s.db.QueryRowContext(
+	return scanUser(s.db.QueryRowContext(
 		ctx,
 		`INSERT INTO users(telegram_chat_id, language, timezone, created_at, updated_at)
 		 VALUES ($1, 'zh-CN', 'Asia/Shanghai', NOW(), NOW())
 		 ON CONFLICT (telegram_chat_id) DO NOTHING
-		 RETURNING id, telegram_chat_id, language, timezone`,
+		 RETURNING `+userColumns,
 		chatID,
-	).Scan(&user.ID, &user.TelegramChatID, &user.Language, &user.Timezone)
-	if err != nil {
-		return User{}, err
-	}
-
-	return user, nil
+	))
 }
 
 func (s *SQLStore) findUserByChatID(ctx context.Context, chatID int64) (User, error) {
-	var user User
-	err := s.db.QueryRowContext(
+	return scanUser(s.db.QueryRowContext(
 		ctx,
-		`SELECT id, telegram_chat_id, language, timezone
+		`SELECT `+userColumns+`
 		 FROM users
 		 WHERE telegram_chat_id = $1`,
 		chatID,
-	).Scan(&user.ID, &user.TelegramChatID, &user.Language, &user.Timezone)
-	if err != nil {
+	))
+}
+
+const userColumns = `id, telegram_chat_id, language, timezone, is_reachable, session_timeout_minutes,
+		        deletion_requested_at, deletion_confirmations`
+
+func scanUser(row *sql.Row) (User, error) {
+	var (
+		user           User
+		timeoutMinutes sql.NullInt64
+		requestedAt    sql.NullTime
+	)
+	if err := row.Scan(
+		&user.ID,
+		&user.TelegramChatID,
+		&user.Language,
+		&user.Timezone,
+		&user.IsReachable,
+		&timeoutMinutes,
+		&requestedAt,
+		&user.DeletionConfirmations,
+	); err != nil {
 		return User{}, err
 	}
 
+	user.SessionTimeout = minutesToDuration(timeoutMinutes)
+	if requestedAt.Valid {
+		user.DeletionRequestedAt = &requestedAt.Time
+	}
 	return user, nil
 }
 
@@ -360,6 +1088,7 @@ func (s *SQLStore) insertPlanningSessionIfNotExists(ctx context.Context, goalID
 		session            PlanningSession
 		stateRaw           string
 		slotCompletionJSON []byte
+		skippedSlotsJSON   []byte
 	)
 
 	err := s.db.QueryRowContext(
@@ -374,7 +1103,7 @@ func (s *SQLStore) insertPlanningSessionIfNotExists(ctx context.Context, goalID
 		 )
 		 VALUES ($1, 'idle', '{}'::jsonb, 0, '', NOW())
 		 ON CONFLICT (goal_id) DO NOTHING
-		 RETURNING id, goal_id, state, slot_completion, turn_count, last_intent, updated_at`,
+		 RETURNING id, goal_id, state, slot_completion, turn_count, last_intent, skipped_slots, pending_action, updated_at`,
 		goalID,
 	).Scan(
 		&session.ID,
@@ -383,6 +1112,8 @@ func (s *SQLStore) insertPlanningSessionIfNotExists(ctx context.Context, goalID
 		&slotCompletionJSON,
 		&session.TurnCount,
 		&session.LastIntent,
+		&skippedSlotsJSON,
+		&session.PendingAction,
 		&session.UpdatedAt,
 	)
 	if err != nil {
@@ -393,6 +1124,9 @@ func (s *SQLStore) insertPlanningSessionIfNotExists(ctx context.Context, goalID
 	if err != nil {
 		return PlanningSession{}, fmt.Errorf("parse slot completion from created planning session: %w", err)
 	}
+	if err := json.Unmarshal(skippedSlotsJSON, &session.SkippedSlots); err != nil {
+		return PlanningSession{}, fmt.Errorf("parse skipped slots from created planning session: %w", err)
+	}
 
 	session.State = ParsePlanningState(stateRaw)
 	session.SlotCompletion = slotCompletion
@@ -404,11 +1138,12 @@ func (s *SQLStore) findPlanningSessionByGoalID(ctx context.Context, goalID strin
 		session            PlanningSession
 		stateRaw           string
 		slotCompletionJSON []byte
+		skippedSlotsJSON   []byte
 	)
 
 	err := s.db.QueryRowContext(
 		ctx,
-		`SELECT id, goal_id, state, slot_completion, turn_count, last_intent, updated_at
+		`SELECT id, goal_id, state, slot_completion, turn_count, last_intent, skipped_slots, pending_action, updated_at
 		 FROM planning_sessions
 		 WHERE goal_id = $1`,
 		goalID,
@@ -419,6 +1154,8 @@ func (s *SQLStore) findPlanningSessionByGoalID(ctx context.Context, goalID strin
 		&slotCompletionJSON,
 		&session.TurnCount,
 		&session.LastIntent,
+		&skippedSlotsJSON,
+		&session.PendingAction,
 		&session.UpdatedAt,
 	)
 	if err != nil {
@@ -429,12 +1166,22 @@ func (s *SQLStore) findPlanningSessionByGoalID(ctx context.Context, goalID strin
 	if err != nil {
 		return PlanningSession{}, fmt.Errorf("parse slot completion from planning session: %w", err)
 	}
+	if err := json.Unmarshal(skippedSlotsJSON, &session.SkippedSlots); err != nil {
+		return PlanningSession{}, fmt.Errorf("parse skipped slots from planning session: %w", err)
+	}
 
 	session.State = ParsePlanningState(stateRaw)
 	session.SlotCompletion = slotCompletion
 	return session, nil
 }
 
+func minutesToDuration(minutes sql.NullInt64) time.Duration {
+	if !minutes.Valid {
+		return 0
+	}
+	return time.Duration(minutes.Int64) * time.Minute
+}
+
 func parseSlotCompletionJSON(raw []byte) (map[string]bool, error) {
 	if len(raw) == 0 {
 		return DefaultSlotCompletion(), nil
@@ -0,0 +1,510 @@
+// Package telegramtest runs a fake Telegram Bot API over HTTP so tests can
+// exercise telegram.HTTPClient end to end: request encoding, error
+// envelopes, retry_after and long polling.
+package telegramtest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/congregalis/aiden/internal/telegram"
+)
+
+// Token is the bot token the server accepts; requests with any other token
+// get 401 Unauthorized like the real API.
+const Token = "123456:test-token"
+
+// Bot is the identity getMe returns.
+var Bot = telegram.BotUser{ID: 123456, IsBot: true, FirstName: "Aiden", Username: "aiden_test_bot"}
+
+// maxPollWait caps getUpdates long polling so a test that forgets to cancel
+// its worker does not hang for the client's full timeout.
+const maxPollWait = 5 * time.Second
+
+// Failure is an error response injected with FailNext.
+type Failure struct {
+	Status      int
+	Description string
+	// RetryAfter is sent as parameters.retry_after, in seconds.
+	RetryAfter int
+	// Raw replaces the JSON envelope, e.g. with a proxy's HTML error page.
+	Raw string
+}
+
+// RateLimited is the 429 Telegram returns when a bot sends too fast.
+func RateLimited(retryAfter int) Failure {
+	return Failure{
+		Status:      http.StatusTooManyRequests,
+		Description: fmt.Sprintf("Too Many Requests: retry after %d", retryAfter),
+		RetryAfter:  retryAfter,
+	}
+}
+
+// Blocked is the 403 Telegram returns once the user has blocked the bot.
+func Blocked() Failure {
+	return Failure{Status: http.StatusForbidden, Description: "Forbidden: bot was blocked by the user"}
+}
+
+// BadGateway is a 502 with a non-JSON body, as seen during Telegram outages.
+func BadGateway() Failure {
+	return Failure{Status: http.StatusBadGateway, Raw: "<html><body>502 Bad Gateway</body></html>"}
+}
+
+// SentMessage is a sendMessage call the server accepted.
+type SentMessage struct {
+	MessageID        int64
+	ChatID           int64
+	Text             string
+	ReplyToMessageID int64
+}
+
+// SentDocument is a sendDocument call the server accepted.
+type SentDocument struct {
+	MessageID        int64
+	ChatID           int64
+	FileName         string
+	Content          []byte
+	Caption          string
+	ReplyToMessageID int64
+}
+
+// CallbackAnswer is an answerCallbackQuery call the server accepted.
+type CallbackAnswer struct {
+	CallbackQueryID string
+	Text            string
+	ShowAlert       bool
+}
+
+type Server struct {
+	srv *httptest.Server
+
+	mu            sync.Mutex
+	updates       []telegram.Update
+	wake          chan struct{}
+	nextUpdateID  int64
+	nextMessageID int64
+	nextQueryID   int64
+	openQueries   map[string]bool
+	webhookURL    string
+	failures      map[string][]Failure
+	calls         map[string]int
+	sent          []SentMessage
+	documents     []SentDocument
+	answers       []CallbackAnswer
+	sentSignal    chan struct{}
+}
+
+// NewServer starts a server that is closed when the test finishes.
+func NewServer(t testing.TB) *Server {
+	t.Helper()
+	s := &Server{
+		wake:        make(chan struct{}),
+		openQueries: make(map[string]bool),
+		failures:    make(map[string][]Failure),
+		calls:       make(map[string]int),
+		sentSignal:  make(chan struct{}),
+	}
+	s.srv = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	t.Cleanup(s.srv.Close)
+	return s
+}
+
+// URL is the base URL to pass to telegram.NewHTTPClientWithBaseURL.
+func (s *Server) URL() string {
+	return s.srv.URL
+}
+
+// Client returns an HTTPClient pointed at the server.
+func (s *Server) Client() *telegram.HTTPClient {
+	return telegram.NewHTTPClientWithBaseURL(Token, s.srv.URL, s.srv.Client())
+}
+
+// SendText queues a private text message from chatID and returns its update ID.
+func (s *Server) SendText(chatID int64, text string) int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.nextMessageID++
+	return s.enqueueLocked(telegram.Update{Message: &telegram.Message{
+		MessageID: s.nextMessageID,
+		From:      &telegram.TelegramUser{ID: chatID, FirstName: "Test"},
+		Chat:      telegram.Chat{ID: chatID, Type: "private"},
+		Text:      text,
+	}})
+}
+
+// AddUpdate queues an arbitrary update; its UpdateID is assigned by the
+// server and returned.
+func (s *Server) AddUpdate(update telegram.Update) int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.enqueueLocked(update)
+}
+
+// PressButton queues a callback query as if chatID pressed an inline button
+// carrying data under messageID. It returns the callback query ID.
+func (s *Server) PressButton(chatID, messageID int64, data string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.nextQueryID++
+	id := fmt.Sprintf("cbq-%d", s.nextQueryID)
+	s.openQueries[id] = true
+	s.enqueueLocked(telegram.Update{CallbackQuery: &telegram.CallbackQuery{
+		ID:   id,
+		From: telegram.TelegramUser{ID: chatID, FirstName: "Test"},
+		Message: &telegram.Message{
+			MessageID: messageID,
+			From:      &telegram.TelegramUser{ID: Bot.ID, IsBot: true, FirstName: Bot.FirstName},
+			Chat:      telegram.Chat{ID: chatID, Type: "private"},
+		},
+		Data: data,
+	}})
+	return id
+}
+
+func (s *Server) enqueueLocked(update telegram.Update) int64 {
+	s.nextUpdateID++
+	update.UpdateID = s.nextUpdateID
+	s.updates = append(s.updates, update)
+	close(s.wake)
+	s.wake = make(chan struct{})
+	return update.UpdateID
+}
+
+// FailNext makes the next calls to method fail, one failure per call, before
+// the method behaves normally again.
+func (s *Server) FailNext(method string, failures ...Failure) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failures[method] = append(s.failures[method], failures...)
+}
+
+// Calls reports how many requests reached method, failed ones included.
+func (s *Server) Calls(method string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.calls[method]
+}
+
+// Sent returns every message accepted by sendMessage, in order.
+func (s *Server) Sent() []SentMessage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]SentMessage(nil), s.sent...)
+}
+
+// Documents returns every file accepted by sendDocument, in order.
+func (s *Server) Documents() []SentDocument {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]SentDocument(nil), s.documents...)
+}
+
+// WaitForSent blocks until at least n messages were sent and returns them,
+// failing the test after timeout.
+func (s *Server) WaitForSent(t testing.TB, n int, timeout time.Duration) []SentMessage {
+	t.Helper()
+	deadline := time.After(timeout)
+	for {
+		s.mu.Lock()
+		if len(s.sent) >= n {
+			sent := append([]SentMessage(nil), s.sent...)
+			s.mu.Unlock()
+			return sent
+		}
+		signal := s.sentSignal
+		s.mu.Unlock()
+
+		select {
+		case <-signal:
+		case <-deadline:
+			t.Fatalf("telegramtest: got %d sent messages after %s, want %d", len(s.Sent()), timeout, n)
+			return nil
+		}
+	}
+}
+
+// CallbackAnswers returns every accepted answerCallbackQuery call.
+func (s *Server) CallbackAnswers() []CallbackAnswer {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]CallbackAnswer(nil), s.answers...)
+}
+
+// Webhook returns the URL registered with setWebhook, if any.
+func (s *Server) Webhook() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.webhookURL
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	token, method, ok := strings.Cut(strings.TrimPrefix(r.URL.Path, "/bot"), "/")
+	if !ok || !strings.HasPrefix(r.URL.Path, "/bot") {
+		writeError(w, http.StatusNotFound, "Not Found")
+		return
+	}
+	if token != Token {
+		writeError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	s.mu.Lock()
+	s.calls[method]++
+	var failure *Failure
+	if queued := s.failures[method]; len(queued) > 0 {
+		failure = &queued[0]
+		s.failures[method] = queued[1:]
+	}
+	s.mu.Unlock()
+	if failure != nil {
+		writeFailure(w, *failure)
+		return
+	}
+
+	switch method {
+	case "getMe":
+		writeResult(w, Bot)
+	case "getUpdates":
+		s.getUpdates(w, r)
+	case "sendMessage":
+		s.sendMessage(w, r)
+	case "sendDocument":
+		s.sendDocument(w, r)
+	case "answerCallbackQuery":
+		s.answerCallbackQuery(w, r)
+	case "setWebhook":
+		s.setWebhook(w, r)
+	case "deleteWebhook":
+		s.mu.Lock()
+		s.webhookURL = ""
+		s.mu.Unlock()
+		writeResult(w, true)
+	default:
+		writeError(w, http.StatusNotFound, "Not Found: method not found")
+	}
+}
+
+func (s *Server) getUpdates(w http.ResponseWriter, r *http.Request) {
+	var params struct {
+		Offset  int64 `json:"offset"`
+		Timeout int   `json:"timeout"`
+	}
+	if !decodeParams(w, r, &params) {
+		return
+	}
+
+	wait := min(time.Duration(params.Timeout)*time.Second, maxPollWait)
+	ctx, cancel := context.WithTimeout(r.Context(), wait)
+	defer cancel()
+
+	for {
+		s.mu.Lock()
+		if s.webhookURL != "" {
+			s.mu.Unlock()
+			writeError(w, http.StatusConflict, "Conflict: can't use getUpdates method while webhook is active; use deleteWebhook to delete the webhook first")
+			return
+		}
+		// Like the real API, asking for an offset confirms every earlier update.
+		kept := s.updates[:0]
+		for _, update := range s.updates {
+			if update.UpdateID >= params.Offset {
+				kept = append(kept, update)
+			}
+		}
+		s.updates = kept
+		if len(kept) > 0 {
+			updates := append([]telegram.Update(nil), kept...)
+			s.mu.Unlock()
+			writeResult(w, updates)
+			return
+		}
+		wake := s.wake
+		s.mu.Unlock()
+
+		select {
+		case <-wake:
+		case <-ctx.Done():
+			writeResult(w, []telegram.Update{})
+			return
+		}
+	}
+}
+
+func (s *Server) sendMessage(w http.ResponseWriter, r *http.Request) {
+	var params struct {
+		ChatID           int64  `json:"chat_id"`
+		Text             string `json:"text"`
+		ReplyToMessageID int64  `json:"reply_to_message_id"`
+	}
+	if !decodeParams(w, r, &params) {
+		return
+	}
+	if params.ChatID == 0 {
+		writeError(w, http.StatusBadRequest, "Bad Request: chat not found")
+		return
+	}
+	if strings.TrimSpace(params.Text) == "" {
+		writeError(w, http.StatusBadRequest, "Bad Request: message text is empty")
+		return
+	}
+
+	s.mu.Lock()
+	s.nextMessageID++
+	sent := SentMessage{
+		MessageID:        s.nextMessageID,
+		ChatID:           params.ChatID,
+		Text:             params.Text,
+		ReplyToMessageID: params.ReplyToMessageID,
+	}
+	s.sent = append(s.sent, sent)
+	close(s.sentSignal)
+	s.sentSignal = make(chan struct{})
+	s.mu.Unlock()
+
+	message := telegram.Message{
+		MessageID: sent.MessageID,
+		From:      &telegram.TelegramUser{ID: Bot.ID, IsBot: true, FirstName: Bot.FirstName, Username: Bot.Username},
+		Chat:      telegram.Chat{ID: sent.ChatID, Type: "private"},
+		Text:      sent.Text,
+	}
+	if sent.ReplyToMessageID > 0 {
+		message.ReplyToMessage = &telegram.Message{MessageID: sent.ReplyToMessageID, Chat: message.Chat}
+	}
+	writeResult(w, message)
+}
+
+func (s *Server) sendDocument(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseMultipartForm(32 << 20); err != nil {
+		writeError(w, http.StatusBadRequest, "Bad Request: "+err.Error())
+		return
+	}
+	chatID, err := strconv.ParseInt(r.FormValue("chat_id"), 10, 64)
+	if err != nil || chatID == 0 {
+		writeError(w, http.StatusBadRequest, "Bad Request: chat not found")
+		return
+	}
+	replyTo, _ := strconv.ParseInt(r.FormValue("reply_to_message_id"), 10, 64)
+	file, header, err := r.FormFile("document")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Bad Request: there is no document in the request")
+		return
+	}
+	defer file.Close()
+	content, err := io.ReadAll(file)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Bad Request: "+err.Error())
+		return
+	}
+
+	s.mu.Lock()
+	s.nextMessageID++
+	sent := SentDocument{
+		MessageID:        s.nextMessageID,
+		ChatID:           chatID,
+		FileName:         header.Filename,
+		Content:          content,
+		Caption:          r.FormValue("caption"),
+		ReplyToMessageID: replyTo,
+	}
+	s.documents = append(s.documents, sent)
+	s.mu.Unlock()
+
+	writeResult(w, telegram.Message{
+		MessageID: sent.MessageID,
+		From:      &telegram.TelegramUser{ID: Bot.ID, IsBot: true, FirstName: Bot.FirstName, Username: Bot.Username},
+		Chat:      telegram.Chat{ID: chatID, Type: "private"},
+		Caption:   sent.Caption,
+		Document:  &telegram.Document{FileID: fmt.Sprintf("doc-%d", sent.MessageID), FileName: sent.FileName, FileSize: int64(len(content))},
+	})
+}
+
+func (s *Server) answerCallbackQuery(w http.ResponseWriter, r *http.Request) {
+	var params struct {
+		CallbackQueryID string `json:"callback_query_id"`
+		Text            string `json:"text"`
+		ShowAlert       bool   `json:"show_alert"`
+	}
+	if !decodeParams(w, r, &params) {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.openQueries[params.CallbackQueryID] {
+		writeError(w, http.StatusBadRequest, "Bad Request: query is too old and response timeout expired or query ID is invalid")
+		return
+	}
+	delete(s.openQueries, params.CallbackQueryID)
+	s.answers = append(s.answers, CallbackAnswer(params))
+	writeResult(w, true)
+}
+
+func (s *Server) setWebhook(w http.ResponseWriter, r *http.Request) {
+	var params struct {
+		URL string `json:"url"`
+	}
+	if !decodeParams(w, r, &params) {
+		return
+	}
+	if params.URL != "" && !strings.HasPrefix(params.URL, "https://") {
+		writeError(w, http.StatusBadRequest, "Bad Request: bad webhook: An HTTPS URL must be provided for webhook")
+		return
+	}
+
+	s.mu.Lock()
+	s.webhookURL = params.URL
+	s.mu.Unlock()
+	writeResult(w, true)
+}
+
+func decodeParams(w http.ResponseWriter, r *http.Request, params any) bool {
+	if r.ContentLength == 0 {
+		return true
+	}
+	if err := json.NewDecoder(r.Body).Decode(params); err != nil {
+		writeError(w, http.StatusBadRequest, "Bad Request: can't parse JSON object")
+		return false
+	}
+	return true
+}
+
+func writeResult(w http.ResponseWriter, result any) {
+	writeJSON(w, http.StatusOK, telegram.APIResponse[any]{OK: true, Result: result})
+}
+
+func writeError(w http.ResponseWriter, status int, description string) {
+	writeFailure(w, Failure{Status: status, Description: description})
+}
+
+func writeFailure(w http.ResponseWriter, failure Failure) {
+	if failure.Raw != "" {
+		w.Header().Set("Content-Type", "text/html")
+		w.WriteHeader(failure.Status)
+		_, _ = w.Write([]byte(failure.Raw))
+		return
+	}
+
+	response := telegram.APIResponse[any]{
+		ErrorCode:   failure.Status,
+		Description: failure.Description,
+	}
+	if failure.RetryAfter > 0 {
+		response.Parameters = &telegram.ResponseParameters{RetryAfter: failure.RetryAfter}
+	}
+	writeJSON(w, failure.Status, response)
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
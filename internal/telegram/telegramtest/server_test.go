@@ -0,0 +1,233 @@
+package telegramtest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/congregalis/aiden/internal/telegram"
+)
+
+func TestGetUpdatesOffsetAndLongPoll(t *testing.T) {
+	server := NewServer(t)
+	client := server.Client()
+	ctx := context.Background()
+
+	me, err := client.GetMe(ctx)
+	if err != nil || me.Username != Bot.Username {
+		t.Fatalf("getMe=(%+v, %v)", me, err)
+	}
+
+	first := server.SendText(42, "hello")
+	server.SendText(42, "again")
+	updates, err := client.GetUpdates(ctx, telegram.GetUpdatesParams{TimeoutSec: 1})
+	if err != nil || len(updates) != 2 || updates[0].Message.Text != "hello" {
+		t.Fatalf("getUpdates=(%+v, %v)", updates, err)
+	}
+
+	updates, err = client.GetUpdates(ctx, telegram.GetUpdatesParams{Offset: first + 1, TimeoutSec: 1})
+	if err != nil || len(updates) != 1 || updates[0].Message.Text != "again" {
+		t.Fatalf("getUpdates after offset=(%+v, %v)", updates, err)
+	}
+
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		server.SendText(42, "late")
+	}()
+	start := time.Now()
+	updates, err = client.GetUpdates(ctx, telegram.GetUpdatesParams{Offset: first + 2, TimeoutSec: 3})
+	if err != nil || len(updates) != 1 || updates[0].Message.Text != "late" {
+		t.Fatalf("long poll=(%+v, %v)", updates, err)
+	}
+	if waited := time.Since(start); waited > 2*time.Second {
+		t.Fatalf("long poll returned after %s, want as soon as the update arrived", waited)
+	}
+
+	updates, err = client.GetUpdates(ctx, telegram.GetUpdatesParams{Offset: first + 3, TimeoutSec: 0})
+	if err != nil || len(updates) != 0 {
+		t.Fatalf("empty poll=(%+v, %v)", updates, err)
+	}
+}
+
+func TestSendMessageRecordsAndInjectsFailures(t *testing.T) {
+	server := NewServer(t)
+	client := server.Client()
+	ctx := context.Background()
+
+	server.FailNext("sendMessage", RateLimited(7), Blocked(), BadGateway())
+
+	_, err := client.SendMessage(ctx, telegram.OutgoingMessage{ChatID: 42, Text: "hi"})
+	if retryAfter, ok := telegram.IsRateLimitError(err); !ok || retryAfter != 7*time.Second {
+		t.Fatalf("429: err=%v retryAfter=%s", err, retryAfter)
+	}
+
+	_, err = client.SendMessage(ctx, telegram.OutgoingMessage{ChatID: 42, Text: "hi"})
+	if !errors.Is(err, telegram.ErrBotBlocked) || !telegram.IsChatUnreachable(err) {
+		t.Fatalf("403: err=%v, want ErrBotBlocked", err)
+	}
+
+	_, err = client.SendMessage(ctx, telegram.OutgoingMessage{ChatID: 42, Text: "hi"})
+	var apiErr *telegram.APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadGateway {
+		t.Fatalf("502: err=%v", err)
+	}
+
+	message, err := client.SendMessage(ctx, telegram.OutgoingMessage{ChatID: 42, Text: "hi", ReplyToMessageID: 9})
+	if err != nil || message.Text != "hi" || message.ReplyToMessage.MessageID != 9 {
+		t.Fatalf("send=(%+v, %v)", message, err)
+	}
+
+	_, err = client.SendMessage(ctx, telegram.OutgoingMessage{ChatID: 42, Text: " "})
+	if !errors.Is(err, telegram.ErrBadRequest) {
+		t.Fatalf("empty text: err=%v, want ErrBadRequest", err)
+	}
+
+	sent := server.Sent()
+	if len(sent) != 1 || sent[0] != (SentMessage{MessageID: message.MessageID, ChatID: 42, Text: "hi", ReplyToMessageID: 9}) {
+		t.Fatalf("sent=%+v", sent)
+	}
+	if calls := server.Calls("sendMessage"); calls != 5 {
+		t.Fatalf("sendMessage calls=%d, want 5", calls)
+	}
+}
+
+func TestSendDocumentUploadsMultipart(t *testing.T) {
+	server := NewServer(t)
+	content := []byte(`{"user":{"id":"u1"}}`)
+
+	message, err := server.Client().SendDocument(context.Background(), telegram.OutgoingDocument{
+		ChatID:           42,
+		FileName:         "aiden-export.json",
+		Content:          content,
+		Caption:          "your data",
+		ReplyToMessageID: 7,
+	})
+	if err != nil || message.Document == nil || message.Document.FileName != "aiden-export.json" {
+		t.Fatalf("sendDocument=(%+v, %v)", message, err)
+	}
+
+	documents := server.Documents()
+	if len(documents) != 1 {
+		t.Fatalf("documents=%+v", documents)
+	}
+	got := documents[0]
+	if got.ChatID != 42 || got.Caption != "your data" || got.ReplyToMessageID != 7 || !bytes.Equal(got.Content, content) {
+		t.Fatalf("document=%+v", got)
+	}
+}
+
+func TestWrongTokenIsUnauthorized(t *testing.T) {
+	server := NewServer(t)
+	client := telegram.NewHTTPClientWithBaseURL("1:wrong", server.URL(), nil)
+
+	_, err := client.GetMe(context.Background())
+	var apiErr *telegram.APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("err=%v, want 401", err)
+	}
+}
+
+func TestWebhookBlocksGetUpdates(t *testing.T) {
+	server := NewServer(t)
+	client := server.Client()
+	ctx := context.Background()
+
+	if response := call(t, server, "setWebhook", map[string]any{"url": "http://example.com/hook"}); response.OK {
+		t.Fatal("setWebhook accepted a plain HTTP URL")
+	}
+	if response := call(t, server, "setWebhook", map[string]any{"url": "https://example.com/hook"}); !response.OK {
+		t.Fatalf("setWebhook failed: %s", response.Description)
+	}
+	if server.Webhook() != "https://example.com/hook" {
+		t.Fatalf("webhook=%q", server.Webhook())
+	}
+
+	_, err := client.GetUpdates(ctx, telegram.GetUpdatesParams{})
+	var apiErr *telegram.APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusConflict {
+		t.Fatalf("getUpdates with webhook: err=%v, want 409", err)
+	}
+
+	call(t, server, "deleteWebhook", nil)
+	if _, err := client.GetUpdates(ctx, telegram.GetUpdatesParams{}); err != nil {
+		t.Fatalf("getUpdates after deleteWebhook: %v", err)
+	}
+}
+
+func TestCallbackQueries(t *testing.T) {
+	server := NewServer(t)
+	queryID := server.PressButton(42, 7, "confirm")
+
+	updates, err := server.Client().GetUpdates(context.Background(), telegram.GetUpdatesParams{})
+	if err != nil || len(updates) != 1 {
+		t.Fatalf("getUpdates=(%+v, %v)", updates, err)
+	}
+	query := updates[0].CallbackQuery
+	if query == nil || query.ID != queryID || query.Data != "confirm" || query.Message.MessageID != 7 || query.From.ID != 42 {
+		t.Fatalf("callback query=%+v", query)
+	}
+
+	if response := call(t, server, "answerCallbackQuery", map[string]any{"callback_query_id": queryID, "text": "ok"}); !response.OK {
+		t.Fatalf("answerCallbackQuery failed: %s", response.Description)
+	}
+	if response := call(t, server, "answerCallbackQuery", map[string]any{"callback_query_id": queryID}); response.OK {
+		t.Fatal("answered the same callback query twice")
+	}
+	if answers := server.CallbackAnswers(); len(answers) != 1 || answers[0].Text != "ok" {
+		t.Fatalf("answers=%+v", answers)
+	}
+}
+
+func TestWorkerOverHTTP(t *testing.T) {
+	server := NewServer(t)
+	server.FailNext("sendMessage", RateLimited(1))
+	server.PressButton(42, 1, "ignored")
+	server.SendText(42, "/help")
+
+	worker := telegram.NewWorker(telegram.WorkerConfig{
+		PollTimeoutSec: 1,
+		PollInterval:   5 * time.Millisecond,
+		Outbox:         telegram.OutboxConfig{PollInterval: 10 * time.Millisecond},
+	}, server.Client(), telegram.NewMemoryStore(), slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- worker.Run(ctx) }()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	sent := server.WaitForSent(t, 1, 5*time.Second)
+	if sent[0].ChatID != 42 || sent[0].Text == "" {
+		t.Fatalf("sent=%+v", sent)
+	}
+	if calls := server.Calls("sendMessage"); calls != 2 {
+		t.Fatalf("sendMessage calls=%d, want a retry after the 429", calls)
+	}
+}
+
+func call(t *testing.T, server *Server, method string, params map[string]any) telegram.APIResponse[json.RawMessage] {
+	t.Helper()
+	body, err := json.Marshal(params)
+	if err != nil {
+		t.Fatalf("marshal params: %v", err)
+	}
+	resp, err := http.Post(server.URL()+"/bot"+Token+"/"+method, "application/json", bytes.NewReader(body))
+	if err != nil {
+		t.Fatalf("%s: %v", method, err)
+	}
+	defer resp.Body.Close()
+
+	var response telegram.APIResponse[json.RawMessage]
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		t.Fatalf("decode %s response: %v", method, err)
+	}
+	return response
+}
@@ -9,7 +9,8 @@ type APIResponse[T any] struct {
 }
 
 type ResponseParameters struct {
-	RetryAfter int `json:"retry_after,omitempty"`
+	RetryAfter      int   `json:"retry_after,omitempty"`
+	MigrateToChatID int64 `json:"migrate_to_chat_id,omitempty"`
 }
 
 type BotUser struct {
@@ -29,17 +30,93 @@ type OutgoingMessage struct {
 	ChatID           int64
 	Text             string
 	ReplyToMessageID int64
+	LanguageCode     string
+	// Export turns the outbox row into a /mydata export; Text is then the
+	// document's caption.
+	Export *OutboxExport
+}
+
+// OutgoingDocument is a file uploaded with sendDocument.
+type OutgoingDocument struct {
+	ChatID           int64
+	FileName         string
+	Content          []byte
+	Caption          string
+	ReplyToMessageID int64
 }
 
 type Update struct {
-	UpdateID int64    `json:"update_id"`
-	Message  *Message `json:"message,omitempty"`
+	UpdateID      int64          `json:"update_id"`
+	Message       *Message       `json:"message,omitempty"`
+	EditedMessage *Message       `json:"edited_message,omitempty"`
+	CallbackQuery *CallbackQuery `json:"callback_query,omitempty"`
+}
+
+// CallbackQuery is sent when a user presses an inline keyboard button.
+type CallbackQuery struct {
+	ID      string       `json:"id"`
+	From    TelegramUser `json:"from"`
+	Message *Message     `json:"message,omitempty"`
+	Data    string       `json:"data,omitempty"`
 }
 
 type Message struct {
-	MessageID int64  `json:"message_id"`
-	Chat      Chat   `json:"chat"`
-	Text      string `json:"text,omitempty"`
+	MessageID       int64         `json:"message_id"`
+	From            *TelegramUser `json:"from,omitempty"`
+	Chat            Chat          `json:"chat"`
+	Text            string        `json:"text,omitempty"`
+	Caption         string        `json:"caption,omitempty"`
+	Voice           *Voice        `json:"voice,omitempty"`
+	Document        *Document     `json:"document,omitempty"`
+	Photo           []PhotoSize   `json:"photo,omitempty"`
+	Sticker         *Sticker      `json:"sticker,omitempty"`
+	ReplyToMessage  *Message      `json:"reply_to_message,omitempty"`
+	MigrateToChatID int64         `json:"migrate_to_chat_id,omitempty"`
+}
+
+type TelegramUser struct {
+	ID           int64  `json:"id"`
+	IsBot        bool   `json:"is_bot"`
+	FirstName    string `json:"first_name"`
+	Username     string `json:"username,omitempty"`
+	LanguageCode string `json:"language_code,omitempty"`
+}
+
+type Voice struct {
+	FileID       string `json:"file_id"`
+	FileUniqueID string `json:"file_unique_id"`
+	Duration     int    `json:"duration"`
+	MimeType     string `json:"mime_type,omitempty"`
+	FileSize     int64  `json:"file_size,omitempty"`
+}
+
+type Document struct {
+	FileID       string `json:"file_id"`
+	FileUniqueID string `json:"file_unique_id"`
+	FileName     string `json:"file_name,omitempty"`
+	MimeType     string `json:"mime_type,omitempty"`
+	FileSize     int64  `json:"file_size,omitempty"`
+}
+
+type PhotoSize struct {
+	FileID       string `json:"file_id"`
+	FileUniqueID string `json:"file_unique_id"`
+	Width        int    `json:"width"`
+	Height       int    `json:"height"`
+	FileSize     int64  `json:"file_size,omitempty"`
+}
+
+type Sticker struct {
+	FileID       string `json:"file_id"`
+	FileUniqueID string `json:"file_unique_id"`
+	Emoji        string `json:"emoji,omitempty"`
+}
+
+type File struct {
+	FileID       string `json:"file_id"`
+	FileUniqueID string `json:"file_unique_id"`
+	FileSize     int64  `json:"file_size,omitempty"`
+	FilePath     string `json:"file_path,omitempty"`
 }
 
 type Chat struct {
@@ -47,9 +124,29 @@ type Chat struct {
 	Type string `json:"type"`
 }
 
+const (
+	MessageKindText     = "text"
+	MessageKindVoice    = "voice"
+	MessageKindDocument = "document"
+	MessageKindPhoto    = "photo"
+	MessageKindSticker  = "sticker"
+	MessageKindOther    = "other"
+)
+
 type IncomingMessage struct {
-	UpdateID  int64
-	MessageID int64
-	ChatID    int64
-	Text      string
+	UpdateID         int64
+	MessageID        int64
+	ChatID           int64
+	FromUserID       int64
+	Kind             string
+	Text             string
+	ReplyToMessageID int64
+	LanguageCode     string
+	Voice            *Voice
+	Document         *Document
+	Attachment       *GoalAttachment
+	Edited           bool
+	// Transcript is the recognized speech of a voice note. Text holds it
+	// too, after the caption if there is one.
+	Transcript string
 }
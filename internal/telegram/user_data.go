@@ -0,0 +1,425 @@
+package telegram
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+var ErrUserNotFound = errors.New("user not found")
+
+const (
+	ExportFormatJSON = "json"
+	ExportFormatZIP  = "zip"
+)
+
+// UserExport is everything stored about one user, as handed to them by
+// /mydata or to an operator by the admin export endpoint.
+type UserExport struct {
+	ExportedAt time.Time      `json:"exported_at"`
+	User       ExportedUser   `json:"user"`
+	Goals      []ExportedGoal `json:"goals"`
+}
+
+type ExportedUser struct {
+	ID                    string    `json:"id"`
+	TelegramChatID        int64     `json:"telegram_chat_id"`
+	Language              string    `json:"language"`
+	Timezone              string    `json:"timezone"`
+	SessionTimeoutMinutes int       `json:"session_timeout_minutes,omitzero"`
+	CreatedAt             time.Time `json:"created_at,omitzero"`
+}
+
+type ExportedGoal struct {
+	ID          string               `json:"id"`
+	Title       string               `json:"title"`
+	Status      string               `json:"status"`
+	CreatedAt   time.Time            `json:"created_at,omitzero"`
+	Session     *ExportedSession     `json:"session,omitempty"`
+	Profiles    []ExportedProfile    `json:"profiles"`
+	Turns       []ExportedTurn       `json:"turns"`
+	Attachments []ExportedAttachment `json:"attachments"`
+}
+
+type ExportedSession struct {
+	State          PlanningState   `json:"state"`
+	SlotCompletion map[string]bool `json:"slot_completion"`
+	SkippedSlots   []string        `json:"skipped_slots"`
+	TurnCount      int             `json:"turn_count"`
+	UpdatedAt      time.Time       `json:"updated_at,omitzero"`
+}
+
+// ExportedProfile is one version of the plan drafted for a goal.
+type ExportedProfile struct {
+	VersionNo         int             `json:"version_no"`
+	ConfirmationState string          `json:"confirmation_state"`
+	CompletenessScore int             `json:"completeness_score"`
+	Profile           json.RawMessage `json:"profile"`
+	OpenQuestions     json.RawMessage `json:"open_questions"`
+	Markdown          string          `json:"markdown"`
+	CreatedAt         time.Time       `json:"created_at,omitzero"`
+}
+
+type ExportedTurn struct {
+	Role     string `json:"role"`
+	Content  string `json:"content"`
+	Intent   string `json:"intent,omitempty"`
+	Revision int    `json:"revision,omitzero"`
+	Undone   bool   `json:"undone,omitzero"`
+	// Redacted turns hold a masked summary instead of the original text.
+	Redacted  bool      `json:"redacted,omitzero"`
+	CreatedAt time.Time `json:"created_at,omitzero"`
+}
+
+type ExportedAttachment struct {
+	FileName    string    `json:"file_name"`
+	MimeType    string    `json:"mime_type"`
+	FileSize    int64     `json:"file_size"`
+	ContentText string    `json:"content_text,omitempty"`
+	CreatedAt   time.Time `json:"created_at,omitzero"`
+}
+
+// DeletionSummary counts what DeleteUserData removed. It is written to the
+// audit log, so it must never carry anything that identifies the user.
+type DeletionSummary struct {
+	Goals      int64 `json:"goals"`
+	Turns      int64 `json:"turns"`
+	DedupRows  int64 `json:"dedup_rows"`
+	OutboxRows int64 `json:"outbox_rows"`
+}
+
+func (d DeletionSummary) auditPayload() map[string]any {
+	return map[string]any{
+		"goals":       d.Goals,
+		"turns":       d.Turns,
+		"dedup_rows":  d.DedupRows,
+		"outbox_rows": d.OutboxRows,
+	}
+}
+
+// ParseExportFormat accepts "", "json" and "zip"; empty means JSON.
+func ParseExportFormat(raw string) (string, bool) {
+	switch strings.ToLower(strings.TrimSpace(raw)) {
+	case "", ExportFormatJSON:
+		return ExportFormatJSON, true
+	case ExportFormatZIP:
+		return ExportFormatZIP, true
+	default:
+		return "", false
+	}
+}
+
+// EncodeUserExport renders an export as a file. The ZIP holds the same
+// data.json plus each plan as Markdown and each conversation as plain text,
+// which are easier to read than JSON.
+func EncodeUserExport(export UserExport, format string) (fileName string, content []byte, err error) {
+	data, err := json.MarshalIndent(export, "", "  ")
+	if err != nil {
+		return "", nil, fmt.Errorf("marshal user export: %w", err)
+	}
+	baseName := "aiden-export-" + export.ExportedAt.UTC().Format("20060102")
+	if format != ExportFormatZIP {
+		return baseName + ".json", data, nil
+	}
+
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+	files := []struct {
+		name    string
+		content string
+	}{{name: "data.json", content: string(data)}}
+	for i, goal := range export.Goals {
+		for _, profile := range goal.Profiles {
+			if profile.Markdown != "" {
+				files = append(files, struct{ name, content string }{
+					name:    fmt.Sprintf("plans/goal-%d-v%d.md", i+1, profile.VersionNo),
+					content: profile.Markdown,
+				})
+			}
+		}
+		if len(goal.Turns) > 0 {
+			files = append(files, struct{ name, content string }{
+				name:    fmt.Sprintf("conversations/goal-%d.txt", i+1),
+				content: formatExportTranscript(goal.Turns),
+			})
+		}
+	}
+	for _, file := range files {
+		writer, err := archive.CreateHeader(&zip.FileHeader{Name: file.name, Method: zip.Deflate, Modified: export.ExportedAt})
+		if err != nil {
+			return "", nil, fmt.Errorf("add %s to export: %w", file.name, err)
+		}
+		if _, err := writer.Write([]byte(file.content)); err != nil {
+			return "", nil, fmt.Errorf("write %s to export: %w", file.name, err)
+		}
+	}
+	if err := archive.Close(); err != nil {
+		return "", nil, fmt.Errorf("close export archive: %w", err)
+	}
+	return baseName + ".zip", buf.Bytes(), nil
+}
+
+func formatExportTranscript(turns []ExportedTurn) string {
+	var b strings.Builder
+	for _, turn := range turns {
+		if !turn.CreatedAt.IsZero() {
+			b.WriteString(turn.CreatedAt.UTC().Format(time.RFC3339) + " ")
+		}
+		b.WriteString(turn.Role)
+		if turn.Undone {
+			b.WriteString(" (undone)")
+		}
+		if turn.Redacted {
+			b.WriteString(" (redacted)")
+		}
+		b.WriteString(": " + turn.Content + "\n")
+	}
+	return b.String()
+}
+
+func (s *SQLStore) SetUserDeletionRequest(ctx context.Context, userID string, requestedAt *time.Time, confirmations int) error {
+	result, err := s.db.ExecContext(
+		ctx,
+		`UPDATE users
+		 SET deletion_requested_at = $2,
+		     deletion_confirmations = $3,
+		     updated_at = NOW()
+		 WHERE id = $1`,
+		userID,
+		requestedAt,
+		confirmations,
+	)
+	if err != nil {
+		return fmt.Errorf("update deletion request for user id %s: %w", userID, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("read update deletion request rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return ErrUserNotFound
+	}
+	return nil
+}
+
+// ExportUserData collects the user, their goals and, per goal, the session,
+// every plan version, every conversation turn (undone ones included) and the
+// attachments.
+func (s *SQLStore) ExportUserData(ctx context.Context, userID string) (UserExport, bool, error) {
+	export := UserExport{ExportedAt: time.Now().UTC(), Goals: make([]ExportedGoal, 0)}
+	var timeoutMinutes sql.NullInt64
+	err := s.db.QueryRowContext(
+		ctx,
+		`SELECT id, telegram_chat_id, language, timezone, session_timeout_minutes, created_at
+		 FROM users
+		 WHERE id = $1`,
+		userID,
+	).Scan(
+		&export.User.ID,
+		&export.User.TelegramChatID,
+		&export.User.Language,
+		&export.User.Timezone,
+		&timeoutMinutes,
+		&export.User.CreatedAt,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return UserExport{}, false, nil
+	}
+	if err != nil {
+		return UserExport{}, false, fmt.Errorf("query user %s for export: %w", userID, err)
+	}
+	export.User.SessionTimeoutMinutes = int(timeoutMinutes.Int64)
+
+	rows, err := s.db.QueryContext(
+		ctx,
+		`SELECT id, title, status, created_at
+		 FROM goals
+		 WHERE user_id = $1
+		 ORDER BY created_at, id`,
+		userID,
+	)
+	if err != nil {
+		return UserExport{}, false, fmt.Errorf("query goals for export: %w", err)
+	}
+	for rows.Next() {
+		var goal ExportedGoal
+		if err := rows.Scan(&goal.ID, &goal.Title, &goal.Status, &goal.CreatedAt); err != nil {
+			rows.Close()
+			return UserExport{}, false, fmt.Errorf("scan goal for export: %w", err)
+		}
+		export.Goals = append(export.Goals, goal)
+	}
+	if err := rows.Err(); err != nil {
+		rows.Close()
+		return UserExport{}, false, fmt.Errorf("iterate goals for export: %w", err)
+	}
+	rows.Close()
+
+	for i := range export.Goals {
+		if err := s.fillExportedGoal(ctx, &export.Goals[i]); err != nil {
+			return UserExport{}, false, err
+		}
+	}
+	return export, true, nil
+}
+
+func (s *SQLStore) fillExportedGoal(ctx context.Context, goal *ExportedGoal) error {
+	session, err := s.findPlanningSessionByGoalID(ctx, goal.ID)
+	switch {
+	case err == nil:
+		goal.Session = exportedSession(session)
+	case !errors.Is(err, sql.ErrNoRows):
+		return fmt.Errorf("find planning session by goal id %s: %w", goal.ID, err)
+	}
+
+	profiles, err := s.listGoalProfiles(ctx, goal.ID)
+	if err != nil {
+		return err
+	}
+	goal.Profiles = make([]ExportedProfile, 0, len(profiles))
+	for _, profile := range profiles {
+		goal.Profiles = append(goal.Profiles, ExportedProfile{
+			VersionNo:         profile.VersionNo,
+			ConfirmationState: profile.ConfirmationState,
+			CompletenessScore: profile.CompletenessScore,
+			Profile:           profile.Profile,
+			OpenQuestions:     profile.OpenQuestions,
+			Markdown:          profile.Markdown,
+			CreatedAt:         profile.CreatedAt,
+		})
+	}
+
+	goal.Turns = make([]ExportedTurn, 0)
+	if goal.Session != nil {
+		rows, err := s.db.QueryContext(
+			ctx,
+			`SELECT role, content, intent, revision, undone_at IS NOT NULL, redacted_at IS NOT NULL, created_at
+			 FROM conversation_turns
+			 WHERE session_id = $1
+			 ORDER BY created_at, id`,
+			session.ID,
+		)
+		if err != nil {
+			return fmt.Errorf("query turns for export: %w", err)
+		}
+		defer rows.Close()
+		for rows.Next() {
+			var turn ExportedTurn
+			if err := rows.Scan(&turn.Role, &turn.Content, &turn.Intent, &turn.Revision, &turn.Undone, &turn.Redacted, &turn.CreatedAt); err != nil {
+				return fmt.Errorf("scan turn for export: %w", err)
+			}
+			goal.Turns = append(goal.Turns, turn)
+		}
+		if err := rows.Err(); err != nil {
+			return fmt.Errorf("iterate turns for export: %w", err)
+		}
+		rows.Close()
+	}
+
+	rows, err := s.db.QueryContext(
+		ctx,
+		`SELECT file_name, mime_type, file_size, content_text, created_at
+		 FROM goal_attachments
+		 WHERE goal_id = $1
+		 ORDER BY created_at, id`,
+		goal.ID,
+	)
+	if err != nil {
+		return fmt.Errorf("query attachments for export: %w", err)
+	}
+	defer rows.Close()
+	goal.Attachments = make([]ExportedAttachment, 0)
+	for rows.Next() {
+		var attachment ExportedAttachment
+		if err := rows.Scan(&attachment.FileName, &attachment.MimeType, &attachment.FileSize, &attachment.ContentText, &attachment.CreatedAt); err != nil {
+			return fmt.Errorf("scan attachment for export: %w", err)
+		}
+		goal.Attachments = append(goal.Attachments, attachment)
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("iterate attachments for export: %w", err)
+	}
+	return nil
+}
+
+func exportedSession(session PlanningSession) *ExportedSession {
+	skipped := session.SkippedSlots
+	if skipped == nil {
+		skipped = []string{}
+	}
+	return &ExportedSession{
+		State:          session.State,
+		SlotCompletion: NormalizeSlotCompletion(session.SlotCompletion),
+		SkippedSlots:   skipped,
+		TurnCount:      session.TurnCount,
+		UpdatedAt:      session.UpdatedAt,
+	}
+}
+
+// DeleteUserData erases a user. Goals, plans, sessions, turns and attachments
+// go with the users row through ON DELETE CASCADE; message_dedup and
+// outgoing_messages only know the chat ID and are scrubbed by it. The audit
+// entry is written in the same transaction with the counts as payload, as a
+// tombstone that says a deletion happened without saying whose data it was.
+func (s *SQLStore) DeleteUserData(ctx context.Context, userID string, audit AuditEntry) (DeletionSummary, error) {
+	var summary DeletionSummary
+	err := s.withinSQLTx(ctx, func(tx *SQLStore) error {
+		var chatID int64
+		err := tx.db.QueryRowContext(
+			ctx,
+			`SELECT telegram_chat_id FROM users WHERE id = $1 FOR UPDATE`,
+			userID,
+		).Scan(&chatID)
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrUserNotFound
+		}
+		if err != nil {
+			return fmt.Errorf("lock user %s: %w", userID, err)
+		}
+
+		if err := tx.db.QueryRowContext(
+			ctx,
+			`SELECT (SELECT COUNT(*) FROM goals WHERE user_id = $1),
+			        (SELECT COUNT(*)
+			         FROM conversation_turns t
+			         JOIN planning_sessions ps ON ps.id = t.session_id
+			         JOIN goals g ON g.id = ps.goal_id
+			         WHERE g.user_id = $1)`,
+			userID,
+		).Scan(&summary.Goals, &summary.Turns); err != nil {
+			return fmt.Errorf("count user data: %w", err)
+		}
+
+		if _, err := tx.db.ExecContext(ctx, `DELETE FROM users WHERE id = $1`, userID); err != nil {
+			return fmt.Errorf("delete user: %w", err)
+		}
+		if summary.DedupRows, err = tx.execCount(ctx, `DELETE FROM message_dedup WHERE chat_id = $1`, chatID); err != nil {
+			return fmt.Errorf("scrub message dedup: %w", err)
+		}
+		if summary.OutboxRows, err = tx.execCount(ctx, `DELETE FROM outgoing_messages WHERE chat_id = $1`, chatID); err != nil {
+			return fmt.Errorf("scrub outgoing messages: %w", err)
+		}
+
+		audit.Payload = summary.auditPayload()
+		return tx.RecordAudit(ctx, audit)
+	})
+	if err != nil {
+		return DeletionSummary{}, err
+	}
+	return summary, nil
+}
+
+func (s *SQLStore) execCount(ctx context.Context, query string, args ...any) (int64, error) {
+	result, err := s.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
@@ -2,10 +2,14 @@ package telegram
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
 	"strings"
+	"sync/atomic"
 	"time"
+
+	"github.com/congregalis/aiden/internal/flags"
 )
 
 const (
@@ -18,19 +22,57 @@ type WorkerConfig struct {
 	PollTimeoutSec int
 	PollInterval   time.Duration
 	AllowedUpdates []string
+	Outbox         OutboxConfig
+	RateLimit      RateLimitConfig
+	Transcriber    Transcriber
+	Session        SessionConfig
+	// Rules classifies free text into intents and slots; nil uses the
+	// embedded defaults.
+	Rules *RuleSet
+	// MaxDownloadBytes caps voice and document downloads; Telegram bots cannot
+	// fetch files larger than 20 MB anyway.
+	MaxDownloadBytes int64
+	// Flags decides which users get features still being rolled out; nil
+	// leaves them all off.
+	Flags *flags.Evaluator
+	// SlotExtractor and PlanGenerator back the llm_extraction and
+	// plan_generation flags; a flag without its provider has no effect.
+	SlotExtractor SlotExtractor
+	PlanGenerator PlanGenerator
+	// Now overrides the clock used for session timeouts and outbox retries;
+	// nil means time.Now. The chat REPL uses it to fake time.
+	Now func() time.Time
+}
+
+// RuntimeConfig is the part of WorkerConfig that can change while the worker
+// runs. A change applies from the next poll; the one in flight is kept.
+type RuntimeConfig struct {
+	PollInterval   time.Duration
+	AllowedUpdates []string
+	RateLimit      RateLimitConfig
 }
 
 type Worker struct {
-	client         Client
-	store          Store
-	router         Router
-	intentRouter   IntentRouter
-	sender         Sender
-	logger         *slog.Logger
-	pollTimeoutSec int
-	pollInterval   time.Duration
-	allowedUpdates []string
-	metrics        *PollingMetrics
+	client           Client
+	store            Store
+	router           Router
+	intentRouter     IntentRouter
+	rules            *RuleSet
+	sender           Sender
+	limiter          *RateLimiter
+	dispatcher       *OutboxDispatcher
+	transcriber      Transcriber
+	extractor        SlotExtractor
+	planner          PlanGenerator
+	logger           *slog.Logger
+	pollTimeoutSec   int
+	runtime          atomic.Pointer[RuntimeConfig]
+	metrics          *PollingMetrics
+	health           *workerHealth
+	maxDownloadBytes int64
+	sessionTimeout   time.Duration
+	flags            *flags.Evaluator
+	now              func() time.Time
 }
 
 func NewWorker(cfg WorkerConfig, client Client, store Store, logger *slog.Logger) *Worker {
@@ -38,18 +80,63 @@ func NewWorker(cfg WorkerConfig, client Client, store Store, logger *slog.Logger
 		logger = slog.Default()
 	}
 
-	return &Worker{
-		client:         client,
-		store:          store,
-		router:         NewRouter(),
-		intentRouter:   NewIntentRouter(),
-		sender:         NewSender(client, logger),
-		logger:         logger,
-		pollTimeoutSec: cfg.PollTimeoutSec,
-		pollInterval:   cfg.PollInterval,
-		allowedUpdates: cfg.AllowedUpdates,
-		metrics:        &PollingMetrics{},
-	}
+	maxDownloadBytes := cfg.MaxDownloadBytes
+	if maxDownloadBytes <= 0 {
+		maxDownloadBytes = defaultMaxDownloadBytes
+	}
+
+	sessionTimeout := cfg.Session.Timeout
+	if sessionTimeout <= 0 {
+		sessionTimeout = defaultSessionTimeout
+	}
+
+	now := cfg.Now
+	if now == nil {
+		now = time.Now
+	}
+
+	limiter := newRateLimiter(cfg.RateLimit)
+	sender := NewSender(client, logger).WithRateLimiter(limiter)
+	dispatcher := NewOutboxDispatcher(cfg.Outbox, store, sender, logger)
+	dispatcher.now = now
+
+	health := &workerHealth{}
+	health.beat(now())
+
+	worker := &Worker{
+		client:           client,
+		store:            store,
+		router:           NewRouter(),
+		intentRouter:     NewIntentRouter(cfg.Rules),
+		rules:            cfg.Rules,
+		sender:           sender,
+		limiter:          limiter,
+		dispatcher:       dispatcher,
+		transcriber:      cfg.Transcriber,
+		extractor:        cfg.SlotExtractor,
+		planner:          cfg.PlanGenerator,
+		logger:           logger,
+		pollTimeoutSec:   cfg.PollTimeoutSec,
+		metrics:          &PollingMetrics{},
+		health:           health,
+		maxDownloadBytes: maxDownloadBytes,
+		sessionTimeout:   sessionTimeout,
+		flags:            cfg.Flags,
+		now:              now,
+	}
+	worker.runtime.Store(&RuntimeConfig{
+		PollInterval:   cfg.PollInterval,
+		AllowedUpdates: cfg.AllowedUpdates,
+		RateLimit:      cfg.RateLimit,
+	})
+	return worker
+}
+
+// ApplyRuntimeConfig swaps in new runtime settings without restarting the
+// polling loop.
+func (w *Worker) ApplyRuntimeConfig(cfg RuntimeConfig) {
+	w.limiter.SetLimits(cfg.RateLimit)
+	w.runtime.Store(&cfg)
 }
 
 func (w *Worker) Run(ctx context.Context) error {
@@ -68,6 +155,17 @@ func (w *Worker) Run(ctx context.Context) error {
 		return fmt.Errorf("load last update id failed: %w", err)
 	}
 
+	dispatchCtx, stopDispatch := context.WithCancel(ctx)
+	dispatchDone := make(chan struct{})
+	go func() {
+		defer close(dispatchDone)
+		w.dispatcher.Run(dispatchCtx)
+	}()
+	defer func() {
+		stopDispatch()
+		<-dispatchDone
+	}()
+
 	w.logger.Info("telegram polling worker started",
 		slog.Int64("last_update_id", lastUpdateID),
 		slog.Int("poll_timeout_sec", w.pollTimeoutSec),
@@ -81,11 +179,13 @@ func (w *Worker) Run(ctx context.Context) error {
 			return nil
 		default:
 		}
+		w.health.beat(w.now())
 
+		runtime := w.runtime.Load()
 		updates, err := w.client.GetUpdates(ctx, GetUpdatesParams{
 			Offset:         lastUpdateID + 1,
 			TimeoutSec:     w.pollTimeoutSec,
-			AllowedUpdates: w.allowedUpdates,
+			AllowedUpdates: runtime.AllowedUpdates,
 		})
 		if err != nil {
 			if ctx.Err() != nil {
@@ -94,6 +194,7 @@ func (w *Worker) Run(ctx context.Context) error {
 			}
 
 			failureStreak++
+			w.health.recordPoll(failureStreak)
 			successCount, failureCount := w.metrics.RecordFailure()
 			backoff := pollingFailureBackoff(failureStreak)
 			w.logger.Warn("polling_cycle_failed",
@@ -112,15 +213,17 @@ func (w *Worker) Run(ctx context.Context) error {
 		}
 
 		failureStreak = 0
+		w.health.recordPoll(failureStreak)
 		successCount, failureCount := w.metrics.RecordSuccess()
 		w.logger.Info("polling_cycle_succeeded",
 			slog.Int("updates_count", len(updates)),
 			slog.Uint64("polling_success_count", successCount),
 			slog.Uint64("polling_failure_count", failureCount),
+			slog.Int64("send_queue_depth", w.limiter.QueueDepth()),
 		)
 
 		if len(updates) == 0 {
-			if !sleepWithContext(ctx, w.pollInterval) {
+			if !sleepWithContext(ctx, runtime.PollInterval) {
 				w.logger.Info("telegram polling worker stopped")
 				return nil
 			}
@@ -134,6 +237,7 @@ func (w *Worker) Run(ctx context.Context) error {
 					slog.Any("error", err),
 				)
 			}
+			w.health.beat(w.now())
 
 			if update.UpdateID > lastUpdateID {
 				if err := w.store.SaveLastUpdateID(ctx, update.UpdateID); err != nil {
@@ -145,7 +249,28 @@ func (w *Worker) Run(ctx context.Context) error {
 	}
 }
 
+// WorkerStats counts polling outcomes and outbound rate limiting since start.
+type WorkerStats struct {
+	PollSuccesses uint64 `json:"poll_successes"`
+	PollFailures  uint64 `json:"poll_failures"`
+	// SendQueueDepth is how many sends are waiting for the rate limiter now.
+	SendQueueDepth int64  `json:"send_queue_depth"`
+	SendWaits      uint64 `json:"send_waits"`
+	SendPauses     uint64 `json:"send_pauses"`
+}
+
+func (w *Worker) Stats() WorkerStats {
+	var stats WorkerStats
+	stats.PollSuccesses, stats.PollFailures = w.metrics.Snapshot()
+	stats.SendQueueDepth, stats.SendWaits, stats.SendPauses = w.limiter.Metrics().Snapshot()
+	return stats
+}
+
 func (w *Worker) handleUpdate(ctx context.Context, update Update) error {
+	if update.Message != nil && update.Message.MigrateToChatID != 0 {
+		return w.handleChatMigration(ctx, update.Message.Chat.ID, update.Message.MigrateToChatID)
+	}
+
 	message, ok := MapUpdateToIncomingMessage(update)
 	if !ok {
 		w.logger.Info("skip non-message update",
@@ -158,13 +283,16 @@ func (w *Worker) handleUpdate(ctx context.Context, update Update) error {
 	w.logger.Info("telegram_update_received",
 		slog.Int64("update_id", message.UpdateID),
 		slog.String("chat_id_masked", chatIDMasked),
+		slog.String("message_kind", message.Kind),
 	)
 
-	isNew, err := w.store.MarkMessageDedup(ctx, message.UpdateID, message.ChatID)
+	// Downloads and transcription are slow and billed, so a redelivered update
+	// is dropped before touching them.
+	processed, err := w.store.IsUpdateProcessed(ctx, message.UpdateID)
 	if err != nil {
-		return fmt.Errorf("message dedup failed: %w", err)
+		return fmt.Errorf("message dedup check failed: %w", err)
 	}
-	if !isNew {
+	if processed {
 		w.logger.Info("duplicate_message_skipped",
 			slog.Int64("update_id", message.UpdateID),
 			slog.String("chat_id_masked", chatIDMasked),
@@ -172,67 +300,202 @@ func (w *Worker) handleUpdate(ctx context.Context, update Update) error {
 		return nil
 	}
 
-	if strings.TrimSpace(message.Text) == "" {
-		return w.sender.Send(ctx, OutgoingMessage{
+	message = w.prepareMedia(ctx, message)
+
+	queued := false
+	err = w.store.WithinTx(ctx, func(store Store) error {
+		isNew, err := store.MarkMessageDedup(ctx, message.UpdateID, message.ChatID)
+		if err != nil {
+			return fmt.Errorf("message dedup failed: %w", err)
+		}
+		if !isNew {
+			w.logger.Info("duplicate_message_skipped",
+				slog.Int64("update_id", message.UpdateID),
+				slog.String("chat_id_masked", chatIDMasked),
+			)
+			return nil
+		}
+
+		reply, err := w.replyForMessage(ctx, store, message)
+		if err != nil {
+			return err
+		}
+		if format, ok := myDataRequest(message); ok {
+			if err := enqueueUserExport(ctx, store, message, format); err != nil {
+				return err
+			}
+			queued = true
+		}
+		if reply == "" {
+			return nil
+		}
+
+		if _, err := store.EnqueueOutgoingMessage(ctx, OutgoingMessage{
 			ChatID:           message.ChatID,
-			Text:             ReplyNonText,
+			Text:             reply,
 			ReplyToMessageID: message.MessageID,
-		})
+		}); err != nil {
+			return fmt.Errorf("enqueue outgoing message: %w", err)
+		}
+		queued = true
+		return nil
+	})
+	if err != nil {
+		return err
 	}
 
-	user, isNewUser, err := w.store.FindOrCreateUserByChatID(ctx, message.ChatID)
+	if queued {
+		w.dispatcher.Notify()
+	}
+	return nil
+}
+
+func (w *Worker) handleChatMigration(ctx context.Context, fromChatID, toChatID int64) error {
+	err := w.store.MigrateChatID(ctx, fromChatID, toChatID)
+	if errors.Is(err, ErrChatIDTaken) {
+		w.logger.Error("telegram_chat_migration_conflict",
+			slog.String("chat_id_masked", MaskChatID(fromChatID)),
+			slog.String("new_chat_id_masked", MaskChatID(toChatID)),
+		)
+		return nil
+	}
 	if err != nil {
-		return fmt.Errorf("find or create user by chat id: %w", err)
+		return fmt.Errorf("migrate chat id: %w", err)
+	}
+
+	w.logger.Info("telegram_chat_migrated",
+		slog.String("chat_id_masked", MaskChatID(fromChatID)),
+		slog.String("new_chat_id_masked", MaskChatID(toChatID)),
+	)
+	return nil
+}
+
+func (w *Worker) replyForMessage(ctx context.Context, store Store, message IncomingMessage) (string, error) {
+	if message.Edited {
+		return w.replyForEditedMessage(ctx, store, message)
+	}
+	if message.Attachment == nil && strings.TrimSpace(message.Text) == "" {
+		return replyForUnreadableMessage(languageHint(message), message), nil
+	}
+
+	user, isNewUser, err := store.FindOrCreateUserByChatID(ctx, message.ChatID)
+	if err != nil {
+		return "", fmt.Errorf("find or create user by chat id: %w", err)
+	}
+	if isNewUser {
+		if user, err = adoptClientLanguage(ctx, store, user, message); err != nil {
+			return "", err
+		}
+	}
+	if !user.IsReachable {
+		if err := store.MarkChatReachable(ctx, message.ChatID); err != nil {
+			return "", fmt.Errorf("mark chat reachable: %w", err)
+		}
+		user.IsReachable = true
+	}
+
+	if message.Attachment != nil {
+		return w.attachToGoal(ctx, store, user, message)
+	}
+
+	if control, ok := w.intentRouter.RouteControl(message.Text); ok {
+		return w.handleSessionControl(ctx, store, user, message, control)
 	}
 
 	command := ParseCommand(message.Text)
-	reply := ReplyNaturalMessage
-	if command.IsCommand {
+	if command.Name == "goals" || (command.Name == "goal" && len(command.Args) > 0) {
+		return w.handleGoalCommand(ctx, store, user, message, command)
+	}
+	if command.IsCommand && command.Name != "goal" {
 		switch command.Name {
 		case "start":
-			reply = w.router.ReplyForStart(isNewUser)
-		case "goal":
-			goal, err := w.ensureActiveGoal(ctx, user, message.ChatID)
-			if err != nil {
-				return err
-			}
-			reply, err = w.handleClarifyRound(ctx, message, goal)
-			if err != nil {
-				return err
-			}
+			return w.router.ReplyForStart(user.Language, isNewUser), nil
 		case "help":
-			reply = ReplyHelp
+			return tr(user.Language, MsgHelp), nil
+		case "timeout":
+			return w.handleTimeoutCommand(ctx, store, user, command)
+		case "lang":
+			return w.handleLanguageCommand(ctx, store, user, command)
+		case "mydata":
+			return replyForMyData(user, command), nil
+		case "deleteme":
+			return w.handleDeleteMe(ctx, store, user, command)
 		default:
-			reply = ReplyUnknownCommand
-		}
-	} else {
-		goal, err := w.ensureActiveGoal(ctx, user, message.ChatID)
-		if err != nil {
-			return err
-		}
-		reply, err = w.handleClarifyRound(ctx, message, goal)
-		if err != nil {
-			return err
+			return tr(user.Language, MsgUnknownCommand), nil
 		}
 	}
 
-	return w.sender.Send(ctx, OutgoingMessage{
-		ChatID:           message.ChatID,
-		Text:             reply,
-		ReplyToMessageID: message.MessageID,
-	})
+	goal, err := w.ensureCurrentGoal(ctx, store, user, message.ChatID)
+	if err != nil {
+		return "", err
+	}
+
+	reply, err := w.handleClarifyRound(ctx, store, user, message, goal)
+	if err != nil {
+		return "", err
+	}
+	if message.Transcript != "" {
+		reply = tr(user.Language, MsgVoiceTranscribed, message.Transcript) + "\n" + reply
+	}
+	return reply, nil
+}
+
+func replyForUnreadableMessage(lang string, message IncomingMessage) string {
+	switch message.Kind {
+	case MessageKindVoice:
+		return tr(lang, MsgVoiceUnavailable)
+	case MessageKindDocument:
+		return tr(lang, MsgDocumentUnsupported)
+	default:
+		return tr(lang, MsgNonText)
+	}
 }
 
-func (w *Worker) ensureActiveGoal(ctx context.Context, user User, chatID int64) (Goal, error) {
-	goal, found, err := w.store.GetActiveGoalByUserID(ctx, user.ID)
+func (w *Worker) attachToGoal(ctx context.Context, store Store, user User, message IncomingMessage) (string, error) {
+	goal, err := w.ensureCurrentGoal(ctx, store, user, message.ChatID)
 	if err != nil {
-		return Goal{}, fmt.Errorf("get active goal by user id: %w", err)
+		return "", err
+	}
+
+	attachment := *message.Attachment
+	attachment.GoalID = goal.ID
+	if err := store.SaveGoalAttachment(ctx, attachment); err != nil {
+		return "", fmt.Errorf("save goal attachment: %w", err)
+	}
+
+	w.logger.Info("goal_attachment_saved",
+		slog.String("goal_id", goal.ID),
+		slog.String("attachment_kind", attachment.Kind),
+		slog.String("mime_type", attachment.MimeType),
+		slog.Int64("file_size", attachment.FileSize),
+		slog.String("chat_id_masked", MaskChatID(message.ChatID)),
+	)
+
+	reply := tr(user.Language, MsgDocumentAttachedNoName)
+	if attachment.FileName != "" {
+		reply = tr(user.Language, MsgDocumentAttached, attachment.FileName)
+	}
+	if strings.TrimSpace(message.Text) == "" {
+		return reply, nil
+	}
+
+	clarifyReply, err := w.handleClarifyRound(ctx, store, user, message, goal)
+	if err != nil {
+		return "", err
+	}
+	return reply + "\n\n" + clarifyReply, nil
+}
+
+func (w *Worker) ensureCurrentGoal(ctx context.Context, store Store, user User, chatID int64) (Goal, error) {
+	goal, found, err := store.GetCurrentGoalByUserID(ctx, user.ID)
+	if err != nil {
+		return Goal{}, fmt.Errorf("get current goal by user id: %w", err)
 	}
 	if found {
 		return goal, nil
 	}
 
-	createdGoal, err := w.store.CreateGoalDraft(ctx, user.ID)
+	createdGoal, err := store.CreateGoalDraft(ctx, user.ID)
 	if err != nil {
 		return Goal{}, fmt.Errorf("create goal draft: %w", err)
 	}
@@ -247,57 +510,104 @@ func (w *Worker) ensureActiveGoal(ctx context.Context, user User, chatID int64)
 	return createdGoal, nil
 }
 
-func (w *Worker) handleClarifyRound(ctx context.Context, message IncomingMessage, goal Goal) (string, error) {
-	session, _, err := w.store.GetOrCreatePlanningSession(ctx, goal.ID)
+func (w *Worker) handleClarifyRound(ctx context.Context, store Store, user User, message IncomingMessage, goal Goal) (string, error) {
+	session, _, err := store.GetOrCreatePlanningSession(ctx, goal.ID)
 	if err != nil {
 		return "", fmt.Errorf("get or create planning session: %w", err)
 	}
 
-	timeoutNotice := ""
-	if shouldResetSessionForTimeout(session.UpdatedAt) && !session.State.IsFinal() {
-		timeoutNotice = ReplySessionTimeout
+	lang := user.Language
+	notices := make([]string, 0, 2)
+	if session.PendingAction == PendingActionReset {
+		intent := w.intentRouter.Route(message.Text, session.State)
+		if intent.Intent == IntentConfirmPlan {
+			return w.resetGoal(ctx, store, lang, message, goal, session, intent)
+		}
+		session.PendingAction = ""
+		notices = append(notices, tr(lang, MsgResetCancelled))
+	}
+
+	if isSessionExpired(session.UpdatedAt, w.sessionTimeoutFor(user), w.now()) && !session.State.IsFinal() {
+		recentTurns, err := store.ListRecentTurns(ctx, session.ID, sessionRecapTurns)
+		if err != nil {
+			return "", fmt.Errorf("list recent turns: %w", err)
+		}
+		notices = append(notices, BuildSessionRecap(lang, session, recentTurns))
 		session.State = StateClarifying
-		session.LastIntent = ""
-		session.TurnCount = 0
-		if err := w.store.UpdatePlanningSession(ctx, session); err != nil {
-			return "", fmt.Errorf("reset planning session after timeout: %w", err)
+		if err := store.UpdatePlanningSession(ctx, session); err != nil {
+			return "", fmt.Errorf("resume planning session after timeout: %w", err)
 		}
+		w.logger.Info("planning_session_resumed",
+			slog.String("session_id", session.ID),
+			slog.Int("turn_count", session.TurnCount),
+		)
 	}
 
 	intent := w.intentRouter.Route(message.Text, session.State)
 
-	turnCount, err := w.store.IncrementPlanningSessionTurn(ctx, session.ID)
+	turnCount, err := store.IncrementPlanningSessionTurn(ctx, session.ID)
 	if err != nil {
 		return "", fmt.Errorf("increment planning session turn: %w", err)
 	}
 	session.TurnCount = turnCount
 
-	if err := w.store.SaveConversationTurn(ctx, ConversationTurn{
-		SessionID:        session.ID,
-		Role:             ConversationRoleUser,
-		Content:          message.Text,
-		Intent:           intent.Intent,
-		IntentConfidence: &intent.Confidence,
+	if err := store.SaveConversationTurn(ctx, ConversationTurn{
+		SessionID:         session.ID,
+		Role:              ConversationRoleUser,
+		Content:           message.Text,
+		Intent:            intent.Intent,
+		IntentConfidence:  &intent.Confidence,
+		IntentRanking:     intent.Ranking,
+		TelegramMessageID: message.MessageID,
 	}); err != nil {
 		return "", fmt.Errorf("save user conversation turn: %w", err)
 	}
 
-	reply, updatedSession := w.buildClarifyReply(session, message.Text, intent)
-	if timeoutNotice != "" {
-		reply = timeoutNotice + "\n\n" + reply
+	flagCtx, evaluations := flags.WithRecorder(ctx)
+	updateSlots := w.rules.Current().UpdateSlotCompletion
+	if intent.Intent == IntentClarifyGoal && w.flags.Enabled(flagCtx, user.ID, flags.LLMExtraction) && w.extractor != nil {
+		slots, err := w.extractor.ExtractSlots(ctx, message.Text)
+		if err != nil {
+			w.logger.Warn("slot_extraction_failed",
+				slog.String("session_id", session.ID),
+				slog.Any("error", err),
+			)
+		} else {
+			updateSlots = extractedSlotUpdate(slots)
+		}
+	}
+
+	reply, updatedSession := w.buildClarifyReply(lang, session, message.Text, intent, updateSlots)
+	if updatedSession.State == StateReview && session.State != StateReview &&
+		w.flags.Enabled(flagCtx, user.ID, flags.PlanGeneration) && w.planner != nil {
+		plan, err := w.planner.GeneratePlan(ctx, lang, updatedSession)
+		if err != nil {
+			w.logger.Warn("plan_generation_failed",
+				slog.String("session_id", session.ID),
+				slog.Any("error", err),
+			)
+		} else if plan != "" {
+			reply = reply + "\n\n" + plan
+		}
+	}
+	if len(notices) > 0 {
+		reply = strings.Join(notices, "\n") + "\n\n" + reply
 	}
 	if updatedSession.TurnCount > 0 &&
 		updatedSession.TurnCount%3 == 0 &&
 		!updatedSession.State.IsFinal() &&
-		!strings.Contains(reply, "【当前摘要】") {
-		reply = reply + "\n\n" + BuildProgressSummary(updatedSession.SlotCompletion)
+		!strings.Contains(reply, tr(lang, MsgProgressSummaryTitle)) {
+		reply = reply + "\n\n" + BuildProgressSummary(lang, updatedSession.SlotCompletion)
 	}
 
-	if err := w.store.UpdatePlanningSession(ctx, updatedSession); err != nil {
+	if err := store.UpdatePlanningSession(ctx, updatedSession); err != nil {
 		return "", fmt.Errorf("update planning session: %w", err)
 	}
+	if err := w.syncGoalWithRound(ctx, store, goal, message.Text, intent, updatedSession); err != nil {
+		return "", err
+	}
 
-	if err := w.store.SaveConversationTurn(ctx, ConversationTurn{
+	if err := store.SaveConversationTurn(ctx, ConversationTurn{
 		SessionID: updatedSession.ID,
 		Role:      ConversationRoleAssistant,
 		Content:   reply,
@@ -306,10 +616,103 @@ func (w *Worker) handleClarifyRound(ctx context.Context, message IncomingMessage
 		return "", fmt.Errorf("save assistant conversation turn: %w", err)
 	}
 
+	if payload := evaluations.Payload(); payload != nil {
+		if err := store.RecordActionLog(ctx, ActionLog{
+			GoalID: goal.ID,
+			Action: ActionClarifyRound,
+			Status: ActionStatusOK,
+			Payload: map[string]any{
+				"intent": intent.Intent,
+				"state":  string(updatedSession.State),
+				"flags":  payload,
+			},
+		}); err != nil {
+			return "", fmt.Errorf("record clarify round action log: %w", err)
+		}
+	}
+
+	return reply, nil
+}
+
+// replyForEditedMessage re-runs slot extraction when the user edits their
+// latest turn in the active session. Edits to older messages are not replayed
+// and get no reply.
+func (w *Worker) replyForEditedMessage(ctx context.Context, store Store, message IncomingMessage) (string, error) {
+	if strings.TrimSpace(message.Text) == "" || ParseCommand(message.Text).IsCommand {
+		return "", nil
+	}
+
+	user, _, err := store.FindOrCreateUserByChatID(ctx, message.ChatID)
+	if err != nil {
+		return "", fmt.Errorf("find or create user by chat id: %w", err)
+	}
+	goal, found, err := store.GetCurrentGoalByUserID(ctx, user.ID)
+	if err != nil {
+		return "", fmt.Errorf("get current goal by user id: %w", err)
+	}
+	if !found {
+		return "", nil
+	}
+	session, _, err := store.GetOrCreatePlanningSession(ctx, goal.ID)
+	if err != nil {
+		return "", fmt.Errorf("get or create planning session: %w", err)
+	}
+
+	turn, found, err := store.GetLatestUserTurn(ctx, session.ID)
+	if err != nil {
+		return "", fmt.Errorf("get latest user turn: %w", err)
+	}
+	if !found || turn.TelegramMessageID != message.MessageID {
+		w.logger.Info("edited_message_ignored",
+			slog.Int64("update_id", message.UpdateID),
+			slog.String("chat_id_masked", MaskChatID(message.ChatID)),
+		)
+		return "", nil
+	}
+
+	intent := w.intentRouter.Route(message.Text, session.State)
+	turn.Content = message.Text
+	turn.Intent = intent.Intent
+	turn.IntentConfidence = &intent.Confidence
+	turn.IntentRanking = intent.Ranking
+	revision, err := store.ReviseConversationTurn(ctx, turn)
+	if err != nil {
+		return "", fmt.Errorf("revise conversation turn: %w", err)
+	}
+
+	turns, err := store.ListUserTurns(ctx, session.ID)
+	if err != nil {
+		return "", fmt.Errorf("list user turns: %w", err)
+	}
+
+	session.SlotCompletion = RecomputeSlotCompletion(w.rules.Current(), turns, session.SkippedSlots)
+	session.LastIntent = intent.Intent
+	reply, updated := replyForRecomputedSlots(user.Language, session, tr(user.Language, MsgEditApplied))
+
+	if err := store.UpdatePlanningSession(ctx, updated); err != nil {
+		return "", fmt.Errorf("update planning session: %w", err)
+	}
+	if err := store.SaveConversationTurn(ctx, ConversationTurn{
+		SessionID: updated.ID,
+		Role:      ConversationRoleAssistant,
+		Content:   reply,
+		Intent:    intent.Intent,
+	}); err != nil {
+		return "", fmt.Errorf("save assistant conversation turn: %w", err)
+	}
+
+	w.logger.Info("edited_turn_revised",
+		slog.Int64("update_id", message.UpdateID),
+		slog.String("session_id", updated.ID),
+		slog.Int("revision", revision),
+		slog.String("state", string(updated.State)),
+	)
 	return reply, nil
 }
 
-func (w *Worker) buildClarifyReply(session PlanningSession, text string, intent IntentResult) (string, PlanningSession) {
+// buildClarifyReply applies updateSlots to a clarification message and picks
+// the reply for the resulting state.
+func (w *Worker) buildClarifyReply(lang string, session PlanningSession, text string, intent IntentResult, updateSlots func(map[string]bool, string) map[string]bool) (string, PlanningSession) {
 	updated := session
 	updated.State = ParsePlanningState(string(updated.State))
 	if updated.State == StateIdle {
@@ -323,64 +726,83 @@ func (w *Worker) buildClarifyReply(session PlanningSession, text string, intent
 
 	if isGoalCommand {
 		updated.State = StateClarifying
-		return ReplyGoal, updated
+		return tr(lang, MsgGoal), updated
+	}
+
+	if intent.Intent == IntentDisambiguate {
+		return disambiguationPrompt(lang, intent.Ranking), updated
 	}
 
 	shouldExtractSlots := intent.Intent == IntentClarifyGoal
 	if shouldExtractSlots {
-		updated.SlotCompletion = UpdateSlotCompletionFromText(updated.SlotCompletion, text)
+		updated.SlotCompletion = updateSlots(updated.SlotCompletion, text)
 	}
 
 	switch updated.State {
 	case StateReview:
 		if intent.Intent == IntentConfirmPlan {
 			updated.State = StateConfirmed
-			return ReplyPlanConfirmed, updated
+			return tr(lang, MsgPlanConfirmed), updated
 		}
 		if intent.Intent == IntentClarifyGoal {
 			updated.State = StateClarifying
-			questions := BuildFollowUpQuestions(MissingRequiredSlots(updated.SlotCompletion), 2)
+			questions := BuildFollowUpQuestions(lang, MissingRequiredSlots(updated.SlotCompletion), 2)
 			if len(questions) == 0 {
-				return "已收到修改意见，我已切回 clarifying。请补充你希望调整的重点。", updated
+				return tr(lang, MsgReviewReopenedNoQuestion), updated
 			}
-			return "已收到修改意见，我已切回 clarifying。\n" + FormatFollowUpQuestions(questions), updated
+			return tr(lang, MsgReviewReopened) + "\n" + FormatFollowUpQuestions(lang, questions), updated
 		}
-		return ReplyReviewFallback, updated
+		return tr(lang, MsgReviewFallback), updated
 
 	case StateConfirmed:
 		if intent.Intent == IntentClarifyGoal {
 			updated.State = StateClarifying
-			questions := BuildFollowUpQuestions(MissingRequiredSlots(updated.SlotCompletion), 1)
+			questions := BuildFollowUpQuestions(lang, MissingRequiredSlots(updated.SlotCompletion), 1)
 			if len(questions) == 0 {
-				return "我已重新打开澄清会话，请告诉我你想调整的目标内容。", updated
+				return tr(lang, MsgSessionReopenedNoQuestion), updated
 			}
-			return "我已重新打开澄清会话。\n" + FormatFollowUpQuestions(questions), updated
+			return tr(lang, MsgSessionReopened) + "\n" + FormatFollowUpQuestions(lang, questions), updated
 		}
-		return ReplyPlanConfirmed, updated
+		return tr(lang, MsgPlanConfirmed), updated
 	}
 
 	if intent.Intent == IntentFallbackUnknown {
-		return ReplyFallbackGuidance, updated
+		return tr(lang, MsgFallbackGuidance), updated
 	}
 
 	if IsRequiredSlotsComplete(updated.SlotCompletion) {
 		updated.State = StateReview
-		return ReplyReviewReady + "\n\n" + BuildProgressSummary(updated.SlotCompletion), updated
+		return tr(lang, MsgReviewReady) + "\n\n" + BuildProgressSummary(lang, updated.SlotCompletion), updated
 	}
 
-	questions := BuildFollowUpQuestions(MissingRequiredSlots(updated.SlotCompletion), 2)
+	questions := BuildFollowUpQuestions(lang, MissingRequiredSlots(updated.SlotCompletion), 2)
 	if len(questions) == 0 {
-		return ReplyNaturalMessage, updated
+		return tr(lang, MsgNaturalMessage), updated
 	}
 
-	return FormatFollowUpQuestions(questions), updated
+	return FormatFollowUpQuestions(lang, questions), updated
 }
 
-func shouldResetSessionForTimeout(lastUpdatedAt time.Time) bool {
-	if lastUpdatedAt.IsZero() {
-		return false
+// disambiguationPrompt lists the intents that scored too close to call, best
+// first, so the user can say which one they meant.
+func disambiguationPrompt(lang string, ranking []IntentScore) string {
+	options := make([]string, 0, 2)
+	for _, entry := range ranking {
+		var option string
+		switch entry.Intent {
+		case IntentConfirmPlan:
+			option = tr(lang, MsgOptionConfirm)
+		case IntentClarifyGoal:
+			option = tr(lang, MsgOptionClarify)
+		default:
+			continue
+		}
+		options = append(options, fmt.Sprintf("%d) %s", len(options)+1, option))
+		if len(options) == cap(options) {
+			break
+		}
 	}
-	return time.Since(lastUpdatedAt) >= defaultSessionTimeout
+	return tr(lang, MsgDisambiguate, strings.Join(options, "\n"))
 }
 
 func pollingFailureBackoff(failureStreak int) time.Duration {
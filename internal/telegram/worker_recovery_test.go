@@ -2,7 +2,6 @@ package telegram
 
 import (
 	"context"
-	"fmt"
 	"io"
 	"log/slog"
 	"sync"
@@ -11,7 +10,7 @@ import (
 )
 
 func TestWorkerRestartRecoverySkipsDuplicateMessages(t *testing.T) {
-	store := newMemoryStore()
+	store := NewMemoryStore()
 
 	firstRunClient := &scriptedClient{
 		updates: [][]Update{{
@@ -55,15 +54,21 @@ func TestWorkerRestartRecoverySkipsDuplicateMessages(t *testing.T) {
 	}
 }
 
-func runWorkerUntilSendCount(t *testing.T, client *scriptedClient, store *memoryStore, sendCount int) error {
+func runWorkerUntilSendCount(t *testing.T, client *scriptedClient, store *MemoryStore, sendCount int) error {
 	t.Helper()
 
+	return runWorkerWithConfigUntilSendCount(t, WorkerConfig{}, client, store, sendCount)
+}
+
+func runWorkerWithConfigUntilSendCount(t *testing.T, cfg WorkerConfig, client *scriptedClient, store *MemoryStore, sendCount int) error {
+	t.Helper()
+
+	cfg.PollTimeoutSec = 1
+	cfg.PollInterval = 5 * time.Millisecond
+	cfg.AllowedUpdates = []string{"message"}
+
 	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
-	worker := NewWorker(WorkerConfig{
-		PollTimeoutSec: 1,
-		PollInterval:   5 * time.Millisecond,
-		AllowedUpdates: []string{"message"},
-	}, client, store, logger)
+	worker := NewWorker(cfg, client, store, logger)
 
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
@@ -89,208 +94,14 @@ func runWorkerUntilSendCount(t *testing.T, client *scriptedClient, store *memory
 	return <-errCh
 }
 
-type memoryStore struct {
-	mu               sync.Mutex
-	lastUpdateID     int64
-	dedup            map[int64]struct{}
-	usersByChatID    map[int64]User
-	activeGoalByUID  map[string]Goal
-	sessionsByGoalID map[string]PlanningSession
-	turns            []ConversationTurn
-	nextUserID       int
-	nextGoalID       int
-	nextSessionID    int
-}
-
-func newMemoryStore() *memoryStore {
-	return &memoryStore{
-		dedup:            make(map[int64]struct{}),
-		usersByChatID:    make(map[int64]User),
-		activeGoalByUID:  make(map[string]Goal),
-		sessionsByGoalID: make(map[string]PlanningSession),
-		turns:            make([]ConversationTurn, 0),
-	}
-}
-
-func (s *memoryStore) LoadLastUpdateID(context.Context) (int64, error) {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-	return s.lastUpdateID, nil
-}
-
-func (s *memoryStore) SaveLastUpdateID(_ context.Context, lastUpdateID int64) error {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-	s.lastUpdateID = lastUpdateID
-	return nil
-}
-
-func (s *memoryStore) MarkMessageDedup(_ context.Context, updateID, _ int64) (bool, error) {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-	if _, exists := s.dedup[updateID]; exists {
-		return false, nil
-	}
-	s.dedup[updateID] = struct{}{}
-	return true, nil
-}
-
-func (s *memoryStore) FindOrCreateUserByChatID(_ context.Context, chatID int64) (User, bool, error) {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-
-	if existing, ok := s.usersByChatID[chatID]; ok {
-		return existing, false, nil
-	}
-
-	s.nextUserID++
-	created := User{
-		ID:             fmt.Sprintf("user-%d", s.nextUserID),
-		TelegramChatID: chatID,
-		Language:       "zh-CN",
-		Timezone:       "Asia/Shanghai",
-	}
-	s.usersByChatID[chatID] = created
-	return created, true, nil
-}
-
-func (s *memoryStore) GetActiveGoalByUserID(_ context.Context, userID string) (Goal, bool, error) {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-
-	goal, ok := s.activeGoalByUID[userID]
-	if !ok {
-		return Goal{}, false, nil
-	}
-
-	return goal, true, nil
-}
-
-func (s *memoryStore) CreateGoalDraft(_ context.Context, userID string) (Goal, error) {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-
-	s.nextGoalID++
-	goal := Goal{
-		ID:     fmt.Sprintf("goal-%d", s.nextGoalID),
-		UserID: userID,
-		Title:  "",
-		Status: "draft",
-	}
-	s.activeGoalByUID[userID] = goal
-	return goal, nil
-}
-
-func (s *memoryStore) GetOrCreatePlanningSession(_ context.Context, goalID string) (PlanningSession, bool, error) {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-
-	if existing, ok := s.sessionsByGoalID[goalID]; ok {
-		return existing, false, nil
-	}
-
-	s.nextSessionID++
-	created := PlanningSession{
-		ID:             fmt.Sprintf("session-%d", s.nextSessionID),
-		GoalID:         goalID,
-		State:          StateIdle,
-		SlotCompletion: DefaultSlotCompletion(),
-		TurnCount:      0,
-		LastIntent:     "",
-		UpdatedAt:      time.Now(),
-	}
-	s.sessionsByGoalID[goalID] = created
-	return created, true, nil
-}
-
-func (s *memoryStore) IncrementPlanningSessionTurn(_ context.Context, sessionID string) (int, error) {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-
-	session, key, ok := s.findSessionByID(sessionID)
-	if !ok {
-		return 0, fmt.Errorf("planning session %s not found", sessionID)
-	}
-
-	session.TurnCount++
-	session.UpdatedAt = time.Now()
-	s.sessionsByGoalID[key] = session
-	return session.TurnCount, nil
-}
-
-func (s *memoryStore) UpdatePlanningSession(_ context.Context, updated PlanningSession) error {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-
-	if _, ok := s.sessionsByGoalID[updated.GoalID]; !ok {
-		return fmt.Errorf("planning session %s not found", updated.ID)
-	}
-
-	updated.SlotCompletion = NormalizeSlotCompletion(updated.SlotCompletion)
-	updated.UpdatedAt = time.Now()
-	s.sessionsByGoalID[updated.GoalID] = updated
-	return nil
-}
-
-func (s *memoryStore) SaveConversationTurn(_ context.Context, turn ConversationTurn) error {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-	s.turns = append(s.turns, turn)
-	return nil
-}
-
-func (s *memoryStore) LastUpdateID() int64 {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-	return s.lastUpdateID
-}
-
-func (s *memoryStore) GoalCreateCount() int {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-	return s.nextGoalID
-}
-
-func (s *memoryStore) UserByChatID(chatID int64) (User, bool) {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-	user, ok := s.usersByChatID[chatID]
-	return user, ok
-}
-
-func (s *memoryStore) SessionByGoalID(goalID string) (PlanningSession, bool) {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-	session, ok := s.sessionsByGoalID[goalID]
-	return session, ok
-}
-
-func (s *memoryStore) ConversationTurnsBySessionID(sessionID string) []ConversationTurn {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-	out := make([]ConversationTurn, 0, len(s.turns))
-	for _, turn := range s.turns {
-		if turn.SessionID == sessionID {
-			out = append(out, turn)
-		}
-	}
-	return out
-}
-
-func (s *memoryStore) findSessionByID(sessionID string) (PlanningSession, string, bool) {
-	for goalID, session := range s.sessionsByGoalID {
-		if session.ID == sessionID {
-			return session, goalID, true
-		}
-	}
-	return PlanningSession{}, "", false
-}
-
 type scriptedClient struct {
-	mu      sync.Mutex
-	updates [][]Update
-	offsets []int64
-	sent    []OutgoingMessage
+	mu        sync.Mutex
+	updates   [][]Update
+	offsets   []int64
+	allowed   [][]string
+	sent      []OutgoingMessage
+	documents []OutgoingDocument
+	files     map[string][]byte
 }
 
 func (c *scriptedClient) GetMe(context.Context) (BotUser, error) {
@@ -300,6 +111,7 @@ func (c *scriptedClient) GetMe(context.Context) (BotUser, error) {
 func (c *scriptedClient) GetUpdates(ctx context.Context, params GetUpdatesParams) ([]Update, error) {
 	c.mu.Lock()
 	c.offsets = append(c.offsets, params.Offset)
+	c.allowed = append(c.allowed, params.AllowedUpdates)
 	if len(c.updates) > 0 {
 		batch := c.updates[0]
 		c.updates = c.updates[1:]
@@ -319,6 +131,29 @@ func (c *scriptedClient) SendMessage(_ context.Context, message OutgoingMessage)
 	return Message{}, nil
 }
 
+func (c *scriptedClient) SendDocument(_ context.Context, document OutgoingDocument) (Message, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.documents = append(c.documents, document)
+	return Message{}, nil
+}
+
+func (c *scriptedClient) GetFile(_ context.Context, fileID string) (File, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	data, ok := c.files[fileID]
+	if !ok {
+		return File{}, &APIError{StatusCode: 400, ErrorCode: 400, Description: "Bad Request: invalid file_id"}
+	}
+	return File{FileID: fileID, FileSize: int64(len(data)), FilePath: "files/" + fileID}, nil
+}
+
+func (c *scriptedClient) DownloadFile(_ context.Context, file File, _ int64) ([]byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.files[file.FileID], nil
+}
+
 func (c *scriptedClient) SendCount() int {
 	c.mu.Lock()
 	defer c.mu.Unlock()
@@ -340,3 +175,11 @@ func (c *scriptedClient) SentMessages() []OutgoingMessage {
 	copy(out, c.sent)
 	return out
 }
+
+func (c *scriptedClient) SentDocuments() []OutgoingDocument {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	out := make([]OutgoingDocument, len(c.documents))
+	copy(out, c.documents)
+	return out
+}
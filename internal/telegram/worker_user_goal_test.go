@@ -7,7 +7,7 @@ import (
 )
 
 func TestWorkerStartInitializesNewUser(t *testing.T) {
-	store := newMemoryStore()
+	store := NewMemoryStore()
 	client := &scriptedClient{
 		updates: [][]Update{{
 			{UpdateID: 1, Message: &Message{MessageID: 11, Chat: Chat{ID: 10001}, Text: "/start"}},
@@ -33,13 +33,13 @@ func TestWorkerStartInitializesNewUser(t *testing.T) {
 	if len(sent) != 1 {
 		t.Fatalf("sent messages=%d, want 1", len(sent))
 	}
-	if sent[0].Text != ReplyStart {
-		t.Fatalf("reply=%q, want %q", sent[0].Text, ReplyStart)
+	if sent[0].Text != zh(MsgStart) {
+		t.Fatalf("reply=%q, want %q", sent[0].Text, zh(MsgStart))
 	}
 }
 
 func TestWorkerStartWelcomesBackExistingUser(t *testing.T) {
-	store := newMemoryStore()
+	store := NewMemoryStore()
 	_, _, err := store.FindOrCreateUserByChatID(context.Background(), 10002)
 	if err != nil {
 		t.Fatalf("seed user failed: %v", err)
@@ -59,13 +59,13 @@ func TestWorkerStartWelcomesBackExistingUser(t *testing.T) {
 	if len(sent) != 1 {
 		t.Fatalf("sent messages=%d, want 1", len(sent))
 	}
-	if sent[0].Text != ReplyStartBack {
-		t.Fatalf("reply=%q, want %q", sent[0].Text, ReplyStartBack)
+	if sent[0].Text != zh(MsgStartBack) {
+		t.Fatalf("reply=%q, want %q", sent[0].Text, zh(MsgStartBack))
 	}
 }
 
 func TestWorkerGoalCreatesDraftWhenNoActiveGoal(t *testing.T) {
-	store := newMemoryStore()
+	store := NewMemoryStore()
 	client := &scriptedClient{
 		updates: [][]Update{{
 			{UpdateID: 1, Message: &Message{MessageID: 13, Chat: Chat{ID: 10003}, Text: "/goal"}},
@@ -85,7 +85,7 @@ func TestWorkerGoalCreatesDraftWhenNoActiveGoal(t *testing.T) {
 		t.Fatalf("expected user to exist")
 	}
 
-	goal, found, err := store.GetActiveGoalByUserID(context.Background(), createdUser.ID)
+	goal, found, err := store.GetCurrentGoalByUserID(context.Background(), createdUser.ID)
 	if err != nil {
 		t.Fatalf("get active goal failed: %v", err)
 	}
@@ -100,13 +100,13 @@ func TestWorkerGoalCreatesDraftWhenNoActiveGoal(t *testing.T) {
 	if len(sent) != 1 {
 		t.Fatalf("sent messages=%d, want 1", len(sent))
 	}
-	if sent[0].Text != ReplyGoal {
-		t.Fatalf("reply=%q, want %q", sent[0].Text, ReplyGoal)
+	if sent[0].Text != zh(MsgGoal) {
+		t.Fatalf("reply=%q, want %q", sent[0].Text, zh(MsgGoal))
 	}
 }
 
 func TestWorkerGoalReusesExistingActiveGoal(t *testing.T) {
-	store := newMemoryStore()
+	store := NewMemoryStore()
 	user, _, err := store.FindOrCreateUserByChatID(context.Background(), 10004)
 	if err != nil {
 		t.Fatalf("seed user failed: %v", err)
@@ -131,7 +131,7 @@ func TestWorkerGoalReusesExistingActiveGoal(t *testing.T) {
 }
 
 func TestWorkerNaturalMessageCreatesDraftWhenNoActiveGoal(t *testing.T) {
-	store := newMemoryStore()
+	store := NewMemoryStore()
 	client := &scriptedClient{
 		updates: [][]Update{{
 			{UpdateID: 1, Message: &Message{MessageID: 15, Chat: Chat{ID: 10005}, Text: "我想在三个月内学完 Go"}},
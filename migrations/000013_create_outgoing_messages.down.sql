@@ -0,0 +1 @@
+DROP TABLE IF EXISTS outgoing_messages;
@@ -0,0 +1,23 @@
+CREATE TABLE IF NOT EXISTS outgoing_messages (
+    id BIGSERIAL PRIMARY KEY,
+    chat_id BIGINT NOT NULL,
+    text TEXT NOT NULL,
+    reply_to_message_id BIGINT NOT NULL DEFAULT 0,
+    status TEXT NOT NULL DEFAULT 'pending',
+    attempts INT NOT NULL DEFAULT 0,
+    last_error TEXT NOT NULL DEFAULT '',
+    next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    sent_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT outgoing_messages_status_chk CHECK (status IN ('pending', 'sent', 'dead')),
+    CONSTRAINT outgoing_messages_attempts_chk CHECK (attempts >= 0)
+);
+
+CREATE INDEX IF NOT EXISTS idx_outgoing_messages_pending_chat_id
+    ON outgoing_messages(chat_id, id)
+    WHERE status = 'pending';
+
+CREATE INDEX IF NOT EXISTS idx_outgoing_messages_dead_updated_at
+    ON outgoing_messages(updated_at DESC)
+    WHERE status = 'dead';
@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS goal_attachments (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    goal_id UUID NOT NULL REFERENCES goals(id) ON DELETE CASCADE,
+    kind TEXT NOT NULL,
+    telegram_file_id TEXT NOT NULL,
+    telegram_file_unique_id TEXT NOT NULL,
+    file_name TEXT NOT NULL DEFAULT '',
+    mime_type TEXT NOT NULL DEFAULT '',
+    file_size BIGINT NOT NULL DEFAULT 0,
+    content_text TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT goal_attachments_kind_chk CHECK (kind IN ('document')),
+    CONSTRAINT goal_attachments_file_size_chk CHECK (file_size >= 0)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_goal_attachments_goal_file_unique_id
+    ON goal_attachments(goal_id, telegram_file_unique_id);
@@ -0,0 +1,4 @@
+ALTER TABLE IF EXISTS conversation_turns
+    DROP COLUMN IF EXISTS edited_at,
+    DROP COLUMN IF EXISTS revision,
+    DROP COLUMN IF EXISTS telegram_message_id;
@@ -0,0 +1,4 @@
+ALTER TABLE conversation_turns
+    ADD COLUMN IF NOT EXISTS telegram_message_id BIGINT NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS revision INT NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS edited_at TIMESTAMPTZ;
@@ -0,0 +1,6 @@
+ALTER TABLE IF EXISTS conversation_turns
+    DROP COLUMN IF EXISTS undone_at;
+
+ALTER TABLE IF EXISTS planning_sessions
+    DROP COLUMN IF EXISTS pending_action,
+    DROP COLUMN IF EXISTS skipped_slots;
@@ -0,0 +1,6 @@
+ALTER TABLE planning_sessions
+    ADD COLUMN IF NOT EXISTS skipped_slots JSONB NOT NULL DEFAULT '[]'::JSONB,
+    ADD COLUMN IF NOT EXISTS pending_action TEXT NOT NULL DEFAULT '';
+
+ALTER TABLE conversation_turns
+    ADD COLUMN IF NOT EXISTS undone_at TIMESTAMPTZ;
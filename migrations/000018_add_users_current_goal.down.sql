@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_goals_user_id_single_active;
+
+ALTER TABLE IF EXISTS users
+    DROP COLUMN IF EXISTS current_goal_id;
@@ -0,0 +1,34 @@
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS current_goal_id UUID REFERENCES goals(id) ON DELETE SET NULL;
+
+-- Keep only the most recently updated active goal per user before enforcing
+-- the single main goal rule.
+UPDATE goals
+SET status = 'draft',
+    updated_at = NOW()
+WHERE status = 'active'
+  AND id NOT IN (
+      SELECT DISTINCT ON (user_id) id
+      FROM goals
+      WHERE status = 'active'
+      ORDER BY user_id, updated_at DESC, created_at DESC
+  );
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_goals_user_id_single_active
+    ON goals(user_id)
+    WHERE status = 'active';
+
+UPDATE users u
+SET current_goal_id = picked.id
+FROM (
+    SELECT DISTINCT ON (user_id) id, user_id
+    FROM goals
+    WHERE status IN ('active', 'draft')
+    ORDER BY
+      user_id,
+      CASE status WHEN 'active' THEN 0 ELSE 1 END,
+      updated_at DESC,
+      created_at DESC
+) AS picked
+WHERE u.id = picked.user_id
+  AND u.current_goal_id IS NULL;
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_planning_sessions_updated_at;
+
+ALTER TABLE IF EXISTS users
+    DROP CONSTRAINT IF EXISTS users_session_timeout_minutes_chk,
+    DROP COLUMN IF EXISTS session_timeout_minutes;
@@ -0,0 +1,8 @@
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS session_timeout_minutes INT,
+    ADD CONSTRAINT users_session_timeout_minutes_chk CHECK (
+        session_timeout_minutes IS NULL OR session_timeout_minutes > 0
+    );
+
+CREATE INDEX IF NOT EXISTS idx_planning_sessions_updated_at
+    ON planning_sessions(updated_at);
@@ -0,0 +1,2 @@
+ALTER TABLE IF EXISTS conversation_turns
+    DROP COLUMN IF EXISTS intent_ranking;
@@ -0,0 +1,2 @@
+ALTER TABLE conversation_turns
+    ADD COLUMN IF NOT EXISTS intent_ranking JSONB;
@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS admin_api_keys (
+    key_id TEXT PRIMARY KEY,
+    name TEXT NOT NULL,
+    secret_hash TEXT NOT NULL,
+    scopes TEXT[] NOT NULL CHECK (
+        cardinality(scopes) > 0
+        AND scopes <@ ARRAY['read', 'write', 'export']::TEXT[]
+    ),
+    created_by TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    last_used_at TIMESTAMPTZ,
+    revoked_at TIMESTAMPTZ
+);
@@ -0,0 +1,3 @@
+ALTER TABLE IF EXISTS users
+    DROP COLUMN IF EXISTS deletion_confirmations,
+    DROP COLUMN IF EXISTS deletion_requested_at;
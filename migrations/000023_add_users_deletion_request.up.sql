@@ -0,0 +1,4 @@
+-- A /deleteme request waits here for its two confirmations.
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS deletion_requested_at TIMESTAMPTZ,
+    ADD COLUMN IF NOT EXISTS deletion_confirmations INT NOT NULL DEFAULT 0;
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_conversation_turns_unredacted;
+
+ALTER TABLE IF EXISTS conversation_turns
+    DROP COLUMN IF EXISTS redacted_at,
+    DROP COLUMN IF EXISTS content_hash;
@@ -0,0 +1,7 @@
+ALTER TABLE conversation_turns
+    ADD COLUMN IF NOT EXISTS content_hash TEXT NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS redacted_at TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS idx_conversation_turns_unredacted
+    ON conversation_turns(created_at)
+    WHERE redacted_at IS NULL;
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_users_deletion_requested_at;
+DROP INDEX IF EXISTS idx_planning_sessions_pending_action;
+DROP INDEX IF EXISTS idx_agent_action_logs_created_at;
+DROP INDEX IF EXISTS idx_message_dedup_received_at;
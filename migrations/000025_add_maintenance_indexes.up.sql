@@ -0,0 +1,16 @@
+-- message_dedup stays a plain table: partitioning it by received_at would
+-- force received_at into the primary key and break the ON CONFLICT
+-- (update_id) dedup check. Batched deletes on this index keep it small.
+CREATE INDEX IF NOT EXISTS idx_message_dedup_received_at
+    ON message_dedup(received_at);
+
+CREATE INDEX IF NOT EXISTS idx_agent_action_logs_created_at
+    ON agent_action_logs(created_at);
+
+CREATE INDEX IF NOT EXISTS idx_planning_sessions_pending_action
+    ON planning_sessions(updated_at)
+    WHERE pending_action <> '';
+
+CREATE INDEX IF NOT EXISTS idx_users_deletion_requested_at
+    ON users(deletion_requested_at)
+    WHERE deletion_requested_at IS NOT NULL;
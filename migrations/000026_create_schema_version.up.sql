@@ -0,0 +1,11 @@
+-- The number of the newest applied migration, checked by /readyz against
+-- db.SchemaVersion. Every later up migration ends by setting it to its own
+-- number and every down migration by setting it back.
+CREATE TABLE IF NOT EXISTS schema_version (
+    id BOOLEAN PRIMARY KEY DEFAULT TRUE CHECK (id),
+    version INT NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+INSERT INTO schema_version (id, version) VALUES (TRUE, 26)
+ON CONFLICT (id) DO UPDATE SET version = EXCLUDED.version, updated_at = NOW();
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS feature_flags;
+
+UPDATE schema_version SET version = 26, updated_at = NOW();
@@ -0,0 +1,20 @@
+-- A user gets a flag when they are on the allow list, or when they are not
+-- on the deny list and either env_defaults[APP_ENV] is true or their stable
+-- bucket falls under rollout_percent.
+CREATE TABLE IF NOT EXISTS feature_flags (
+    key TEXT PRIMARY KEY,
+    description TEXT NOT NULL DEFAULT '',
+    rollout_percent INT NOT NULL DEFAULT 0 CHECK (rollout_percent BETWEEN 0 AND 100),
+    allow_user_ids TEXT[] NOT NULL DEFAULT '{}',
+    deny_user_ids TEXT[] NOT NULL DEFAULT '{}',
+    env_defaults JSONB NOT NULL DEFAULT '{}'::JSONB,
+    updated_by TEXT NOT NULL DEFAULT '',
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+INSERT INTO feature_flags (key, description) VALUES
+    ('llm_extraction', 'Extract goal slots with an LLM instead of the keyword rules'),
+    ('plan_generation', 'Generate the first plan once the goal brief is ready for review')
+ON CONFLICT (key) DO NOTHING;
+
+UPDATE schema_version SET version = 27, updated_at = NOW();
@@ -0,0 +1,4 @@
+ALTER TABLE IF EXISTS conversation_turns
+    DROP COLUMN IF EXISTS redacted_slots;
+
+UPDATE schema_version SET version = 27, updated_at = NOW();
@@ -0,0 +1,6 @@
+-- Slots the original text of a redacted turn filled. The summary left in
+-- content is too short to match them again when slots are replayed.
+ALTER TABLE conversation_turns
+    ADD COLUMN IF NOT EXISTS redacted_slots JSONB NOT NULL DEFAULT '[]'::jsonb;
+
+UPDATE schema_version SET version = 28, updated_at = NOW();
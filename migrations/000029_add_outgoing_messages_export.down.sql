@@ -0,0 +1,7 @@
+-- Without the column an export row would be sent as its caption alone.
+DELETE FROM outgoing_messages WHERE export IS NOT NULL;
+
+ALTER TABLE IF EXISTS outgoing_messages
+    DROP COLUMN IF EXISTS export;
+
+UPDATE schema_version SET version = 28, updated_at = NOW();
@@ -0,0 +1,7 @@
+-- Set on /mydata export rows: who to export, in which format and language.
+-- The dispatcher builds the document when it sends the row, so the export
+-- itself is never stored here.
+ALTER TABLE outgoing_messages
+    ADD COLUMN IF NOT EXISTS export JSONB;
+
+UPDATE schema_version SET version = 29, updated_at = NOW();